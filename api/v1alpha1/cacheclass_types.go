@@ -0,0 +1,67 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CacheClassSpec defines the desired state of CacheClass
+type CacheClassSpec struct {
+
+	// Default values for the AWS configuration of ElasticCaches using this class.
+	// Only fields an ElasticCache leaves unset are filled in when it is created,
+	// skipping fields that may not be set together with a field it sets or that
+	// do not apply to its engine. Tags are merged, tags of the ElasticCache take
+	// precedence over tags with the same key. Secrets and CacheSubnetGroups are
+	// referenced in the namespace of the ElasticCache.
+	AWSConfig ElasticCacheAwsConfig `json:"awsConfig"`
+
+	// Selects the namespaces in which this class is used by ElasticCaches that do
+	// not set cacheClassName. An empty selector selects all namespaces. When
+	// several classes select a namespace, the first one in alphabetical order is
+	// used. A class without a selector is only used when it is named explicitly.
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Engine",type=string,JSONPath=`.spec.awsConfig.engine`
+//+kubebuilder:printcolumn:name="Node Type",type=string,JSONPath=`.spec.awsConfig.cacheNodeType`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// CacheClass is the Schema for the cacheclasses API. It holds defaults shared
+// by the ElasticCaches of one or more namespaces.
+type CacheClass struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec CacheClassSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// CacheClassList contains a list of CacheClass
+type CacheClassList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CacheClass `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CacheClass{}, &CacheClassList{})
+}
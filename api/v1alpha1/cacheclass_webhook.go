@@ -0,0 +1,218 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// elasticCacheDefaulterPath is the path the CacheClass defaulting webhook of
+// ElasticCaches is served on.
+const elasticCacheDefaulterPath = "/mutate-aws-sergeyshevch-dev-v1alpha1-elasticcache"
+
+//+kubebuilder:webhook:path=/mutate-aws-sergeyshevch-dev-v1alpha1-elasticcache,mutating=true,failurePolicy=fail,sideEffects=None,groups=aws.sergeyshevch.dev,resources=elasticcaches,verbs=create,versions=v1alpha1,matchPolicy=Equivalent,name=melasticcache.kb.io,admissionReviewVersions={v1,v1beta1}
+//+kubebuilder:rbac:groups=aws.sergeyshevch.dev,resources=cacheclasses,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+
+// elasticCacheDefaulter fills the unset fields of new ElasticCaches from their
+// CacheClass. Unlike a webhook.Defaulter it needs a client to look up classes
+// and namespaces.
+type elasticCacheDefaulter struct {
+	client  client.Client
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &elasticCacheDefaulter{}
+var _ admission.DecoderInjector = &elasticCacheDefaulter{}
+
+// Handle implements admission.Handler.
+func (d *elasticCacheDefaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	elasticCache := &ElasticCache{}
+	err := d.decoder.Decode(req, elasticCache)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	elasticcachelog.Info("default", "name", elasticCache.Name)
+
+	class, err := d.selectCacheClass(ctx, req.Namespace, elasticCache)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return admission.Denied(err.Error())
+		}
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if class == nil {
+		return admission.Allowed("no CacheClass applies")
+	}
+
+	elasticCache.applyCacheClass(class)
+
+	marshaled, err := json.Marshal(elasticCache)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
+
+// InjectDecoder implements admission.DecoderInjector.
+func (d *elasticCacheDefaulter) InjectDecoder(decoder *admission.Decoder) error {
+	d.decoder = decoder
+	return nil
+}
+
+// selectCacheClass returns the CacheClass named by the ElasticCache or, when it
+// names none, the first class selecting its namespace. It returns nil when no
+// class applies.
+func (d *elasticCacheDefaulter) selectCacheClass(ctx context.Context, namespace string, elasticCache *ElasticCache) (*CacheClass, error) {
+	if name := elasticCache.Spec.CacheClassName; name != nil {
+		class := &CacheClass{}
+		err := d.client.Get(ctx, client.ObjectKey{Name: *name}, class)
+		if err != nil {
+			return nil, err
+		}
+		return class, nil
+	}
+
+	ns := &corev1.Namespace{}
+	err := d.client.Get(ctx, client.ObjectKey{Name: namespace}, ns)
+	if err != nil {
+		return nil, err
+	}
+
+	classes := &CacheClassList{}
+	err = d.client.List(ctx, classes)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(classes.Items, func(i, j int) bool {
+		return classes.Items[i].Name < classes.Items[j].Name
+	})
+
+	for i := range classes.Items {
+		class := &classes.Items[i]
+		if class.Spec.NamespaceSelector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(class.Spec.NamespaceSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid namespaceSelector of CacheClass %s: %w", class.Name, err)
+		}
+		if selector.Matches(labels.Set(ns.Labels)) {
+			return class, nil
+		}
+	}
+	return nil, nil
+}
+
+// exclusiveFields maps the fields of the AWS configuration to the field they
+// may not be set together with.
+var exclusiveFields = map[string]string{
+	"cacheSubnetGroupName":       "subnetGroupRef",
+	"subnetGroupRef":             "cacheSubnetGroupName",
+	"preferredAvailabilityZone":  "preferredAvailabilityZones",
+	"preferredAvailabilityZones": "preferredAvailabilityZone",
+}
+
+// engineFields maps the fields of the AWS configuration that only apply to one
+// engine to that engine.
+var engineFields = map[string]string{
+	"azMode":                     engineMemcached,
+	"preferredAvailabilityZones": engineMemcached,
+	"authTokenSecretRef":         engineRedis,
+	"logDeliveryConfigurations":  engineRedis,
+	"replicationGroupId":         engineRedis,
+	"snapshotArns":               engineRedis,
+	"snapshotName":               engineRedis,
+	"snapshotRetentionLimit":     engineRedis,
+	"snapshotWindow":             engineRedis,
+}
+
+// applyCacheClass fills the unset fields of the AWS configuration from class,
+// merges the tags of both and records the name of the class. Defaults that
+// conflict with a field set by the ElasticCache or that do not apply to its
+// engine are skipped, so a class can serve both engines.
+func (r *ElasticCache) applyCacheClass(class *CacheClass) {
+	r.Spec.CacheClassName = &class.Name
+	if r.Spec.AWSConfig == nil {
+		r.Spec.AWSConfig = &ElasticCacheAwsConfig{}
+	}
+
+	tags := mergeTags(class.Spec.AWSConfig.Tags, r.Spec.AWSConfig.Tags)
+
+	engine := r.Spec.AWSConfig.Engine
+	if engine == nil {
+		engine = class.Spec.AWSConfig.Engine
+	}
+
+	config := reflect.ValueOf(r.Spec.AWSConfig).Elem()
+	defaults := reflect.ValueOf(class.Spec.AWSConfig.DeepCopy()).Elem()
+	fieldsByName := map[string]reflect.Value{}
+	for i := 0; i < config.NumField(); i++ {
+		fieldsByName[jsonName(config.Type().Field(i))] = config.Field(i)
+	}
+	for i := 0; i < config.NumField(); i++ {
+		if config.Type().Field(i).PkgPath != "" || !config.Field(i).IsZero() {
+			continue
+		}
+		name := jsonName(config.Type().Field(i))
+		if other, ok := exclusiveFields[name]; ok && !fieldsByName[other].IsZero() {
+			continue
+		}
+		if only, ok := engineFields[name]; ok && engine != nil && *engine != only {
+			continue
+		}
+		config.Field(i).Set(defaults.Field(i))
+	}
+
+	r.Spec.AWSConfig.Tags = tags
+}
+
+// jsonName returns the name of the field in JSON.
+func jsonName(f reflect.StructField) string {
+	return strings.Split(f.Tag.Get("json"), ",")[0]
+}
+
+// mergeTags returns the defaults whose keys are not in tags followed by tags.
+func mergeTags(defaults []Tag, tags []Tag) []Tag {
+	keys := map[string]bool{}
+	for _, tag := range tags {
+		if tag.Key != nil {
+			keys[*tag.Key] = true
+		}
+	}
+
+	var merged []Tag
+	for _, tag := range defaults {
+		if tag.Key != nil && !keys[*tag.Key] {
+			merged = append(merged, *tag.DeepCopy())
+		}
+	}
+	return append(merged, tags...)
+}
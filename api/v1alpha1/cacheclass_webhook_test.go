@@ -0,0 +1,342 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/diff"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestApplyCacheClass(t *testing.T) {
+	tests := []struct {
+		name  string
+		class ElasticCacheAwsConfig
+		spec  *ElasticCacheAwsConfig
+		want  *ElasticCacheAwsConfig
+	}{
+		{
+			name:  "fills unset fields",
+			class: ElasticCacheAwsConfig{CacheNodeType: stringPtr("cache.t3.micro"), Engine: stringPtr(engineRedis), SnapshotRetentionLimit: int32Ptr(3)},
+			spec:  &ElasticCacheAwsConfig{CacheNodeType: stringPtr("cache.t3.small")},
+			want:  &ElasticCacheAwsConfig{CacheNodeType: stringPtr("cache.t3.small"), Engine: stringPtr(engineRedis), SnapshotRetentionLimit: int32Ptr(3)},
+		},
+		{
+			name:  "fills a missing AWS configuration",
+			class: ElasticCacheAwsConfig{CacheNodeType: stringPtr("cache.t3.micro")},
+			want:  &ElasticCacheAwsConfig{CacheNodeType: stringPtr("cache.t3.micro")},
+		},
+		{
+			name: "merges tags",
+			class: ElasticCacheAwsConfig{Tags: []Tag{
+				{Key: stringPtr("team"), Value: stringPtr("platform")},
+				{Key: stringPtr("env"), Value: stringPtr("dev")},
+			}},
+			spec: &ElasticCacheAwsConfig{Tags: []Tag{{Key: stringPtr("env"), Value: stringPtr("prod")}}},
+			want: &ElasticCacheAwsConfig{Tags: []Tag{
+				{Key: stringPtr("team"), Value: stringPtr("platform")},
+				{Key: stringPtr("env"), Value: stringPtr("prod")},
+			}},
+		},
+		{
+			name:  "skips the subnet group name when the spec references a subnet group",
+			class: ElasticCacheAwsConfig{CacheSubnetGroupName: stringPtr("default")},
+			spec:  &ElasticCacheAwsConfig{SubnetGroupRef: &LocalObjectReference{Name: "subnets"}},
+			want:  &ElasticCacheAwsConfig{SubnetGroupRef: &LocalObjectReference{Name: "subnets"}},
+		},
+		{
+			name:  "skips the subnet group reference when the spec names a subnet group",
+			class: ElasticCacheAwsConfig{SubnetGroupRef: &LocalObjectReference{Name: "subnets"}},
+			spec:  &ElasticCacheAwsConfig{CacheSubnetGroupName: stringPtr("default")},
+			want:  &ElasticCacheAwsConfig{CacheSubnetGroupName: stringPtr("default")},
+		},
+		{
+			name:  "skips the availability zones when the spec sets one",
+			class: ElasticCacheAwsConfig{PreferredAvailabilityZones: []string{"us-east-1a", "us-east-1b"}},
+			spec:  &ElasticCacheAwsConfig{Engine: stringPtr(engineMemcached), PreferredAvailabilityZone: stringPtr("us-east-1a")},
+			want:  &ElasticCacheAwsConfig{Engine: stringPtr(engineMemcached), PreferredAvailabilityZone: stringPtr("us-east-1a")},
+		},
+		{
+			name:  "skips the availability zone when the spec sets several",
+			class: ElasticCacheAwsConfig{PreferredAvailabilityZone: stringPtr("us-east-1a")},
+			spec:  &ElasticCacheAwsConfig{Engine: stringPtr(engineMemcached), PreferredAvailabilityZones: []string{"us-east-1a", "us-east-1b"}},
+			want:  &ElasticCacheAwsConfig{Engine: stringPtr(engineMemcached), PreferredAvailabilityZones: []string{"us-east-1a", "us-east-1b"}},
+		},
+		{
+			name: "skips memcached fields for redis",
+			class: ElasticCacheAwsConfig{
+				AZMode:                     types.AZModeCrossAz,
+				PreferredAvailabilityZones: []string{"us-east-1a", "us-east-1b"},
+				SnapshotRetentionLimit:     int32Ptr(3),
+			},
+			spec: &ElasticCacheAwsConfig{Engine: stringPtr(engineRedis)},
+			want: &ElasticCacheAwsConfig{Engine: stringPtr(engineRedis), SnapshotRetentionLimit: int32Ptr(3)},
+		},
+		{
+			name: "skips redis fields for memcached",
+			class: ElasticCacheAwsConfig{
+				AZMode:                 types.AZModeCrossAz,
+				AuthTokenSecretRef:     &SecretKeySelector{Name: "auth", Key: "token"},
+				ReplicationGroupId:     stringPtr("group"),
+				SnapshotArns:           []string{"arn:aws:s3:::bucket/snapshot.rdb"},
+				SnapshotName:           stringPtr("snapshot"),
+				SnapshotRetentionLimit: int32Ptr(3),
+				SnapshotWindow:         stringPtr("05:00-09:00"),
+				LogDeliveryConfigurations: []LogDeliveryConfiguration{
+					{LogType: LogTypeSlowLog, CloudWatchLogs: &CloudWatchLogsDestination{LogGroup: "slow"}},
+				},
+			},
+			spec: &ElasticCacheAwsConfig{Engine: stringPtr(engineMemcached)},
+			want: &ElasticCacheAwsConfig{Engine: stringPtr(engineMemcached), AZMode: types.AZModeCrossAz},
+		},
+		{
+			name:  "uses the engine of the class when the spec sets none",
+			class: ElasticCacheAwsConfig{Engine: stringPtr(engineMemcached), SnapshotRetentionLimit: int32Ptr(3)},
+			spec:  &ElasticCacheAwsConfig{},
+			want:  &ElasticCacheAwsConfig{Engine: stringPtr(engineMemcached)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			class := &CacheClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "standard"},
+				Spec:       CacheClassSpec{AWSConfig: tt.class},
+			}
+			elasticCache := &ElasticCache{Spec: ElasticCacheSpec{AWSConfig: tt.spec}}
+
+			elasticCache.applyCacheClass(class)
+
+			if got := elasticCache.Spec.CacheClassName; got == nil || *got != "standard" {
+				t.Errorf("cacheClassName = %v, want standard", got)
+			}
+			if !equality.Semantic.DeepEqual(elasticCache.Spec.AWSConfig, tt.want) {
+				t.Errorf("awsConfig differs:\n%s", diff.ObjectReflectDiff(tt.want, elasticCache.Spec.AWSConfig))
+			}
+		})
+	}
+}
+
+// newTestDefaulter returns a defaulter backed by a fake client holding objs.
+func newTestDefaulter(t *testing.T, objs ...client.Object) *elasticCacheDefaulter {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	decoder, err := admission.NewDecoder(scheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &elasticCacheDefaulter{
+		client:  fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+		decoder: decoder,
+	}
+}
+
+func cacheClass(name string, selector *metav1.LabelSelector) *CacheClass {
+	return &CacheClass{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: CacheClassSpec{
+			AWSConfig:         ElasticCacheAwsConfig{CacheNodeType: stringPtr("cache.t3.micro")},
+			NamespaceSelector: selector,
+		},
+	}
+}
+
+func TestSelectCacheClass(t *testing.T) {
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "team-a",
+		Labels: map[string]string{"env": "prod"},
+	}}
+	prod := &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}
+	dev := &metav1.LabelSelector{MatchLabels: map[string]string{"env": "dev"}}
+
+	tests := []struct {
+		name      string
+		classes   []client.Object
+		className *string
+		want      string
+		wantErr   func(error) bool
+	}{
+		{
+			name:    "selects no class without classes",
+			classes: nil,
+		},
+		{
+			name:    "selects the class matching the namespace",
+			classes: []client.Object{cacheClass("development", dev), cacheClass("production", prod)},
+			want:    "production",
+		},
+		{
+			name:    "selects the first matching class by name",
+			classes: []client.Object{cacheClass("b-production", prod), cacheClass("a-production", prod)},
+			want:    "a-production",
+		},
+		{
+			name:    "ignores classes without a namespace selector",
+			classes: []client.Object{cacheClass("manual", nil)},
+		},
+		{
+			name:    "matches every namespace with an empty selector",
+			classes: []client.Object{cacheClass("everywhere", &metav1.LabelSelector{})},
+			want:    "everywhere",
+		},
+		{
+			name:      "selects the named class over matching classes",
+			classes:   []client.Object{cacheClass("manual", nil), cacheClass("production", prod)},
+			className: stringPtr("manual"),
+			want:      "manual",
+		},
+		{
+			name:      "fails when the named class does not exist",
+			classes:   []client.Object{cacheClass("production", prod)},
+			className: stringPtr("missing"),
+			wantErr:   apierrors.IsNotFound,
+		},
+		{
+			name: "fails on an invalid selector",
+			classes: []client.Object{cacheClass("broken", &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "env", Operator: "Matches"}},
+			})},
+			wantErr: func(err error) bool { return err != nil && !apierrors.IsNotFound(err) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newTestDefaulter(t, append(tt.classes, namespace)...)
+			elasticCache := &ElasticCache{Spec: ElasticCacheSpec{CacheClassName: tt.className}}
+
+			class, err := d.selectCacheClass(context.Background(), namespace.Name, elasticCache)
+			if tt.wantErr != nil {
+				if !tt.wantErr(err) {
+					t.Fatalf("unexpected error %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ""
+			if class != nil {
+				got = class.Name
+			}
+			if got != tt.want {
+				t.Errorf("selected class = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestElasticCacheDefaulterHandle(t *testing.T) {
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "team-a",
+		Labels: map[string]string{"env": "prod"},
+	}}
+	production := cacheClass("production", &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}})
+
+	tests := []struct {
+		name        string
+		objs        []client.Object
+		spec        ElasticCacheSpec
+		wantAllowed bool
+		wantPatches map[string]interface{}
+	}{
+		{
+			name:        "allows the object unchanged when no class applies",
+			objs:        []client.Object{namespace},
+			spec:        ElasticCacheSpec{AWSConfig: &ElasticCacheAwsConfig{CacheNodeType: stringPtr("cache.t3.small")}},
+			wantAllowed: true,
+		},
+		{
+			name:        "fills the spec from the matching class",
+			objs:        []client.Object{namespace, production},
+			spec:        ElasticCacheSpec{AWSConfig: &ElasticCacheAwsConfig{Engine: stringPtr(engineRedis)}},
+			wantAllowed: true,
+			wantPatches: map[string]interface{}{
+				"/spec/cacheClassName":          "production",
+				"/spec/awsConfig/cacheNodeType": "cache.t3.micro",
+			},
+		},
+		{
+			name:        "keeps the fields set by the object",
+			objs:        []client.Object{namespace, production},
+			spec:        ElasticCacheSpec{AWSConfig: &ElasticCacheAwsConfig{CacheNodeType: stringPtr("cache.t3.small")}},
+			wantAllowed: true,
+			wantPatches: map[string]interface{}{
+				"/spec/cacheClassName": "production",
+			},
+		},
+		{
+			name: "denies an object naming a missing class",
+			objs: []client.Object{namespace, production},
+			spec: ElasticCacheSpec{CacheClassName: stringPtr("missing")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newTestDefaulter(t, tt.objs...)
+			raw, err := json.Marshal(&ElasticCache{
+				TypeMeta:   metav1.TypeMeta{APIVersion: GroupVersion.String(), Kind: "ElasticCache"},
+				ObjectMeta: metav1.ObjectMeta{Name: "cache", Namespace: namespace.Name},
+				Spec:       tt.spec,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp := d.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: admissionv1.Create,
+				Namespace: namespace.Name,
+				Object:    runtime.RawExtension{Raw: raw},
+			}})
+
+			if resp.Allowed != tt.wantAllowed {
+				t.Fatalf("allowed = %v, want %v: %v", resp.Allowed, tt.wantAllowed, resp.Result)
+			}
+			patches := map[string]interface{}{}
+			for _, patch := range resp.Patches {
+				patches[patch.Path] = patch.Value
+			}
+			if len(tt.wantPatches) == 0 && len(patches) > 0 {
+				t.Errorf("unexpected patches %v", patches)
+			}
+			for path, value := range tt.wantPatches {
+				if got, ok := patches[path]; !ok || !equality.Semantic.DeepEqual(got, value) {
+					t.Errorf("patch of %s = %v, want %v", path, got, value)
+				}
+			}
+		})
+	}
+}
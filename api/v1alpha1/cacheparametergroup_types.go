@@ -0,0 +1,117 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CacheParameterGroupSpec defines the desired state of CacheParameterGroup
+type CacheParameterGroupSpec struct {
+
+	// The name of the cache parameter group family that the cache parameter group
+	// can be used with. Valid values are: memcached1.4 | memcached1.5 | memcached1.6
+	// | redis2.6 | redis2.8 | redis3.2 | redis4.0 | redis5.0 | redis6.x. The family
+	// cannot be changed after the group is created.
+	CacheParameterGroupFamily string `json:"cacheParameterGroupFamily"`
+
+	// A user-specified description for the cache parameter group. The description
+	// cannot be changed after the group is created.
+	Description string `json:"description"`
+
+	// The parameters of the group that differ from the family defaults, keyed by
+	// parameter name. Parameters removed from this map are reset to their default
+	// values.
+	Parameters map[string]string `json:"parameters,omitempty"`
+
+	// A list of tags to be added to this resource.
+	Tags []Tag `json:"tags,omitempty"`
+}
+
+// PendingRebootCluster is a cluster using the parameter group whose nodes have
+// to be rebooted before pending parameter changes take effect.
+type PendingRebootCluster struct {
+
+	// The cluster identifier.
+	CacheClusterId string `json:"cacheClusterId"`
+
+	// A list of the cache node IDs which need to be rebooted for parameter changes
+	// to be applied. A node ID is a numeric identifier (0001, 0002, etc.).
+	CacheNodeIdsToReboot []string `json:"cacheNodeIdsToReboot,omitempty"`
+}
+
+// CacheParameterGroupStatus defines the observed state of CacheParameterGroup
+type CacheParameterGroupStatus struct {
+
+	// Conditions represent the latest available observations of the parameter group
+	// state. Known condition types are Ready, Synced, Deleting and Error.
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// The generation of the CacheParameterGroup most recently observed by the
+	// controller.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// The ARN (Amazon Resource Name) of the cache parameter group.
+	ARN *string `json:"arn,omitempty"`
+
+	// The name of the cache parameter group in AWS.
+	CacheParameterGroupName *string `json:"cacheParameterGroupName,omitempty"`
+
+	// The user modified parameters currently set on the group in AWS.
+	Parameters map[string]string `json:"parameters,omitempty"`
+
+	// The clusters that have to be rebooted to apply pending parameter changes.
+	// They are looked up after the parameters are changed and refreshed until
+	// every reboot is done.
+	ClustersPendingReboot []PendingRebootCluster `json:"clustersPendingReboot,omitempty"`
+
+	// The last time the controller successfully synchronized this status with AWS.
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Family",type=string,JSONPath=`.spec.cacheParameterGroupFamily`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// CacheParameterGroup is the Schema for the cacheparametergroups API
+type CacheParameterGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CacheParameterGroupSpec   `json:"spec,omitempty"`
+	Status CacheParameterGroupStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// CacheParameterGroupList contains a list of CacheParameterGroup
+type CacheParameterGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CacheParameterGroup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CacheParameterGroup{}, &CacheParameterGroupList{})
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SnapshotExport Represents the Amazon S3 bucket a snapshot is exported to.
+type SnapshotExport struct {
+
+	// The Amazon S3 bucket to which the snapshot is exported. Be sure Amazon
+	// ElastiCache has the needed permissions to this S3 bucket.
+	TargetBucket string `json:"targetBucket"`
+
+	// A name for the exported snapshot. ElastiCache does not permit overwriting a
+	// snapshot, therefore this name must be unique within the bucket. Defaults to the
+	// name of the snapshot in AWS.
+	TargetSnapshotName *string `json:"targetSnapshotName,omitempty"`
+}
+
+// CacheSnapshotSpec defines the desired state of CacheSnapshot. Snapshots are
+// immutable, the spec is only used when the snapshot is created.
+type CacheSnapshotSpec struct {
+
+	// A reference to the ElasticCache in the namespace of the CacheSnapshot the
+	// snapshot is created from. Exactly one of ElasticCacheRef and
+	// ReplicationGroupRef must be set.
+	ElasticCacheRef *LocalObjectReference `json:"elasticCacheRef,omitempty"`
+
+	// A reference to the ReplicationGroup in the namespace of the CacheSnapshot the
+	// snapshot is created from.
+	ReplicationGroupRef *LocalObjectReference `json:"replicationGroupRef,omitempty"`
+
+	// The ID of the KMS key used to encrypt the snapshot.
+	KmsKeyId *string `json:"kmsKeyId,omitempty"`
+
+	// A list of tags to be added to this resource.
+	Tags []Tag `json:"tags,omitempty"`
+
+	// Exports the snapshot to an Amazon S3 bucket once it is available.
+	ExportTo *SnapshotExport `json:"exportTo,omitempty"`
+
+	// Specifies whether the snapshot is deleted from AWS when the CacheSnapshot is
+	// deleted. Exported copies in Amazon S3 are never deleted. Defaults to Delete.
+	// +kubebuilder:validation:Enum=Delete;Retain
+	// +kubebuilder:default=Delete
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+}
+
+// SnapshotExportStatus Represents a completed export of the snapshot to Amazon S3.
+type SnapshotExportStatus struct {
+
+	// The Amazon S3 bucket the snapshot was exported to.
+	TargetBucket string `json:"targetBucket"`
+
+	// The name of the exported snapshot.
+	TargetSnapshotName string `json:"targetSnapshotName"`
+
+	// The time the export was requested.
+	ExportTime metav1.Time `json:"exportTime"`
+}
+
+// CacheSnapshotStatus defines the observed state of CacheSnapshot
+type CacheSnapshotStatus struct {
+
+	// Conditions represent the latest available observations of the snapshot
+	// state. Known condition types are Ready, Synced, Deleting and Error.
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// The generation of the CacheSnapshot most recently observed by the controller.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// The name of the snapshot in AWS. It combines the namespace, the name and a
+	// hash of both, is recorded before the snapshot is taken and kept for the
+	// lifetime of the snapshot.
+	SnapshotName *string `json:"snapshotName,omitempty"`
+
+	// The ARN (Amazon Resource Name) of the snapshot.
+	ARN *string `json:"arn,omitempty"`
+
+	// The status of the snapshot. Valid values: creating | available | restoring |
+	// copying | deleting.
+	SnapshotStatus *string `json:"snapshotStatus,omitempty"`
+
+	// Indicates whether the snapshot is from an automatic backup (automated) or was
+	// created manually (manual).
+	SnapshotSource *string `json:"snapshotSource,omitempty"`
+
+	// The user-supplied identifier of the source cluster.
+	CacheClusterId *string `json:"cacheClusterId,omitempty"`
+
+	// The unique identifier of the source replication group.
+	ReplicationGroupId *string `json:"replicationGroupId,omitempty"`
+
+	// The name of the cache engine (memcached or redis) used by the source cluster.
+	Engine *string `json:"engine,omitempty"`
+
+	// The version of the cache engine version that is used by the source cluster.
+	EngineVersion *string `json:"engineVersion,omitempty"`
+
+	// The name of the compute and memory capacity node type for the source cluster.
+	CacheNodeType *string `json:"cacheNodeType,omitempty"`
+
+	// The number of node groups (shards) in this snapshot.
+	NumNodeGroups *int32 `json:"numNodeGroups,omitempty"`
+
+	// The export of the snapshot to Amazon S3, once requested.
+	Export *SnapshotExportStatus `json:"export,omitempty"`
+
+	// The last time the controller successfully synchronized this status with AWS.
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.snapshotStatus`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// CacheSnapshot is the Schema for the cachesnapshots API
+type CacheSnapshot struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CacheSnapshotSpec   `json:"spec,omitempty"`
+	Status CacheSnapshotStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// CacheSnapshotList contains a list of CacheSnapshot
+type CacheSnapshotList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CacheSnapshot `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CacheSnapshot{}, &CacheSnapshotList{})
+}
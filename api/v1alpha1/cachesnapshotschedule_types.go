@@ -0,0 +1,143 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SnapshotScheduleRule describes a series of snapshots taken on a cron schedule
+// and how long they are kept. A snapshot is kept while it satisfies KeepLast or
+// KeepWithin; when neither is set snapshots are never pruned.
+type SnapshotScheduleRule struct {
+
+	// The name of the rule, used in the names of the snapshots it creates.
+	// +kubebuilder:validation:Pattern=^[a-z0-9]([-a-z0-9]*[a-z0-9])?$
+	// +kubebuilder:validation:MaxLength=20
+	Name string `json:"name"`
+
+	// The schedule in Cron format, e.g. "0 * * * *" or "@weekly".
+	// +kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule"`
+
+	// The number of most recent snapshots of this rule that are kept.
+	// +kubebuilder:validation:Minimum=1
+	KeepLast *int32 `json:"keepLast,omitempty"`
+
+	// Snapshots of this rule younger than this duration are kept, e.g. "168h".
+	KeepWithin *metav1.Duration `json:"keepWithin,omitempty"`
+}
+
+// CacheSnapshotScheduleSpec defines the desired state of CacheSnapshotSchedule
+type CacheSnapshotScheduleSpec struct {
+
+	// A reference to the ElasticCache in the namespace of the schedule that is
+	// snapshotted. Exactly one of ElasticCacheRef and ReplicationGroupRef must be
+	// set.
+	ElasticCacheRef *LocalObjectReference `json:"elasticCacheRef,omitempty"`
+
+	// A reference to the ReplicationGroup in the namespace of the schedule that is
+	// snapshotted.
+	ReplicationGroupRef *LocalObjectReference `json:"replicationGroupRef,omitempty"`
+
+	// The rules creating and pruning snapshots.
+	// +kubebuilder:validation:MinItems=1
+	Rules []SnapshotScheduleRule `json:"rules"`
+
+	// This flag tells the controller to suspend subsequent snapshots, it does not
+	// apply to already created snapshots. Pruning continues while suspended.
+	Suspend bool `json:"suspend,omitempty"`
+
+	// Deadline in seconds for taking a snapshot that missed its scheduled time,
+	// e.g. while the operator was down. Missed runs older than the deadline are
+	// skipped. Without a deadline the latest missed run is always taken.
+	// +kubebuilder:validation:Minimum=0
+	StartingDeadlineSeconds *int64 `json:"startingDeadlineSeconds,omitempty"`
+
+	// The ID of the KMS key used to encrypt the snapshots.
+	KmsKeyId *string `json:"kmsKeyId,omitempty"`
+
+	// A list of tags to be added to the snapshots.
+	Tags []Tag `json:"tags,omitempty"`
+}
+
+// SnapshotScheduleRuleStatus Represents the observed state of a rule.
+type SnapshotScheduleRuleStatus struct {
+
+	// The name of the rule.
+	Name string `json:"name"`
+
+	// The last time a snapshot was scheduled by the rule.
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	// The next time a snapshot will be scheduled by the rule.
+	NextScheduleTime *metav1.Time `json:"nextScheduleTime,omitempty"`
+
+	// The names of the CacheSnapshots of the rule that are currently kept.
+	Snapshots []string `json:"snapshots,omitempty"`
+}
+
+// CacheSnapshotScheduleStatus defines the observed state of CacheSnapshotSchedule
+type CacheSnapshotScheduleStatus struct {
+
+	// Conditions represent the latest available observations of the schedule
+	// state. Known condition types are Ready, Synced and Error.
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// The generation of the CacheSnapshotSchedule most recently observed by the
+	// controller.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// The observed state of every rule.
+	Rules []SnapshotScheduleRuleStatus `json:"rules,omitempty"`
+
+	// The last time the controller successfully reconciled the schedule.
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Suspend",type=boolean,JSONPath=`.spec.suspend`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// CacheSnapshotSchedule is the Schema for the cachesnapshotschedules API
+type CacheSnapshotSchedule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CacheSnapshotScheduleSpec   `json:"spec,omitempty"`
+	Status CacheSnapshotScheduleStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// CacheSnapshotScheduleList contains a list of CacheSnapshotSchedule
+type CacheSnapshotScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CacheSnapshotSchedule `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CacheSnapshotSchedule{}, &CacheSnapshotScheduleList{})
+}
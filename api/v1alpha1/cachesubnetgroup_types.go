@@ -0,0 +1,107 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CacheSubnetGroupSpec defines the desired state of CacheSubnetGroup
+type CacheSubnetGroupSpec struct {
+
+	// A description for the cache subnet group.
+	Description string `json:"description"`
+
+	// A list of VPC subnet IDs for the cache subnet group.
+	// +kubebuilder:validation:MinItems=1
+	SubnetIds []string `json:"subnetIds"`
+
+	// A list of tags to be added to this resource.
+	Tags []Tag `json:"tags,omitempty"`
+}
+
+// Subnet Represents the subnet associated with a cluster. This parameter refers
+// to subnets defined in Amazon Virtual Private Cloud (Amazon VPC) and used with
+// ElastiCache.
+type Subnet struct {
+
+	// The unique identifier for the subnet.
+	SubnetIdentifier *string `json:"subnetIdentifier,omitempty"`
+
+	// The Availability Zone associated with the subnet.
+	SubnetAvailabilityZone *string `json:"subnetAvailabilityZone,omitempty"`
+}
+
+// CacheSubnetGroupStatus defines the observed state of CacheSubnetGroup
+type CacheSubnetGroupStatus struct {
+
+	// Conditions represent the latest available observations of the subnet group
+	// state. Known condition types are Ready, Synced, Deleting and Error.
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// The generation of the CacheSubnetGroup most recently observed by the
+	// controller.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// The ARN (Amazon Resource Name) of the cache subnet group.
+	ARN *string `json:"arn,omitempty"`
+
+	// The name of the cache subnet group in AWS.
+	CacheSubnetGroupName *string `json:"cacheSubnetGroupName,omitempty"`
+
+	// The Amazon Virtual Private Cloud identifier (VPC ID) of the cache subnet group.
+	VpcId *string `json:"vpcId,omitempty"`
+
+	// A list of subnets associated with the cache subnet group.
+	Subnets []Subnet `json:"subnets,omitempty"`
+
+	// The last time the controller successfully synchronized this status with AWS.
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="VPC",type=string,JSONPath=`.status.vpcId`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// CacheSubnetGroup is the Schema for the cachesubnetgroups API
+type CacheSubnetGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CacheSubnetGroupSpec   `json:"spec,omitempty"`
+	Status CacheSubnetGroupStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// CacheSubnetGroupList contains a list of CacheSubnetGroup
+type CacheSubnetGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CacheSubnetGroup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CacheSubnetGroup{}, &CacheSubnetGroupList{})
+}
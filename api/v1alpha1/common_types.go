@@ -1,4 +1,88 @@
 package v1alpha1
 
 type Region string
-type AvailabilityZone string
\ No newline at end of file
+type AvailabilityZone string
+
+// LocalObjectReference references another object of this API group by name.
+// Namespaced objects are looked up in the namespace of the referencing
+// resource.
+type LocalObjectReference struct {
+
+	// The name of the referenced object.
+	Name string `json:"name"`
+}
+
+// SecretReference references a Secret in any namespace. It is used by
+// cluster-scoped resources.
+type SecretReference struct {
+
+	// The name of the Secret.
+	Name string `json:"name"`
+
+	// The namespace of the Secret.
+	Namespace string `json:"namespace"`
+}
+
+// SecretKeySelector selects a key of a Secret in the namespace of the resource
+// that references it.
+type SecretKeySelector struct {
+
+	// The name of the Secret.
+	Name string `json:"name"`
+
+	// The key of the Secret to select from.
+	Key string `json:"key"`
+}
+
+// ImportAnnotation marks an object whose AWS resource already exists and should
+// be adopted instead of created. Its value must be "true".
+const ImportAnnotation = "aws.sergeyshevch.dev/import"
+
+// OperatorTagPrefix starts the keys of the AWS tags the operator puts on the
+// resources it manages. Tags with this prefix can not be set in a spec.
+const OperatorTagPrefix = "aws.sergeyshevch.dev/"
+
+// DeletionPolicy specifies what happens to the AWS resource when the Kubernetes
+// object managing it is deleted.
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes the AWS resource together with the object.
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+
+	// DeletionPolicyRetain keeps the AWS resource when the object is deleted.
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+
+	// DeletionPolicySnapshot deletes the AWS resource after taking a final
+	// snapshot of it.
+	DeletionPolicySnapshot DeletionPolicy = "Snapshot"
+)
+
+// Condition types reported in the status of managed AWS resources.
+const (
+	// ConditionTypeReady indicates that the AWS resource is available and can be used.
+	ConditionTypeReady = "Ready"
+
+	// ConditionTypeSynced indicates that the last reconciliation with AWS succeeded.
+	ConditionTypeSynced = "Synced"
+
+	// ConditionTypeDeleting indicates that the AWS resource is being deleted.
+	ConditionTypeDeleting = "Deleting"
+
+	// ConditionTypeError indicates that the last reconciliation with AWS failed.
+	ConditionTypeError = "Error"
+)
+
+// Condition reasons reported in the status of managed AWS resources.
+const (
+	ReasonAvailable        = "Available"
+	ReasonProvisioning     = "Provisioning"
+	ReasonModifying        = "Modifying"
+	ReasonDeleting         = "Deleting"
+	ReasonFailed           = "Failed"
+	ReasonReconcileSuccess = "ReconcileSuccess"
+	ReasonReconcileError   = "ReconcileError"
+	ReasonWaitingForRefs   = "WaitingForReferences"
+	ReasonSuspended        = "Suspended"
+	ReasonNotOwned         = "NotOwned"
+)
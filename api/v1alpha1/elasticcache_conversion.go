@@ -0,0 +1,440 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/sergeyshevch/cloud-resource-operator/api/v1beta1"
+)
+
+// The enumerations of v1alpha1 use the values of the AWS API while v1beta1
+// spells them the Kubernetes way. Values without a counterpart are kept as
+// they are.
+var (
+	azModes = map[string]string{
+		string(types.AZModeSingleAz): string(v1beta1.AZModeSingleAZ),
+		string(types.AZModeCrossAz):  string(v1beta1.AZModeCrossAZ),
+	}
+	outpostModes = map[string]string{
+		string(types.OutpostModeSingleOutpost): string(v1beta1.OutpostModeSingleOutpost),
+		string(types.OutpostModeCrossOutpost):  string(v1beta1.OutpostModeCrossOutpost),
+	}
+	authTokenUpdateStrategies = map[string]string{
+		string(types.AuthTokenUpdateStrategyTypeRotate): string(v1beta1.AuthTokenUpdateStrategyRotate),
+		string(types.AuthTokenUpdateStrategyTypeSet):    string(v1beta1.AuthTokenUpdateStrategySet),
+		string(types.AuthTokenUpdateStrategyTypeDelete): string(v1beta1.AuthTokenUpdateStrategyDelete),
+	}
+	authTokenStatuses = map[string]string{
+		string(types.AuthTokenUpdateStatusSetting):  string(v1beta1.AuthTokenStatusSetting),
+		string(types.AuthTokenUpdateStatusRotating): string(v1beta1.AuthTokenStatusRotating),
+	}
+)
+
+// unconvertedValuesAnnotation keeps the values of v1alpha1 that v1beta1 can not
+// represent, e.g. a v1alpha1 azMode of CrossAZ would come back as cross-az.
+// v1alpha1 does not restrict its enumerations, so such values are restored from
+// the annotation when converting back. It also carries the deprecated authToken
+// until the controller has moved it to a Secret.
+const unconvertedValuesAnnotation = "aws.sergeyshevch.dev/v1alpha1-unconverted-values"
+
+// unconvertedValues holds the v1alpha1 values of the enumerations whose
+// conversion is lossy, and the fields v1beta1 does not have.
+type unconvertedValues struct {
+	AZMode                  string  `json:"azMode,omitempty"`
+	OutpostMode             string  `json:"outpostMode,omitempty"`
+	AuthTokenUpdateStrategy string  `json:"authTokenUpdateStrategy,omitempty"`
+	AuthTokenStatus         string  `json:"authTokenStatus,omitempty"`
+	AuthToken               *string `json:"authToken,omitempty"`
+}
+
+var _ conversion.Convertible = &ElasticCache{}
+
+// ConvertTo converts this ElasticCache to the hub version v1beta1.
+func (r *ElasticCache) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.ElasticCache)
+	dst.ObjectMeta = r.ObjectMeta
+
+	dst.Spec = v1beta1.ElasticCacheSpec{
+		CacheCluster:      convertAWSConfigTo(r.Spec.AWSConfig),
+		ProviderConfigRef: convertReferenceTo(r.Spec.ProviderConfigRef),
+		ExternalName:      r.Spec.ExternalName,
+		NamingStrategy:    v1beta1.ClusterNamingStrategy(r.Spec.NamingStrategy),
+		DeletionPolicy:    v1beta1.DeletionPolicy(r.Spec.DeletionPolicy),
+	}
+	if r.Spec.CacheClassName != nil {
+		dst.Spec.CacheClassRef = &v1beta1.LocalObjectReference{Name: *r.Spec.CacheClassName}
+	}
+	if target := r.Spec.WriteConnectionDetailsTo; target != nil {
+		dst.Spec.WriteConnectionDetailsTo = &v1beta1.ConnectionDetailsTarget{
+			SecretName:    target.SecretName,
+			ConfigMapName: target.ConfigMapName,
+		}
+	}
+
+	status := r.Status
+	dst.Status = v1beta1.ElasticCacheStatus{
+		Conditions:                status.Conditions,
+		ObservedGeneration:        status.ObservedGeneration,
+		CacheClusterId:            status.CacheClusterId,
+		CacheClusterStatus:        status.CacheClusterStatus,
+		ARN:                       status.ARN,
+		Engine:                    status.Engine,
+		EngineVersion:             status.EngineVersion,
+		CacheNodeType:             status.CacheNodeType,
+		NumCacheNodes:             status.NumCacheNodes,
+		PreferredAvailabilityZone: status.PreferredAvailabilityZone,
+		ConfigurationEndpoint:     (*v1beta1.Endpoint)(status.ConfigurationEndpoint),
+		AuthTokenHash:             status.AuthTokenHash,
+		DriftedFields:             status.DriftedFields,
+		FinalSnapshotIdentifier:   status.FinalSnapshotIdentifier,
+		LastSyncTime:              status.LastSyncTime,
+	}
+	for _, node := range status.CacheNodes {
+		dst.Status.CacheNodes = append(dst.Status.CacheNodes, v1beta1.CacheNode{
+			CacheNodeId:              node.CacheNodeId,
+			CacheNodeStatus:          node.CacheNodeStatus,
+			CacheNodeCreateTime:      node.CacheNodeCreateTime,
+			CustomerAvailabilityZone: node.CustomerAvailabilityZone,
+			CustomerOutpostArn:       node.CustomerOutpostArn,
+			Endpoint:                 (*v1beta1.Endpoint)(node.Endpoint),
+			ParameterGroupStatus:     node.ParameterGroupStatus,
+		})
+	}
+	if pending := status.PendingModifiedValues; pending != nil {
+		dst.Status.PendingModifiedValues = &v1beta1.PendingModifiedValues{
+			AuthTokenStatus:      v1beta1.AuthTokenStatus(toHubEnum(authTokenStatuses, string(pending.AuthTokenStatus))),
+			CacheNodeIdsToRemove: pending.CacheNodeIdsToRemove,
+			CacheNodeType:        pending.CacheNodeType,
+			EngineVersion:        pending.EngineVersion,
+			NumCacheNodes:        pending.NumCacheNodes,
+		}
+	}
+	for _, delivery := range status.LogDeliveryConfigurations {
+		dst.Status.LogDeliveryConfigurations = append(dst.Status.LogDeliveryConfigurations, v1beta1.LogDeliveryStatus{
+			LogType:         v1beta1.LogType(delivery.LogType),
+			LogFormat:       v1beta1.LogFormat(delivery.LogFormat),
+			DestinationType: delivery.DestinationType,
+			Destination:     delivery.Destination,
+			Status:          delivery.Status,
+			Message:         delivery.Message,
+		})
+	}
+	return r.saveUnconvertedValues(dst)
+}
+
+// ConvertFrom converts from the hub version v1beta1 to this version.
+func (r *ElasticCache) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.ElasticCache)
+	r.ObjectMeta = src.ObjectMeta
+
+	r.Spec = ElasticCacheSpec{
+		AWSConfig:         convertAWSConfigFrom(src.Spec.CacheCluster),
+		ProviderConfigRef: convertReferenceFrom(src.Spec.ProviderConfigRef),
+		ExternalName:      src.Spec.ExternalName,
+		NamingStrategy:    ClusterNamingStrategy(src.Spec.NamingStrategy),
+		DeletionPolicy:    DeletionPolicy(src.Spec.DeletionPolicy),
+	}
+	if src.Spec.CacheClassRef != nil {
+		name := src.Spec.CacheClassRef.Name
+		r.Spec.CacheClassName = &name
+	}
+	if target := src.Spec.WriteConnectionDetailsTo; target != nil {
+		r.Spec.WriteConnectionDetailsTo = &ConnectionDetailsTarget{
+			SecretName:    target.SecretName,
+			ConfigMapName: target.ConfigMapName,
+		}
+	}
+
+	status := src.Status
+	r.Status = ElasticCacheStatus{
+		Conditions:                status.Conditions,
+		ObservedGeneration:        status.ObservedGeneration,
+		CacheClusterId:            status.CacheClusterId,
+		CacheClusterStatus:        status.CacheClusterStatus,
+		ARN:                       status.ARN,
+		Engine:                    status.Engine,
+		EngineVersion:             status.EngineVersion,
+		CacheNodeType:             status.CacheNodeType,
+		NumCacheNodes:             status.NumCacheNodes,
+		PreferredAvailabilityZone: status.PreferredAvailabilityZone,
+		ConfigurationEndpoint:     (*Endpoint)(status.ConfigurationEndpoint),
+		AuthTokenHash:             status.AuthTokenHash,
+		DriftedFields:             status.DriftedFields,
+		FinalSnapshotIdentifier:   status.FinalSnapshotIdentifier,
+		LastSyncTime:              status.LastSyncTime,
+	}
+	for _, node := range status.CacheNodes {
+		r.Status.CacheNodes = append(r.Status.CacheNodes, CacheNode{
+			CacheNodeId:              node.CacheNodeId,
+			CacheNodeStatus:          node.CacheNodeStatus,
+			CacheNodeCreateTime:      node.CacheNodeCreateTime,
+			CustomerAvailabilityZone: node.CustomerAvailabilityZone,
+			CustomerOutpostArn:       node.CustomerOutpostArn,
+			Endpoint:                 (*Endpoint)(node.Endpoint),
+			ParameterGroupStatus:     node.ParameterGroupStatus,
+		})
+	}
+	if pending := status.PendingModifiedValues; pending != nil {
+		r.Status.PendingModifiedValues = &PendingModifiedValues{
+			AuthTokenStatus:      types.AuthTokenUpdateStatus(fromHubEnum(authTokenStatuses, string(pending.AuthTokenStatus))),
+			CacheNodeIdsToRemove: pending.CacheNodeIdsToRemove,
+			CacheNodeType:        pending.CacheNodeType,
+			EngineVersion:        pending.EngineVersion,
+			NumCacheNodes:        pending.NumCacheNodes,
+		}
+	}
+	for _, delivery := range status.LogDeliveryConfigurations {
+		r.Status.LogDeliveryConfigurations = append(r.Status.LogDeliveryConfigurations, LogDeliveryStatus{
+			LogType:         LogType(delivery.LogType),
+			LogFormat:       LogFormat(delivery.LogFormat),
+			DestinationType: delivery.DestinationType,
+			Destination:     delivery.Destination,
+			Status:          delivery.Status,
+			Message:         delivery.Message,
+		})
+	}
+	return r.restoreUnconvertedValues(src)
+}
+
+// saveUnconvertedValues records the values of r that do not survive the
+// conversion to dst in an annotation of dst.
+func (r *ElasticCache) saveUnconvertedValues(dst *v1beta1.ElasticCache) error {
+	values := unconvertedValues{}
+	if config := r.Spec.AWSConfig; config != nil {
+		values.AZMode = lossyEnum(azModes, string(config.AZMode))
+		values.OutpostMode = lossyEnum(outpostModes, string(config.OutpostMode))
+		values.AuthTokenUpdateStrategy = lossyEnum(authTokenUpdateStrategies, string(config.AuthTokenUpdateStrategy))
+		values.AuthToken = config.AuthToken
+	}
+	if pending := r.Status.PendingModifiedValues; pending != nil {
+		values.AuthTokenStatus = lossyEnum(authTokenStatuses, string(pending.AuthTokenStatus))
+	}
+	if values == (unconvertedValues{}) {
+		return nil
+	}
+
+	data, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	annotations := map[string]string{}
+	for key, value := range dst.Annotations {
+		annotations[key] = value
+	}
+	annotations[unconvertedValuesAnnotation] = string(data)
+	dst.Annotations = annotations
+	return nil
+}
+
+// restoreUnconvertedValues restores the values recorded by
+// saveUnconvertedValues, unless the fields were changed in v1beta1 since, and
+// removes the annotation from r.
+func (r *ElasticCache) restoreUnconvertedValues(src *v1beta1.ElasticCache) error {
+	data, ok := r.Annotations[unconvertedValuesAnnotation]
+	if !ok {
+		return nil
+	}
+	annotations := map[string]string{}
+	for key, value := range r.Annotations {
+		if key != unconvertedValuesAnnotation {
+			annotations[key] = value
+		}
+	}
+	r.Annotations = annotations
+	if len(annotations) == 0 {
+		r.Annotations = nil
+	}
+
+	values := unconvertedValues{}
+	if err := json.Unmarshal([]byte(data), &values); err != nil {
+		return err
+	}
+	if config, params := r.Spec.AWSConfig, src.Spec.CacheCluster; config != nil {
+		if isUnchangedEnum(azModes, values.AZMode, string(params.AZMode)) {
+			config.AZMode = types.AZMode(values.AZMode)
+		}
+		if isUnchangedEnum(outpostModes, values.OutpostMode, string(params.OutpostMode)) {
+			config.OutpostMode = types.OutpostMode(values.OutpostMode)
+		}
+		if isUnchangedEnum(authTokenUpdateStrategies, values.AuthTokenUpdateStrategy, string(params.AuthTokenUpdateStrategy)) {
+			config.AuthTokenUpdateStrategy = types.AuthTokenUpdateStrategyType(values.AuthTokenUpdateStrategy)
+		}
+		// A token referenced from a Secret in v1beta1 replaces the deprecated
+		// one.
+		if params.AuthTokenSecretRef == nil {
+			config.AuthToken = values.AuthToken
+		}
+	}
+	if pending, hubPending := r.Status.PendingModifiedValues, src.Status.PendingModifiedValues; pending != nil {
+		if isUnchangedEnum(authTokenStatuses, values.AuthTokenStatus, string(hubPending.AuthTokenStatus)) {
+			pending.AuthTokenStatus = types.AuthTokenUpdateStatus(values.AuthTokenStatus)
+		}
+	}
+	return nil
+}
+
+// lossyEnum returns value when converting it to v1beta1 and back yields a
+// different value, and an empty string otherwise.
+func lossyEnum(values map[string]string, value string) string {
+	if fromHubEnum(values, toHubEnum(values, value)) == value {
+		return ""
+	}
+	return value
+}
+
+// isUnchangedEnum tells whether the recorded v1alpha1 value is set and still
+// converts to hubValue.
+func isUnchangedEnum(values map[string]string, value string, hubValue string) bool {
+	return value != "" && toHubEnum(values, value) == hubValue
+}
+
+func convertAWSConfigTo(config *ElasticCacheAwsConfig) *v1beta1.CacheClusterParameters {
+	if config == nil {
+		return nil
+	}
+	params := &v1beta1.CacheClusterParameters{
+		Engine:                     (*v1beta1.CacheEngine)(config.Engine),
+		EngineVersion:              config.EngineVersion,
+		CacheNodeType:              config.CacheNodeType,
+		NumCacheNodes:              config.NumCacheNodes,
+		Port:                       config.Port,
+		AZMode:                     v1beta1.AZMode(toHubEnum(azModes, string(config.AZMode))),
+		PreferredAvailabilityZone:  config.PreferredAvailabilityZone,
+		PreferredAvailabilityZones: config.PreferredAvailabilityZones,
+		OutpostMode:                v1beta1.OutpostMode(toHubEnum(outpostModes, string(config.OutpostMode))),
+		PreferredOutpostArn:        config.PreferredOutpostArn,
+		PreferredOutpostArns:       config.PreferredOutpostArns,
+		AuthTokenUpdateStrategy:    v1beta1.AuthTokenUpdateStrategy(toHubEnum(authTokenUpdateStrategies, string(config.AuthTokenUpdateStrategy))),
+		CacheParameterGroupName:    config.CacheParameterGroupName,
+		CacheSubnetGroupName:       config.CacheSubnetGroupName,
+		CacheSubnetGroupRef:        convertReferenceTo(config.SubnetGroupRef),
+		CacheSecurityGroupNames:    config.CacheSecurityGroupNames,
+		SecurityGroupIds:           config.SecurityGroupIds,
+		NotificationTopicArn:       config.NotificationTopicArn,
+		ReplicationGroupId:         config.ReplicationGroupId,
+		SnapshotArns:               config.SnapshotArns,
+		SnapshotName:               config.SnapshotName,
+		SnapshotRetentionLimit:     config.SnapshotRetentionLimit,
+		SnapshotWindow:             config.SnapshotWindow,
+		PreferredMaintenanceWindow: config.PreferredMaintenanceWindow,
+	}
+	if ref := config.AuthTokenSecretRef; ref != nil {
+		params.AuthTokenSecretRef = &v1beta1.SecretKeySelector{Name: ref.Name, Key: ref.Key}
+	}
+	for _, logDelivery := range config.LogDeliveryConfigurations {
+		params.LogDeliveryConfigurations = append(params.LogDeliveryConfigurations, v1beta1.LogDeliveryConfiguration{
+			LogType:         v1beta1.LogType(logDelivery.LogType),
+			LogFormat:       v1beta1.LogFormat(logDelivery.LogFormat),
+			CloudWatchLogs:  (*v1beta1.CloudWatchLogsDestination)(logDelivery.CloudWatchLogs),
+			KinesisFirehose: (*v1beta1.KinesisFirehoseDestination)(logDelivery.KinesisFirehose),
+		})
+	}
+	for _, tag := range config.Tags {
+		// The key is required by the schema, it is only nil for objects that
+		// were never stored.
+		var key string
+		if tag.Key != nil {
+			key = *tag.Key
+		}
+		params.Tags = append(params.Tags, v1beta1.Tag{Key: key, Value: tag.Value})
+	}
+	return params
+}
+
+func convertAWSConfigFrom(params *v1beta1.CacheClusterParameters) *ElasticCacheAwsConfig {
+	if params == nil {
+		return nil
+	}
+	config := &ElasticCacheAwsConfig{
+		Engine:                     (*string)(params.Engine),
+		EngineVersion:              params.EngineVersion,
+		CacheNodeType:              params.CacheNodeType,
+		NumCacheNodes:              params.NumCacheNodes,
+		Port:                       params.Port,
+		AZMode:                     types.AZMode(fromHubEnum(azModes, string(params.AZMode))),
+		PreferredAvailabilityZone:  params.PreferredAvailabilityZone,
+		PreferredAvailabilityZones: params.PreferredAvailabilityZones,
+		OutpostMode:                types.OutpostMode(fromHubEnum(outpostModes, string(params.OutpostMode))),
+		PreferredOutpostArn:        params.PreferredOutpostArn,
+		PreferredOutpostArns:       params.PreferredOutpostArns,
+		AuthTokenUpdateStrategy:    types.AuthTokenUpdateStrategyType(fromHubEnum(authTokenUpdateStrategies, string(params.AuthTokenUpdateStrategy))),
+		CacheParameterGroupName:    params.CacheParameterGroupName,
+		CacheSubnetGroupName:       params.CacheSubnetGroupName,
+		SubnetGroupRef:             convertReferenceFrom(params.CacheSubnetGroupRef),
+		CacheSecurityGroupNames:    params.CacheSecurityGroupNames,
+		SecurityGroupIds:           params.SecurityGroupIds,
+		NotificationTopicArn:       params.NotificationTopicArn,
+		ReplicationGroupId:         params.ReplicationGroupId,
+		SnapshotArns:               params.SnapshotArns,
+		SnapshotName:               params.SnapshotName,
+		SnapshotRetentionLimit:     params.SnapshotRetentionLimit,
+		SnapshotWindow:             params.SnapshotWindow,
+		PreferredMaintenanceWindow: params.PreferredMaintenanceWindow,
+	}
+	if ref := params.AuthTokenSecretRef; ref != nil {
+		config.AuthTokenSecretRef = &SecretKeySelector{Name: ref.Name, Key: ref.Key}
+	}
+	for _, logDelivery := range params.LogDeliveryConfigurations {
+		config.LogDeliveryConfigurations = append(config.LogDeliveryConfigurations, LogDeliveryConfiguration{
+			LogType:         LogType(logDelivery.LogType),
+			LogFormat:       LogFormat(logDelivery.LogFormat),
+			CloudWatchLogs:  (*CloudWatchLogsDestination)(logDelivery.CloudWatchLogs),
+			KinesisFirehose: (*KinesisFirehoseDestination)(logDelivery.KinesisFirehose),
+		})
+	}
+	for _, tag := range params.Tags {
+		key := tag.Key
+		config.Tags = append(config.Tags, Tag{Key: &key, Value: tag.Value})
+	}
+	return config
+}
+
+func convertReferenceTo(ref *LocalObjectReference) *v1beta1.LocalObjectReference {
+	if ref == nil {
+		return nil
+	}
+	return &v1beta1.LocalObjectReference{Name: ref.Name}
+}
+
+func convertReferenceFrom(ref *v1beta1.LocalObjectReference) *LocalObjectReference {
+	if ref == nil {
+		return nil
+	}
+	return &LocalObjectReference{Name: ref.Name}
+}
+
+// toHubEnum returns the v1beta1 spelling of the v1alpha1 value.
+func toHubEnum(values map[string]string, value string) string {
+	if hubValue, ok := values[value]; ok {
+		return hubValue
+	}
+	return value
+}
+
+// fromHubEnum returns the v1alpha1 spelling of the v1beta1 value.
+func fromHubEnum(values map[string]string, hubValue string) string {
+	for value, candidate := range values {
+		if candidate == hubValue {
+			return value
+		}
+	}
+	return hubValue
+}
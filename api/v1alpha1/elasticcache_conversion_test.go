@@ -0,0 +1,306 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/diff"
+
+	"github.com/sergeyshevch/cloud-resource-operator/api/v1beta1"
+)
+
+func TestElasticCacheConversionRoundTrip(t *testing.T) {
+	now := metav1.NewTime(time.Date(2021, 7, 1, 12, 0, 0, 0, time.UTC))
+	original := &ElasticCache{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "cache",
+			Namespace:   "default",
+			Annotations: map[string]string{ImportAnnotation: "true"},
+			Finalizers:  []string{"aws.sergeyshevch.dev/finalizer"},
+		},
+		Spec: ElasticCacheSpec{
+			AWSConfig: &ElasticCacheAwsConfig{
+				AZMode:                     types.AZModeCrossAz,
+				AuthTokenSecretRef:         &SecretKeySelector{Name: "auth", Key: "token"},
+				AuthTokenUpdateStrategy:    types.AuthTokenUpdateStrategyTypeSet,
+				CacheNodeType:              stringPtr("cache.t3.micro"),
+				CacheParameterGroupName:    stringPtr("params"),
+				CacheSecurityGroupNames:    []string{"default"},
+				CacheSubnetGroupName:       stringPtr("subnets"),
+				SubnetGroupRef:             &LocalObjectReference{Name: "subnets"},
+				Engine:                     stringPtr("memcached"),
+				EngineVersion:              stringPtr("1.6.6"),
+				NotificationTopicArn:       stringPtr("arn:aws:sns:us-east-1:123456789012:topic"),
+				NumCacheNodes:              int32Ptr(2),
+				OutpostMode:                types.OutpostModeCrossOutpost,
+				Port:                       int32Ptr(11211),
+				PreferredAvailabilityZone:  stringPtr("us-east-1a"),
+				PreferredAvailabilityZones: []string{"us-east-1a", "us-east-1b"},
+				PreferredMaintenanceWindow: stringPtr("sun:23:00-mon:01:30"),
+				PreferredOutpostArn:        stringPtr("arn:aws:outposts:us-east-1:123456789012:outpost/op-1"),
+				PreferredOutpostArns:       []string{"arn:aws:outposts:us-east-1:123456789012:outpost/op-2"},
+				ReplicationGroupId:         stringPtr("group"),
+				SecurityGroupIds:           []string{"sg-1"},
+				SnapshotArns:               []string{"arn:aws:s3:::bucket/snapshot.rdb"},
+				SnapshotName:               stringPtr("snapshot"),
+				SnapshotRetentionLimit:     int32Ptr(5),
+				SnapshotWindow:             stringPtr("05:00-09:00"),
+				LogDeliveryConfigurations: []LogDeliveryConfiguration{
+					{LogType: LogTypeSlowLog, LogFormat: LogFormatJSON, CloudWatchLogs: &CloudWatchLogsDestination{LogGroup: "slow"}},
+					{LogType: LogTypeEngineLog, LogFormat: LogFormatText, KinesisFirehose: &KinesisFirehoseDestination{DeliveryStream: "engine"}},
+				},
+				Tags: []Tag{
+					{Key: stringPtr("team"), Value: stringPtr("platform")},
+					{Key: stringPtr("empty")},
+				},
+			},
+			CacheClassName:           stringPtr("standard"),
+			ProviderConfigRef:        &LocalObjectReference{Name: "provider"},
+			ExternalName:             stringPtr("external"),
+			NamingStrategy:           ClusterNamingStrategyNamespacedHash,
+			WriteConnectionDetailsTo: &ConnectionDetailsTarget{SecretName: "conn", ConfigMapName: stringPtr("conn")},
+			DeletionPolicy:           DeletionPolicySnapshot,
+		},
+		Status: ElasticCacheStatus{
+			Conditions: []metav1.Condition{{
+				Type:               ConditionTypeReady,
+				Status:             metav1.ConditionTrue,
+				Reason:             ReasonAvailable,
+				ObservedGeneration: 2,
+				LastTransitionTime: now,
+			}},
+			ObservedGeneration:        2,
+			CacheClusterId:            stringPtr("external"),
+			CacheClusterStatus:        stringPtr("modifying"),
+			ARN:                       stringPtr("arn:aws:elasticache:us-east-1:123456789012:cluster:external"),
+			Engine:                    stringPtr("memcached"),
+			EngineVersion:             stringPtr("1.6.6"),
+			CacheNodeType:             stringPtr("cache.t3.micro"),
+			NumCacheNodes:             int32Ptr(2),
+			PreferredAvailabilityZone: stringPtr("Multiple"),
+			ConfigurationEndpoint:     &Endpoint{Address: stringPtr("external.cfg.cache.amazonaws.com"), Port: 11211},
+			CacheNodes: []CacheNode{{
+				CacheNodeId:              stringPtr("0001"),
+				CacheNodeStatus:          stringPtr("available"),
+				CacheNodeCreateTime:      &now,
+				CustomerAvailabilityZone: stringPtr("us-east-1a"),
+				CustomerOutpostArn:       stringPtr("arn:aws:outposts:us-east-1:123456789012:outpost/op-1"),
+				Endpoint:                 &Endpoint{Address: stringPtr("external.0001.cache.amazonaws.com"), Port: 11211},
+				ParameterGroupStatus:     stringPtr("in-sync"),
+			}},
+			PendingModifiedValues: &PendingModifiedValues{
+				AuthTokenStatus:      types.AuthTokenUpdateStatusRotating,
+				CacheNodeIdsToRemove: []string{"0002"},
+				CacheNodeType:        stringPtr("cache.t3.small"),
+				EngineVersion:        stringPtr("1.6.12"),
+				NumCacheNodes:        int32Ptr(1),
+			},
+			LogDeliveryConfigurations: []LogDeliveryStatus{{
+				LogType:         LogTypeSlowLog,
+				LogFormat:       LogFormatJSON,
+				DestinationType: "cloudwatch-logs",
+				Destination:     "slow",
+				Status:          "error",
+				Message:         stringPtr("log group not found"),
+			}},
+			AuthTokenHash:           "hash",
+			DriftedFields:           []string{"snapshotRetentionLimit"},
+			FinalSnapshotIdentifier: stringPtr("final"),
+			LastSyncTime:            &now,
+		},
+	}
+
+	hub := &v1beta1.ElasticCache{}
+	if err := original.DeepCopy().ConvertTo(hub); err != nil {
+		t.Fatalf("ConvertTo: %v", err)
+	}
+	if got := *hub.Spec.CacheCluster.Engine; got != v1beta1.CacheEngineMemcached {
+		t.Errorf("engine = %q, want %q", got, v1beta1.CacheEngineMemcached)
+	}
+	if got := hub.Spec.CacheCluster.AZMode; got != v1beta1.AZModeCrossAZ {
+		t.Errorf("azMode = %q, want %q", got, v1beta1.AZModeCrossAZ)
+	}
+	if got := hub.Spec.CacheCluster.OutpostMode; got != v1beta1.OutpostModeCrossOutpost {
+		t.Errorf("outpostMode = %q, want %q", got, v1beta1.OutpostModeCrossOutpost)
+	}
+	if got := hub.Spec.CacheCluster.AuthTokenUpdateStrategy; got != v1beta1.AuthTokenUpdateStrategySet {
+		t.Errorf("authTokenUpdateStrategy = %q, want %q", got, v1beta1.AuthTokenUpdateStrategySet)
+	}
+	if got := hub.Status.PendingModifiedValues.AuthTokenStatus; got != v1beta1.AuthTokenStatusRotating {
+		t.Errorf("authTokenStatus = %q, want %q", got, v1beta1.AuthTokenStatusRotating)
+	}
+	if got := hub.Spec.CacheClassRef; got == nil || got.Name != "standard" {
+		t.Errorf("cacheClassRef = %v, want standard", got)
+	}
+
+	restored := &ElasticCache{}
+	if err := restored.ConvertFrom(hub.DeepCopy()); err != nil {
+		t.Fatalf("ConvertFrom: %v", err)
+	}
+	if !equality.Semantic.DeepEqual(original, restored) {
+		t.Errorf("round trip is lossy:\n%s", diff.ObjectReflectDiff(original, restored))
+	}
+
+	roundTripped := &v1beta1.ElasticCache{}
+	if err := restored.ConvertTo(roundTripped); err != nil {
+		t.Fatalf("ConvertTo: %v", err)
+	}
+	if !equality.Semantic.DeepEqual(hub, roundTripped) {
+		t.Errorf("round trip of the hub is lossy:\n%s", diff.ObjectReflectDiff(hub, roundTripped))
+	}
+}
+
+func TestElasticCacheConversionKeepsUnsetFields(t *testing.T) {
+	original := &ElasticCache{
+		ObjectMeta: metav1.ObjectMeta{Name: "cache", Namespace: "default"},
+		Spec:       ElasticCacheSpec{AWSConfig: &ElasticCacheAwsConfig{}},
+	}
+
+	hub := &v1beta1.ElasticCache{}
+	if err := original.DeepCopy().ConvertTo(hub); err != nil {
+		t.Fatalf("ConvertTo: %v", err)
+	}
+	restored := &ElasticCache{}
+	if err := restored.ConvertFrom(hub); err != nil {
+		t.Fatalf("ConvertFrom: %v", err)
+	}
+	if !equality.Semantic.DeepEqual(original, restored) {
+		t.Errorf("round trip is lossy:\n%s", diff.ObjectReflectDiff(original, restored))
+	}
+}
+
+// The deprecated authToken has no v1beta1 field, it is kept in the annotation
+// until the controller moves it to a Secret.
+func TestElasticCacheConversionKeepsDeprecatedAuthToken(t *testing.T) {
+	original := &ElasticCache{
+		ObjectMeta: metav1.ObjectMeta{Name: "cache", Namespace: "default"},
+		Spec: ElasticCacheSpec{AWSConfig: &ElasticCacheAwsConfig{
+			AuthToken: stringPtr("0123456789abcdef0123"),
+		}},
+	}
+
+	hub := &v1beta1.ElasticCache{}
+	if err := original.DeepCopy().ConvertTo(hub); err != nil {
+		t.Fatalf("ConvertTo: %v", err)
+	}
+	restored := &ElasticCache{}
+	if err := restored.ConvertFrom(hub); err != nil {
+		t.Fatalf("ConvertFrom: %v", err)
+	}
+	if !equality.Semantic.DeepEqual(original, restored) {
+		t.Errorf("round trip is lossy:\n%s", diff.ObjectReflectDiff(original, restored))
+	}
+
+	hub.Spec.CacheCluster.AuthTokenSecretRef = &v1beta1.SecretKeySelector{Name: "auth", Key: "token"}
+	migrated := &ElasticCache{}
+	if err := migrated.ConvertFrom(hub); err != nil {
+		t.Fatalf("ConvertFrom: %v", err)
+	}
+	if migrated.Spec.AWSConfig.AuthToken != nil {
+		t.Errorf("authToken = %q, want it replaced by authTokenSecretRef", *migrated.Spec.AWSConfig.AuthToken)
+	}
+}
+
+// The webhooks only serve v1alpha1 and match v1beta1 requests through their
+// equivalent match policy, so the API server validates v1beta1 objects after
+// converting them with ConvertFrom.
+func TestConvertedElasticCacheIsValidated(t *testing.T) {
+	engine := v1beta1.CacheEngineRedis
+	hub := &v1beta1.ElasticCache{
+		ObjectMeta: metav1.ObjectMeta{Name: "cache", Namespace: "default"},
+		Spec: v1beta1.ElasticCacheSpec{
+			CacheCluster: &v1beta1.CacheClusterParameters{
+				Engine:        &engine,
+				EngineVersion: stringPtr("6.x"),
+				CacheNodeType: stringPtr("cache.t3.micro"),
+				NumCacheNodes: int32Ptr(1),
+			},
+		},
+	}
+
+	valid := &ElasticCache{}
+	if err := valid.ConvertFrom(hub.DeepCopy()); err != nil {
+		t.Fatalf("ConvertFrom: %v", err)
+	}
+	if err := valid.ValidateCreate(); err != nil {
+		t.Errorf("ValidateCreate of a valid converted object: %v", err)
+	}
+
+	hub.Spec.CacheCluster.NumCacheNodes = int32Ptr(2)
+	invalid := &ElasticCache{}
+	if err := invalid.ConvertFrom(hub.DeepCopy()); err != nil {
+		t.Fatalf("ConvertFrom: %v", err)
+	}
+	if err := invalid.ValidateCreate(); err == nil {
+		t.Error("ValidateCreate accepted a redis cluster with 2 nodes")
+	}
+	if err := invalid.ValidateUpdate(valid); err == nil {
+		t.Error("ValidateUpdate accepted a redis cluster with 2 nodes")
+	}
+}
+
+func TestElasticCacheConversionKeepsUnknownValues(t *testing.T) {
+	original := &ElasticCache{
+		ObjectMeta: metav1.ObjectMeta{Name: "cache", Namespace: "default"},
+		Spec: ElasticCacheSpec{
+			AWSConfig: &ElasticCacheAwsConfig{
+				// v1alpha1 accepts any value, including the v1beta1
+				// spellings that convert back to the AWS ones.
+				AZMode:                  "CrossAZ",
+				OutpostMode:             "elsewhere",
+				AuthTokenUpdateStrategy: "Rotate",
+			},
+		},
+		Status: ElasticCacheStatus{
+			PendingModifiedValues: &PendingModifiedValues{AuthTokenStatus: "Setting"},
+		},
+	}
+
+	hub := &v1beta1.ElasticCache{}
+	if err := original.DeepCopy().ConvertTo(hub); err != nil {
+		t.Fatalf("ConvertTo: %v", err)
+	}
+	if _, ok := hub.Annotations[unconvertedValuesAnnotation]; !ok {
+		t.Errorf("annotations = %v, want %s", hub.Annotations, unconvertedValuesAnnotation)
+	}
+
+	restored := &ElasticCache{}
+	if err := restored.ConvertFrom(hub.DeepCopy()); err != nil {
+		t.Fatalf("ConvertFrom: %v", err)
+	}
+	if !equality.Semantic.DeepEqual(original, restored) {
+		t.Errorf("round trip is lossy:\n%s", diff.ObjectReflectDiff(original, restored))
+	}
+
+	// Values changed in v1beta1 take precedence over the recorded ones.
+	hub.Spec.CacheCluster.AZMode = v1beta1.AZModeSingleAZ
+	changed := &ElasticCache{}
+	if err := changed.ConvertFrom(hub.DeepCopy()); err != nil {
+		t.Fatalf("ConvertFrom: %v", err)
+	}
+	if got := changed.Spec.AWSConfig.AZMode; got != types.AZModeSingleAz {
+		t.Errorf("azMode = %q, want %q", got, types.AZModeSingleAz)
+	}
+	if got := changed.Spec.AWSConfig.AuthTokenUpdateStrategy; got != "Rotate" {
+		t.Errorf("authTokenUpdateStrategy = %q, want Rotate", got)
+	}
+}
@@ -49,7 +49,9 @@ type ElasticCacheAwsConfig struct {
 	// mode.
 	AZMode types.AZMode `json:"azMode,omitempty"`
 
-	// Reserved parameter. The password used to access a password protected server.
+	// Reference to the key of a Secret holding the password used to access a
+	// password protected server. The Secret must live in the namespace of the
+	// ElasticCache. When the value changes the token is rotated on the cluster.
 	// Password constraints:
 	//
 	// * Must be only printable ASCII characters.
@@ -64,10 +66,16 @@ type ElasticCacheAwsConfig struct {
 	// For more
 	// information, see AUTH password (http://redis.io/commands/AUTH) at
 	// http://redis.io/commands/AUTH.
+	AuthTokenSecretRef *SecretKeySelector `json:"authTokenSecretRef,omitempty"`
+
+	// Deprecated: use authTokenSecretRef. The password used to access a password
+	// protected server. The operator moves it to a Secret named
+	// <name>-auth-token, references that Secret in authTokenSecretRef and clears
+	// this field.
 	AuthToken *string `json:"authToken,omitempty"`
 
-	// Specifies the strategy to use to update the AUTH token. This parameter must be
-	// specified with the auth-token parameter. Possible values:
+	// Specifies the strategy to use to update the AUTH token when the value referenced
+	// by authTokenSecretRef changes. Defaults to Rotate. Possible values:
 	//
 	// * Rotate
 	//
@@ -142,7 +150,9 @@ type ElasticCacheAwsConfig struct {
 	//
 	// * Redis configuration variables appendonly
 	// and appendfsync are not supported on Redis version 2.8.22 and later.
-	CacheNodeType *string `json:"cacheNodeType"`
+	//
+	// Required unless an existing cluster is imported.
+	CacheNodeType *string `json:"cacheNodeType,omitempty"`
 
 	// The name of the parameter group to associate with this cluster. If this argument
 	// is omitted, the default parameter group for the specified engine is used. You
@@ -163,9 +173,15 @@ type ElasticCacheAwsConfig struct {
 	// (https://docs.aws.amazon.com/AmazonElastiCache/latest/red-ug/SubnetGroups.html).
 	CacheSubnetGroupName *string `json:"cacheSubnetGroupName,omitempty"`
 
+	// A reference to a CacheSubnetGroup in the namespace of the ElasticCache. The
+	// cluster is only created once the referenced subnet group is Ready, and its AWS
+	// name is used instead of CacheSubnetGroupName.
+	SubnetGroupRef *LocalObjectReference `json:"subnetGroupRef,omitempty"`
+
 	// The name of the cache engine to be used for this cluster. Valid values for this
-	// parameter are: memcached | redis
-	Engine *string `json:"engine"`
+	// parameter are: memcached | redis. Required unless an existing cluster is
+	// imported.
+	Engine *string `json:"engine,omitempty"`
 
 	// The version number of the cache engine to be used for this cluster. To view the
 	// supported cache engine versions, use the DescribeCacheEngineVersions operation.
@@ -174,12 +190,16 @@ type ElasticCacheAwsConfig struct {
 	// (https://docs.aws.amazon.com/AmazonElastiCache/latest/red-ug/SelectEngine.html#VersionManagement)),
 	// but you cannot downgrade to an earlier engine version. If you want to use an
 	// earlier engine version, you must delete the existing cluster or replication
-	// group and create it anew with the earlier engine version.
-	EngineVersion *string `json:"engineVersion"`
+	// group and create it anew with the earlier engine version. Required unless an
+	// existing cluster is imported.
+	EngineVersion *string `json:"engineVersion,omitempty"`
 
-	// Specifies the destination, format and type of the logs.
-	// TODO: Enable LogDeliveryConfigurations
-	// LogDeliveryConfigurations []types.LogDeliveryConfigurationRequest `json:"logDeliveryConfigurations,omitempty"`
+	// Specifies the destination and format of each log of the cache engine that is
+	// delivered. Logs that are not listed are not delivered, delivery of a log is
+	// stopped when it is removed from the list. Only supported for redis.
+	// +listType=map
+	// +listMapKey=logType
+	LogDeliveryConfigurations []LogDeliveryConfiguration `json:"logDeliveryConfigurations,omitempty"`
 
 	// The Amazon Resource Name (ARN) of the Amazon Simple Notification Service (SNS)
 	// topic to which notifications are sent. The Amazon SNS topic owner must be the
@@ -192,7 +212,8 @@ type ElasticCacheAwsConfig struct {
 	// please fill out the ElastiCache Limit Increase Request form at
 	// http://aws.amazon.com/contact-us/elasticache-node-limit-request/
 	// (http://aws.amazon.com/contact-us/elasticache-node-limit-request/).
-	NumCacheNodes *int32 `json:"numCacheNodes"`
+	// Required unless an existing cluster is imported.
+	NumCacheNodes *int32 `json:"numCacheNodes,omitempty"`
 
 	// Specifies whether the nodes in the cluster are created in a single outpost or
 	// across multiple outposts.
@@ -275,20 +296,304 @@ type ElasticCacheAwsConfig struct {
 	Tags []Tag `json:"tags,omitempty"`
 }
 
+// ConnectionDetailsTarget names the Kubernetes objects that receive the
+// connection details of a cache once it becomes available. Both objects are
+// created in the namespace of the owning resource and are garbage collected
+// together with it.
+type ConnectionDetailsTarget struct {
+
+	// The name of the Secret that receives the endpoints, port, engine and auth
+	// token of the cache.
+	SecretName string `json:"secretName"`
+
+	// The name of an optional ConfigMap that receives the same connection details
+	// except the auth token.
+	ConfigMapName *string `json:"configMapName,omitempty"`
+}
+
+// LogType names a log of the cache engine that can be delivered.
+// +kubebuilder:validation:Enum=slow-log;engine-log
+type LogType string
+
+const (
+	// LogTypeSlowLog is the Redis SLOWLOG.
+	LogTypeSlowLog LogType = "slow-log"
+
+	// LogTypeEngineLog is the log of the Redis engine.
+	LogTypeEngineLog LogType = "engine-log"
+)
+
+// LogFormat is the format log entries are delivered in.
+// +kubebuilder:validation:Enum=json;text
+type LogFormat string
+
+const (
+	// LogFormatJSON delivers every log entry as a JSON document.
+	LogFormatJSON LogFormat = "json"
+
+	// LogFormatText delivers log entries as plain text.
+	LogFormatText LogFormat = "text"
+)
+
+// LogDeliveryConfiguration specifies where and how a log of the cache engine is
+// delivered. Exactly one destination must be set.
+type LogDeliveryConfiguration struct {
+
+	// The log that is delivered, slow-log or engine-log.
+	LogType LogType `json:"logType"`
+
+	// The format of the delivered log entries, json or text. Defaults to json.
+	// +kubebuilder:default=json
+	LogFormat LogFormat `json:"logFormat,omitempty"`
+
+	// Delivers the log to a CloudWatch Logs log group.
+	CloudWatchLogs *CloudWatchLogsDestination `json:"cloudWatchLogs,omitempty"`
+
+	// Delivers the log to a Kinesis Data Firehose delivery stream.
+	KinesisFirehose *KinesisFirehoseDestination `json:"kinesisFirehose,omitempty"`
+}
+
+// CloudWatchLogsDestination names the CloudWatch Logs log group a log is
+// delivered to.
+type CloudWatchLogsDestination struct {
+
+	// The name of the log group.
+	LogGroup string `json:"logGroup"`
+}
+
+// KinesisFirehoseDestination names the Kinesis Data Firehose delivery stream a
+// log is delivered to.
+type KinesisFirehoseDestination struct {
+
+	// The name of the delivery stream.
+	DeliveryStream string `json:"deliveryStream"`
+}
+
+// LogDeliveryStatus reports the delivery of a log of the cache engine as
+// observed in AWS.
+type LogDeliveryStatus struct {
+
+	// The log that is delivered.
+	LogType LogType `json:"logType"`
+
+	// The format of the delivered log entries.
+	LogFormat LogFormat `json:"logFormat,omitempty"`
+
+	// The type of the destination, cloudwatch-logs or kinesis-firehose.
+	DestinationType string `json:"destinationType,omitempty"`
+
+	// The log group or delivery stream the log is delivered to.
+	Destination string `json:"destination,omitempty"`
+
+	// The state of the delivery, one of active, enabling, modifying, disabling or
+	// error.
+	Status string `json:"status,omitempty"`
+
+	// The reason of an error reported by AWS.
+	Message *string `json:"message,omitempty"`
+}
+
+// ClusterNamingStrategy specifies how the identifier of a cache cluster or a
+// replication group is derived from the object managing it.
+type ClusterNamingStrategy string
+
+const (
+	// ClusterNamingStrategyName uses the name of the object.
+	ClusterNamingStrategyName ClusterNamingStrategy = "Name"
+
+	// ClusterNamingStrategyNamespacedHash uses the namespace and the name of the
+	// object followed by a hash of both.
+	ClusterNamingStrategyNamespacedHash ClusterNamingStrategy = "NamespacedHash"
+)
+
 // ElasticCacheSpec defines the desired state of ElasticCache
 type ElasticCacheSpec struct {
 	AWSConfig *ElasticCacheAwsConfig `json:"awsConfig"`
+
+	// The name of the CacheClass whose defaults fill in the unset fields of
+	// AWSConfig when the ElasticCache is created. When omitted the class selecting
+	// the namespace of the ElasticCache is used, if any, and recorded here.
+	CacheClassName *string `json:"cacheClassName,omitempty"`
+
+	// A reference to a ProviderConfig configuring the region and credentials the
+	// cluster is managed with. The ProviderConfig must select the namespace of
+	// the ElasticCache. When omitted the configuration the operator was started
+	// with is used. Only the cache cluster is managed with the ProviderConfig:
+	// other resources, like a referenced CacheSubnetGroup, are managed with the
+	// configuration the operator was started with and must exist in the same
+	// region and account as the cluster.
+	ProviderConfigRef *LocalObjectReference `json:"providerConfigRef,omitempty"`
+
+	// The identifier of the cache cluster in AWS. When omitted the identifier is
+	// derived from the NamingStrategy. An existing cluster that was not created by
+	// this ElasticCache is only managed after it is imported with the
+	// aws.sergeyshevch.dev/import annotation.
+	ExternalName *string `json:"externalName,omitempty"`
+
+	// How the identifier of the cache cluster is derived when ExternalName is not
+	// set. Name uses the name of the ElasticCache, NamespacedHash combines the
+	// namespace, the name and a hash of both so equally named caches in different
+	// namespaces do not collide. The resolved identifier is recorded in the status
+	// and kept for the lifetime of the cluster. Defaults to Name.
+	// +kubebuilder:validation:Enum=Name;NamespacedHash
+	// +kubebuilder:default=Name
+	NamingStrategy ClusterNamingStrategy `json:"namingStrategy,omitempty"`
+
+	// Where to publish connection details of the cluster once it is available.
+	WriteConnectionDetailsTo *ConnectionDetailsTarget `json:"writeConnectionDetailsTo,omitempty"`
+
+	// Specifies what happens to the cache cluster in AWS when the ElasticCache is
+	// deleted. Delete removes the cluster, Retain keeps it and Snapshot removes it
+	// after taking a final snapshot. Defaults to Delete.
+	// +kubebuilder:validation:Enum=Delete;Retain;Snapshot
+	// +kubebuilder:default=Delete
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+}
+
+// Endpoint Represents the information required for client programs to connect to
+// a cache node.
+type Endpoint struct {
+
+	// The DNS hostname of the cache node.
+	Address *string `json:"address,omitempty"`
+
+	// The port number that the cache engine is listening on.
+	Port int32 `json:"port,omitempty"`
+}
+
+// CacheNode Represents an individual cache node within a cluster. Each cache node
+// runs its own instance of the cluster's protocol-compliant caching software -
+// either Memcached or Redis.
+type CacheNode struct {
+
+	// The cache node identifier. A node ID is a numeric identifier (0001, 0002,
+	// etc.). The combination of cluster ID and node ID uniquely identifies every cache
+	// node used in a customer's Amazon account.
+	CacheNodeId *string `json:"cacheNodeId,omitempty"`
+
+	// The current state of this cache node, one of the following values: available,
+	// creating, rebooting, or deleting.
+	CacheNodeStatus *string `json:"cacheNodeStatus,omitempty"`
+
+	// The date and time when the cache node was created.
+	CacheNodeCreateTime *metav1.Time `json:"cacheNodeCreateTime,omitempty"`
+
+	// The Availability Zone where this node was created and now resides.
+	CustomerAvailabilityZone *string `json:"customerAvailabilityZone,omitempty"`
+
+	// The customer outpost ARN of the cache node.
+	CustomerOutpostArn *string `json:"customerOutpostArn,omitempty"`
+
+	// The hostname for connecting to this cache node.
+	Endpoint *Endpoint `json:"endpoint,omitempty"`
+
+	// The status of the parameter group applied to this cache node.
+	ParameterGroupStatus *string `json:"parameterGroupStatus,omitempty"`
+}
+
+// PendingModifiedValues A group of settings that are applied to the cluster in the
+// future, or that are currently being applied.
+type PendingModifiedValues struct {
+
+	// The auth token status
+	AuthTokenStatus types.AuthTokenUpdateStatus `json:"authTokenStatus,omitempty"`
+
+	// A list of cache node IDs that are being removed (or will be removed) from the
+	// cluster. A node ID is a 4-digit numeric identifier (0001, 0002, etc.).
+	CacheNodeIdsToRemove []string `json:"cacheNodeIdsToRemove,omitempty"`
+
+	// The cache node type that this cluster or replication group is scaled to.
+	CacheNodeType *string `json:"cacheNodeType,omitempty"`
+
+	// The new cache engine version that the cluster runs.
+	EngineVersion *string `json:"engineVersion,omitempty"`
+
+	// The new number of cache nodes for the cluster. For clusters running Redis, this
+	// value must be 1. For clusters running Memcached, this value must be between 1
+	// and 40.
+	NumCacheNodes *int32 `json:"numCacheNodes,omitempty"`
 }
 
 // ElasticCacheStatus defines the observed state of ElasticCache
 type ElasticCacheStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
-	CacheClusterStatus *string `json:"cacheClusterStatus"`
+
+	// Conditions represent the latest available observations of the cluster state.
+	// Known condition types are Ready, Synced, Deleting and Error.
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// The generation of the ElasticCache most recently observed by the controller.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// The identifier of the cache cluster in AWS managed by this ElasticCache.
+	CacheClusterId *string `json:"cacheClusterId,omitempty"`
+
+	// The current state of this cluster, one of the following values: available,
+	// creating, deleted, deleting, incompatible-network, modifying, rebooting cluster
+	// nodes, restore-failed, or snapshotting.
+	CacheClusterStatus *string `json:"cacheClusterStatus,omitempty"`
+
+	// The ARN (Amazon Resource Name) of the cache cluster.
+	ARN *string `json:"arn,omitempty"`
+
+	// The name of the cache engine (memcached or redis) used for this cluster.
+	Engine *string `json:"engine,omitempty"`
+
+	// The version of the cache engine that is used in this cluster.
+	EngineVersion *string `json:"engineVersion,omitempty"`
+
+	// The name of the compute and memory capacity node type for the cluster.
+	CacheNodeType *string `json:"cacheNodeType,omitempty"`
+
+	// The number of cache nodes in the cluster.
+	NumCacheNodes *int32 `json:"numCacheNodes,omitempty"`
+
+	// The name of the Availability Zone in which the cluster is located or "Multiple"
+	// if the cache nodes are located in different Availability Zones.
+	PreferredAvailabilityZone *string `json:"preferredAvailabilityZone,omitempty"`
+
+	// Represents a Memcached cluster endpoint which can be used by an application to
+	// connect to any node in the cluster. The configuration endpoint will always have
+	// .cfg in it.
+	ConfigurationEndpoint *Endpoint `json:"configurationEndpoint,omitempty"`
+
+	// A list of cache nodes that are members of the cluster.
+	CacheNodes []CacheNode `json:"cacheNodes,omitempty"`
+
+	// A group of settings that are applied to the cluster in the future, or that are
+	// currently being applied.
+	PendingModifiedValues *PendingModifiedValues `json:"pendingModifiedValues,omitempty"`
+
+	// The delivery of the logs of the cache engine, one entry per delivered log.
+	LogDeliveryConfigurations []LogDeliveryStatus `json:"logDeliveryConfigurations,omitempty"`
+
+	// A salted hash of the auth token that was last applied to the cluster. It is
+	// used to detect rotations of the referenced Secret without storing the token.
+	AuthTokenHash string `json:"authTokenHash,omitempty"`
+
+	// The modifiable fields of the spec that differed from the cache cluster when
+	// it was last observed. They are modified as soon as the cluster is available.
+	DriftedFields []string `json:"driftedFields,omitempty"`
+
+	// The name of the final snapshot requested when the cluster was deleted with
+	// the Snapshot deletion policy.
+	FinalSnapshotIdentifier *string `json:"finalSnapshotIdentifier,omitempty"`
+
+	// The last time the controller successfully synchronized this status with AWS.
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.cacheClusterStatus`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Engine",type=string,JSONPath=`.status.engine`
+//+kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.status.engineVersion`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // ElasticCache is the Schema for the elasticcaches API
 type ElasticCache struct {
@@ -0,0 +1,387 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var elasticcachelog = logf.Log.WithName("elasticcache-resource")
+
+const (
+	engineRedis     = "redis"
+	engineMemcached = "memcached"
+
+	// maxMemcachedNodes is the default ElastiCache limit of nodes in a Memcached
+	// cluster.
+	maxMemcachedNodes = 40
+
+	// minWindowMinutes is the shortest maintenance or snapshot window accepted
+	// by ElastiCache.
+	minWindowMinutes = 60
+)
+
+var (
+	cacheNodeTypeRegexp     = regexp.MustCompile(`^cache\.[a-z]+[0-9]+[a-z]*\.(micro|small|medium|large|[0-9]*xlarge)$`)
+	maintenanceWindowRegexp = regexp.MustCompile(`^(sun|mon|tue|wed|thu|fri|sat):([01][0-9]|2[0-3]):([0-5][0-9])-(sun|mon|tue|wed|thu|fri|sat):([01][0-9]|2[0-3]):([0-5][0-9])$`)
+	snapshotWindowRegexp    = regexp.MustCompile(`^([01][0-9]|2[0-3]):([0-5][0-9])-([01][0-9]|2[0-3]):([0-5][0-9])$`)
+
+	weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+func (r *ElasticCache) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(elasticCacheDefaulterPath, &webhook.Admission{
+		Handler: &elasticCacheDefaulter{client: mgr.GetClient()},
+	})
+
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-aws-sergeyshevch-dev-v1alpha1-elasticcache,mutating=false,failurePolicy=fail,sideEffects=None,groups=aws.sergeyshevch.dev,resources=elasticcaches,verbs=create;update,versions=v1alpha1,matchPolicy=Equivalent,name=velasticcache.kb.io,admissionReviewVersions={v1,v1beta1}
+
+var _ webhook.Validator = &ElasticCache{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *ElasticCache) ValidateCreate() error {
+	elasticcachelog.Info("validate create", "name", r.Name)
+
+	return r.toInvalid(r.validateSpec())
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *ElasticCache) ValidateUpdate(old runtime.Object) error {
+	elasticcachelog.Info("validate update", "name", r.Name)
+
+	oldElasticCache, ok := old.(*ElasticCache)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected an ElasticCache but got a %T", old))
+	}
+
+	// Objects that are being deleted must stay updatable so their finalizer
+	// can be removed, and metadata-only updates of objects created before a
+	// rule was introduced are not blocked either.
+	if r.GetDeletionTimestamp() != nil || reflect.DeepEqual(r.Spec, oldElasticCache.Spec) {
+		return nil
+	}
+
+	allErrs := r.validateSpec()
+	allErrs = append(allErrs, r.validateCreateOnlyFields(oldElasticCache)...)
+	return r.toInvalid(allErrs)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *ElasticCache) ValidateDelete() error {
+	return nil
+}
+
+func (r *ElasticCache) toInvalid(allErrs field.ErrorList) error {
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("ElasticCache").GroupKind(), r.Name, allErrs)
+}
+
+// isImported reports whether the ElasticCache adopts an existing cluster, in
+// which case fields left empty are filled in from AWS.
+func (r *ElasticCache) isImported() bool {
+	return r.GetAnnotations()[ImportAnnotation] == "true"
+}
+
+// validateSpec checks the constraints ElastiCache places on a cluster that can
+// be verified without calling AWS. The auth token is kept in a Secret and is
+// validated by the controller instead.
+func (r *ElasticCache) validateSpec() field.ErrorList {
+	var allErrs field.ErrorList
+
+	path := field.NewPath("spec", "awsConfig")
+	config := r.Spec.AWSConfig
+	if config == nil {
+		return append(allErrs, field.Required(path, ""))
+	}
+
+	if !r.isImported() {
+		if config.CacheNodeType == nil {
+			allErrs = append(allErrs, field.Required(path.Child("cacheNodeType"), "required unless an existing cluster is imported"))
+		}
+		if config.Engine == nil {
+			allErrs = append(allErrs, field.Required(path.Child("engine"), "required unless an existing cluster is imported"))
+		}
+		if config.EngineVersion == nil {
+			allErrs = append(allErrs, field.Required(path.Child("engineVersion"), "required unless an existing cluster is imported"))
+		}
+		if config.NumCacheNodes == nil {
+			allErrs = append(allErrs, field.Required(path.Child("numCacheNodes"), "required unless an existing cluster is imported"))
+		}
+	}
+
+	if config.CacheNodeType != nil && !cacheNodeTypeRegexp.MatchString(*config.CacheNodeType) {
+		allErrs = append(allErrs, field.Invalid(path.Child("cacheNodeType"), *config.CacheNodeType,
+			"must be an ElastiCache node type such as cache.t3.micro or cache.r6g.xlarge"))
+	}
+
+	if config.CacheSubnetGroupName != nil && config.SubnetGroupRef != nil {
+		allErrs = append(allErrs, field.Forbidden(path.Child("subnetGroupRef"), "may not be set together with cacheSubnetGroupName"))
+	}
+
+	if config.AuthToken != nil && config.AuthTokenSecretRef != nil {
+		allErrs = append(allErrs, field.Forbidden(path.Child("authToken"), "may not be set together with authTokenSecretRef"))
+	}
+
+	if config.NumCacheNodes != nil && *config.NumCacheNodes < 1 {
+		allErrs = append(allErrs, field.Invalid(path.Child("numCacheNodes"), *config.NumCacheNodes, "must be at least 1"))
+	}
+
+	if config.PreferredAvailabilityZone != nil && len(config.PreferredAvailabilityZones) > 0 {
+		allErrs = append(allErrs, field.Forbidden(path.Child("preferredAvailabilityZones"), "may not be set together with preferredAvailabilityZone"))
+	}
+
+	if config.PreferredMaintenanceWindow != nil {
+		allErrs = append(allErrs, validateMaintenanceWindow(path.Child("preferredMaintenanceWindow"), *config.PreferredMaintenanceWindow)...)
+	}
+	if config.SnapshotWindow != nil {
+		allErrs = append(allErrs, validateSnapshotWindow(path.Child("snapshotWindow"), *config.SnapshotWindow)...)
+	}
+
+	allErrs = append(allErrs, validateTags(path.Child("tags"), config.Tags)...)
+	allErrs = append(allErrs, validateLogDelivery(path.Child("logDeliveryConfigurations"), config.LogDeliveryConfigurations)...)
+
+	if config.Engine == nil {
+		return allErrs
+	}
+	switch *config.Engine {
+	case engineRedis:
+		allErrs = append(allErrs, validateRedisConfig(path, config)...)
+	case engineMemcached:
+		allErrs = append(allErrs, validateMemcachedConfig(path, config)...)
+	default:
+		allErrs = append(allErrs, field.NotSupported(path.Child("engine"), *config.Engine, []string{engineMemcached, engineRedis}))
+	}
+	return allErrs
+}
+
+// validateTags rejects tag keys that are reserved by AWS or by the operator,
+// which would otherwise be overwritten on every reconciliation.
+func validateTags(path *field.Path, tags []Tag) field.ErrorList {
+	var allErrs field.ErrorList
+
+	seen := map[string]bool{}
+	for i, tag := range tags {
+		if tag.Key == nil || *tag.Key == "" {
+			allErrs = append(allErrs, field.Required(path.Index(i).Child("key"), ""))
+			continue
+		}
+		key := *tag.Key
+		switch {
+		case strings.HasPrefix(key, "aws:"):
+			allErrs = append(allErrs, field.Invalid(path.Index(i).Child("key"), key, "the aws: prefix is reserved by AWS"))
+		case strings.HasPrefix(key, OperatorTagPrefix):
+			allErrs = append(allErrs, field.Invalid(path.Index(i).Child("key"), key,
+				fmt.Sprintf("the %s prefix is reserved by the operator", OperatorTagPrefix)))
+		case seen[key]:
+			allErrs = append(allErrs, field.Duplicate(path.Index(i).Child("key"), key))
+		}
+		seen[key] = true
+	}
+	return allErrs
+}
+
+// validateLogDelivery requires every log delivery to name exactly one
+// destination.
+func validateLogDelivery(path *field.Path, configs []LogDeliveryConfiguration) field.ErrorList {
+	var allErrs field.ErrorList
+
+	for i, config := range configs {
+		switch {
+		case config.CloudWatchLogs == nil && config.KinesisFirehose == nil:
+			allErrs = append(allErrs, field.Required(path.Index(i), "one of cloudWatchLogs or kinesisFirehose is required"))
+		case config.CloudWatchLogs != nil && config.KinesisFirehose != nil:
+			allErrs = append(allErrs, field.Forbidden(path.Index(i).Child("kinesisFirehose"), "may not be set together with cloudWatchLogs"))
+		case config.CloudWatchLogs != nil && config.CloudWatchLogs.LogGroup == "":
+			allErrs = append(allErrs, field.Required(path.Index(i).Child("cloudWatchLogs", "logGroup"), ""))
+		case config.KinesisFirehose != nil && config.KinesisFirehose.DeliveryStream == "":
+			allErrs = append(allErrs, field.Required(path.Index(i).Child("kinesisFirehose", "deliveryStream"), ""))
+		}
+	}
+	return allErrs
+}
+
+func validateRedisConfig(path *field.Path, config *ElasticCacheAwsConfig) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if config.NumCacheNodes != nil && *config.NumCacheNodes != 1 {
+		allErrs = append(allErrs, field.Invalid(path.Child("numCacheNodes"), *config.NumCacheNodes, "must be 1 for redis"))
+	}
+	if config.AZMode != "" {
+		allErrs = append(allErrs, field.Forbidden(path.Child("azMode"), "only supported for memcached"))
+	}
+	if len(config.PreferredAvailabilityZones) > 0 {
+		allErrs = append(allErrs, field.Forbidden(path.Child("preferredAvailabilityZones"), "only supported for memcached, use preferredAvailabilityZone"))
+	}
+	return allErrs
+}
+
+func validateMemcachedConfig(path *field.Path, config *ElasticCacheAwsConfig) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if config.NumCacheNodes != nil && *config.NumCacheNodes > maxMemcachedNodes {
+		allErrs = append(allErrs, field.Invalid(path.Child("numCacheNodes"), *config.NumCacheNodes,
+			fmt.Sprintf("must be between 1 and %d for memcached", maxMemcachedNodes)))
+	}
+	if config.AZMode == types.AZModeCrossAz && config.NumCacheNodes != nil && *config.NumCacheNodes < 2 {
+		allErrs = append(allErrs, field.Invalid(path.Child("azMode"), config.AZMode, "cross-az requires at least 2 cache nodes"))
+	}
+	if len(config.PreferredAvailabilityZones) > 0 && config.NumCacheNodes != nil &&
+		len(config.PreferredAvailabilityZones) != int(*config.NumCacheNodes) {
+		allErrs = append(allErrs, field.Invalid(path.Child("preferredAvailabilityZones"), config.PreferredAvailabilityZones,
+			"the number of availability zones must equal numCacheNodes"))
+	}
+
+	redisOnly := []struct {
+		name string
+		set  bool
+	}{
+		{"authToken", config.AuthToken != nil},
+		{"authTokenSecretRef", config.AuthTokenSecretRef != nil},
+		{"logDeliveryConfigurations", len(config.LogDeliveryConfigurations) > 0},
+		{"replicationGroupId", config.ReplicationGroupId != nil},
+		{"snapshotArns", len(config.SnapshotArns) > 0},
+		{"snapshotName", config.SnapshotName != nil},
+		{"snapshotRetentionLimit", config.SnapshotRetentionLimit != nil},
+		{"snapshotWindow", config.SnapshotWindow != nil},
+	}
+	for _, f := range redisOnly {
+		if f.set {
+			allErrs = append(allErrs, field.Forbidden(path.Child(f.name), "only supported for redis"))
+		}
+	}
+	return allErrs
+}
+
+// validateMaintenanceWindow checks a weekly window in the format
+// ddd:hh24:mi-ddd:hh24:mi that lasts at least 60 minutes.
+func validateMaintenanceWindow(path *field.Path, window string) field.ErrorList {
+	match := maintenanceWindowRegexp.FindStringSubmatch(strings.ToLower(window))
+	if match == nil {
+		return field.ErrorList{field.Invalid(path, window, "must be in the format ddd:hh24:mi-ddd:hh24:mi, for example sun:23:00-mon:01:30")}
+	}
+
+	const minutesPerWeek = 7 * 24 * 60
+	start := weekday(match[1])*24*60 + clockMinute(match[2], match[3])
+	end := weekday(match[4])*24*60 + clockMinute(match[5], match[6])
+	if (end-start+minutesPerWeek)%minutesPerWeek < minWindowMinutes {
+		return field.ErrorList{field.Invalid(path, window, fmt.Sprintf("must be at least %d minutes long", minWindowMinutes))}
+	}
+	return nil
+}
+
+// validateSnapshotWindow checks a daily window in the format hh24:mi-hh24:mi
+// that lasts at least 60 minutes.
+func validateSnapshotWindow(path *field.Path, window string) field.ErrorList {
+	match := snapshotWindowRegexp.FindStringSubmatch(window)
+	if match == nil {
+		return field.ErrorList{field.Invalid(path, window, "must be in the format hh24:mi-hh24:mi, for example 05:00-09:00")}
+	}
+
+	const minutesPerDay = 24 * 60
+	start := clockMinute(match[1], match[2])
+	end := clockMinute(match[3], match[4])
+	if (end-start+minutesPerDay)%minutesPerDay < minWindowMinutes {
+		return field.ErrorList{field.Invalid(path, window, fmt.Sprintf("must be at least %d minutes long", minWindowMinutes))}
+	}
+	return nil
+}
+
+// weekday returns the index of a day matched by maintenanceWindowRegexp,
+// starting with sunday.
+func weekday(day string) int {
+	for i, name := range weekdays {
+		if name == day {
+			return i
+		}
+	}
+	return 0
+}
+
+// clockMinute returns the minute of the day of an hour and minute matched by
+// one of the window expressions.
+func clockMinute(hour, minute string) int {
+	h, _ := strconv.Atoi(hour)
+	m, _ := strconv.Atoi(minute)
+	return h*60 + m
+}
+
+// validateCreateOnlyFields rejects changes to fields ElastiCache only accepts
+// when a cluster is created. An imported ElasticCache may still fill in fields
+// it left empty, as the controller does when adopting the cluster.
+func (r *ElasticCache) validateCreateOnlyFields(old *ElasticCache) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if r.Spec.AWSConfig == nil || old.Spec.AWSConfig == nil {
+		return allErrs
+	}
+
+	specPath := field.NewPath("spec")
+	configPath := specPath.Child("awsConfig")
+	createOnly := []struct {
+		path     *field.Path
+		old, new interface{}
+	}{
+		{configPath.Child("engine"), old.Spec.AWSConfig.Engine, r.Spec.AWSConfig.Engine},
+		{configPath.Child("port"), old.Spec.AWSConfig.Port, r.Spec.AWSConfig.Port},
+		{configPath.Child("cacheSubnetGroupName"), old.Spec.AWSConfig.CacheSubnetGroupName, r.Spec.AWSConfig.CacheSubnetGroupName},
+		{configPath.Child("subnetGroupRef"), old.Spec.AWSConfig.SubnetGroupRef, r.Spec.AWSConfig.SubnetGroupRef},
+		{configPath.Child("replicationGroupId"), old.Spec.AWSConfig.ReplicationGroupId, r.Spec.AWSConfig.ReplicationGroupId},
+		{configPath.Child("preferredAvailabilityZone"), old.Spec.AWSConfig.PreferredAvailabilityZone, r.Spec.AWSConfig.PreferredAvailabilityZone},
+		{configPath.Child("outpostMode"), old.Spec.AWSConfig.OutpostMode, r.Spec.AWSConfig.OutpostMode},
+		{configPath.Child("preferredOutpostArn"), old.Spec.AWSConfig.PreferredOutpostArn, r.Spec.AWSConfig.PreferredOutpostArn},
+		{specPath.Child("cacheClassName"), old.Spec.CacheClassName, r.Spec.CacheClassName},
+		{specPath.Child("providerConfigRef"), old.Spec.ProviderConfigRef, r.Spec.ProviderConfigRef},
+		{specPath.Child("externalName"), old.Spec.ExternalName, r.Spec.ExternalName},
+		{specPath.Child("namingStrategy"), old.Spec.NamingStrategy, r.Spec.NamingStrategy},
+	}
+	for _, f := range createOnly {
+		if reflect.DeepEqual(f.old, f.new) {
+			continue
+		}
+		if isUnset(f.old) && r.isImported() {
+			continue
+		}
+		allErrs = append(allErrs, field.Forbidden(f.path, "may not be changed once the cluster is created"))
+	}
+	return allErrs
+}
+
+// isUnset reports whether v holds a nil pointer or an empty value.
+func isUnset(v interface{}) bool {
+	value := reflect.ValueOf(v)
+	return !value.IsValid() || value.IsZero()
+}
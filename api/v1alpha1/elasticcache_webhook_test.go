@@ -0,0 +1,377 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func stringPtr(s string) *string { return &s }
+
+func int32Ptr(i int32) *int32 { return &i }
+
+// validElasticCache returns a redis ElasticCache accepted by the webhook.
+func validElasticCache() *ElasticCache {
+	return &ElasticCache{
+		ObjectMeta: metav1.ObjectMeta{Name: "cache", Namespace: "default"},
+		Spec: ElasticCacheSpec{
+			AWSConfig: &ElasticCacheAwsConfig{
+				CacheNodeType: stringPtr("cache.t3.micro"),
+				Engine:        stringPtr(engineRedis),
+				EngineVersion: stringPtr("6.x"),
+				NumCacheNodes: int32Ptr(1),
+			},
+		},
+	}
+}
+
+// memcached turns the ElasticCache into a memcached cluster with two nodes.
+func memcached(r *ElasticCache) {
+	r.Spec.AWSConfig.Engine = stringPtr(engineMemcached)
+	r.Spec.AWSConfig.NumCacheNodes = int32Ptr(2)
+}
+
+// invalidFields returns the sorted fields rejected by err, which must be an
+// Invalid error or nil.
+func invalidFields(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	statusErr, ok := err.(*apierrors.StatusError)
+	if !ok || !apierrors.IsInvalid(err) {
+		t.Fatalf("expected an Invalid error, got %v", err)
+	}
+	var fields []string
+	for _, cause := range statusErr.ErrStatus.Details.Causes {
+		fields = append(fields, cause.Field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func TestElasticCacheValidateCreate(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(r *ElasticCache)
+		want   []string
+	}{
+		{
+			name:   "accepts a redis cluster",
+			mutate: func(r *ElasticCache) {},
+		},
+		{
+			name: "accepts a memcached cluster spread over availability zones",
+			mutate: func(r *ElasticCache) {
+				memcached(r)
+				r.Spec.AWSConfig.AZMode = types.AZModeCrossAz
+				r.Spec.AWSConfig.PreferredAvailabilityZones = []string{"us-east-1a", "us-east-1b"}
+			},
+		},
+		{
+			name:   "requires the AWS configuration",
+			mutate: func(r *ElasticCache) { r.Spec.AWSConfig = nil },
+			want:   []string{"spec.awsConfig"},
+		},
+		{
+			name:   "requires the cluster settings",
+			mutate: func(r *ElasticCache) { r.Spec.AWSConfig = &ElasticCacheAwsConfig{} },
+			want: []string{
+				"spec.awsConfig.cacheNodeType",
+				"spec.awsConfig.engine",
+				"spec.awsConfig.engineVersion",
+				"spec.awsConfig.numCacheNodes",
+			},
+		},
+		{
+			name: "fills the cluster settings of imported clusters from AWS",
+			mutate: func(r *ElasticCache) {
+				r.Annotations = map[string]string{ImportAnnotation: "true"}
+				r.Spec.AWSConfig = &ElasticCacheAwsConfig{}
+			},
+		},
+		{
+			name:   "rejects an unknown node type",
+			mutate: func(r *ElasticCache) { r.Spec.AWSConfig.CacheNodeType = stringPtr("t3.micro") },
+			want:   []string{"spec.awsConfig.cacheNodeType"},
+		},
+		{
+			name:   "rejects an unsupported engine",
+			mutate: func(r *ElasticCache) { r.Spec.AWSConfig.Engine = stringPtr("valkey") },
+			want:   []string{"spec.awsConfig.engine"},
+		},
+		{
+			name: "rejects a subnet group name together with a reference",
+			mutate: func(r *ElasticCache) {
+				r.Spec.AWSConfig.CacheSubnetGroupName = stringPtr("subnets")
+				r.Spec.AWSConfig.SubnetGroupRef = &LocalObjectReference{Name: "subnets"}
+			},
+			want: []string{"spec.awsConfig.subnetGroupRef"},
+		},
+		{
+			name: "rejects one availability zone together with several",
+			mutate: func(r *ElasticCache) {
+				memcached(r)
+				r.Spec.AWSConfig.PreferredAvailabilityZone = stringPtr("us-east-1a")
+				r.Spec.AWSConfig.PreferredAvailabilityZones = []string{"us-east-1a", "us-east-1b"}
+			},
+			want: []string{"spec.awsConfig.preferredAvailabilityZones"},
+		},
+		{
+			name:   "rejects a redis cluster with several nodes",
+			mutate: func(r *ElasticCache) { r.Spec.AWSConfig.NumCacheNodes = int32Ptr(2) },
+			want:   []string{"spec.awsConfig.numCacheNodes"},
+		},
+		{
+			name:   "rejects a redis cluster without nodes",
+			mutate: func(r *ElasticCache) { r.Spec.AWSConfig.NumCacheNodes = int32Ptr(0) },
+			want:   []string{"spec.awsConfig.numCacheNodes", "spec.awsConfig.numCacheNodes"},
+		},
+		{
+			name: "rejects memcached settings for redis",
+			mutate: func(r *ElasticCache) {
+				r.Spec.AWSConfig.AZMode = types.AZModeSingleAz
+				r.Spec.AWSConfig.PreferredAvailabilityZones = []string{"us-east-1a"}
+			},
+			want: []string{"spec.awsConfig.azMode", "spec.awsConfig.preferredAvailabilityZones"},
+		},
+		{
+			name: "rejects too many memcached nodes",
+			mutate: func(r *ElasticCache) {
+				memcached(r)
+				r.Spec.AWSConfig.NumCacheNodes = int32Ptr(maxMemcachedNodes + 1)
+			},
+			want: []string{"spec.awsConfig.numCacheNodes"},
+		},
+		{
+			name: "rejects a memcached cluster across zones with one node",
+			mutate: func(r *ElasticCache) {
+				memcached(r)
+				r.Spec.AWSConfig.NumCacheNodes = int32Ptr(1)
+				r.Spec.AWSConfig.AZMode = types.AZModeCrossAz
+			},
+			want: []string{"spec.awsConfig.azMode"},
+		},
+		{
+			name: "rejects a zone count different from the node count",
+			mutate: func(r *ElasticCache) {
+				memcached(r)
+				r.Spec.AWSConfig.PreferredAvailabilityZones = []string{"us-east-1a"}
+			},
+			want: []string{"spec.awsConfig.preferredAvailabilityZones"},
+		},
+		{
+			name: "rejects redis settings for memcached",
+			mutate: func(r *ElasticCache) {
+				memcached(r)
+				r.Spec.AWSConfig.AuthTokenSecretRef = &SecretKeySelector{Name: "auth", Key: "token"}
+				r.Spec.AWSConfig.SnapshotRetentionLimit = int32Ptr(3)
+			},
+			want: []string{"spec.awsConfig.authTokenSecretRef", "spec.awsConfig.snapshotRetentionLimit"},
+		},
+		{
+			name: "rejects the deprecated auth token together with its Secret reference",
+			mutate: func(r *ElasticCache) {
+				r.Spec.AWSConfig.AuthToken = stringPtr("0123456789abcdef0123")
+				r.Spec.AWSConfig.AuthTokenSecretRef = &SecretKeySelector{Name: "auth", Key: "token"}
+			},
+			want: []string{"spec.awsConfig.authToken"},
+		},
+		{
+			name:   "accepts a maintenance window across the week",
+			mutate: func(r *ElasticCache) { r.Spec.AWSConfig.PreferredMaintenanceWindow = stringPtr("sat:23:30-sun:00:30") },
+		},
+		{
+			name: "rejects a malformed maintenance window",
+			mutate: func(r *ElasticCache) {
+				r.Spec.AWSConfig.PreferredMaintenanceWindow = stringPtr("sunday:23:00-monday:01:00")
+			},
+			want: []string{"spec.awsConfig.preferredMaintenanceWindow"},
+		},
+		{
+			name:   "rejects a short maintenance window",
+			mutate: func(r *ElasticCache) { r.Spec.AWSConfig.PreferredMaintenanceWindow = stringPtr("sun:23:30-mon:00:00") },
+			want:   []string{"spec.awsConfig.preferredMaintenanceWindow"},
+		},
+		{
+			name:   "accepts a snapshot window across midnight",
+			mutate: func(r *ElasticCache) { r.Spec.AWSConfig.SnapshotWindow = stringPtr("23:30-00:30") },
+		},
+		{
+			name:   "rejects a malformed snapshot window",
+			mutate: func(r *ElasticCache) { r.Spec.AWSConfig.SnapshotWindow = stringPtr("5:00-9:00") },
+			want:   []string{"spec.awsConfig.snapshotWindow"},
+		},
+		{
+			name:   "rejects a short snapshot window",
+			mutate: func(r *ElasticCache) { r.Spec.AWSConfig.SnapshotWindow = stringPtr("05:00-05:30") },
+			want:   []string{"spec.awsConfig.snapshotWindow"},
+		},
+		{
+			name: "rejects reserved and duplicate tags",
+			mutate: func(r *ElasticCache) {
+				r.Spec.AWSConfig.Tags = []Tag{
+					{Key: stringPtr("aws:cloudformation:stack-name"), Value: stringPtr("stack")},
+					{Key: stringPtr(OperatorTagPrefix + "owner"), Value: stringPtr("default/other")},
+					{Key: stringPtr("team"), Value: stringPtr("platform")},
+					{Key: stringPtr("team"), Value: stringPtr("data")},
+					{Value: stringPtr("orphan")},
+				}
+			},
+			want: []string{
+				"spec.awsConfig.tags[0].key",
+				"spec.awsConfig.tags[1].key",
+				"spec.awsConfig.tags[3].key",
+				"spec.awsConfig.tags[4].key",
+			},
+		},
+		{
+			name: "requires exactly one log destination",
+			mutate: func(r *ElasticCache) {
+				r.Spec.AWSConfig.LogDeliveryConfigurations = []LogDeliveryConfiguration{
+					{LogType: LogTypeSlowLog},
+					{
+						LogType:         LogTypeSlowLog,
+						CloudWatchLogs:  &CloudWatchLogsDestination{LogGroup: "slow"},
+						KinesisFirehose: &KinesisFirehoseDestination{DeliveryStream: "slow"},
+					},
+					{LogType: LogTypeSlowLog, CloudWatchLogs: &CloudWatchLogsDestination{}},
+				}
+			},
+			want: []string{
+				"spec.awsConfig.logDeliveryConfigurations[0]",
+				"spec.awsConfig.logDeliveryConfigurations[1].kinesisFirehose",
+				"spec.awsConfig.logDeliveryConfigurations[2].cloudWatchLogs.logGroup",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := validElasticCache()
+			tt.mutate(r)
+
+			got := invalidFields(t, r.ValidateCreate())
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rejected fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestElasticCacheValidateUpdate(t *testing.T) {
+	tests := []struct {
+		name     string
+		imported bool
+		old      func(r *ElasticCache)
+		mutate   func(r *ElasticCache)
+		want     []string
+	}{
+		{
+			name:   "accepts a change of a modifiable field",
+			mutate: func(r *ElasticCache) { r.Spec.AWSConfig.CacheNodeType = stringPtr("cache.t3.small") },
+		},
+		{
+			name:   "rejects a change of the engine",
+			mutate: func(r *ElasticCache) { memcached(r) },
+			want:   []string{"spec.awsConfig.engine"},
+		},
+		{
+			name:   "rejects setting the port",
+			mutate: func(r *ElasticCache) { r.Spec.AWSConfig.Port = int32Ptr(6380) },
+			want:   []string{"spec.awsConfig.port"},
+		},
+		{
+			name:   "rejects a change of the subnet group",
+			old:    func(r *ElasticCache) { r.Spec.AWSConfig.CacheSubnetGroupName = stringPtr("subnets") },
+			mutate: func(r *ElasticCache) { r.Spec.AWSConfig.CacheSubnetGroupName = stringPtr("other") },
+			want:   []string{"spec.awsConfig.cacheSubnetGroupName"},
+		},
+		{
+			name:   "rejects a change of the replication group",
+			mutate: func(r *ElasticCache) { r.Spec.AWSConfig.ReplicationGroupId = stringPtr("group") },
+			want:   []string{"spec.awsConfig.replicationGroupId"},
+		},
+		{
+			name:   "rejects a change of the cluster identifier",
+			mutate: func(r *ElasticCache) { r.Spec.NamingStrategy = ClusterNamingStrategyNamespacedHash },
+			want:   []string{"spec.namingStrategy"},
+		},
+		{
+			name:     "lets an imported cluster fill in unset fields",
+			imported: true,
+			mutate:   func(r *ElasticCache) { r.Spec.AWSConfig.Port = int32Ptr(6380) },
+		},
+		{
+			name:     "rejects changes of set fields of an imported cluster",
+			imported: true,
+			old:      func(r *ElasticCache) { r.Spec.AWSConfig.Port = int32Ptr(6379) },
+			mutate:   func(r *ElasticCache) { r.Spec.AWSConfig.Port = int32Ptr(6380) },
+			want:     []string{"spec.awsConfig.port"},
+		},
+		{
+			name:   "validates the new spec",
+			mutate: func(r *ElasticCache) { r.Spec.AWSConfig.NumCacheNodes = int32Ptr(2) },
+			want:   []string{"spec.awsConfig.numCacheNodes"},
+		},
+		{
+			name:   "accepts metadata changes of a spec that is no longer valid",
+			old:    func(r *ElasticCache) { r.Spec.AWSConfig.NumCacheNodes = int32Ptr(2) },
+			mutate: func(r *ElasticCache) { r.Labels = map[string]string{"team": "platform"} },
+		},
+		{
+			name: "accepts any change of an object being deleted",
+			mutate: func(r *ElasticCache) {
+				now := metav1.Now()
+				r.DeletionTimestamp = &now
+				memcached(r)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := validElasticCache()
+			if tt.imported {
+				old.Annotations = map[string]string{ImportAnnotation: "true"}
+			}
+			if tt.old != nil {
+				tt.old(old)
+			}
+			r := old.DeepCopy()
+			tt.mutate(r)
+
+			got := invalidFields(t, r.ValidateUpdate(old))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rejected fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestElasticCacheValidateUpdateRejectsOtherKinds(t *testing.T) {
+	err := validElasticCache().ValidateUpdate(&ReplicationGroup{})
+	if !apierrors.IsBadRequest(err) {
+		t.Errorf("expected a BadRequest error, got %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CredentialsSource names where the credentials of a ProviderConfig come from.
+type CredentialsSource string
+
+const (
+	// CredentialsSourceDefault uses the credentials the operator was started
+	// with, resolved through the default AWS credential chain.
+	CredentialsSourceDefault CredentialsSource = "Default"
+
+	// CredentialsSourceSecret uses static credentials stored in a Secret.
+	CredentialsSourceSecret CredentialsSource = "Secret"
+
+	// CredentialsSourceWebIdentity exchanges a web identity token for the
+	// credentials of an IAM role.
+	CredentialsSourceWebIdentity CredentialsSource = "WebIdentity"
+)
+
+// Keys of the Secret holding static credentials.
+const (
+	CredentialsKeyAccessKeyId     = "aws_access_key_id"
+	CredentialsKeySecretAccessKey = "aws_secret_access_key"
+	CredentialsKeySessionToken    = "aws_session_token"
+)
+
+// ProviderCredentials describes how the credentials of a ProviderConfig are
+// obtained.
+type ProviderCredentials struct {
+
+	// Where the credentials come from. Defaults to Default.
+	// +kubebuilder:validation:Enum=Default;Secret;WebIdentity
+	// +kubebuilder:default=Default
+	Source CredentialsSource `json:"source,omitempty"`
+
+	// A Secret holding static credentials under the keys aws_access_key_id,
+	// aws_secret_access_key and optionally aws_session_token. Required when
+	// Source is Secret.
+	SecretRef *SecretReference `json:"secretRef,omitempty"`
+
+	// The role to assume with a web identity token. Required when Source is
+	// WebIdentity.
+	WebIdentity *WebIdentityCredentials `json:"webIdentity,omitempty"`
+}
+
+// WebIdentityCredentials describes the role assumed with a web identity token,
+// such as a projected service account token on EKS.
+type WebIdentityCredentials struct {
+
+	// The Amazon Resource Name (ARN) of the role to assume.
+	RoleArn string `json:"roleArn"`
+
+	// The path of the file holding the web identity token, read from the file
+	// system of the operator. Defaults to the token mounted by EKS for IAM roles
+	// for service accounts.
+	// +kubebuilder:default="/var/run/secrets/eks.amazonaws.com/serviceaccount/token"
+	TokenFile string `json:"tokenFile,omitempty"`
+
+	// An identifier for the assumed role session.
+	RoleSessionName *string `json:"roleSessionName,omitempty"`
+}
+
+// AssumeRoleOptions describes a role assumed with the credentials of a
+// ProviderConfig, typically one in another AWS account.
+type AssumeRoleOptions struct {
+
+	// The Amazon Resource Name (ARN) of the role to assume.
+	RoleArn string `json:"roleArn"`
+
+	// A unique identifier that might be required when you assume a role in
+	// another account.
+	ExternalId *string `json:"externalId,omitempty"`
+
+	// An identifier for the assumed role session.
+	RoleSessionName *string `json:"roleSessionName,omitempty"`
+}
+
+// ProviderConfigSpec defines the desired state of ProviderConfig
+type ProviderConfigSpec struct {
+
+	// The AWS region resources using this ProviderConfig are managed in. Defaults
+	// to the region the operator was started with.
+	Region *string `json:"region,omitempty"`
+
+	// The credentials used to call AWS.
+	Credentials ProviderCredentials `json:"credentials,omitempty"`
+
+	// A role assumed with the credentials before AWS is called.
+	AssumeRole *AssumeRoleOptions `json:"assumeRole,omitempty"`
+
+	// Selects the namespaces whose resources may reference this ProviderConfig.
+	// An empty selector selects all namespaces. When omitted the ProviderConfig
+	// may be referenced from every namespace.
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Region",type=string,JSONPath=`.spec.region`
+//+kubebuilder:printcolumn:name="Source",type=string,JSONPath=`.spec.credentials.source`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ProviderConfig is the Schema for the providerconfigs API. It configures the
+// region and credentials used to manage the AWS resources referencing it.
+// ProviderConfigs are cluster-scoped, so only cluster administrators decide
+// which credentials, token files and roles of the operator may be used.
+type ProviderConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ProviderConfigSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ProviderConfigList contains a list of ProviderConfig
+type ProviderConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ProviderConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ProviderConfig{}, &ProviderConfigList{})
+}
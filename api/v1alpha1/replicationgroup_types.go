@@ -0,0 +1,383 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type ReplicationGroupAwsConfig struct {
+
+	// A user-created description for the replication group.
+	ReplicationGroupDescription *string `json:"replicationGroupDescription"`
+
+	// A flag that enables encryption at rest when set to true. You cannot modify the
+	// value of AtRestEncryptionEnabled after the replication group is created. To
+	// enable encryption at rest on a replication group you must set
+	// AtRestEncryptionEnabled to true when you create the replication group. Required:
+	// Only available when creating a replication group in an Amazon VPC using redis
+	// version 3.2.6, 4.x or later. Default: false
+	AtRestEncryptionEnabled *bool `json:"atRestEncryptionEnabled,omitempty"`
+
+	// Reference to the key of a Secret holding the password used to access a
+	// password protected server. AuthToken can be specified only on replication
+	// groups where TransitEncryptionEnabled is true. When the value changes the
+	// token is rotated on the replication group.
+	AuthTokenSecretRef *SecretKeySelector `json:"authTokenSecretRef,omitempty"`
+
+	// Specifies the strategy to use to update the AUTH token when the value referenced
+	// by authTokenSecretRef changes. Defaults to Rotate. Possible values:
+	//
+	// * Rotate
+	//
+	// * Set
+	AuthTokenUpdateStrategy types.AuthTokenUpdateStrategyType `json:"authTokenUpdateStrategy,omitempty"`
+
+	// If you are running Redis engine version 6.0 or later, set this parameter to
+	// yes if you want to opt-in to the next minor version upgrade campaign. This
+	// parameter is disabled for previous versions.
+	AutoMinorVersionUpgrade *bool `json:"autoMinorVersionUpgrade,omitempty"`
+
+	// Specifies whether a read-only replica is automatically promoted to read/write
+	// primary if the existing primary fails. AutomaticFailoverEnabled must be enabled
+	// for Redis (cluster mode enabled) replication groups. Default: false
+	AutomaticFailoverEnabled *bool `json:"automaticFailoverEnabled,omitempty"`
+
+	// The compute and memory capacity of the nodes in the node group (shard). See
+	// ElasticCacheAwsConfig.CacheNodeType for the supported node types.
+	CacheNodeType *string `json:"cacheNodeType"`
+
+	// The name of the parameter group to associate with this replication group. If
+	// this argument is omitted, the default cache parameter group for the specified
+	// engine is used. If you are running Redis version 3.2.4 or later, only one node
+	// group (shard), and want to use a default parameter group, we recommend that you
+	// specify the parameter group by name.
+	//
+	// * To create a Redis (cluster mode disabled)
+	// replication group, use CacheParameterGroupName=default.redis3.2.
+	//
+	// * To create a
+	// Redis (cluster mode enabled) replication group, use
+	// CacheParameterGroupName=default.redis3.2.cluster.on.
+	CacheParameterGroupName *string `json:"cacheParameterGroupName,omitempty"`
+
+	// A list of cache security group names to associate with this replication group.
+	CacheSecurityGroupNames []string `json:"cacheSecurityGroupNames,omitempty"`
+
+	// The name of the cache subnet group to be used for the replication group. If
+	// you're going to launch your cluster in an Amazon VPC, you need to create a
+	// subnet group before you start creating a cluster.
+	CacheSubnetGroupName *string `json:"cacheSubnetGroupName,omitempty"`
+
+	// The name of the cache engine to be used for the clusters in this replication
+	// group. Must be Redis.
+	Engine *string `json:"engine,omitempty"`
+
+	// The version number of the cache engine to be used for the clusters in this
+	// replication group. To view the supported cache engine versions, use the
+	// DescribeCacheEngineVersions operation.
+	EngineVersion *string `json:"engineVersion,omitempty"`
+
+	// The ID of the KMS key used to encrypt the disk in the cluster.
+	KmsKeyId *string `json:"kmsKeyId,omitempty"`
+
+	// A flag indicating if you have Multi-AZ enabled to enhance fault tolerance.
+	MultiAZEnabled *bool `json:"multiAZEnabled,omitempty"`
+
+	// The Amazon Resource Name (ARN) of the Amazon Simple Notification Service (SNS)
+	// topic to which notifications are sent. The Amazon SNS topic owner must be the
+	// same as the cluster owner.
+	NotificationTopicArn *string `json:"notificationTopicArn,omitempty"`
+
+	// The number of clusters this replication group initially has. This parameter is
+	// not used if there is more than one node group (shard). You should use
+	// ReplicasPerNodeGroup instead. If AutomaticFailoverEnabled is true, the value of
+	// this parameter must be at least 2. The maximum permitted value for
+	// NumCacheClusters is 6 (1 primary plus 5 replicas). Changes are applied by
+	// adding or removing replicas.
+	NumCacheClusters *int32 `json:"numCacheClusters,omitempty"`
+
+	// An optional parameter that specifies the number of node groups (shards) for
+	// this Redis (cluster mode enabled) replication group. For Redis (cluster mode
+	// disabled) either omit this parameter or set it to 1. Changes are applied with
+	// online resharding. Default: 1
+	NumNodeGroups *int32 `json:"numNodeGroups,omitempty"`
+
+	// The port number on which each member of the replication group accepts
+	// connections.
+	Port *int32 `json:"port,omitempty"`
+
+	// A list of EC2 Availability Zones in which the replication group's clusters are
+	// created. The order of the Availability Zones in the list is the order in which
+	// clusters are allocated. The primary cluster is created in the first AZ in the
+	// list. This parameter is not used if there is more than one node group (shard).
+	PreferredCacheClusterAZs []string `json:"preferredCacheClusterAZs,omitempty"`
+
+	// Specifies the weekly time range during which maintenance on the cluster is
+	// performed. It is specified as a range in the format ddd:hh24:mi-ddd:hh24:mi (24H
+	// Clock UTC). The minimum maintenance window is a 60 minute period.
+	PreferredMaintenanceWindow *string `json:"preferredMaintenanceWindow,omitempty"`
+
+	// An optional parameter that specifies the number of replica nodes in each node
+	// group (shard). Valid values are 0 to 5. Changes are applied by adding or
+	// removing replicas in every shard.
+	ReplicasPerNodeGroup *int32 `json:"replicasPerNodeGroup,omitempty"`
+
+	// One or more Amazon VPC security groups associated with this replication group.
+	// Use this parameter only when you are creating a replication group in an Amazon
+	// Virtual Private Cloud (Amazon VPC).
+	SecurityGroupIds []string `json:"securityGroupIds,omitempty"`
+
+	// A list of Amazon Resource Names (ARN) that uniquely identify the Redis RDB
+	// snapshot files stored in Amazon S3. The snapshot files are used to populate the
+	// new replication group.
+	SnapshotArns []string `json:"snapshotArns,omitempty"`
+
+	// The name of a snapshot from which to restore data into the new replication
+	// group. The snapshot status changes to restoring while the new replication group
+	// is being created.
+	SnapshotName *string `json:"snapshotName,omitempty"`
+
+	// The number of days for which ElastiCache retains automatic snapshots before
+	// deleting them. Default: 0 (i.e., automatic backups are disabled for this
+	// cluster).
+	SnapshotRetentionLimit *int32 `json:"snapshotRetentionLimit,omitempty"`
+
+	// The daily time range (in UTC) during which ElastiCache begins taking a daily
+	// snapshot of your node group (shard). Example: 05:00-09:00
+	SnapshotWindow *string `json:"snapshotWindow,omitempty"`
+
+	// A list of tags to be added to this resource.
+	Tags []Tag `json:"tags,omitempty"`
+
+	// A flag that enables in-transit encryption when set to true. You cannot modify
+	// the value of TransitEncryptionEnabled after the cluster is created. This
+	// parameter is valid only if the Engine parameter is redis, the EngineVersion
+	// parameter is 3.2.6, 4.x or later, and the cluster is being created in an Amazon
+	// VPC. Default: false
+	TransitEncryptionEnabled *bool `json:"transitEncryptionEnabled,omitempty"`
+
+	// References to UserGroup objects in the namespace of the ReplicationGroup
+	// that have access to the replication group. The replication group is only
+	// created once all referenced user groups are Ready. User groups require
+	// TransitEncryptionEnabled and cannot be combined with AuthTokenSecretRef.
+	UserGroupRefs []LocalObjectReference `json:"userGroupRefs,omitempty"`
+}
+
+// ReplicationGroupSpec defines the desired state of ReplicationGroup
+type ReplicationGroupSpec struct {
+	AWSConfig *ReplicationGroupAwsConfig `json:"awsConfig"`
+
+	// Where to publish connection details of the replication group once it is
+	// available.
+	WriteConnectionDetailsTo *ConnectionDetailsTarget `json:"writeConnectionDetailsTo,omitempty"`
+
+	// The identifier of the replication group in AWS. When omitted the identifier
+	// is derived from the NamingStrategy. An existing replication group that was
+	// not created by this ReplicationGroup is only managed after it is imported
+	// with the aws.sergeyshevch.dev/import annotation.
+	ExternalName *string `json:"externalName,omitempty"`
+
+	// How the identifier of the replication group is derived when ExternalName is
+	// not set. Name uses the name of the ReplicationGroup, NamespacedHash combines
+	// the namespace, the name and a hash of both so equally named groups in
+	// different namespaces do not collide. The resolved identifier is recorded in
+	// the status and kept for the lifetime of the replication group. Defaults to
+	// Name.
+	// +kubebuilder:validation:Enum=Name;NamespacedHash
+	// +kubebuilder:default=Name
+	NamingStrategy ClusterNamingStrategy `json:"namingStrategy,omitempty"`
+}
+
+// NodeGroupMember Represents a single node within a node group (shard).
+type NodeGroupMember struct {
+
+	// The ID of the cluster to which the node belongs.
+	CacheClusterId *string `json:"cacheClusterId,omitempty"`
+
+	// The ID of the node within its cluster. A node ID is a numeric identifier (0001,
+	// 0002, etc.).
+	CacheNodeId *string `json:"cacheNodeId,omitempty"`
+
+	// The role that is currently assigned to the node - primary or replica. This
+	// member is only applicable for Redis (cluster mode disabled) replication groups.
+	CurrentRole *string `json:"currentRole,omitempty"`
+
+	// The name of the Availability Zone in which the node is located.
+	PreferredAvailabilityZone *string `json:"preferredAvailabilityZone,omitempty"`
+
+	// The information required for client programs to connect to a node for read
+	// operations.
+	ReadEndpoint *Endpoint `json:"readEndpoint,omitempty"`
+}
+
+// NodeGroup Represents a collection of cache nodes in a replication group. One
+// node in the node group is the read/write primary node. All the other nodes are
+// read-only Replica nodes.
+type NodeGroup struct {
+
+	// The identifier for the node group (shard).
+	NodeGroupId *string `json:"nodeGroupId,omitempty"`
+
+	// The current state of this replication group - creating, available, modifying,
+	// deleting.
+	Status *string `json:"status,omitempty"`
+
+	// The endpoint of the primary node in this node group (shard).
+	PrimaryEndpoint *Endpoint `json:"primaryEndpoint,omitempty"`
+
+	// The endpoint of the replica nodes in this node group (shard).
+	ReaderEndpoint *Endpoint `json:"readerEndpoint,omitempty"`
+
+	// The keyspace for this node group (shard).
+	Slots *string `json:"slots,omitempty"`
+
+	// A list containing information about individual nodes within the node group
+	// (shard).
+	NodeGroupMembers []NodeGroupMember `json:"nodeGroupMembers,omitempty"`
+}
+
+// ReplicationGroupPendingModifiedValues The settings to be applied to the Redis
+// replication group, either immediately or during the next maintenance window.
+type ReplicationGroupPendingModifiedValues struct {
+
+	// The auth token status
+	AuthTokenStatus types.AuthTokenUpdateStatus `json:"authTokenStatus,omitempty"`
+
+	// Indicates the status of automatic failover for this Redis replication group.
+	AutomaticFailoverStatus types.PendingAutomaticFailoverStatus `json:"automaticFailoverStatus,omitempty"`
+
+	// The primary cluster ID that is applied immediately (if
+	// --apply-immediately was specified), or during the next maintenance window.
+	PrimaryClusterId *string `json:"primaryClusterId,omitempty"`
+
+	// Whether an online resharding operation is in progress.
+	Resharding bool `json:"resharding,omitempty"`
+}
+
+// ReplicationGroupStatus defines the observed state of ReplicationGroup
+type ReplicationGroupStatus struct {
+
+	// Conditions represent the latest available observations of the replication
+	// group state. Known condition types are Ready, Synced, Deleting and Error.
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// The generation of the ReplicationGroup most recently observed by the
+	// controller.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// The identifier of the replication group in AWS managed by this
+	// ReplicationGroup.
+	ReplicationGroupId *string `json:"replicationGroupId,omitempty"`
+
+	// The current state of this replication group - creating, available, modifying,
+	// deleting, create-failed, snapshotting.
+	Status *string `json:"status,omitempty"`
+
+	// The ARN (Amazon Resource Name) of the replication group.
+	ARN *string `json:"arn,omitempty"`
+
+	// The name of the compute and memory capacity node type for each node in the
+	// replication group.
+	CacheNodeType *string `json:"cacheNodeType,omitempty"`
+
+	// A flag indicating whether or not this replication group is cluster enabled;
+	// i.e., whether its data can be partitioned across multiple shards (API/CLI: node
+	// groups).
+	ClusterEnabled *bool `json:"clusterEnabled,omitempty"`
+
+	// Indicates the status of automatic failover for this Redis replication group.
+	AutomaticFailover types.AutomaticFailoverStatus `json:"automaticFailover,omitempty"`
+
+	// A flag indicating if you have Multi-AZ enabled to enhance fault tolerance.
+	MultiAZ types.MultiAZStatus `json:"multiAZ,omitempty"`
+
+	// A flag that enables encryption at-rest when set to true.
+	AtRestEncryptionEnabled *bool `json:"atRestEncryptionEnabled,omitempty"`
+
+	// A flag that enables in-transit encryption when set to true.
+	TransitEncryptionEnabled *bool `json:"transitEncryptionEnabled,omitempty"`
+
+	// A flag that enables using an AuthToken (password) when issuing Redis commands.
+	AuthTokenEnabled *bool `json:"authTokenEnabled,omitempty"`
+
+	// The list of user group IDs that have access to the replication group.
+	UserGroupIds []string `json:"userGroupIds,omitempty"`
+
+	// The configuration endpoint for this replication group. Use the configuration
+	// endpoint to connect to this replication group.
+	ConfigurationEndpoint *Endpoint `json:"configurationEndpoint,omitempty"`
+
+	// The names of all the cache clusters that are part of this replication group.
+	MemberClusters []string `json:"memberClusters,omitempty"`
+
+	// A list of node groups in this replication group. For Redis (cluster mode
+	// disabled) replication groups, this is a single-element list. For Redis (cluster
+	// mode enabled) replication groups, the list contains an entry for each node
+	// group (shard).
+	NodeGroups []NodeGroup `json:"nodeGroups,omitempty"`
+
+	// A group of settings to be applied to the replication group, either immediately
+	// or during the next maintenance window.
+	PendingModifiedValues *ReplicationGroupPendingModifiedValues `json:"pendingModifiedValues,omitempty"`
+
+	// A salted hash of the auth token that was last applied to the replication group.
+	AuthTokenHash string `json:"authTokenHash,omitempty"`
+
+	// The modifiable fields of the spec that differed from the replication group
+	// when it was last observed. They are modified as soon as the replication
+	// group is available.
+	DriftedFields []string `json:"driftedFields,omitempty"`
+
+	// The last time the controller successfully synchronized this status with AWS.
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.status`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Node Type",type=string,JSONPath=`.status.cacheNodeType`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ReplicationGroup is the Schema for the replicationgroups API
+type ReplicationGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ReplicationGroupSpec   `json:"spec,omitempty"`
+	Status ReplicationGroupStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ReplicationGroupList contains a list of ReplicationGroup
+type ReplicationGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ReplicationGroup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ReplicationGroup{}, &ReplicationGroupList{})
+}
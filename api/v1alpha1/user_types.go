@@ -0,0 +1,124 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// UserSpec defines the desired state of User
+type UserSpec struct {
+
+	// The username of the user.
+	UserName string `json:"userName"`
+
+	// Access permissions string used for this user.
+	AccessString string `json:"accessString"`
+
+	// The current supported value is Redis.
+	// +kubebuilder:default=redis
+	// +kubebuilder:validation:Enum=redis
+	Engine string `json:"engine,omitempty"`
+
+	// References to the Secret keys holding the passwords used for this user. You
+	// can set up to two passwords for each user. When a referenced value changes the
+	// passwords of the user are replaced.
+	// +kubebuilder:validation:MaxItems=2
+	PasswordSecretRefs []SecretKeySelector `json:"passwordSecretRefs,omitempty"`
+
+	// Indicates a password is not required for this user.
+	NoPasswordRequired bool `json:"noPasswordRequired,omitempty"`
+
+	// A list of tags to be added to this resource.
+	Tags []Tag `json:"tags,omitempty"`
+}
+
+// UserStatus defines the observed state of User
+type UserStatus struct {
+
+	// Conditions represent the latest available observations of the user state.
+	// Known condition types are Ready, Synced, Deleting and Error.
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// The generation of the User most recently observed by the controller.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// The ID of the user in AWS.
+	UserId *string `json:"userId,omitempty"`
+
+	// The Amazon Resource Name (ARN) of the user.
+	ARN *string `json:"arn,omitempty"`
+
+	// Indicates the user status. Can be "active", "modifying" or "deleting".
+	Status *string `json:"status,omitempty"`
+
+	// Access permissions string used for this user.
+	AccessString *string `json:"accessString,omitempty"`
+
+	// Indicates whether the user requires a password to authenticate.
+	AuthenticationType string `json:"authenticationType,omitempty"`
+
+	// The number of passwords belonging to the user. The maximum is two.
+	PasswordCount *int32 `json:"passwordCount,omitempty"`
+
+	// Returns a list of the user group IDs the user belongs to.
+	UserGroupIds []string `json:"userGroupIds,omitempty"`
+
+	// A hash of the passwords last sent to AWS, used to detect password changes.
+	PasswordsHash string `json:"passwordsHash,omitempty"`
+
+	// A hash of the access string of the spec last sent to AWS. AWS normalizes
+	// access strings, so spec changes are detected with this hash while
+	// changes made outside of the operator are detected against AccessString.
+	AccessStringHash string `json:"accessStringHash,omitempty"`
+
+	// The last time the controller successfully synchronized this status with AWS.
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.status`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// User is the Schema for the users API
+type User struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   UserSpec   `json:"spec,omitempty"`
+	Status UserStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// UserList contains a list of User
+type UserList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []User `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&User{}, &UserList{})
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// UserGroupSpec defines the desired state of UserGroup
+type UserGroupSpec struct {
+
+	// The current supported value is Redis.
+	// +kubebuilder:default=redis
+	// +kubebuilder:validation:Enum=redis
+	Engine string `json:"engine,omitempty"`
+
+	// References to User objects in the namespace of the UserGroup whose users
+	// belong to the user group.
+	UserRefs []LocalObjectReference `json:"userRefs,omitempty"`
+
+	// The list of user IDs not managed by a User object that belong to the user
+	// group, e.g. the "default" user every user group must contain.
+	UserIds []string `json:"userIds,omitempty"`
+
+	// A list of tags to be added to this resource.
+	Tags []Tag `json:"tags,omitempty"`
+}
+
+// UserGroupPendingChanges Returns the updates being applied to the user group.
+type UserGroupPendingChanges struct {
+
+	// The list of user IDs to add.
+	UserIdsToAdd []string `json:"userIdsToAdd,omitempty"`
+
+	// The list of user IDs to remove.
+	UserIdsToRemove []string `json:"userIdsToRemove,omitempty"`
+}
+
+// UserGroupStatus defines the observed state of UserGroup
+type UserGroupStatus struct {
+
+	// Conditions represent the latest available observations of the user group
+	// state. Known condition types are Ready, Synced, Deleting and Error.
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// The generation of the UserGroup most recently observed by the controller.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// The ID of the user group in AWS.
+	UserGroupId *string `json:"userGroupId,omitempty"`
+
+	// The Amazon Resource Name (ARN) of the user group.
+	ARN *string `json:"arn,omitempty"`
+
+	// Indicates user group status. Can be "creating", "active", "modifying",
+	// "deleting".
+	Status *string `json:"status,omitempty"`
+
+	// The list of user IDs that belong to the user group.
+	UserIds []string `json:"userIds,omitempty"`
+
+	// A list of updates being applied to the user groups.
+	PendingChanges *UserGroupPendingChanges `json:"pendingChanges,omitempty"`
+
+	// A list of replication groups that the user group can access.
+	ReplicationGroups []string `json:"replicationGroups,omitempty"`
+
+	// The last time the controller successfully synchronized this status with AWS.
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.status`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// UserGroup is the Schema for the usergroups API
+type UserGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   UserGroupSpec   `json:"spec,omitempty"`
+	Status UserGroupStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// UserGroupList contains a list of UserGroup
+type UserGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []UserGroup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&UserGroup{}, &UserGroupList{})
+}
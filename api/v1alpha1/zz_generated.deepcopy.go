@@ -22,9 +22,793 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AssumeRoleOptions) DeepCopyInto(out *AssumeRoleOptions) {
+	*out = *in
+	if in.ExternalId != nil {
+		in, out := &in.ExternalId, &out.ExternalId
+		*out = new(string)
+		**out = **in
+	}
+	if in.RoleSessionName != nil {
+		in, out := &in.RoleSessionName, &out.RoleSessionName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AssumeRoleOptions.
+func (in *AssumeRoleOptions) DeepCopy() *AssumeRoleOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(AssumeRoleOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CacheClass) DeepCopyInto(out *CacheClass) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CacheClass.
+func (in *CacheClass) DeepCopy() *CacheClass {
+	if in == nil {
+		return nil
+	}
+	out := new(CacheClass)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CacheClass) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CacheClassList) DeepCopyInto(out *CacheClassList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CacheClass, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CacheClassList.
+func (in *CacheClassList) DeepCopy() *CacheClassList {
+	if in == nil {
+		return nil
+	}
+	out := new(CacheClassList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CacheClassList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CacheClassSpec) DeepCopyInto(out *CacheClassSpec) {
+	*out = *in
+	in.AWSConfig.DeepCopyInto(&out.AWSConfig)
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CacheClassSpec.
+func (in *CacheClassSpec) DeepCopy() *CacheClassSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CacheClassSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CacheNode) DeepCopyInto(out *CacheNode) {
+	*out = *in
+	if in.CacheNodeId != nil {
+		in, out := &in.CacheNodeId, &out.CacheNodeId
+		*out = new(string)
+		**out = **in
+	}
+	if in.CacheNodeStatus != nil {
+		in, out := &in.CacheNodeStatus, &out.CacheNodeStatus
+		*out = new(string)
+		**out = **in
+	}
+	if in.CacheNodeCreateTime != nil {
+		in, out := &in.CacheNodeCreateTime, &out.CacheNodeCreateTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CustomerAvailabilityZone != nil {
+		in, out := &in.CustomerAvailabilityZone, &out.CustomerAvailabilityZone
+		*out = new(string)
+		**out = **in
+	}
+	if in.CustomerOutpostArn != nil {
+		in, out := &in.CustomerOutpostArn, &out.CustomerOutpostArn
+		*out = new(string)
+		**out = **in
+	}
+	if in.Endpoint != nil {
+		in, out := &in.Endpoint, &out.Endpoint
+		*out = new(Endpoint)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ParameterGroupStatus != nil {
+		in, out := &in.ParameterGroupStatus, &out.ParameterGroupStatus
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CacheNode.
+func (in *CacheNode) DeepCopy() *CacheNode {
+	if in == nil {
+		return nil
+	}
+	out := new(CacheNode)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CacheParameterGroup) DeepCopyInto(out *CacheParameterGroup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CacheParameterGroup.
+func (in *CacheParameterGroup) DeepCopy() *CacheParameterGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(CacheParameterGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CacheParameterGroup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CacheParameterGroupList) DeepCopyInto(out *CacheParameterGroupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CacheParameterGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CacheParameterGroupList.
+func (in *CacheParameterGroupList) DeepCopy() *CacheParameterGroupList {
+	if in == nil {
+		return nil
+	}
+	out := new(CacheParameterGroupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CacheParameterGroupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CacheParameterGroupSpec) DeepCopyInto(out *CacheParameterGroupSpec) {
+	*out = *in
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]Tag, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CacheParameterGroupSpec.
+func (in *CacheParameterGroupSpec) DeepCopy() *CacheParameterGroupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CacheParameterGroupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CacheParameterGroupStatus) DeepCopyInto(out *CacheParameterGroupStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ARN != nil {
+		in, out := &in.ARN, &out.ARN
+		*out = new(string)
+		**out = **in
+	}
+	if in.CacheParameterGroupName != nil {
+		in, out := &in.CacheParameterGroupName, &out.CacheParameterGroupName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ClustersPendingReboot != nil {
+		in, out := &in.ClustersPendingReboot, &out.ClustersPendingReboot
+		*out = make([]PendingRebootCluster, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CacheParameterGroupStatus.
+func (in *CacheParameterGroupStatus) DeepCopy() *CacheParameterGroupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CacheParameterGroupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CacheSnapshot) DeepCopyInto(out *CacheSnapshot) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CacheSnapshot.
+func (in *CacheSnapshot) DeepCopy() *CacheSnapshot {
+	if in == nil {
+		return nil
+	}
+	out := new(CacheSnapshot)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CacheSnapshot) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CacheSnapshotList) DeepCopyInto(out *CacheSnapshotList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CacheSnapshot, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CacheSnapshotList.
+func (in *CacheSnapshotList) DeepCopy() *CacheSnapshotList {
+	if in == nil {
+		return nil
+	}
+	out := new(CacheSnapshotList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CacheSnapshotList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CacheSnapshotSchedule) DeepCopyInto(out *CacheSnapshotSchedule) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CacheSnapshotSchedule.
+func (in *CacheSnapshotSchedule) DeepCopy() *CacheSnapshotSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(CacheSnapshotSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CacheSnapshotSchedule) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CacheSnapshotScheduleList) DeepCopyInto(out *CacheSnapshotScheduleList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CacheSnapshotSchedule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CacheSnapshotScheduleList.
+func (in *CacheSnapshotScheduleList) DeepCopy() *CacheSnapshotScheduleList {
+	if in == nil {
+		return nil
+	}
+	out := new(CacheSnapshotScheduleList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CacheSnapshotScheduleList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CacheSnapshotScheduleSpec) DeepCopyInto(out *CacheSnapshotScheduleSpec) {
+	*out = *in
+	if in.ElasticCacheRef != nil {
+		in, out := &in.ElasticCacheRef, &out.ElasticCacheRef
+		*out = new(LocalObjectReference)
+		**out = **in
+	}
+	if in.ReplicationGroupRef != nil {
+		in, out := &in.ReplicationGroupRef, &out.ReplicationGroupRef
+		*out = new(LocalObjectReference)
+		**out = **in
+	}
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]SnapshotScheduleRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.StartingDeadlineSeconds != nil {
+		in, out := &in.StartingDeadlineSeconds, &out.StartingDeadlineSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.KmsKeyId != nil {
+		in, out := &in.KmsKeyId, &out.KmsKeyId
+		*out = new(string)
+		**out = **in
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]Tag, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CacheSnapshotScheduleSpec.
+func (in *CacheSnapshotScheduleSpec) DeepCopy() *CacheSnapshotScheduleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CacheSnapshotScheduleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CacheSnapshotScheduleStatus) DeepCopyInto(out *CacheSnapshotScheduleStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]SnapshotScheduleRuleStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CacheSnapshotScheduleStatus.
+func (in *CacheSnapshotScheduleStatus) DeepCopy() *CacheSnapshotScheduleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CacheSnapshotScheduleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CacheSnapshotSpec) DeepCopyInto(out *CacheSnapshotSpec) {
+	*out = *in
+	if in.ElasticCacheRef != nil {
+		in, out := &in.ElasticCacheRef, &out.ElasticCacheRef
+		*out = new(LocalObjectReference)
+		**out = **in
+	}
+	if in.ReplicationGroupRef != nil {
+		in, out := &in.ReplicationGroupRef, &out.ReplicationGroupRef
+		*out = new(LocalObjectReference)
+		**out = **in
+	}
+	if in.KmsKeyId != nil {
+		in, out := &in.KmsKeyId, &out.KmsKeyId
+		*out = new(string)
+		**out = **in
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]Tag, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ExportTo != nil {
+		in, out := &in.ExportTo, &out.ExportTo
+		*out = new(SnapshotExport)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CacheSnapshotSpec.
+func (in *CacheSnapshotSpec) DeepCopy() *CacheSnapshotSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CacheSnapshotSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CacheSnapshotStatus) DeepCopyInto(out *CacheSnapshotStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SnapshotName != nil {
+		in, out := &in.SnapshotName, &out.SnapshotName
+		*out = new(string)
+		**out = **in
+	}
+	if in.ARN != nil {
+		in, out := &in.ARN, &out.ARN
+		*out = new(string)
+		**out = **in
+	}
+	if in.SnapshotStatus != nil {
+		in, out := &in.SnapshotStatus, &out.SnapshotStatus
+		*out = new(string)
+		**out = **in
+	}
+	if in.SnapshotSource != nil {
+		in, out := &in.SnapshotSource, &out.SnapshotSource
+		*out = new(string)
+		**out = **in
+	}
+	if in.CacheClusterId != nil {
+		in, out := &in.CacheClusterId, &out.CacheClusterId
+		*out = new(string)
+		**out = **in
+	}
+	if in.ReplicationGroupId != nil {
+		in, out := &in.ReplicationGroupId, &out.ReplicationGroupId
+		*out = new(string)
+		**out = **in
+	}
+	if in.Engine != nil {
+		in, out := &in.Engine, &out.Engine
+		*out = new(string)
+		**out = **in
+	}
+	if in.EngineVersion != nil {
+		in, out := &in.EngineVersion, &out.EngineVersion
+		*out = new(string)
+		**out = **in
+	}
+	if in.CacheNodeType != nil {
+		in, out := &in.CacheNodeType, &out.CacheNodeType
+		*out = new(string)
+		**out = **in
+	}
+	if in.NumNodeGroups != nil {
+		in, out := &in.NumNodeGroups, &out.NumNodeGroups
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Export != nil {
+		in, out := &in.Export, &out.Export
+		*out = new(SnapshotExportStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CacheSnapshotStatus.
+func (in *CacheSnapshotStatus) DeepCopy() *CacheSnapshotStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CacheSnapshotStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CacheSubnetGroup) DeepCopyInto(out *CacheSubnetGroup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CacheSubnetGroup.
+func (in *CacheSubnetGroup) DeepCopy() *CacheSubnetGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(CacheSubnetGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CacheSubnetGroup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CacheSubnetGroupList) DeepCopyInto(out *CacheSubnetGroupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CacheSubnetGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CacheSubnetGroupList.
+func (in *CacheSubnetGroupList) DeepCopy() *CacheSubnetGroupList {
+	if in == nil {
+		return nil
+	}
+	out := new(CacheSubnetGroupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CacheSubnetGroupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CacheSubnetGroupSpec) DeepCopyInto(out *CacheSubnetGroupSpec) {
+	*out = *in
+	if in.SubnetIds != nil {
+		in, out := &in.SubnetIds, &out.SubnetIds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]Tag, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CacheSubnetGroupSpec.
+func (in *CacheSubnetGroupSpec) DeepCopy() *CacheSubnetGroupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CacheSubnetGroupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CacheSubnetGroupStatus) DeepCopyInto(out *CacheSubnetGroupStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ARN != nil {
+		in, out := &in.ARN, &out.ARN
+		*out = new(string)
+		**out = **in
+	}
+	if in.CacheSubnetGroupName != nil {
+		in, out := &in.CacheSubnetGroupName, &out.CacheSubnetGroupName
+		*out = new(string)
+		**out = **in
+	}
+	if in.VpcId != nil {
+		in, out := &in.VpcId, &out.VpcId
+		*out = new(string)
+		**out = **in
+	}
+	if in.Subnets != nil {
+		in, out := &in.Subnets, &out.Subnets
+		*out = make([]Subnet, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CacheSubnetGroupStatus.
+func (in *CacheSubnetGroupStatus) DeepCopy() *CacheSubnetGroupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CacheSubnetGroupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudWatchLogsDestination) DeepCopyInto(out *CloudWatchLogsDestination) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudWatchLogsDestination.
+func (in *CloudWatchLogsDestination) DeepCopy() *CloudWatchLogsDestination {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudWatchLogsDestination)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConnectionDetailsTarget) DeepCopyInto(out *ConnectionDetailsTarget) {
+	*out = *in
+	if in.ConfigMapName != nil {
+		in, out := &in.ConfigMapName, &out.ConfigMapName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConnectionDetailsTarget.
+func (in *ConnectionDetailsTarget) DeepCopy() *ConnectionDetailsTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(ConnectionDetailsTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ElasticCache) DeepCopyInto(out *ElasticCache) {
 	*out = *in
@@ -34,18 +818,1246 @@ func (in *ElasticCache) DeepCopyInto(out *ElasticCache) {
 	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticCache.
-func (in *ElasticCache) DeepCopy() *ElasticCache {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticCache.
+func (in *ElasticCache) DeepCopy() *ElasticCache {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticCache)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElasticCache) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticCacheAwsConfig) DeepCopyInto(out *ElasticCacheAwsConfig) {
+	*out = *in
+	if in.AuthTokenSecretRef != nil {
+		in, out := &in.AuthTokenSecretRef, &out.AuthTokenSecretRef
+		*out = new(SecretKeySelector)
+		**out = **in
+	}
+	if in.AuthToken != nil {
+		in, out := &in.AuthToken, &out.AuthToken
+		*out = new(string)
+		**out = **in
+	}
+	if in.CacheNodeType != nil {
+		in, out := &in.CacheNodeType, &out.CacheNodeType
+		*out = new(string)
+		**out = **in
+	}
+	if in.CacheParameterGroupName != nil {
+		in, out := &in.CacheParameterGroupName, &out.CacheParameterGroupName
+		*out = new(string)
+		**out = **in
+	}
+	if in.CacheSecurityGroupNames != nil {
+		in, out := &in.CacheSecurityGroupNames, &out.CacheSecurityGroupNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CacheSubnetGroupName != nil {
+		in, out := &in.CacheSubnetGroupName, &out.CacheSubnetGroupName
+		*out = new(string)
+		**out = **in
+	}
+	if in.SubnetGroupRef != nil {
+		in, out := &in.SubnetGroupRef, &out.SubnetGroupRef
+		*out = new(LocalObjectReference)
+		**out = **in
+	}
+	if in.Engine != nil {
+		in, out := &in.Engine, &out.Engine
+		*out = new(string)
+		**out = **in
+	}
+	if in.EngineVersion != nil {
+		in, out := &in.EngineVersion, &out.EngineVersion
+		*out = new(string)
+		**out = **in
+	}
+	if in.LogDeliveryConfigurations != nil {
+		in, out := &in.LogDeliveryConfigurations, &out.LogDeliveryConfigurations
+		*out = make([]LogDeliveryConfiguration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NotificationTopicArn != nil {
+		in, out := &in.NotificationTopicArn, &out.NotificationTopicArn
+		*out = new(string)
+		**out = **in
+	}
+	if in.NumCacheNodes != nil {
+		in, out := &in.NumCacheNodes, &out.NumCacheNodes
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Port != nil {
+		in, out := &in.Port, &out.Port
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PreferredAvailabilityZone != nil {
+		in, out := &in.PreferredAvailabilityZone, &out.PreferredAvailabilityZone
+		*out = new(string)
+		**out = **in
+	}
+	if in.PreferredAvailabilityZones != nil {
+		in, out := &in.PreferredAvailabilityZones, &out.PreferredAvailabilityZones
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PreferredMaintenanceWindow != nil {
+		in, out := &in.PreferredMaintenanceWindow, &out.PreferredMaintenanceWindow
+		*out = new(string)
+		**out = **in
+	}
+	if in.PreferredOutpostArn != nil {
+		in, out := &in.PreferredOutpostArn, &out.PreferredOutpostArn
+		*out = new(string)
+		**out = **in
+	}
+	if in.PreferredOutpostArns != nil {
+		in, out := &in.PreferredOutpostArns, &out.PreferredOutpostArns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ReplicationGroupId != nil {
+		in, out := &in.ReplicationGroupId, &out.ReplicationGroupId
+		*out = new(string)
+		**out = **in
+	}
+	if in.SecurityGroupIds != nil {
+		in, out := &in.SecurityGroupIds, &out.SecurityGroupIds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SnapshotArns != nil {
+		in, out := &in.SnapshotArns, &out.SnapshotArns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SnapshotName != nil {
+		in, out := &in.SnapshotName, &out.SnapshotName
+		*out = new(string)
+		**out = **in
+	}
+	if in.SnapshotRetentionLimit != nil {
+		in, out := &in.SnapshotRetentionLimit, &out.SnapshotRetentionLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SnapshotWindow != nil {
+		in, out := &in.SnapshotWindow, &out.SnapshotWindow
+		*out = new(string)
+		**out = **in
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]Tag, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticCacheAwsConfig.
+func (in *ElasticCacheAwsConfig) DeepCopy() *ElasticCacheAwsConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticCacheAwsConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticCacheList) DeepCopyInto(out *ElasticCacheList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ElasticCache, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticCacheList.
+func (in *ElasticCacheList) DeepCopy() *ElasticCacheList {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticCacheList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElasticCacheList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticCacheSpec) DeepCopyInto(out *ElasticCacheSpec) {
+	*out = *in
+	if in.AWSConfig != nil {
+		in, out := &in.AWSConfig, &out.AWSConfig
+		*out = new(ElasticCacheAwsConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CacheClassName != nil {
+		in, out := &in.CacheClassName, &out.CacheClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.ProviderConfigRef != nil {
+		in, out := &in.ProviderConfigRef, &out.ProviderConfigRef
+		*out = new(LocalObjectReference)
+		**out = **in
+	}
+	if in.ExternalName != nil {
+		in, out := &in.ExternalName, &out.ExternalName
+		*out = new(string)
+		**out = **in
+	}
+	if in.WriteConnectionDetailsTo != nil {
+		in, out := &in.WriteConnectionDetailsTo, &out.WriteConnectionDetailsTo
+		*out = new(ConnectionDetailsTarget)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticCacheSpec.
+func (in *ElasticCacheSpec) DeepCopy() *ElasticCacheSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticCacheSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticCacheStatus) DeepCopyInto(out *ElasticCacheStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CacheClusterId != nil {
+		in, out := &in.CacheClusterId, &out.CacheClusterId
+		*out = new(string)
+		**out = **in
+	}
+	if in.CacheClusterStatus != nil {
+		in, out := &in.CacheClusterStatus, &out.CacheClusterStatus
+		*out = new(string)
+		**out = **in
+	}
+	if in.ARN != nil {
+		in, out := &in.ARN, &out.ARN
+		*out = new(string)
+		**out = **in
+	}
+	if in.Engine != nil {
+		in, out := &in.Engine, &out.Engine
+		*out = new(string)
+		**out = **in
+	}
+	if in.EngineVersion != nil {
+		in, out := &in.EngineVersion, &out.EngineVersion
+		*out = new(string)
+		**out = **in
+	}
+	if in.CacheNodeType != nil {
+		in, out := &in.CacheNodeType, &out.CacheNodeType
+		*out = new(string)
+		**out = **in
+	}
+	if in.NumCacheNodes != nil {
+		in, out := &in.NumCacheNodes, &out.NumCacheNodes
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PreferredAvailabilityZone != nil {
+		in, out := &in.PreferredAvailabilityZone, &out.PreferredAvailabilityZone
+		*out = new(string)
+		**out = **in
+	}
+	if in.ConfigurationEndpoint != nil {
+		in, out := &in.ConfigurationEndpoint, &out.ConfigurationEndpoint
+		*out = new(Endpoint)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CacheNodes != nil {
+		in, out := &in.CacheNodes, &out.CacheNodes
+		*out = make([]CacheNode, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PendingModifiedValues != nil {
+		in, out := &in.PendingModifiedValues, &out.PendingModifiedValues
+		*out = new(PendingModifiedValues)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LogDeliveryConfigurations != nil {
+		in, out := &in.LogDeliveryConfigurations, &out.LogDeliveryConfigurations
+		*out = make([]LogDeliveryStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DriftedFields != nil {
+		in, out := &in.DriftedFields, &out.DriftedFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.FinalSnapshotIdentifier != nil {
+		in, out := &in.FinalSnapshotIdentifier, &out.FinalSnapshotIdentifier
+		*out = new(string)
+		**out = **in
+	}
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticCacheStatus.
+func (in *ElasticCacheStatus) DeepCopy() *ElasticCacheStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticCacheStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Endpoint) DeepCopyInto(out *Endpoint) {
+	*out = *in
+	if in.Address != nil {
+		in, out := &in.Address, &out.Address
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Endpoint.
+func (in *Endpoint) DeepCopy() *Endpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(Endpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KinesisFirehoseDestination) DeepCopyInto(out *KinesisFirehoseDestination) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KinesisFirehoseDestination.
+func (in *KinesisFirehoseDestination) DeepCopy() *KinesisFirehoseDestination {
+	if in == nil {
+		return nil
+	}
+	out := new(KinesisFirehoseDestination)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LocalObjectReference) DeepCopyInto(out *LocalObjectReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalObjectReference.
+func (in *LocalObjectReference) DeepCopy() *LocalObjectReference {
+	if in == nil {
+		return nil
+	}
+	out := new(LocalObjectReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogDeliveryConfiguration) DeepCopyInto(out *LogDeliveryConfiguration) {
+	*out = *in
+	if in.CloudWatchLogs != nil {
+		in, out := &in.CloudWatchLogs, &out.CloudWatchLogs
+		*out = new(CloudWatchLogsDestination)
+		**out = **in
+	}
+	if in.KinesisFirehose != nil {
+		in, out := &in.KinesisFirehose, &out.KinesisFirehose
+		*out = new(KinesisFirehoseDestination)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogDeliveryConfiguration.
+func (in *LogDeliveryConfiguration) DeepCopy() *LogDeliveryConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(LogDeliveryConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogDeliveryStatus) DeepCopyInto(out *LogDeliveryStatus) {
+	*out = *in
+	if in.Message != nil {
+		in, out := &in.Message, &out.Message
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogDeliveryStatus.
+func (in *LogDeliveryStatus) DeepCopy() *LogDeliveryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LogDeliveryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeGroup) DeepCopyInto(out *NodeGroup) {
+	*out = *in
+	if in.NodeGroupId != nil {
+		in, out := &in.NodeGroupId, &out.NodeGroupId
+		*out = new(string)
+		**out = **in
+	}
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(string)
+		**out = **in
+	}
+	if in.PrimaryEndpoint != nil {
+		in, out := &in.PrimaryEndpoint, &out.PrimaryEndpoint
+		*out = new(Endpoint)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReaderEndpoint != nil {
+		in, out := &in.ReaderEndpoint, &out.ReaderEndpoint
+		*out = new(Endpoint)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Slots != nil {
+		in, out := &in.Slots, &out.Slots
+		*out = new(string)
+		**out = **in
+	}
+	if in.NodeGroupMembers != nil {
+		in, out := &in.NodeGroupMembers, &out.NodeGroupMembers
+		*out = make([]NodeGroupMember, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeGroup.
+func (in *NodeGroup) DeepCopy() *NodeGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeGroupMember) DeepCopyInto(out *NodeGroupMember) {
+	*out = *in
+	if in.CacheClusterId != nil {
+		in, out := &in.CacheClusterId, &out.CacheClusterId
+		*out = new(string)
+		**out = **in
+	}
+	if in.CacheNodeId != nil {
+		in, out := &in.CacheNodeId, &out.CacheNodeId
+		*out = new(string)
+		**out = **in
+	}
+	if in.CurrentRole != nil {
+		in, out := &in.CurrentRole, &out.CurrentRole
+		*out = new(string)
+		**out = **in
+	}
+	if in.PreferredAvailabilityZone != nil {
+		in, out := &in.PreferredAvailabilityZone, &out.PreferredAvailabilityZone
+		*out = new(string)
+		**out = **in
+	}
+	if in.ReadEndpoint != nil {
+		in, out := &in.ReadEndpoint, &out.ReadEndpoint
+		*out = new(Endpoint)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeGroupMember.
+func (in *NodeGroupMember) DeepCopy() *NodeGroupMember {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeGroupMember)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PendingModifiedValues) DeepCopyInto(out *PendingModifiedValues) {
+	*out = *in
+	if in.CacheNodeIdsToRemove != nil {
+		in, out := &in.CacheNodeIdsToRemove, &out.CacheNodeIdsToRemove
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CacheNodeType != nil {
+		in, out := &in.CacheNodeType, &out.CacheNodeType
+		*out = new(string)
+		**out = **in
+	}
+	if in.EngineVersion != nil {
+		in, out := &in.EngineVersion, &out.EngineVersion
+		*out = new(string)
+		**out = **in
+	}
+	if in.NumCacheNodes != nil {
+		in, out := &in.NumCacheNodes, &out.NumCacheNodes
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PendingModifiedValues.
+func (in *PendingModifiedValues) DeepCopy() *PendingModifiedValues {
+	if in == nil {
+		return nil
+	}
+	out := new(PendingModifiedValues)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PendingRebootCluster) DeepCopyInto(out *PendingRebootCluster) {
+	*out = *in
+	if in.CacheNodeIdsToReboot != nil {
+		in, out := &in.CacheNodeIdsToReboot, &out.CacheNodeIdsToReboot
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PendingRebootCluster.
+func (in *PendingRebootCluster) DeepCopy() *PendingRebootCluster {
+	if in == nil {
+		return nil
+	}
+	out := new(PendingRebootCluster)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProviderConfig) DeepCopyInto(out *ProviderConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProviderConfig.
+func (in *ProviderConfig) DeepCopy() *ProviderConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ProviderConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ProviderConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProviderConfigList) DeepCopyInto(out *ProviderConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ProviderConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProviderConfigList.
+func (in *ProviderConfigList) DeepCopy() *ProviderConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(ProviderConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ProviderConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProviderConfigSpec) DeepCopyInto(out *ProviderConfigSpec) {
+	*out = *in
+	if in.Region != nil {
+		in, out := &in.Region, &out.Region
+		*out = new(string)
+		**out = **in
+	}
+	in.Credentials.DeepCopyInto(&out.Credentials)
+	if in.AssumeRole != nil {
+		in, out := &in.AssumeRole, &out.AssumeRole
+		*out = new(AssumeRoleOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProviderConfigSpec.
+func (in *ProviderConfigSpec) DeepCopy() *ProviderConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ProviderConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProviderCredentials) DeepCopyInto(out *ProviderCredentials) {
+	*out = *in
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(SecretReference)
+		**out = **in
+	}
+	if in.WebIdentity != nil {
+		in, out := &in.WebIdentity, &out.WebIdentity
+		*out = new(WebIdentityCredentials)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProviderCredentials.
+func (in *ProviderCredentials) DeepCopy() *ProviderCredentials {
+	if in == nil {
+		return nil
+	}
+	out := new(ProviderCredentials)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicationGroup) DeepCopyInto(out *ReplicationGroup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationGroup.
+func (in *ReplicationGroup) DeepCopy() *ReplicationGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicationGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ReplicationGroup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicationGroupAwsConfig) DeepCopyInto(out *ReplicationGroupAwsConfig) {
+	*out = *in
+	if in.ReplicationGroupDescription != nil {
+		in, out := &in.ReplicationGroupDescription, &out.ReplicationGroupDescription
+		*out = new(string)
+		**out = **in
+	}
+	if in.AtRestEncryptionEnabled != nil {
+		in, out := &in.AtRestEncryptionEnabled, &out.AtRestEncryptionEnabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.AuthTokenSecretRef != nil {
+		in, out := &in.AuthTokenSecretRef, &out.AuthTokenSecretRef
+		*out = new(SecretKeySelector)
+		**out = **in
+	}
+	if in.AutoMinorVersionUpgrade != nil {
+		in, out := &in.AutoMinorVersionUpgrade, &out.AutoMinorVersionUpgrade
+		*out = new(bool)
+		**out = **in
+	}
+	if in.AutomaticFailoverEnabled != nil {
+		in, out := &in.AutomaticFailoverEnabled, &out.AutomaticFailoverEnabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.CacheNodeType != nil {
+		in, out := &in.CacheNodeType, &out.CacheNodeType
+		*out = new(string)
+		**out = **in
+	}
+	if in.CacheParameterGroupName != nil {
+		in, out := &in.CacheParameterGroupName, &out.CacheParameterGroupName
+		*out = new(string)
+		**out = **in
+	}
+	if in.CacheSecurityGroupNames != nil {
+		in, out := &in.CacheSecurityGroupNames, &out.CacheSecurityGroupNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CacheSubnetGroupName != nil {
+		in, out := &in.CacheSubnetGroupName, &out.CacheSubnetGroupName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Engine != nil {
+		in, out := &in.Engine, &out.Engine
+		*out = new(string)
+		**out = **in
+	}
+	if in.EngineVersion != nil {
+		in, out := &in.EngineVersion, &out.EngineVersion
+		*out = new(string)
+		**out = **in
+	}
+	if in.KmsKeyId != nil {
+		in, out := &in.KmsKeyId, &out.KmsKeyId
+		*out = new(string)
+		**out = **in
+	}
+	if in.MultiAZEnabled != nil {
+		in, out := &in.MultiAZEnabled, &out.MultiAZEnabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.NotificationTopicArn != nil {
+		in, out := &in.NotificationTopicArn, &out.NotificationTopicArn
+		*out = new(string)
+		**out = **in
+	}
+	if in.NumCacheClusters != nil {
+		in, out := &in.NumCacheClusters, &out.NumCacheClusters
+		*out = new(int32)
+		**out = **in
+	}
+	if in.NumNodeGroups != nil {
+		in, out := &in.NumNodeGroups, &out.NumNodeGroups
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Port != nil {
+		in, out := &in.Port, &out.Port
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PreferredCacheClusterAZs != nil {
+		in, out := &in.PreferredCacheClusterAZs, &out.PreferredCacheClusterAZs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PreferredMaintenanceWindow != nil {
+		in, out := &in.PreferredMaintenanceWindow, &out.PreferredMaintenanceWindow
+		*out = new(string)
+		**out = **in
+	}
+	if in.ReplicasPerNodeGroup != nil {
+		in, out := &in.ReplicasPerNodeGroup, &out.ReplicasPerNodeGroup
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SecurityGroupIds != nil {
+		in, out := &in.SecurityGroupIds, &out.SecurityGroupIds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SnapshotArns != nil {
+		in, out := &in.SnapshotArns, &out.SnapshotArns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SnapshotName != nil {
+		in, out := &in.SnapshotName, &out.SnapshotName
+		*out = new(string)
+		**out = **in
+	}
+	if in.SnapshotRetentionLimit != nil {
+		in, out := &in.SnapshotRetentionLimit, &out.SnapshotRetentionLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SnapshotWindow != nil {
+		in, out := &in.SnapshotWindow, &out.SnapshotWindow
+		*out = new(string)
+		**out = **in
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]Tag, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TransitEncryptionEnabled != nil {
+		in, out := &in.TransitEncryptionEnabled, &out.TransitEncryptionEnabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.UserGroupRefs != nil {
+		in, out := &in.UserGroupRefs, &out.UserGroupRefs
+		*out = make([]LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationGroupAwsConfig.
+func (in *ReplicationGroupAwsConfig) DeepCopy() *ReplicationGroupAwsConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicationGroupAwsConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicationGroupList) DeepCopyInto(out *ReplicationGroupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ReplicationGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationGroupList.
+func (in *ReplicationGroupList) DeepCopy() *ReplicationGroupList {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicationGroupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ReplicationGroupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicationGroupPendingModifiedValues) DeepCopyInto(out *ReplicationGroupPendingModifiedValues) {
+	*out = *in
+	if in.PrimaryClusterId != nil {
+		in, out := &in.PrimaryClusterId, &out.PrimaryClusterId
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationGroupPendingModifiedValues.
+func (in *ReplicationGroupPendingModifiedValues) DeepCopy() *ReplicationGroupPendingModifiedValues {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicationGroupPendingModifiedValues)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicationGroupSpec) DeepCopyInto(out *ReplicationGroupSpec) {
+	*out = *in
+	if in.AWSConfig != nil {
+		in, out := &in.AWSConfig, &out.AWSConfig
+		*out = new(ReplicationGroupAwsConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WriteConnectionDetailsTo != nil {
+		in, out := &in.WriteConnectionDetailsTo, &out.WriteConnectionDetailsTo
+		*out = new(ConnectionDetailsTarget)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExternalName != nil {
+		in, out := &in.ExternalName, &out.ExternalName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationGroupSpec.
+func (in *ReplicationGroupSpec) DeepCopy() *ReplicationGroupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicationGroupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicationGroupStatus) DeepCopyInto(out *ReplicationGroupStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ReplicationGroupId != nil {
+		in, out := &in.ReplicationGroupId, &out.ReplicationGroupId
+		*out = new(string)
+		**out = **in
+	}
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(string)
+		**out = **in
+	}
+	if in.ARN != nil {
+		in, out := &in.ARN, &out.ARN
+		*out = new(string)
+		**out = **in
+	}
+	if in.CacheNodeType != nil {
+		in, out := &in.CacheNodeType, &out.CacheNodeType
+		*out = new(string)
+		**out = **in
+	}
+	if in.ClusterEnabled != nil {
+		in, out := &in.ClusterEnabled, &out.ClusterEnabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.AtRestEncryptionEnabled != nil {
+		in, out := &in.AtRestEncryptionEnabled, &out.AtRestEncryptionEnabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.TransitEncryptionEnabled != nil {
+		in, out := &in.TransitEncryptionEnabled, &out.TransitEncryptionEnabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.AuthTokenEnabled != nil {
+		in, out := &in.AuthTokenEnabled, &out.AuthTokenEnabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.UserGroupIds != nil {
+		in, out := &in.UserGroupIds, &out.UserGroupIds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ConfigurationEndpoint != nil {
+		in, out := &in.ConfigurationEndpoint, &out.ConfigurationEndpoint
+		*out = new(Endpoint)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MemberClusters != nil {
+		in, out := &in.MemberClusters, &out.MemberClusters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodeGroups != nil {
+		in, out := &in.NodeGroups, &out.NodeGroups
+		*out = make([]NodeGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PendingModifiedValues != nil {
+		in, out := &in.PendingModifiedValues, &out.PendingModifiedValues
+		*out = new(ReplicationGroupPendingModifiedValues)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DriftedFields != nil {
+		in, out := &in.DriftedFields, &out.DriftedFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationGroupStatus.
+func (in *ReplicationGroupStatus) DeepCopy() *ReplicationGroupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicationGroupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretKeySelector) DeepCopyInto(out *SecretKeySelector) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretKeySelector.
+func (in *SecretKeySelector) DeepCopy() *SecretKeySelector {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretKeySelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretReference) DeepCopyInto(out *SecretReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretReference.
+func (in *SecretReference) DeepCopy() *SecretReference {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotExport) DeepCopyInto(out *SnapshotExport) {
+	*out = *in
+	if in.TargetSnapshotName != nil {
+		in, out := &in.TargetSnapshotName, &out.TargetSnapshotName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotExport.
+func (in *SnapshotExport) DeepCopy() *SnapshotExport {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotExport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotExportStatus) DeepCopyInto(out *SnapshotExportStatus) {
+	*out = *in
+	in.ExportTime.DeepCopyInto(&out.ExportTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotExportStatus.
+func (in *SnapshotExportStatus) DeepCopy() *SnapshotExportStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotExportStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotScheduleRule) DeepCopyInto(out *SnapshotScheduleRule) {
+	*out = *in
+	if in.KeepLast != nil {
+		in, out := &in.KeepLast, &out.KeepLast
+		*out = new(int32)
+		**out = **in
+	}
+	if in.KeepWithin != nil {
+		in, out := &in.KeepWithin, &out.KeepWithin
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotScheduleRule.
+func (in *SnapshotScheduleRule) DeepCopy() *SnapshotScheduleRule {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotScheduleRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotScheduleRuleStatus) DeepCopyInto(out *SnapshotScheduleRuleStatus) {
+	*out = *in
+	if in.LastScheduleTime != nil {
+		in, out := &in.LastScheduleTime, &out.LastScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextScheduleTime != nil {
+		in, out := &in.NextScheduleTime, &out.NextScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Snapshots != nil {
+		in, out := &in.Snapshots, &out.Snapshots
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotScheduleRuleStatus.
+func (in *SnapshotScheduleRuleStatus) DeepCopy() *SnapshotScheduleRuleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotScheduleRuleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Subnet) DeepCopyInto(out *Subnet) {
+	*out = *in
+	if in.SubnetIdentifier != nil {
+		in, out := &in.SubnetIdentifier, &out.SubnetIdentifier
+		*out = new(string)
+		**out = **in
+	}
+	if in.SubnetAvailabilityZone != nil {
+		in, out := &in.SubnetAvailabilityZone, &out.SubnetAvailabilityZone
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Subnet.
+func (in *Subnet) DeepCopy() *Subnet {
+	if in == nil {
+		return nil
+	}
+	out := new(Subnet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Tag) DeepCopyInto(out *Tag) {
+	*out = *in
+	if in.Key != nil {
+		in, out := &in.Key, &out.Key
+		*out = new(string)
+		**out = **in
+	}
+	if in.Value != nil {
+		in, out := &in.Value, &out.Value
+		*out = new(string)
+		**out = **in
+	}
+	out.noSmithyDocumentSerde = in.noSmithyDocumentSerde
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Tag.
+func (in *Tag) DeepCopy() *Tag {
+	if in == nil {
+		return nil
+	}
+	out := new(Tag)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *User) DeepCopyInto(out *User) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new User.
+func (in *User) DeepCopy() *User {
 	if in == nil {
 		return nil
 	}
-	out := new(ElasticCache)
+	out := new(User)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ElasticCache) DeepCopyObject() runtime.Object {
+func (in *User) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -53,158 +2065,203 @@ func (in *ElasticCache) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElasticCacheAwsConfig) DeepCopyInto(out *ElasticCacheAwsConfig) {
+func (in *UserGroup) DeepCopyInto(out *UserGroup) {
 	*out = *in
-	if in.AuthToken != nil {
-		in, out := &in.AuthToken, &out.AuthToken
-		*out = new(string)
-		**out = **in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserGroup.
+func (in *UserGroup) DeepCopy() *UserGroup {
+	if in == nil {
+		return nil
 	}
-	if in.CacheNodeType != nil {
-		in, out := &in.CacheNodeType, &out.CacheNodeType
-		*out = new(string)
-		**out = **in
+	out := new(UserGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *UserGroup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
 	}
-	if in.CacheParameterGroupName != nil {
-		in, out := &in.CacheParameterGroupName, &out.CacheParameterGroupName
-		*out = new(string)
-		**out = **in
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UserGroupList) DeepCopyInto(out *UserGroupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]UserGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
-	if in.CacheSecurityGroupNames != nil {
-		in, out := &in.CacheSecurityGroupNames, &out.CacheSecurityGroupNames
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserGroupList.
+func (in *UserGroupList) DeepCopy() *UserGroupList {
+	if in == nil {
+		return nil
+	}
+	out := new(UserGroupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *UserGroupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UserGroupPendingChanges) DeepCopyInto(out *UserGroupPendingChanges) {
+	*out = *in
+	if in.UserIdsToAdd != nil {
+		in, out := &in.UserIdsToAdd, &out.UserIdsToAdd
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
-	if in.CacheSubnetGroupName != nil {
-		in, out := &in.CacheSubnetGroupName, &out.CacheSubnetGroupName
-		*out = new(string)
-		**out = **in
-	}
-	if in.Engine != nil {
-		in, out := &in.Engine, &out.Engine
-		*out = new(string)
-		**out = **in
+	if in.UserIdsToRemove != nil {
+		in, out := &in.UserIdsToRemove, &out.UserIdsToRemove
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
-	if in.EngineVersion != nil {
-		in, out := &in.EngineVersion, &out.EngineVersion
-		*out = new(string)
-		**out = **in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserGroupPendingChanges.
+func (in *UserGroupPendingChanges) DeepCopy() *UserGroupPendingChanges {
+	if in == nil {
+		return nil
 	}
-	if in.NotificationTopicArn != nil {
-		in, out := &in.NotificationTopicArn, &out.NotificationTopicArn
-		*out = new(string)
-		**out = **in
+	out := new(UserGroupPendingChanges)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UserGroupSpec) DeepCopyInto(out *UserGroupSpec) {
+	*out = *in
+	if in.UserRefs != nil {
+		in, out := &in.UserRefs, &out.UserRefs
+		*out = make([]LocalObjectReference, len(*in))
+		copy(*out, *in)
 	}
-	if in.NumCacheNodes != nil {
-		in, out := &in.NumCacheNodes, &out.NumCacheNodes
-		*out = new(int32)
-		**out = **in
+	if in.UserIds != nil {
+		in, out := &in.UserIds, &out.UserIds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
-	if in.Port != nil {
-		in, out := &in.Port, &out.Port
-		*out = new(int32)
-		**out = **in
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]Tag, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
-	if in.PreferredAvailabilityZone != nil {
-		in, out := &in.PreferredAvailabilityZone, &out.PreferredAvailabilityZone
-		*out = new(string)
-		**out = **in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserGroupSpec.
+func (in *UserGroupSpec) DeepCopy() *UserGroupSpec {
+	if in == nil {
+		return nil
 	}
-	if in.PreferredAvailabilityZones != nil {
-		in, out := &in.PreferredAvailabilityZones, &out.PreferredAvailabilityZones
-		*out = make([]string, len(*in))
-		copy(*out, *in)
+	out := new(UserGroupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UserGroupStatus) DeepCopyInto(out *UserGroupStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
-	if in.PreferredMaintenanceWindow != nil {
-		in, out := &in.PreferredMaintenanceWindow, &out.PreferredMaintenanceWindow
+	if in.UserGroupId != nil {
+		in, out := &in.UserGroupId, &out.UserGroupId
 		*out = new(string)
 		**out = **in
 	}
-	if in.PreferredOutpostArn != nil {
-		in, out := &in.PreferredOutpostArn, &out.PreferredOutpostArn
+	if in.ARN != nil {
+		in, out := &in.ARN, &out.ARN
 		*out = new(string)
 		**out = **in
 	}
-	if in.PreferredOutpostArns != nil {
-		in, out := &in.PreferredOutpostArns, &out.PreferredOutpostArns
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
-	if in.ReplicationGroupId != nil {
-		in, out := &in.ReplicationGroupId, &out.ReplicationGroupId
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
 		*out = new(string)
 		**out = **in
 	}
-	if in.SecurityGroupIds != nil {
-		in, out := &in.SecurityGroupIds, &out.SecurityGroupIds
+	if in.UserIds != nil {
+		in, out := &in.UserIds, &out.UserIds
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
-	if in.SnapshotArns != nil {
-		in, out := &in.SnapshotArns, &out.SnapshotArns
+	if in.PendingChanges != nil {
+		in, out := &in.PendingChanges, &out.PendingChanges
+		*out = new(UserGroupPendingChanges)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReplicationGroups != nil {
+		in, out := &in.ReplicationGroups, &out.ReplicationGroups
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
-	if in.SnapshotName != nil {
-		in, out := &in.SnapshotName, &out.SnapshotName
-		*out = new(string)
-		**out = **in
-	}
-	if in.SnapshotRetentionLimit != nil {
-		in, out := &in.SnapshotRetentionLimit, &out.SnapshotRetentionLimit
-		*out = new(int32)
-		**out = **in
-	}
-	if in.SnapshotWindow != nil {
-		in, out := &in.SnapshotWindow, &out.SnapshotWindow
-		*out = new(string)
-		**out = **in
-	}
-	if in.Tags != nil {
-		in, out := &in.Tags, &out.Tags
-		*out = make([]Tag, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticCacheAwsConfig.
-func (in *ElasticCacheAwsConfig) DeepCopy() *ElasticCacheAwsConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserGroupStatus.
+func (in *UserGroupStatus) DeepCopy() *UserGroupStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ElasticCacheAwsConfig)
+	out := new(UserGroupStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElasticCacheList) DeepCopyInto(out *ElasticCacheList) {
+func (in *UserList) DeepCopyInto(out *UserList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]ElasticCache, len(*in))
+		*out = make([]User, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticCacheList.
-func (in *ElasticCacheList) DeepCopy() *ElasticCacheList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserList.
+func (in *UserList) DeepCopy() *UserList {
 	if in == nil {
 		return nil
 	}
-	out := new(ElasticCacheList)
+	out := new(UserList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ElasticCacheList) DeepCopyObject() runtime.Object {
+func (in *UserList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -212,67 +2269,104 @@ func (in *ElasticCacheList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElasticCacheSpec) DeepCopyInto(out *ElasticCacheSpec) {
+func (in *UserSpec) DeepCopyInto(out *UserSpec) {
 	*out = *in
-	if in.AWSConfig != nil {
-		in, out := &in.AWSConfig, &out.AWSConfig
-		*out = new(ElasticCacheAwsConfig)
-		(*in).DeepCopyInto(*out)
+	if in.PasswordSecretRefs != nil {
+		in, out := &in.PasswordSecretRefs, &out.PasswordSecretRefs
+		*out = make([]SecretKeySelector, len(*in))
+		copy(*out, *in)
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]Tag, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticCacheSpec.
-func (in *ElasticCacheSpec) DeepCopy() *ElasticCacheSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserSpec.
+func (in *UserSpec) DeepCopy() *UserSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(ElasticCacheSpec)
+	out := new(UserSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElasticCacheStatus) DeepCopyInto(out *ElasticCacheStatus) {
+func (in *UserStatus) DeepCopyInto(out *UserStatus) {
 	*out = *in
-	if in.CacheClusterStatus != nil {
-		in, out := &in.CacheClusterStatus, &out.CacheClusterStatus
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.UserId != nil {
+		in, out := &in.UserId, &out.UserId
+		*out = new(string)
+		**out = **in
+	}
+	if in.ARN != nil {
+		in, out := &in.ARN, &out.ARN
+		*out = new(string)
+		**out = **in
+	}
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(string)
+		**out = **in
+	}
+	if in.AccessString != nil {
+		in, out := &in.AccessString, &out.AccessString
 		*out = new(string)
 		**out = **in
 	}
+	if in.PasswordCount != nil {
+		in, out := &in.PasswordCount, &out.PasswordCount
+		*out = new(int32)
+		**out = **in
+	}
+	if in.UserGroupIds != nil {
+		in, out := &in.UserGroupIds, &out.UserGroupIds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticCacheStatus.
-func (in *ElasticCacheStatus) DeepCopy() *ElasticCacheStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserStatus.
+func (in *UserStatus) DeepCopy() *UserStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ElasticCacheStatus)
+	out := new(UserStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Tag) DeepCopyInto(out *Tag) {
+func (in *WebIdentityCredentials) DeepCopyInto(out *WebIdentityCredentials) {
 	*out = *in
-	if in.Key != nil {
-		in, out := &in.Key, &out.Key
-		*out = new(string)
-		**out = **in
-	}
-	if in.Value != nil {
-		in, out := &in.Value, &out.Value
+	if in.RoleSessionName != nil {
+		in, out := &in.RoleSessionName, &out.RoleSessionName
 		*out = new(string)
 		**out = **in
 	}
-	out.noSmithyDocumentSerde = in.noSmithyDocumentSerde
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Tag.
-func (in *Tag) DeepCopy() *Tag {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebIdentityCredentials.
+func (in *WebIdentityCredentials) DeepCopy() *WebIdentityCredentials {
 	if in == nil {
 		return nil
 	}
-	out := new(Tag)
+	out := new(WebIdentityCredentials)
 	in.DeepCopyInto(out)
 	return out
 }
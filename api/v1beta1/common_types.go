@@ -0,0 +1,48 @@
+package v1beta1
+
+// LocalObjectReference references another object of this API group by name.
+// Namespaced objects are looked up in the namespace of the referencing
+// resource.
+type LocalObjectReference struct {
+
+	// The name of the referenced object.
+	Name string `json:"name"`
+}
+
+// SecretKeySelector selects a key of a Secret in the namespace of the resource
+// that references it.
+type SecretKeySelector struct {
+
+	// The name of the Secret.
+	Name string `json:"name"`
+
+	// The key of the Secret to select from.
+	Key string `json:"key"`
+}
+
+// Tag is a key and value pair attached to an AWS resource to categorize and
+// track it.
+type Tag struct {
+
+	// The key of the tag.
+	Key string `json:"key"`
+
+	// The value of the tag. May be omitted.
+	Value *string `json:"value,omitempty"`
+}
+
+// DeletionPolicy specifies what happens to the AWS resource when the Kubernetes
+// object managing it is deleted.
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes the AWS resource together with the object.
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+
+	// DeletionPolicyRetain keeps the AWS resource when the object is deleted.
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+
+	// DeletionPolicySnapshot deletes the AWS resource after taking a final
+	// snapshot of it.
+	DeletionPolicySnapshot DeletionPolicy = "Snapshot"
+)
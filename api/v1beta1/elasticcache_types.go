@@ -0,0 +1,494 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CacheEngine is the cache engine a cluster runs.
+// +kubebuilder:validation:Enum=redis;memcached
+type CacheEngine string
+
+const (
+	CacheEngineRedis     CacheEngine = "redis"
+	CacheEngineMemcached CacheEngine = "memcached"
+)
+
+// AZMode specifies whether the nodes of a Memcached cluster are placed in one or
+// across several Availability Zones.
+// +kubebuilder:validation:Enum=SingleAZ;CrossAZ
+type AZMode string
+
+const (
+	AZModeSingleAZ AZMode = "SingleAZ"
+	AZModeCrossAZ  AZMode = "CrossAZ"
+)
+
+// OutpostMode specifies whether the nodes of a cluster are placed in one or
+// across several outposts.
+// +kubebuilder:validation:Enum=SingleOutpost;CrossOutpost
+type OutpostMode string
+
+const (
+	OutpostModeSingleOutpost OutpostMode = "SingleOutpost"
+	OutpostModeCrossOutpost  OutpostMode = "CrossOutpost"
+)
+
+// AuthTokenUpdateStrategy specifies how the AUTH token of a cluster is updated
+// when the referenced Secret changes.
+// +kubebuilder:validation:Enum=Rotate;Set;Delete
+type AuthTokenUpdateStrategy string
+
+const (
+	// AuthTokenUpdateStrategyRotate adds the new token while the old one stays
+	// valid until the next update.
+	AuthTokenUpdateStrategyRotate AuthTokenUpdateStrategy = "Rotate"
+
+	// AuthTokenUpdateStrategySet replaces the tokens of the cluster with the new
+	// one.
+	AuthTokenUpdateStrategySet AuthTokenUpdateStrategy = "Set"
+
+	// AuthTokenUpdateStrategyDelete removes the AUTH token from the cluster.
+	AuthTokenUpdateStrategyDelete AuthTokenUpdateStrategy = "Delete"
+)
+
+// AuthTokenStatus is the state of an AUTH token update that is in progress.
+type AuthTokenStatus string
+
+const (
+	AuthTokenStatusSetting  AuthTokenStatus = "Setting"
+	AuthTokenStatusRotating AuthTokenStatus = "Rotating"
+)
+
+// ClusterNamingStrategy specifies how the identifier of a cache cluster is
+// derived from the ElasticCache.
+// +kubebuilder:validation:Enum=Name;NamespacedHash
+type ClusterNamingStrategy string
+
+const (
+	// ClusterNamingStrategyName uses the name of the ElasticCache.
+	ClusterNamingStrategyName ClusterNamingStrategy = "Name"
+
+	// ClusterNamingStrategyNamespacedHash uses the namespace and the name of the
+	// ElasticCache followed by a hash of both.
+	ClusterNamingStrategyNamespacedHash ClusterNamingStrategy = "NamespacedHash"
+)
+
+// CacheClusterParameters are the settings of the cache cluster in AWS.
+type CacheClusterParameters struct {
+
+	// The cache engine of the cluster. Required unless an existing cluster is
+	// imported.
+	Engine *CacheEngine `json:"engine,omitempty"`
+
+	// The version of the cache engine, e.g. 6.x. Engines can be upgraded but not
+	// downgraded. Required unless an existing cluster is imported.
+	EngineVersion *string `json:"engineVersion,omitempty"`
+
+	// The compute and memory capacity of the nodes, e.g. cache.t3.micro.
+	// Required unless an existing cluster is imported.
+	CacheNodeType *string `json:"cacheNodeType,omitempty"`
+
+	// The number of cache nodes. Must be 1 for redis and between 1 and 40 for
+	// memcached. Required unless an existing cluster is imported.
+	NumCacheNodes *int32 `json:"numCacheNodes,omitempty"`
+
+	// The port the cache nodes accept connections on.
+	Port *int32 `json:"port,omitempty"`
+
+	// Whether the nodes are placed in a single Availability Zone or across
+	// several ones. Only supported for memcached, defaults to SingleAZ.
+	AZMode AZMode `json:"azMode,omitempty"`
+
+	// The Availability Zone all nodes are placed in.
+	PreferredAvailabilityZone *string `json:"preferredAvailabilityZone,omitempty"`
+
+	// The Availability Zones the nodes are placed in, one per node. Only
+	// supported for memcached.
+	PreferredAvailabilityZones []string `json:"preferredAvailabilityZones,omitempty"`
+
+	// Whether the nodes are placed in a single outpost or across several ones.
+	OutpostMode OutpostMode `json:"outpostMode,omitempty"`
+
+	// The ARN of the outpost the cluster is created in.
+	PreferredOutpostArn *string `json:"preferredOutpostArn,omitempty"`
+
+	// The ARNs of the outposts the cluster is created in.
+	PreferredOutpostArns []string `json:"preferredOutpostArns,omitempty"`
+
+	// Reference to the key of a Secret in the namespace of the ElasticCache
+	// holding the AUTH token of the cluster. The token is updated when the value
+	// changes. Only supported for redis.
+	AuthTokenSecretRef *SecretKeySelector `json:"authTokenSecretRef,omitempty"`
+
+	// How the AUTH token is updated when the referenced Secret changes. Defaults
+	// to Rotate.
+	AuthTokenUpdateStrategy AuthTokenUpdateStrategy `json:"authTokenUpdateStrategy,omitempty"`
+
+	// The name of the parameter group of the cluster. The default parameter group
+	// of the engine is used when omitted.
+	CacheParameterGroupName *string `json:"cacheParameterGroupName,omitempty"`
+
+	// The name of the subnet group the cluster is created in.
+	CacheSubnetGroupName *string `json:"cacheSubnetGroupName,omitempty"`
+
+	// A reference to a CacheSubnetGroup in the namespace of the ElasticCache.
+	// The cluster is only created once the subnet group is Ready, and its AWS
+	// name is used instead of CacheSubnetGroupName.
+	CacheSubnetGroupRef *LocalObjectReference `json:"cacheSubnetGroupRef,omitempty"`
+
+	// The names of the cache security groups of a cluster outside of a VPC.
+	CacheSecurityGroupNames []string `json:"cacheSecurityGroupNames,omitempty"`
+
+	// The IDs of the VPC security groups of the cluster.
+	SecurityGroupIds []string `json:"securityGroupIds,omitempty"`
+
+	// The ARN of the SNS topic notifications of the cluster are sent to.
+	NotificationTopicArn *string `json:"notificationTopicArn,omitempty"`
+
+	// The ID of the replication group the cluster joins as a read replica. Only
+	// supported for redis.
+	ReplicationGroupId *string `json:"replicationGroupId,omitempty"`
+
+	// The ARN of a Redis RDB snapshot file in S3 the cluster is seeded from.
+	SnapshotArns []string `json:"snapshotArns,omitempty"`
+
+	// The name of a snapshot the cluster is restored from. Only supported for
+	// redis.
+	SnapshotName *string `json:"snapshotName,omitempty"`
+
+	// The number of days automatic snapshots are retained. 0 disables automatic
+	// snapshots. Only supported for redis.
+	SnapshotRetentionLimit *int32 `json:"snapshotRetentionLimit,omitempty"`
+
+	// The daily time range in UTC automatic snapshots are taken in, e.g.
+	// 05:00-09:00. Only supported for redis.
+	SnapshotWindow *string `json:"snapshotWindow,omitempty"`
+
+	// The weekly time range in UTC maintenance is performed in, e.g.
+	// sun:23:00-mon:01:30.
+	PreferredMaintenanceWindow *string `json:"preferredMaintenanceWindow,omitempty"`
+
+	// Specifies the destination and format of each log of the cache engine that is
+	// delivered. Logs that are not listed are not delivered. Only supported for
+	// redis.
+	// +listType=map
+	// +listMapKey=logType
+	LogDeliveryConfigurations []LogDeliveryConfiguration `json:"logDeliveryConfigurations,omitempty"`
+
+	// The tags of the cluster. Keys must be unique and may not start with aws: or
+	// aws.sergeyshevch.dev/.
+	Tags []Tag `json:"tags,omitempty"`
+}
+
+// LogType names a log of the cache engine that can be delivered.
+// +kubebuilder:validation:Enum=slow-log;engine-log
+type LogType string
+
+const (
+	// LogTypeSlowLog is the Redis SLOWLOG.
+	LogTypeSlowLog LogType = "slow-log"
+
+	// LogTypeEngineLog is the log of the Redis engine.
+	LogTypeEngineLog LogType = "engine-log"
+)
+
+// LogFormat is the format log entries are delivered in.
+// +kubebuilder:validation:Enum=json;text
+type LogFormat string
+
+const (
+	// LogFormatJSON delivers every log entry as a JSON document.
+	LogFormatJSON LogFormat = "json"
+
+	// LogFormatText delivers log entries as plain text.
+	LogFormatText LogFormat = "text"
+)
+
+// LogDeliveryConfiguration specifies where and how a log of the cache engine is
+// delivered. Exactly one destination must be set.
+type LogDeliveryConfiguration struct {
+
+	// The log that is delivered, slow-log or engine-log.
+	LogType LogType `json:"logType"`
+
+	// The format of the delivered log entries, json or text. Defaults to json.
+	// +kubebuilder:default=json
+	LogFormat LogFormat `json:"logFormat,omitempty"`
+
+	// Delivers the log to a CloudWatch Logs log group.
+	CloudWatchLogs *CloudWatchLogsDestination `json:"cloudWatchLogs,omitempty"`
+
+	// Delivers the log to a Kinesis Data Firehose delivery stream.
+	KinesisFirehose *KinesisFirehoseDestination `json:"kinesisFirehose,omitempty"`
+}
+
+// CloudWatchLogsDestination names the CloudWatch Logs log group a log is
+// delivered to.
+type CloudWatchLogsDestination struct {
+
+	// The name of the log group.
+	LogGroup string `json:"logGroup"`
+}
+
+// KinesisFirehoseDestination names the Kinesis Data Firehose delivery stream a
+// log is delivered to.
+type KinesisFirehoseDestination struct {
+
+	// The name of the delivery stream.
+	DeliveryStream string `json:"deliveryStream"`
+}
+
+// ConnectionDetailsTarget names the Kubernetes objects that receive the
+// connection details of a cache once it becomes available. Both objects are
+// created in the namespace of the owning resource and are garbage collected
+// together with it.
+type ConnectionDetailsTarget struct {
+
+	// The name of the Secret that receives the endpoints, port, engine and auth
+	// token of the cache.
+	SecretName string `json:"secretName"`
+
+	// The name of an optional ConfigMap that receives the same connection details
+	// except the auth token.
+	ConfigMapName *string `json:"configMapName,omitempty"`
+}
+
+// ElasticCacheSpec defines the desired state of ElasticCache
+type ElasticCacheSpec struct {
+
+	// The settings of the cache cluster in AWS.
+	CacheCluster *CacheClusterParameters `json:"cacheCluster"`
+
+	// A reference to the CacheClass whose defaults fill in the unset fields of
+	// CacheCluster when the ElasticCache is created. When omitted the class
+	// selecting the namespace of the ElasticCache is used, if any, and recorded
+	// here.
+	CacheClassRef *LocalObjectReference `json:"cacheClassRef,omitempty"`
+
+	// A reference to a ProviderConfig configuring the region and credentials the
+	// cluster is managed with. The ProviderConfig must select the namespace of
+	// the ElasticCache. When omitted the configuration the operator was started
+	// with is used. Only the cache cluster is managed with the ProviderConfig:
+	// other resources, like a referenced CacheSubnetGroup, are managed with the
+	// configuration the operator was started with and must exist in the same
+	// region and account as the cluster.
+	ProviderConfigRef *LocalObjectReference `json:"providerConfigRef,omitempty"`
+
+	// The identifier of the cache cluster in AWS. When omitted the identifier is
+	// derived from the NamingStrategy. An existing cluster that was not created by
+	// this ElasticCache is only managed after it is imported with the
+	// aws.sergeyshevch.dev/import annotation.
+	ExternalName *string `json:"externalName,omitempty"`
+
+	// How the identifier of the cache cluster is derived when ExternalName is not
+	// set. Name uses the name of the ElasticCache, NamespacedHash combines the
+	// namespace, the name and a hash of both. Defaults to Name.
+	// +kubebuilder:default=Name
+	NamingStrategy ClusterNamingStrategy `json:"namingStrategy,omitempty"`
+
+	// Where to publish connection details of the cluster once it is available.
+	WriteConnectionDetailsTo *ConnectionDetailsTarget `json:"writeConnectionDetailsTo,omitempty"`
+
+	// Specifies what happens to the cache cluster in AWS when the ElasticCache is
+	// deleted. Delete removes the cluster, Retain keeps it and Snapshot removes it
+	// after taking a final snapshot. Defaults to Delete.
+	// +kubebuilder:validation:Enum=Delete;Retain;Snapshot
+	// +kubebuilder:default=Delete
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+}
+
+// Endpoint is the address clients connect to.
+type Endpoint struct {
+
+	// The DNS hostname of the cache node.
+	Address *string `json:"address,omitempty"`
+
+	// The port number that the cache engine is listening on.
+	Port int32 `json:"port,omitempty"`
+}
+
+// CacheNode is a node of a cache cluster.
+type CacheNode struct {
+
+	// The identifier of the node within the cluster, e.g. 0001.
+	CacheNodeId *string `json:"cacheNodeId,omitempty"`
+
+	// The state of the node: available, creating, rebooting or deleting.
+	CacheNodeStatus *string `json:"cacheNodeStatus,omitempty"`
+
+	// The time the node was created.
+	CacheNodeCreateTime *metav1.Time `json:"cacheNodeCreateTime,omitempty"`
+
+	// The Availability Zone the node is placed in.
+	CustomerAvailabilityZone *string `json:"customerAvailabilityZone,omitempty"`
+
+	// The ARN of the outpost the node is placed in.
+	CustomerOutpostArn *string `json:"customerOutpostArn,omitempty"`
+
+	// The address of the node.
+	Endpoint *Endpoint `json:"endpoint,omitempty"`
+
+	// The state of the parameter group on the node.
+	ParameterGroupStatus *string `json:"parameterGroupStatus,omitempty"`
+}
+
+// PendingModifiedValues are changes to a cluster that are not applied yet or
+// are being applied.
+type PendingModifiedValues struct {
+
+	// The state of an AUTH token update, Setting or Rotating.
+	AuthTokenStatus AuthTokenStatus `json:"authTokenStatus,omitempty"`
+
+	// The IDs of the nodes that are removed from the cluster.
+	CacheNodeIdsToRemove []string `json:"cacheNodeIdsToRemove,omitempty"`
+
+	// The node type the cluster is scaled to.
+	CacheNodeType *string `json:"cacheNodeType,omitempty"`
+
+	// The engine version the cluster is upgraded to.
+	EngineVersion *string `json:"engineVersion,omitempty"`
+
+	// The number of nodes the cluster is scaled to.
+	NumCacheNodes *int32 `json:"numCacheNodes,omitempty"`
+}
+
+// LogDeliveryStatus reports the delivery of a log of the cache engine as
+// observed in AWS.
+type LogDeliveryStatus struct {
+
+	// The log that is delivered.
+	LogType LogType `json:"logType"`
+
+	// The format of the delivered log entries.
+	LogFormat LogFormat `json:"logFormat,omitempty"`
+
+	// The type of the destination, cloudwatch-logs or kinesis-firehose.
+	DestinationType string `json:"destinationType,omitempty"`
+
+	// The log group or delivery stream the log is delivered to.
+	Destination string `json:"destination,omitempty"`
+
+	// The state of the delivery, one of active, enabling, modifying, disabling or
+	// error.
+	Status string `json:"status,omitempty"`
+
+	// The reason of an error reported by AWS.
+	Message *string `json:"message,omitempty"`
+}
+
+// ElasticCacheStatus defines the observed state of ElasticCache
+type ElasticCacheStatus struct {
+
+	// Conditions represent the latest available observations of the cluster state.
+	// Known condition types are Ready, Synced, Deleting and Error.
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// The generation of the ElasticCache most recently observed by the controller.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// The identifier of the cache cluster in AWS managed by this ElasticCache.
+	CacheClusterId *string `json:"cacheClusterId,omitempty"`
+
+	// The state of the cluster as reported by AWS, e.g. available, creating or
+	// modifying.
+	CacheClusterStatus *string `json:"cacheClusterStatus,omitempty"`
+
+	// The ARN of the cache cluster.
+	ARN *string `json:"arn,omitempty"`
+
+	// The cache engine of the cluster.
+	Engine *string `json:"engine,omitempty"`
+
+	// The version of the cache engine the cluster runs.
+	EngineVersion *string `json:"engineVersion,omitempty"`
+
+	// The node type of the cluster.
+	CacheNodeType *string `json:"cacheNodeType,omitempty"`
+
+	// The number of nodes of the cluster.
+	NumCacheNodes *int32 `json:"numCacheNodes,omitempty"`
+
+	// The Availability Zone of the cluster, or Multiple if its nodes are placed
+	// in several ones.
+	PreferredAvailabilityZone *string `json:"preferredAvailabilityZone,omitempty"`
+
+	// The endpoint of a Memcached cluster clients use to discover its nodes.
+	ConfigurationEndpoint *Endpoint `json:"configurationEndpoint,omitempty"`
+
+	// The nodes of the cluster.
+	CacheNodes []CacheNode `json:"cacheNodes,omitempty"`
+
+	// Changes to the cluster that are not applied yet or are being applied.
+	PendingModifiedValues *PendingModifiedValues `json:"pendingModifiedValues,omitempty"`
+
+	// The delivery of the logs of the cache engine, one entry per delivered log.
+	LogDeliveryConfigurations []LogDeliveryStatus `json:"logDeliveryConfigurations,omitempty"`
+
+	// A salted hash of the auth token that was last applied to the cluster. It is
+	// used to detect rotations of the referenced Secret without storing the token.
+	AuthTokenHash string `json:"authTokenHash,omitempty"`
+
+	// The modifiable fields of the spec that differed from the cache cluster when
+	// it was last observed. They are modified as soon as the cluster is available.
+	DriftedFields []string `json:"driftedFields,omitempty"`
+
+	// The name of the final snapshot requested when the cluster was deleted with
+	// the Snapshot deletion policy.
+	FinalSnapshotIdentifier *string `json:"finalSnapshotIdentifier,omitempty"`
+
+	// The last time the controller successfully synchronized this status with AWS.
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:storageversion
+//+kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.cacheClusterStatus`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Synced",type=string,JSONPath=`.status.conditions[?(@.type=="Synced")].status`
+//+kubebuilder:printcolumn:name="Engine",type=string,JSONPath=`.status.engine`
+//+kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.status.engineVersion`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ElasticCache is the Schema for the elasticcaches API
+type ElasticCache struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ElasticCacheSpec   `json:"spec,omitempty"`
+	Status ElasticCacheStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ElasticCacheList contains a list of ElasticCache
+type ElasticCacheList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ElasticCache `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ElasticCache{}, &ElasticCacheList{})
+}
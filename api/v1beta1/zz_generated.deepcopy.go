@@ -0,0 +1,614 @@
+//go:build !ignore_autogenerated
+// +build !ignore_autogenerated
+
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by controller-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CacheClusterParameters) DeepCopyInto(out *CacheClusterParameters) {
+	*out = *in
+	if in.Engine != nil {
+		in, out := &in.Engine, &out.Engine
+		*out = new(CacheEngine)
+		**out = **in
+	}
+	if in.EngineVersion != nil {
+		in, out := &in.EngineVersion, &out.EngineVersion
+		*out = new(string)
+		**out = **in
+	}
+	if in.CacheNodeType != nil {
+		in, out := &in.CacheNodeType, &out.CacheNodeType
+		*out = new(string)
+		**out = **in
+	}
+	if in.NumCacheNodes != nil {
+		in, out := &in.NumCacheNodes, &out.NumCacheNodes
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Port != nil {
+		in, out := &in.Port, &out.Port
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PreferredAvailabilityZone != nil {
+		in, out := &in.PreferredAvailabilityZone, &out.PreferredAvailabilityZone
+		*out = new(string)
+		**out = **in
+	}
+	if in.PreferredAvailabilityZones != nil {
+		in, out := &in.PreferredAvailabilityZones, &out.PreferredAvailabilityZones
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PreferredOutpostArn != nil {
+		in, out := &in.PreferredOutpostArn, &out.PreferredOutpostArn
+		*out = new(string)
+		**out = **in
+	}
+	if in.PreferredOutpostArns != nil {
+		in, out := &in.PreferredOutpostArns, &out.PreferredOutpostArns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AuthTokenSecretRef != nil {
+		in, out := &in.AuthTokenSecretRef, &out.AuthTokenSecretRef
+		*out = new(SecretKeySelector)
+		**out = **in
+	}
+	if in.CacheParameterGroupName != nil {
+		in, out := &in.CacheParameterGroupName, &out.CacheParameterGroupName
+		*out = new(string)
+		**out = **in
+	}
+	if in.CacheSubnetGroupName != nil {
+		in, out := &in.CacheSubnetGroupName, &out.CacheSubnetGroupName
+		*out = new(string)
+		**out = **in
+	}
+	if in.CacheSubnetGroupRef != nil {
+		in, out := &in.CacheSubnetGroupRef, &out.CacheSubnetGroupRef
+		*out = new(LocalObjectReference)
+		**out = **in
+	}
+	if in.CacheSecurityGroupNames != nil {
+		in, out := &in.CacheSecurityGroupNames, &out.CacheSecurityGroupNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SecurityGroupIds != nil {
+		in, out := &in.SecurityGroupIds, &out.SecurityGroupIds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NotificationTopicArn != nil {
+		in, out := &in.NotificationTopicArn, &out.NotificationTopicArn
+		*out = new(string)
+		**out = **in
+	}
+	if in.ReplicationGroupId != nil {
+		in, out := &in.ReplicationGroupId, &out.ReplicationGroupId
+		*out = new(string)
+		**out = **in
+	}
+	if in.SnapshotArns != nil {
+		in, out := &in.SnapshotArns, &out.SnapshotArns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SnapshotName != nil {
+		in, out := &in.SnapshotName, &out.SnapshotName
+		*out = new(string)
+		**out = **in
+	}
+	if in.SnapshotRetentionLimit != nil {
+		in, out := &in.SnapshotRetentionLimit, &out.SnapshotRetentionLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SnapshotWindow != nil {
+		in, out := &in.SnapshotWindow, &out.SnapshotWindow
+		*out = new(string)
+		**out = **in
+	}
+	if in.PreferredMaintenanceWindow != nil {
+		in, out := &in.PreferredMaintenanceWindow, &out.PreferredMaintenanceWindow
+		*out = new(string)
+		**out = **in
+	}
+	if in.LogDeliveryConfigurations != nil {
+		in, out := &in.LogDeliveryConfigurations, &out.LogDeliveryConfigurations
+		*out = make([]LogDeliveryConfiguration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]Tag, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CacheClusterParameters.
+func (in *CacheClusterParameters) DeepCopy() *CacheClusterParameters {
+	if in == nil {
+		return nil
+	}
+	out := new(CacheClusterParameters)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CacheNode) DeepCopyInto(out *CacheNode) {
+	*out = *in
+	if in.CacheNodeId != nil {
+		in, out := &in.CacheNodeId, &out.CacheNodeId
+		*out = new(string)
+		**out = **in
+	}
+	if in.CacheNodeStatus != nil {
+		in, out := &in.CacheNodeStatus, &out.CacheNodeStatus
+		*out = new(string)
+		**out = **in
+	}
+	if in.CacheNodeCreateTime != nil {
+		in, out := &in.CacheNodeCreateTime, &out.CacheNodeCreateTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CustomerAvailabilityZone != nil {
+		in, out := &in.CustomerAvailabilityZone, &out.CustomerAvailabilityZone
+		*out = new(string)
+		**out = **in
+	}
+	if in.CustomerOutpostArn != nil {
+		in, out := &in.CustomerOutpostArn, &out.CustomerOutpostArn
+		*out = new(string)
+		**out = **in
+	}
+	if in.Endpoint != nil {
+		in, out := &in.Endpoint, &out.Endpoint
+		*out = new(Endpoint)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ParameterGroupStatus != nil {
+		in, out := &in.ParameterGroupStatus, &out.ParameterGroupStatus
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CacheNode.
+func (in *CacheNode) DeepCopy() *CacheNode {
+	if in == nil {
+		return nil
+	}
+	out := new(CacheNode)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudWatchLogsDestination) DeepCopyInto(out *CloudWatchLogsDestination) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudWatchLogsDestination.
+func (in *CloudWatchLogsDestination) DeepCopy() *CloudWatchLogsDestination {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudWatchLogsDestination)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConnectionDetailsTarget) DeepCopyInto(out *ConnectionDetailsTarget) {
+	*out = *in
+	if in.ConfigMapName != nil {
+		in, out := &in.ConfigMapName, &out.ConfigMapName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConnectionDetailsTarget.
+func (in *ConnectionDetailsTarget) DeepCopy() *ConnectionDetailsTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(ConnectionDetailsTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticCache) DeepCopyInto(out *ElasticCache) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticCache.
+func (in *ElasticCache) DeepCopy() *ElasticCache {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticCache)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElasticCache) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticCacheList) DeepCopyInto(out *ElasticCacheList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ElasticCache, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticCacheList.
+func (in *ElasticCacheList) DeepCopy() *ElasticCacheList {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticCacheList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElasticCacheList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticCacheSpec) DeepCopyInto(out *ElasticCacheSpec) {
+	*out = *in
+	if in.CacheCluster != nil {
+		in, out := &in.CacheCluster, &out.CacheCluster
+		*out = new(CacheClusterParameters)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CacheClassRef != nil {
+		in, out := &in.CacheClassRef, &out.CacheClassRef
+		*out = new(LocalObjectReference)
+		**out = **in
+	}
+	if in.ProviderConfigRef != nil {
+		in, out := &in.ProviderConfigRef, &out.ProviderConfigRef
+		*out = new(LocalObjectReference)
+		**out = **in
+	}
+	if in.ExternalName != nil {
+		in, out := &in.ExternalName, &out.ExternalName
+		*out = new(string)
+		**out = **in
+	}
+	if in.WriteConnectionDetailsTo != nil {
+		in, out := &in.WriteConnectionDetailsTo, &out.WriteConnectionDetailsTo
+		*out = new(ConnectionDetailsTarget)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticCacheSpec.
+func (in *ElasticCacheSpec) DeepCopy() *ElasticCacheSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticCacheSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticCacheStatus) DeepCopyInto(out *ElasticCacheStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CacheClusterId != nil {
+		in, out := &in.CacheClusterId, &out.CacheClusterId
+		*out = new(string)
+		**out = **in
+	}
+	if in.CacheClusterStatus != nil {
+		in, out := &in.CacheClusterStatus, &out.CacheClusterStatus
+		*out = new(string)
+		**out = **in
+	}
+	if in.ARN != nil {
+		in, out := &in.ARN, &out.ARN
+		*out = new(string)
+		**out = **in
+	}
+	if in.Engine != nil {
+		in, out := &in.Engine, &out.Engine
+		*out = new(string)
+		**out = **in
+	}
+	if in.EngineVersion != nil {
+		in, out := &in.EngineVersion, &out.EngineVersion
+		*out = new(string)
+		**out = **in
+	}
+	if in.CacheNodeType != nil {
+		in, out := &in.CacheNodeType, &out.CacheNodeType
+		*out = new(string)
+		**out = **in
+	}
+	if in.NumCacheNodes != nil {
+		in, out := &in.NumCacheNodes, &out.NumCacheNodes
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PreferredAvailabilityZone != nil {
+		in, out := &in.PreferredAvailabilityZone, &out.PreferredAvailabilityZone
+		*out = new(string)
+		**out = **in
+	}
+	if in.ConfigurationEndpoint != nil {
+		in, out := &in.ConfigurationEndpoint, &out.ConfigurationEndpoint
+		*out = new(Endpoint)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CacheNodes != nil {
+		in, out := &in.CacheNodes, &out.CacheNodes
+		*out = make([]CacheNode, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PendingModifiedValues != nil {
+		in, out := &in.PendingModifiedValues, &out.PendingModifiedValues
+		*out = new(PendingModifiedValues)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LogDeliveryConfigurations != nil {
+		in, out := &in.LogDeliveryConfigurations, &out.LogDeliveryConfigurations
+		*out = make([]LogDeliveryStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DriftedFields != nil {
+		in, out := &in.DriftedFields, &out.DriftedFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.FinalSnapshotIdentifier != nil {
+		in, out := &in.FinalSnapshotIdentifier, &out.FinalSnapshotIdentifier
+		*out = new(string)
+		**out = **in
+	}
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticCacheStatus.
+func (in *ElasticCacheStatus) DeepCopy() *ElasticCacheStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticCacheStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Endpoint) DeepCopyInto(out *Endpoint) {
+	*out = *in
+	if in.Address != nil {
+		in, out := &in.Address, &out.Address
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Endpoint.
+func (in *Endpoint) DeepCopy() *Endpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(Endpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KinesisFirehoseDestination) DeepCopyInto(out *KinesisFirehoseDestination) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KinesisFirehoseDestination.
+func (in *KinesisFirehoseDestination) DeepCopy() *KinesisFirehoseDestination {
+	if in == nil {
+		return nil
+	}
+	out := new(KinesisFirehoseDestination)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LocalObjectReference) DeepCopyInto(out *LocalObjectReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalObjectReference.
+func (in *LocalObjectReference) DeepCopy() *LocalObjectReference {
+	if in == nil {
+		return nil
+	}
+	out := new(LocalObjectReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogDeliveryConfiguration) DeepCopyInto(out *LogDeliveryConfiguration) {
+	*out = *in
+	if in.CloudWatchLogs != nil {
+		in, out := &in.CloudWatchLogs, &out.CloudWatchLogs
+		*out = new(CloudWatchLogsDestination)
+		**out = **in
+	}
+	if in.KinesisFirehose != nil {
+		in, out := &in.KinesisFirehose, &out.KinesisFirehose
+		*out = new(KinesisFirehoseDestination)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogDeliveryConfiguration.
+func (in *LogDeliveryConfiguration) DeepCopy() *LogDeliveryConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(LogDeliveryConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogDeliveryStatus) DeepCopyInto(out *LogDeliveryStatus) {
+	*out = *in
+	if in.Message != nil {
+		in, out := &in.Message, &out.Message
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogDeliveryStatus.
+func (in *LogDeliveryStatus) DeepCopy() *LogDeliveryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LogDeliveryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PendingModifiedValues) DeepCopyInto(out *PendingModifiedValues) {
+	*out = *in
+	if in.CacheNodeIdsToRemove != nil {
+		in, out := &in.CacheNodeIdsToRemove, &out.CacheNodeIdsToRemove
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CacheNodeType != nil {
+		in, out := &in.CacheNodeType, &out.CacheNodeType
+		*out = new(string)
+		**out = **in
+	}
+	if in.EngineVersion != nil {
+		in, out := &in.EngineVersion, &out.EngineVersion
+		*out = new(string)
+		**out = **in
+	}
+	if in.NumCacheNodes != nil {
+		in, out := &in.NumCacheNodes, &out.NumCacheNodes
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PendingModifiedValues.
+func (in *PendingModifiedValues) DeepCopy() *PendingModifiedValues {
+	if in == nil {
+		return nil
+	}
+	out := new(PendingModifiedValues)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretKeySelector) DeepCopyInto(out *SecretKeySelector) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretKeySelector.
+func (in *SecretKeySelector) DeepCopy() *SecretKeySelector {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretKeySelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Tag) DeepCopyInto(out *Tag) {
+	*out = *in
+	if in.Value != nil {
+		in, out := &in.Value, &out.Value
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Tag.
+func (in *Tag) DeepCopy() *Tag {
+	if in == nil {
+		return nil
+	}
+	out := new(Tag)
+	in.DeepCopyInto(out)
+	return out
+}
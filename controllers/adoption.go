@@ -0,0 +1,250 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+)
+
+// importAnnotation marks an object whose AWS resource already exists and should
+// be adopted instead of created.
+var importAnnotation = awsv1alpha1.ImportAnnotation
+
+// ownerTagKey is the AWS tag recording the object that manages a resource.
+var ownerTagKey = awsv1alpha1.OperatorTagPrefix + "owner"
+
+// ownerTagValue returns the value of the owner tag for obj.
+func ownerTagValue(obj client.Object) string {
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
+
+// ownerTag returns the owner tag for obj.
+func ownerTag(obj client.Object) types.Tag {
+	return types.Tag{Key: aws.String(ownerTagKey), Value: aws.String(ownerTagValue(obj))}
+}
+
+func hasImportAnnotation(obj client.Object) bool {
+	return obj.GetAnnotations()[importAnnotation] == "true"
+}
+
+// ensureClusterOwnership verifies that the existing cache cluster is managed by
+// the ElasticCache. A cluster is owned when the ElasticCache already observed it
+// or when it carries the owner tag of the ElasticCache. Untagged clusters are
+// adopted when the import annotation is set. It reports false, with the reason
+// recorded in the status conditions, when the cluster must not be modified.
+func (r *ElasticCacheReconciler) ensureClusterOwnership(awsClient ElastiCacheAPI, instance *awsv1alpha1.ElasticCache,
+	cluster *types.CacheCluster, authToken *string) (bool, error) {
+	if instance.Status.ARN != nil && aws.ToString(instance.Status.ARN) == aws.ToString(cluster.ARN) {
+		return true, nil
+	}
+
+	tags, err := listTags(awsClient, cluster.ARN)
+	if err != nil {
+		return false, err
+	}
+	owner := tagValue(tags, ownerTagKey)
+	if owner == ownerTagValue(instance) {
+		return true, nil
+	}
+
+	clusterId := aws.ToString(cluster.CacheClusterId)
+	if owner != "" {
+		r.refuseCluster(instance, fmt.Sprintf("cache cluster %s is managed by %s", clusterId, owner))
+		return false, nil
+	}
+	if !hasImportAnnotation(instance) {
+		r.refuseCluster(instance, fmt.Sprintf("cache cluster %s already exists, set the %s annotation to \"true\" to import it",
+			clusterId, importAnnotation))
+		return false, nil
+	}
+
+	_, err = awsClient.AddTagsToResource(context.TODO(), &elasticache.AddTagsToResourceInput{
+		ResourceName: cluster.ARN,
+		Tags:         []types.Tag{ownerTag(instance)},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	// Update overwrites the in-memory status, so the token hash is recorded
+	// afterwards and persisted with the cluster status.
+	backfillElasticCacheSpec(instance.Spec.AWSConfig, cluster)
+	backfillTags(instance.Spec.AWSConfig, tags)
+	backfillLogDelivery(instance.Spec.AWSConfig, cluster)
+	err = r.Update(context.TODO(), instance)
+	if err != nil {
+		return false, err
+	}
+	instance.Status.AuthTokenHash = authTokenHash(instance, authToken)
+
+	r.Recorder.Eventf(instance, corev1.EventTypeNormal, "Adopted", "Imported existing cache cluster %s", clusterId)
+	return true, nil
+}
+
+// refuseCluster records that the ElasticCache does not manage the existing
+// cache cluster with its identifier.
+func (r *ElasticCacheReconciler) refuseCluster(instance *awsv1alpha1.ElasticCache, message string) {
+	setNotOwnedConditions(&instance.Status.Conditions, instance, message)
+	r.Recorder.Event(instance, corev1.EventTypeWarning, "NotOwned", message)
+}
+
+// ensureReplicationGroupOwnership verifies that the existing replication group
+// is managed by the ReplicationGroup, following the rules of
+// ensureResourceOwnership. Drift detection brings adopted replication groups to
+// the spec.
+func (r *ReplicationGroupReconciler) ensureReplicationGroupOwnership(awsClient ElastiCacheAPI, instance *awsv1alpha1.ReplicationGroup,
+	replicationGroup *types.ReplicationGroup) (bool, error) {
+	return ensureResourceOwnership(awsClient, instance, &instance.Status.Conditions, instance.Status.ARN, replicationGroup.ARN,
+		"replication group "+aws.ToString(replicationGroup.ReplicationGroupId))
+}
+
+// ensureCacheParameterGroupOwnership verifies that the existing cache parameter
+// group is managed by the CacheParameterGroup, following the rules of
+// ensureResourceOwnership. The parameters of an adopted group are brought to
+// the spec, parameters missing from the spec are reset.
+func (r *CacheParameterGroupReconciler) ensureCacheParameterGroupOwnership(awsClient ElastiCacheAPI, instance *awsv1alpha1.CacheParameterGroup,
+	parameterGroup *types.CacheParameterGroup) (bool, error) {
+	return ensureResourceOwnership(awsClient, instance, &instance.Status.Conditions, instance.Status.ARN, parameterGroup.ARN,
+		"cache parameter group "+aws.ToString(parameterGroup.CacheParameterGroupName))
+}
+
+// ensureUserOwnership verifies that the existing user is managed by the User,
+// following the rules of ensureResourceOwnership. The access string and the
+// authentication of an adopted user are brought to the spec.
+func (r *UserReconciler) ensureUserOwnership(awsClient ElastiCacheAPI, instance *awsv1alpha1.User, user *types.User) (bool, error) {
+	return ensureResourceOwnership(awsClient, instance, &instance.Status.Conditions, instance.Status.ARN, user.ARN,
+		"user "+aws.ToString(user.UserId))
+}
+
+// ensureCacheSnapshotOwnership verifies that the existing snapshot is managed by
+// the CacheSnapshot, following the rules of ensureResourceOwnership.
+func (r *CacheSnapshotReconciler) ensureCacheSnapshotOwnership(awsClient ElastiCacheAPI, instance *awsv1alpha1.CacheSnapshot,
+	snapshot *types.Snapshot) (bool, error) {
+	return ensureResourceOwnership(awsClient, instance, &instance.Status.Conditions, instance.Status.ARN, snapshot.ARN,
+		"snapshot "+aws.ToString(snapshot.SnapshotName))
+}
+
+// ensureResourceOwnership verifies that the existing AWS resource with the given
+// ARN is managed by obj. The resource is owned when obj already observed it, as
+// recorded in observedARN, or when it carries the owner tag of obj. Untagged
+// resources are adopted and tagged when the import annotation is set. It
+// reports false, with the reason recorded in conditions, when the resource must
+// be neither modified nor deleted. resource names the resource in the reason.
+func ensureResourceOwnership(awsClient ElastiCacheAPI, obj client.Object, conditions *[]metav1.Condition,
+	observedARN *string, arn *string, resource string) (bool, error) {
+	if observedARN != nil && aws.ToString(observedARN) == aws.ToString(arn) {
+		return true, nil
+	}
+
+	tags, err := listTags(awsClient, arn)
+	if err != nil {
+		return false, err
+	}
+	owner := tagValue(tags, ownerTagKey)
+	if owner == ownerTagValue(obj) {
+		return true, nil
+	}
+
+	if owner != "" {
+		setNotOwnedConditions(conditions, obj, fmt.Sprintf("%s is managed by %s", resource, owner))
+		return false, nil
+	}
+	if !hasImportAnnotation(obj) {
+		setNotOwnedConditions(conditions, obj,
+			fmt.Sprintf("%s already exists, set the %s annotation to \"true\" to import it", resource, importAnnotation))
+		return false, nil
+	}
+
+	_, err = awsClient.AddTagsToResource(context.TODO(), &elasticache.AddTagsToResourceInput{
+		ResourceName: arn,
+		Tags:         []types.Tag{ownerTag(obj)},
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// backfillElasticCacheSpec copies the observed settings of an imported cache
+// cluster into the fields of the spec that are not set, so adopting a cluster
+// does not modify it.
+func backfillElasticCacheSpec(config *awsv1alpha1.ElasticCacheAwsConfig, cluster *types.CacheCluster) {
+	if config.CacheNodeType == nil {
+		config.CacheNodeType = cluster.CacheNodeType
+	}
+	if config.Engine == nil {
+		config.Engine = cluster.Engine
+	}
+	if config.EngineVersion == nil {
+		config.EngineVersion = cluster.EngineVersion
+	}
+	if config.NumCacheNodes == nil {
+		config.NumCacheNodes = cluster.NumCacheNodes
+	}
+	if config.CacheParameterGroupName == nil && cluster.CacheParameterGroup != nil {
+		config.CacheParameterGroupName = cluster.CacheParameterGroup.CacheParameterGroupName
+	}
+	if config.CacheSubnetGroupName == nil && config.SubnetGroupRef == nil {
+		config.CacheSubnetGroupName = cluster.CacheSubnetGroupName
+	}
+	if config.CacheSecurityGroupNames == nil {
+		for _, group := range cluster.CacheSecurityGroups {
+			config.CacheSecurityGroupNames = append(config.CacheSecurityGroupNames, aws.ToString(group.CacheSecurityGroupName))
+		}
+	}
+	if config.SecurityGroupIds == nil {
+		for _, group := range cluster.SecurityGroups {
+			config.SecurityGroupIds = append(config.SecurityGroupIds, aws.ToString(group.SecurityGroupId))
+		}
+	}
+	if config.NotificationTopicArn == nil && cluster.NotificationConfiguration != nil {
+		config.NotificationTopicArn = cluster.NotificationConfiguration.TopicArn
+	}
+	if config.PreferredAvailabilityZone == nil && cluster.PreferredAvailabilityZone != nil &&
+		aws.ToString(cluster.PreferredAvailabilityZone) != "Multiple" {
+		config.PreferredAvailabilityZone = cluster.PreferredAvailabilityZone
+	}
+	if config.PreferredMaintenanceWindow == nil {
+		config.PreferredMaintenanceWindow = cluster.PreferredMaintenanceWindow
+	}
+	if config.ReplicationGroupId == nil {
+		config.ReplicationGroupId = cluster.ReplicationGroupId
+	}
+	if config.SnapshotRetentionLimit == nil {
+		config.SnapshotRetentionLimit = cluster.SnapshotRetentionLimit
+	}
+	if config.SnapshotWindow == nil {
+		config.SnapshotWindow = cluster.SnapshotWindow
+	}
+	if config.Port == nil {
+		if cluster.ConfigurationEndpoint != nil {
+			config.Port = aws.Int32(cluster.ConfigurationEndpoint.Port)
+		} else if len(cluster.CacheNodes) > 0 && cluster.CacheNodes[0].Endpoint != nil {
+			config.Port = aws.Int32(cluster.CacheNodes[0].Endpoint.Port)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+	"github.com/sergeyshevch/cloud-resource-operator/internal/fake"
+)
+
+var _ = Describe("ElasticCache adoption", func() {
+	var (
+		ctx        context.Context
+		fakeAPI    *fake.ElastiCache
+		reconciler *ElasticCacheReconciler
+		instance   *awsv1alpha1.ElasticCache
+		key        client.ObjectKey
+	)
+
+	reconcile := func() (ctrl.Result, error) {
+		return reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	}
+
+	get := func() *awsv1alpha1.ElasticCache {
+		current := &awsv1alpha1.ElasticCache{}
+		Expect(k8sClient.Get(ctx, key, current)).To(Succeed())
+		return current
+	}
+
+	// existingCluster seeds an available cache cluster with the identifier of
+	// the ElasticCache, tagged with the given owner unless it is empty.
+	existingCluster := func(owner string) types.CacheCluster {
+		fakeAPI.PutCacheCluster(types.CacheCluster{
+			CacheClusterId:             aws.String(key.Name),
+			CacheClusterStatus:         aws.String("available"),
+			CacheNodeType:              aws.String("cache.t3.small"),
+			Engine:                     aws.String("redis"),
+			EngineVersion:              aws.String("6.0.5"),
+			NumCacheNodes:              aws.Int32(1),
+			CacheSubnetGroupName:       aws.String("existing-subnets"),
+			PreferredMaintenanceWindow: aws.String("mon:01:00-mon:02:00"),
+			SnapshotRetentionLimit:     aws.Int32(0),
+		})
+		cluster, ok := fakeAPI.CacheCluster(key.Name)
+		Expect(ok).To(BeTrue())
+		if owner != "" {
+			_, err := fakeAPI.AddTagsToResource(ctx, &elasticache.AddTagsToResourceInput{
+				ResourceName: cluster.ARN,
+				Tags:         []types.Tag{{Key: aws.String(ownerTagKey), Value: aws.String(owner)}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		}
+		fakeAPI.ResetCalls()
+		return cluster
+	}
+
+	expectNotOwned := func(message string) {
+		ready := meta.FindStatusCondition(get().Status.Conditions, awsv1alpha1.ConditionTypeReady)
+		Expect(ready).NotTo(BeNil())
+		Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+		Expect(ready.Reason).To(Equal(awsv1alpha1.ReasonNotOwned))
+		Expect(ready.Message).To(ContainSubstring(message))
+		Expect(recordedEvents(reconciler.Recorder)).To(ConsistOf(HavePrefix("Warning NotOwned")))
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		fakeAPI = fake.New()
+		reconciler = &ElasticCacheReconciler{
+			Client:               k8sClient,
+			Scheme:               scheme.Scheme,
+			Recorder:             record.NewFakeRecorder(100),
+			NewElastiCacheClient: fakeClientFunc(fakeAPI),
+		}
+
+		instance = &awsv1alpha1.ElasticCache{
+			Spec: awsv1alpha1.ElasticCacheSpec{
+				AWSConfig: &awsv1alpha1.ElasticCacheAwsConfig{
+					Engine: aws.String("redis"),
+				},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		key = createTestObject(ctx, instance, "adopted-")
+	})
+
+	AfterEach(func() {
+		removeTestObject(ctx, key, &awsv1alpha1.ElasticCache{})
+	})
+
+	It("refuses a cache cluster managed by another object", func() {
+		existingCluster("other/cache")
+
+		result, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(DefaultStableRequeueInterval))
+		expectNotOwned("is managed by other/cache")
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("ModifyCacheCluster"))
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("AddTagsToResource"))
+		Expect(get().Status.ARN).To(BeNil())
+		Expect(controllerutil.ContainsFinalizer(get(), elasticCacheFinalizer)).To(BeFalse())
+
+		// Without the finalizer the cluster outlives the ElasticCache.
+		Expect(k8sClient.Delete(ctx, get())).To(Succeed())
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("DeleteCacheCluster"))
+		_, ok := fakeAPI.CacheCluster(key.Name)
+		Expect(ok).To(BeTrue())
+	})
+
+	It("refuses an untagged cache cluster without the import annotation", func() {
+		existingCluster("")
+
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		expectNotOwned("already exists, set the " + awsv1alpha1.ImportAnnotation + " annotation")
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("ModifyCacheCluster"))
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("AddTagsToResource"))
+	})
+
+	It("manages a cache cluster carrying its owner tag", func() {
+		existingCluster("default/" + key.Name)
+
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(meta.IsStatusConditionTrue(get().Status.Conditions, awsv1alpha1.ConditionTypeReady)).To(BeTrue())
+		Expect(get().Status.ARN).NotTo(BeNil())
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("CreateCacheCluster"))
+	})
+
+	Context("with the import annotation", func() {
+		BeforeEach(func() {
+			instance.Annotations = map[string]string{awsv1alpha1.ImportAnnotation: "true"}
+		})
+
+		It("adopts an untagged cache cluster without modifying it", func() {
+			cluster := existingCluster("")
+
+			_, err := reconcile()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeAPI.Calls()).NotTo(ContainElement("CreateCacheCluster"))
+			Expect(fakeAPI.Calls()).NotTo(ContainElement("ModifyCacheCluster"))
+
+			tags, err := fakeAPI.ListTagsForResource(ctx, &elasticache.ListTagsForResourceInput{ResourceName: cluster.ARN})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tagValue(tags.TagList, ownerTagKey)).To(Equal("default/" + key.Name))
+
+			current := get()
+			config := current.Spec.AWSConfig
+			Expect(aws.ToString(config.CacheNodeType)).To(Equal("cache.t3.small"))
+			Expect(aws.ToString(config.EngineVersion)).To(Equal("6.0.5"))
+			Expect(aws.ToInt32(config.NumCacheNodes)).To(Equal(int32(1)))
+			Expect(aws.ToString(config.CacheSubnetGroupName)).To(Equal("existing-subnets"))
+			Expect(aws.ToString(config.PreferredMaintenanceWindow)).To(Equal("mon:01:00-mon:02:00"))
+			Expect(aws.ToString(current.Status.ARN)).To(Equal(aws.ToString(cluster.ARN)))
+			Expect(meta.IsStatusConditionTrue(current.Status.Conditions, awsv1alpha1.ConditionTypeReady)).To(BeTrue())
+			Expect(controllerutil.ContainsFinalizer(current, elasticCacheFinalizer)).To(BeTrue())
+			Expect(recordedEvents(reconciler.Recorder)).To(ContainElement(HavePrefix("Normal Adopted")))
+
+			fakeAPI.ResetCalls()
+			_, err = reconcile()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeAPI.Calls()).NotTo(ContainElement("ModifyCacheCluster"))
+			Expect(fakeAPI.Calls()).NotTo(ContainElement("AddTagsToResource"))
+		})
+
+		It("still refuses a cache cluster managed by another object", func() {
+			existingCluster("other/cache")
+
+			_, err := reconcile()
+			Expect(err).NotTo(HaveOccurred())
+			expectNotOwned("is managed by other/cache")
+		})
+
+		It("does not create a cache cluster that was not found", func() {
+			result, err := reconcile()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(DefaultStableRequeueInterval))
+			expectNotOwned("to import was not found")
+			Expect(fakeAPI.Calls()).NotTo(ContainElement("CreateCacheCluster"))
+			_, ok := fakeAPI.CacheCluster(key.Name)
+			Expect(ok).To(BeFalse())
+		})
+	})
+})
+
+var _ = DescribeTable("backfillElasticCacheSpec",
+	func(config awsv1alpha1.ElasticCacheAwsConfig, cluster types.CacheCluster, expected awsv1alpha1.ElasticCacheAwsConfig) {
+		backfillElasticCacheSpec(&config, &cluster)
+		Expect(config).To(Equal(expected))
+	},
+	Entry("fills the fields that are not set",
+		awsv1alpha1.ElasticCacheAwsConfig{},
+		types.CacheCluster{
+			CacheNodeType:             aws.String("cache.t3.small"),
+			Engine:                    aws.String("redis"),
+			EngineVersion:             aws.String("6.0.5"),
+			NumCacheNodes:             aws.Int32(2),
+			CacheSubnetGroupName:      aws.String("subnets"),
+			PreferredAvailabilityZone: aws.String("eu-west-1a"),
+		},
+		awsv1alpha1.ElasticCacheAwsConfig{
+			CacheNodeType:             aws.String("cache.t3.small"),
+			Engine:                    aws.String("redis"),
+			EngineVersion:             aws.String("6.0.5"),
+			NumCacheNodes:             aws.Int32(2),
+			CacheSubnetGroupName:      aws.String("subnets"),
+			PreferredAvailabilityZone: aws.String("eu-west-1a"),
+		}),
+	Entry("keeps the fields that are set",
+		awsv1alpha1.ElasticCacheAwsConfig{
+			CacheNodeType: aws.String("cache.t3.micro"),
+			EngineVersion: aws.String("6.x"),
+		},
+		types.CacheCluster{
+			CacheNodeType: aws.String("cache.t3.small"),
+			EngineVersion: aws.String("6.0.5"),
+		},
+		awsv1alpha1.ElasticCacheAwsConfig{
+			CacheNodeType: aws.String("cache.t3.micro"),
+			EngineVersion: aws.String("6.x"),
+		}),
+	Entry("skips the availability zone of clusters spread over several zones",
+		awsv1alpha1.ElasticCacheAwsConfig{},
+		types.CacheCluster{PreferredAvailabilityZone: aws.String("Multiple")},
+		awsv1alpha1.ElasticCacheAwsConfig{}),
+	Entry("skips the subnet group name when it is referenced",
+		awsv1alpha1.ElasticCacheAwsConfig{SubnetGroupRef: &awsv1alpha1.LocalObjectReference{Name: "subnets"}},
+		types.CacheCluster{CacheSubnetGroupName: aws.String("subnets")},
+		awsv1alpha1.ElasticCacheAwsConfig{SubnetGroupRef: &awsv1alpha1.LocalObjectReference{Name: "subnets"}}),
+)
@@ -0,0 +1,195 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	goerrors "errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/smithy-go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// awsErrorClass tells how a reconciliation that failed with an error is retried.
+type awsErrorClass int
+
+const (
+	// awsErrorUnknown is any error not returned by the ElastiCache API. It is
+	// handed to controller-runtime, which retries it with its rate limiter.
+	awsErrorUnknown awsErrorClass = iota
+	// awsErrorRetryable is an API error that is expected to go away on its own,
+	// e.g. throttling or a resource that is still being modified.
+	awsErrorRetryable
+	// awsErrorTerminal is an API error that only a change of the spec or of the
+	// AWS account can fix, e.g. an invalid parameter or an exceeded quota.
+	awsErrorTerminal
+	// awsErrorMissingReference is an API error about a resource referenced by
+	// the spec that does not exist, e.g. a parameter group. It is retried with
+	// backoff in case the resource is created later.
+	awsErrorMissingReference
+)
+
+// terminalErrorCodes are the ElastiCache error codes that retrying the same
+// request cannot fix. Codes ending in AlreadyExists and quota faults are
+// matched by classifyAwsError.
+var terminalErrorCodes = map[string]bool{
+	"InvalidParameterValue":            true,
+	"InvalidParameterCombination":      true,
+	"InvalidARN":                       true,
+	"InvalidKMSKeyFault":               true,
+	"InvalidSubnet":                    true,
+	"InvalidVPCNetworkStateFault":      true,
+	"SubnetNotAllowedFault":            true,
+	"DefaultUserRequired":              true,
+	"DuplicateUserName":                true,
+	"NoOperationFault":                 true,
+	"ServiceLinkedRoleNotFoundFault":   true,
+	"SnapshotFeatureNotSupportedFault": true,
+	"InvalidClientTokenId":             true,
+	"SignatureDoesNotMatch":            true,
+	"OptInRequired":                    true,
+}
+
+// retryableErrorCodes are the ElastiCache error codes that are expected to go
+// away on their own. Codes of the form Invalid<Resource>State and resources
+// still in use are matched by classifyAwsError.
+var retryableErrorCodes = map[string]bool{
+	"Throttling":                       true,
+	"ThrottlingException":              true,
+	"RequestLimitExceeded":             true,
+	"APICallRateForCustomerExceeded":   true,
+	"InsufficientCacheClusterCapacity": true,
+	"RequestExpired":                   true,
+	"ServiceUnavailable":               true,
+	"InternalFailure":                  true,
+}
+
+// classifyAwsError tells whether err, as returned by the ElastiCache API, is
+// worth retrying.
+func classifyAwsError(err error) awsErrorClass {
+	var apiErr smithy.APIError
+	if !goerrors.As(err, &apiErr) {
+		return awsErrorUnknown
+	}
+
+	code := apiErr.ErrorCode()
+	switch {
+	case terminalErrorCodes[code]:
+		return awsErrorTerminal
+	case retryableErrorCodes[code]:
+		return awsErrorRetryable
+	case strings.Contains(code, "AlreadyExists"):
+		return awsErrorTerminal
+	case strings.Contains(code, "Quota") && strings.Contains(code, "Exceeded"):
+		return awsErrorTerminal
+	case strings.HasPrefix(code, "Invalid") && (strings.HasSuffix(code, "State") || strings.HasSuffix(code, "StateFault")):
+		// A resource that is still being created or modified.
+		return awsErrorRetryable
+	case strings.HasSuffix(code, "InUse"):
+		return awsErrorRetryable
+	case strings.Contains(code, "NotFound"):
+		// Missing resources are references of the spec, unless it is the
+		// reconciled resource itself as told by handleReconcileError.
+		return awsErrorMissingReference
+	case apiErr.ErrorFault() == smithy.FaultServer:
+		return awsErrorRetryable
+	}
+	return awsErrorUnknown
+}
+
+// Bounds of the backoff applied to retryable AWS errors.
+var (
+	minRetryBackoff = 5 * time.Second
+	maxRetryBackoff = 5 * time.Minute
+)
+
+// requeueBackoff tracks how often the reconciliation of each object failed
+// with a retryable AWS error in a row, to requeue it with an exponentially
+// growing delay.
+type requeueBackoff struct {
+	mu       sync.Mutex
+	failures map[types.NamespacedName]int
+}
+
+// next records another failure of key and returns the delay before the next
+// attempt.
+func (b *requeueBackoff) next(key types.NamespacedName) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures == nil {
+		b.failures = map[types.NamespacedName]int{}
+	}
+	failures := b.failures[key]
+	b.failures[key] = failures + 1
+
+	delay := minRetryBackoff
+	for i := 0; i < failures && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryBackoff {
+		delay = maxRetryBackoff
+	}
+	return delay
+}
+
+// reset forgets the failures of key after a successful reconciliation.
+func (b *requeueBackoff) reset(key types.NamespacedName) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.failures, key)
+}
+
+// handleReconcileError records the failed reconciliation of obj in conditions
+// and decides how it is retried. Terminal AWS errors are not retried until the
+// object changes, retryable ones and missing references are requeued with
+// backoff and all other errors are returned to controller-runtime.
+//
+// isResourceNotFound tells the errors about the missing AWS resource of obj
+// apart from missing references, the resource may have been deleted while it
+// was reconciled.
+func handleReconcileError(conditions *[]metav1.Condition, obj metav1.Object, backoff *requeueBackoff,
+	isResourceNotFound func(error) bool, reconcileErr error) (ctrl.Result, error) {
+	key := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+
+	class := classifyAwsError(reconcileErr)
+	if class == awsErrorMissingReference && isResourceNotFound(reconcileErr) {
+		class = awsErrorRetryable
+	}
+
+	switch class {
+	case awsErrorTerminal:
+		backoff.reset(key)
+		setTerminalErrorConditions(conditions, obj, reconcileErr)
+		return ctrl.Result{}, nil
+	case awsErrorRetryable:
+		setReconcileErrorConditions(conditions, obj, reconcileErr)
+		return ctrl.Result{RequeueAfter: backoff.next(key)}, nil
+	case awsErrorMissingReference:
+		setReconcileErrorConditions(conditions, obj, reconcileErr)
+		setWaitingForReferencesConditions(conditions, obj, reconcileErr.Error())
+		return ctrl.Result{RequeueAfter: backoff.next(key)}, nil
+	default:
+		setReconcileErrorConditions(conditions, obj, reconcileErr)
+		return ctrl.Result{}, reconcileErr
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	"github.com/aws/smithy-go"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+)
+
+var _ = Describe("AWS errors", func() {
+	apiError := func(code string) error {
+		return &smithy.GenericAPIError{Code: code, Message: code}
+	}
+
+	DescribeTable("classifyAwsError",
+		func(err error, want awsErrorClass) {
+			Expect(classifyAwsError(err)).To(Equal(want))
+		},
+		Entry("an error not returned by the API", errors.New("connection refused"), awsErrorUnknown),
+		Entry("throttling", apiError("Throttling"), awsErrorRetryable),
+		Entry("insufficient capacity", &types.InsufficientCacheClusterCapacityFault{}, awsErrorRetryable),
+		Entry("a resource in a transition", &types.InvalidCacheClusterStateFault{}, awsErrorRetryable),
+		Entry("a resource in use", apiError("CacheSubnetGroupInUse"), awsErrorRetryable),
+		Entry("an invalid parameter", apiError("InvalidParameterValue"), awsErrorTerminal),
+		Entry("an existing resource", &types.CacheClusterAlreadyExistsFault{}, awsErrorTerminal),
+		Entry("an exceeded quota", &types.NodeQuotaForCustomerExceededFault{}, awsErrorTerminal),
+		Entry("a missing service linked role", apiError("ServiceLinkedRoleNotFoundFault"), awsErrorTerminal),
+		Entry("a missing parameter group", &types.CacheParameterGroupNotFoundFault{}, awsErrorMissingReference),
+		Entry("a missing subnet group", &types.CacheSubnetGroupNotFoundFault{}, awsErrorMissingReference),
+		Entry("a missing user group", &types.UserGroupNotFoundFault{}, awsErrorMissingReference),
+		Entry("a missing snapshot", &types.SnapshotNotFoundFault{}, awsErrorMissingReference),
+		Entry("a server fault", &smithy.GenericAPIError{Code: "Unexpected", Fault: smithy.FaultServer}, awsErrorRetryable),
+	)
+
+	Describe("handleReconcileError", func() {
+		var (
+			obj     *awsv1alpha1.ElasticCache
+			backoff requeueBackoff
+		)
+
+		BeforeEach(func() {
+			obj = &awsv1alpha1.ElasticCache{ObjectMeta: metav1.ObjectMeta{Name: "cache", Namespace: "default"}}
+			backoff = requeueBackoff{}
+		})
+
+		handle := func(err error) (ctrl.Result, error) {
+			return handleReconcileError(&obj.Status.Conditions, obj, &backoff, isCacheClusterNotFound, err)
+		}
+
+		It("waits for missing references", func() {
+			result, err := handle(&types.CacheSubnetGroupNotFoundFault{Message: aws.String("subnet group not found")})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(minRetryBackoff))
+
+			ready := meta.FindStatusCondition(obj.Status.Conditions, awsv1alpha1.ConditionTypeReady)
+			Expect(ready).NotTo(BeNil())
+			Expect(ready.Reason).To(Equal(awsv1alpha1.ReasonWaitingForRefs))
+			Expect(ready.Message).To(ContainSubstring("subnet group not found"))
+		})
+
+		It("retries when the reconciled resource is missing", func() {
+			result, err := handle(&types.CacheClusterNotFoundFault{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(minRetryBackoff))
+			Expect(meta.FindStatusCondition(obj.Status.Conditions, awsv1alpha1.ConditionTypeReady)).To(BeNil())
+			synced := meta.FindStatusCondition(obj.Status.Conditions, awsv1alpha1.ConditionTypeSynced)
+			Expect(synced.Reason).To(Equal(awsv1alpha1.ReasonReconcileError))
+		})
+
+		It("does not retry terminal errors", func() {
+			result, err := handle(apiError("InvalidParameterCombination"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(ctrl.Result{}))
+			synced := meta.FindStatusCondition(obj.Status.Conditions, awsv1alpha1.ConditionTypeSynced)
+			Expect(synced.Reason).To(Equal(awsv1alpha1.ReasonFailed))
+		})
+
+		It("backs off retryable errors", func() {
+			first, err := handle(apiError("Throttling"))
+			Expect(err).NotTo(HaveOccurred())
+			second, err := handle(apiError("Throttling"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(second.RequeueAfter).To(Equal(2 * first.RequeueAfter))
+			Expect(second.RequeueAfter).To(BeNumerically("<=", 5*time.Minute))
+		})
+
+		It("returns other errors to controller-runtime", func() {
+			reconcileErr := errors.New("connection refused")
+			_, err := handle(reconcileErr)
+			Expect(err).To(Equal(reconcileErr))
+		})
+	})
+})
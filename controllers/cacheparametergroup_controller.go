@@ -0,0 +1,382 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+)
+
+var cacheParameterGroupFinalizer = elasticCacheFinalizer
+
+// maxParametersPerRequest is the number of parameters ElastiCache accepts in a
+// single ModifyCacheParameterGroup or ResetCacheParameterGroup call.
+const maxParametersPerRequest = 20
+
+// CacheParameterGroupReconciler reconciles a CacheParameterGroup object
+type CacheParameterGroupReconciler struct {
+	client.Client
+	AwsConfig aws.Config
+	Scheme    *runtime.Scheme
+
+	// NewElastiCacheClient builds the ElastiCache client, defaults to the
+	// client of the AWS SDK.
+	NewElastiCacheClient ElastiCacheClientFunc
+
+	// RequeueIntervals sets how often the AWS resources are polled.
+	RequeueIntervals RequeueIntervals
+
+	backoff requeueBackoff
+}
+
+//+kubebuilder:rbac:groups=aws.sergeyshevch.dev,resources=cacheparametergroups,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=aws.sergeyshevch.dev,resources=cacheparametergroups/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=aws.sergeyshevch.dev,resources=cacheparametergroups/finalizers,verbs=update
+
+// Reconcile creates the cache parameter group, applies parameter changes,
+// resets parameters that were removed from the spec and reports the clusters
+// that need a reboot for pending changes.
+func (r *CacheParameterGroupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	defer observeReconcileDuration("CacheParameterGroup", time.Now())
+
+	instance := &awsv1alpha1.CacheParameterGroup{}
+	err := r.Client.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	result, err := r.reconcileCacheParameterGroup(ctx, instance)
+	if err != nil {
+		result, err = handleReconcileError(&instance.Status.Conditions, instance, &r.backoff, isCacheParameterGroupNotFound, err)
+		if statusErr := r.Status().Update(context.TODO(), instance); statusErr != nil {
+			log.FromContext(ctx).Error(statusErr, "unable to update CacheParameterGroup status")
+		}
+		return result, err
+	}
+	r.backoff.reset(req.NamespacedName)
+	return result, nil
+}
+
+func (r *CacheParameterGroupReconciler) reconcileCacheParameterGroup(ctx context.Context, instance *awsv1alpha1.CacheParameterGroup) (ctrl.Result, error) {
+	awsClient := newElastiCacheClient(r.NewElastiCacheClient, r.AwsConfig)
+
+	isCacheParameterGroupMarkedToDeletion := instance.GetDeletionTimestamp() != nil
+	if isCacheParameterGroupMarkedToDeletion {
+		return ctrl.Result{}, r.finalizeCacheParameterGroup(ctx, awsClient, instance)
+	}
+
+	parameterGroup, err := r.getCacheParameterGroup(awsClient, instance)
+	if err != nil {
+		if !isCacheParameterGroupNotFound(err) {
+			return ctrl.Result{}, err
+		}
+
+		// Persist the finalizer before the parameter group exists, a deletion
+		// right after the creation would otherwise leak it.
+		err = r.addFinalizer(ctx, instance)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		parameterGroup, err = r.createCacheParameterGroup(awsClient, instance)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	} else {
+		// Never touch a parameter group this CacheParameterGroup did not
+		// create or import.
+		owned, err := r.ensureCacheParameterGroupOwnership(awsClient, instance, parameterGroup)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !owned {
+			err = r.Status().Update(context.TODO(), instance)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{RequeueAfter: r.RequeueIntervals.stable()}, nil
+		}
+
+		err = r.addFinalizer(ctx, instance)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	parameters, err := r.getUserParameters(awsClient, instance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	changed, err := r.syncParameters(awsClient, instance, parameters)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if changed {
+		parameters, err = r.getUserParameters(awsClient, instance)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Listing the clusters pages through every cluster of the account, so it
+	// is only done after a change and until the pending reboots are done.
+	pendingReboot := instance.Status.ClustersPendingReboot
+	if changed || len(pendingReboot) > 0 {
+		pendingReboot, err = r.getClustersPendingReboot(awsClient, instance)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	status := &instance.Status
+	status.ObservedGeneration = instance.GetGeneration()
+	status.ARN = parameterGroup.ARN
+	status.CacheParameterGroupName = parameterGroup.CacheParameterGroupName
+	status.Parameters = parameters
+	status.ClustersPendingReboot = pendingReboot
+	setAwsResourceConditions(&status.Conditions, instance, "available")
+	now := metav1.Now()
+	status.LastSyncTime = &now
+
+	err = r.Status().Update(context.TODO(), instance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: r.RequeueIntervals.stable()}, nil
+}
+
+// finalizeCacheParameterGroup deletes the parameter group of a
+// CacheParameterGroup that is being deleted and removes the finalizer. Parameter
+// groups the CacheParameterGroup does not own are left in place.
+func (r *CacheParameterGroupReconciler) finalizeCacheParameterGroup(ctx context.Context, awsClient ElastiCacheAPI, instance *awsv1alpha1.CacheParameterGroup) error {
+	if !controllerutil.ContainsFinalizer(instance, cacheParameterGroupFinalizer) {
+		return nil
+	}
+
+	parameterGroup, err := r.getCacheParameterGroup(awsClient, instance)
+	if err != nil && !isCacheParameterGroupNotFound(err) {
+		return err
+	}
+	if err == nil {
+		owned, err := r.ensureCacheParameterGroupOwnership(awsClient, instance, parameterGroup)
+		if err != nil {
+			return err
+		}
+		if owned {
+			err = r.deleteCacheParameterGroup(awsClient, instance)
+			if err != nil && !isCacheParameterGroupNotFound(err) {
+				return err
+			}
+		}
+	}
+
+	controllerutil.RemoveFinalizer(instance, cacheParameterGroupFinalizer)
+	return r.Update(ctx, instance)
+}
+
+func (r *CacheParameterGroupReconciler) addFinalizer(ctx context.Context, instance *awsv1alpha1.CacheParameterGroup) error {
+	if controllerutil.ContainsFinalizer(instance, cacheParameterGroupFinalizer) {
+		return nil
+	}
+	controllerutil.AddFinalizer(instance, cacheParameterGroupFinalizer)
+	return r.Update(ctx, instance)
+}
+
+// syncParameters modifies parameters whose value differs from the spec and
+// resets parameters that are set in AWS but no longer present in the spec. It
+// reports whether any change was sent to AWS.
+func (r *CacheParameterGroupReconciler) syncParameters(awsClient ElastiCacheAPI, cr *awsv1alpha1.CacheParameterGroup, current map[string]string) (bool, error) {
+	var toModify []types.ParameterNameValue
+	for _, name := range sortedKeys(cr.Spec.Parameters) {
+		value := cr.Spec.Parameters[name]
+		if currentValue, ok := current[name]; ok && currentValue == value {
+			continue
+		}
+		toModify = append(toModify, types.ParameterNameValue{ParameterName: aws.String(name), ParameterValue: aws.String(value)})
+	}
+
+	var toReset []types.ParameterNameValue
+	for _, name := range sortedKeys(current) {
+		if _, ok := cr.Spec.Parameters[name]; !ok {
+			toReset = append(toReset, types.ParameterNameValue{ParameterName: aws.String(name)})
+		}
+	}
+
+	for _, batch := range batchParameters(toModify) {
+		_, err := awsClient.ModifyCacheParameterGroup(context.TODO(), &elasticache.ModifyCacheParameterGroupInput{
+			CacheParameterGroupName: &cr.Name,
+			ParameterNameValues:     batch,
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+
+	for _, batch := range batchParameters(toReset) {
+		_, err := awsClient.ResetCacheParameterGroup(context.TODO(), &elasticache.ResetCacheParameterGroupInput{
+			CacheParameterGroupName: &cr.Name,
+			ParameterNameValues:     batch,
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return len(toModify) > 0 || len(toReset) > 0, nil
+}
+
+// getUserParameters returns the parameters of the group that were changed from
+// the family defaults.
+func (r *CacheParameterGroupReconciler) getUserParameters(awsClient ElastiCacheAPI, cr *awsv1alpha1.CacheParameterGroup) (map[string]string, error) {
+	parameters := map[string]string{}
+	paginator := elasticache.NewDescribeCacheParametersPaginator(awsClient, &elasticache.DescribeCacheParametersInput{
+		CacheParameterGroupName: &cr.Name,
+		Source:                  aws.String("user"),
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, err
+		}
+		for _, parameter := range output.Parameters {
+			parameters[aws.ToString(parameter.ParameterName)] = aws.ToString(parameter.ParameterValue)
+		}
+	}
+	return parameters, nil
+}
+
+// getClustersPendingReboot lists the clusters using the parameter group whose
+// parameter changes are only applied after a reboot.
+func (r *CacheParameterGroupReconciler) getClustersPendingReboot(awsClient ElastiCacheAPI, cr *awsv1alpha1.CacheParameterGroup) ([]awsv1alpha1.PendingRebootCluster, error) {
+	var pendingReboot []awsv1alpha1.PendingRebootCluster
+	paginator := elasticache.NewDescribeCacheClustersPaginator(awsClient, &elasticache.DescribeCacheClustersInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, err
+		}
+		for _, cluster := range output.CacheClusters {
+			parameterGroup := cluster.CacheParameterGroup
+			if parameterGroup == nil || aws.ToString(parameterGroup.CacheParameterGroupName) != cr.Name {
+				continue
+			}
+			if aws.ToString(parameterGroup.ParameterApplyStatus) != "pending-reboot" {
+				continue
+			}
+			pendingReboot = append(pendingReboot, awsv1alpha1.PendingRebootCluster{
+				CacheClusterId:       aws.ToString(cluster.CacheClusterId),
+				CacheNodeIdsToReboot: parameterGroup.CacheNodeIdsToReboot,
+			})
+		}
+	}
+	return pendingReboot, nil
+}
+
+func (r *CacheParameterGroupReconciler) createCacheParameterGroup(awsClient ElastiCacheAPI, cr *awsv1alpha1.CacheParameterGroup) (*types.CacheParameterGroup, error) {
+	params := &elasticache.CreateCacheParameterGroupInput{
+		CacheParameterGroupName:   &cr.Name,
+		CacheParameterGroupFamily: &cr.Spec.CacheParameterGroupFamily,
+		Description:               &cr.Spec.Description,
+		Tags:                      desiredTags(nil, cr.Spec.Tags, cr),
+	}
+
+	output, err := awsClient.CreateCacheParameterGroup(context.TODO(), params)
+	if err != nil {
+		return &types.CacheParameterGroup{}, err
+	}
+	return output.CacheParameterGroup, nil
+}
+
+func (r *CacheParameterGroupReconciler) getCacheParameterGroup(awsClient ElastiCacheAPI, cr *awsv1alpha1.CacheParameterGroup) (*types.CacheParameterGroup, error) {
+	params := &elasticache.DescribeCacheParameterGroupsInput{
+		CacheParameterGroupName: &cr.Name,
+	}
+
+	output, err := awsClient.DescribeCacheParameterGroups(context.TODO(), params)
+	if err != nil {
+		return &types.CacheParameterGroup{}, err
+	}
+
+	if len(output.CacheParameterGroups) == 1 {
+		return &output.CacheParameterGroups[0], nil
+	}
+	return &types.CacheParameterGroup{}, &types.CacheParameterGroupNotFoundFault{
+		Message: aws.String(fmt.Sprintf("CacheParameterGroup %s not found.", cr.Name)),
+	}
+}
+
+func (r *CacheParameterGroupReconciler) deleteCacheParameterGroup(awsClient ElastiCacheAPI, cr *awsv1alpha1.CacheParameterGroup) error {
+	params := &elasticache.DeleteCacheParameterGroupInput{
+		CacheParameterGroupName: &cr.Name,
+	}
+
+	_, err := awsClient.DeleteCacheParameterGroup(context.TODO(), params)
+	return err
+}
+
+func isCacheParameterGroupNotFound(err error) bool {
+	var notFound *types.CacheParameterGroupNotFoundFault
+	return goerrors.As(err, &notFound)
+}
+
+func batchParameters(parameters []types.ParameterNameValue) [][]types.ParameterNameValue {
+	var batches [][]types.ParameterNameValue
+	for len(parameters) > maxParametersPerRequest {
+		batches = append(batches, parameters[:maxParametersPerRequest])
+		parameters = parameters[maxParametersPerRequest:]
+	}
+	if len(parameters) > 0 {
+		batches = append(batches, parameters)
+	}
+	return batches
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *CacheParameterGroupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&awsv1alpha1.CacheParameterGroup{}, specChanged()).
+		Complete(r)
+}
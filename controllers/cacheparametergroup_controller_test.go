@@ -0,0 +1,325 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+	"github.com/sergeyshevch/cloud-resource-operator/internal/fake"
+)
+
+var _ = Describe("CacheParameterGroup controller", func() {
+	var (
+		ctx        context.Context
+		fakeAPI    *fake.ElastiCache
+		reconciler *CacheParameterGroupReconciler
+		instance   *awsv1alpha1.CacheParameterGroup
+		key        client.ObjectKey
+	)
+
+	reconcile := func() (ctrl.Result, error) {
+		return reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	}
+
+	get := func() *awsv1alpha1.CacheParameterGroup {
+		current := &awsv1alpha1.CacheParameterGroup{}
+		Expect(k8sClient.Get(ctx, key, current)).To(Succeed())
+		return current
+	}
+
+	// parameters returns the parameters set on the parameter group in AWS.
+	parameters := func() map[string]string {
+		output, err := fakeAPI.DescribeCacheParameters(ctx, &elasticache.DescribeCacheParametersInput{
+			CacheParameterGroupName: aws.String(key.Name),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		values := map[string]string{}
+		for _, parameter := range output.Parameters {
+			values[aws.ToString(parameter.ParameterName)] = aws.ToString(parameter.ParameterValue)
+		}
+		return values
+	}
+
+	setParameters := func(values map[string]string) {
+		current := get()
+		current.Spec.Parameters = values
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+	}
+
+	// createExisting creates a parameter group with the name of the
+	// CacheParameterGroup outside of the operator.
+	createExisting := func(tags ...types.Tag) {
+		_, err := fakeAPI.CreateCacheParameterGroup(ctx, &elasticache.CreateCacheParameterGroupInput{
+			CacheParameterGroupName:   aws.String(key.Name),
+			CacheParameterGroupFamily: aws.String("redis6.x"),
+			Description:               aws.String("existing"),
+			Tags:                      tags,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		fakeAPI.ResetCalls()
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		fakeAPI = fake.New()
+		reconciler = &CacheParameterGroupReconciler{
+			Client:               k8sClient,
+			Scheme:               scheme.Scheme,
+			NewElastiCacheClient: fakeClientFunc(fakeAPI),
+		}
+
+		instance = &awsv1alpha1.CacheParameterGroup{
+			Spec: awsv1alpha1.CacheParameterGroupSpec{
+				CacheParameterGroupFamily: "redis6.x",
+				Description:               "test",
+				Parameters: map[string]string{
+					"maxmemory-policy": "allkeys-lru",
+					"timeout":          "300",
+				},
+			},
+		}
+		key = createTestObject(ctx, instance, "params-")
+	})
+
+	AfterEach(func() {
+		removeTestObject(ctx, key, &awsv1alpha1.CacheParameterGroup{})
+	})
+
+	It("creates the parameter group with the parameters of the spec", func() {
+		result, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(DefaultStableRequeueInterval))
+
+		Expect(parameters()).To(Equal(instance.Spec.Parameters))
+
+		current := get()
+		Expect(controllerutil.ContainsFinalizer(current, cacheParameterGroupFinalizer)).To(BeTrue())
+		Expect(aws.ToString(current.Status.CacheParameterGroupName)).To(Equal(key.Name))
+		Expect(current.Status.ARN).NotTo(BeNil())
+		Expect(current.Status.Parameters).To(Equal(instance.Spec.Parameters))
+		Expect(meta.IsStatusConditionTrue(current.Status.Conditions, awsv1alpha1.ConditionTypeReady)).To(BeTrue())
+	})
+
+	It("modifies changed parameters and resets removed ones", func() {
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		setParameters(map[string]string{"timeout": "600"})
+		fakeAPI.ResetCalls()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fakeAPI.Calls()).To(ContainElements("ModifyCacheParameterGroup", "ResetCacheParameterGroup"))
+		Expect(parameters()).To(Equal(map[string]string{"timeout": "600"}))
+		Expect(get().Status.Parameters).To(Equal(map[string]string{"timeout": "600"}))
+	})
+
+	It("reverts parameters changed outside of the operator", func() {
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = fakeAPI.ModifyCacheParameterGroup(ctx, &elasticache.ModifyCacheParameterGroupInput{
+			CacheParameterGroupName: aws.String(key.Name),
+			ParameterNameValues: []types.ParameterNameValue{
+				{ParameterName: aws.String("timeout"), ParameterValue: aws.String("0")},
+				{ParameterName: aws.String("notify-keyspace-events"), ParameterValue: aws.String("Ex")},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parameters()).To(Equal(instance.Spec.Parameters))
+	})
+
+	It("does not call AWS when the parameters are in sync", func() {
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		fakeAPI.ResetCalls()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("ModifyCacheParameterGroup"))
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("ResetCacheParameterGroup"))
+	})
+
+	It("splits parameter changes into batches AWS accepts", func() {
+		values := map[string]string{}
+		for i := 0; i < 2*maxParametersPerRequest+1; i++ {
+			values[fmt.Sprintf("parameter-%02d", i)] = "value"
+		}
+		setParameters(values)
+
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		modifications := 0
+		for _, call := range fakeAPI.Calls() {
+			if call == "ModifyCacheParameterGroup" {
+				modifications++
+			}
+		}
+		Expect(modifications).To(Equal(3))
+		Expect(parameters()).To(Equal(values))
+	})
+
+	It("reports the clusters that need a reboot after a change", func() {
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		pending := types.CacheCluster{
+			CacheClusterId:     aws.String("pending"),
+			CacheClusterStatus: aws.String("available"),
+			CacheParameterGroup: &types.CacheParameterGroupStatus{
+				CacheParameterGroupName: aws.String(key.Name),
+				ParameterApplyStatus:    aws.String("pending-reboot"),
+				CacheNodeIdsToReboot:    []string{"0001"},
+			},
+		}
+		fakeAPI.PutCacheCluster(pending)
+		fakeAPI.PutCacheCluster(types.CacheCluster{
+			CacheClusterId:     aws.String("in-sync"),
+			CacheClusterStatus: aws.String("available"),
+			CacheParameterGroup: &types.CacheParameterGroupStatus{
+				CacheParameterGroupName: aws.String(key.Name),
+				ParameterApplyStatus:    aws.String("in-sync"),
+			},
+		})
+		fakeAPI.PutCacheCluster(types.CacheCluster{
+			CacheClusterId:     aws.String("other-group"),
+			CacheClusterStatus: aws.String("available"),
+			CacheParameterGroup: &types.CacheParameterGroupStatus{
+				CacheParameterGroupName: aws.String("other"),
+				ParameterApplyStatus:    aws.String("pending-reboot"),
+			},
+		})
+
+		// The clusters are only listed after a change.
+		fakeAPI.ResetCalls()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("DescribeCacheClusters"))
+		Expect(get().Status.ClustersPendingReboot).To(BeEmpty())
+
+		setParameters(map[string]string{"timeout": "600"})
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(get().Status.ClustersPendingReboot).To(Equal([]awsv1alpha1.PendingRebootCluster{
+			{CacheClusterId: "pending", CacheNodeIdsToReboot: []string{"0001"}},
+		}))
+
+		// They are listed until the reboot is done.
+		pending.CacheParameterGroup.ParameterApplyStatus = aws.String("in-sync")
+		pending.CacheParameterGroup.CacheNodeIdsToReboot = nil
+		fakeAPI.PutCacheCluster(pending)
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(get().Status.ClustersPendingReboot).To(BeEmpty())
+
+		fakeAPI.ResetCalls()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("DescribeCacheClusters"))
+	})
+
+	It("retries parameter changes the group is not ready for", func() {
+		fakeAPI.InjectError("ModifyCacheParameterGroup", &types.InvalidCacheParameterGroupStateFault{Message: aws.String("group is being modified")})
+
+		result, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+		Expect(meta.IsStatusConditionFalse(get().Status.Conditions, awsv1alpha1.ConditionTypeSynced)).To(BeTrue())
+
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parameters()).To(Equal(instance.Spec.Parameters))
+		Expect(meta.IsStatusConditionTrue(get().Status.Conditions, awsv1alpha1.ConditionTypeSynced)).To(BeTrue())
+	})
+
+	It("deletes the parameter group before removing the finalizer", func() {
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Delete(ctx, get())).To(Succeed())
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = fakeAPI.DescribeCacheParameterGroups(ctx, &elasticache.DescribeCacheParameterGroupsInput{
+			CacheParameterGroupName: aws.String(key.Name),
+		})
+		Expect(isCacheParameterGroupNotFound(err)).To(BeTrue())
+		err = k8sClient.Get(ctx, key, &awsv1alpha1.CacheParameterGroup{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("refuses to manage a parameter group it does not own", func() {
+		createExisting(types.Tag{Key: aws.String(ownerTagKey), Value: aws.String("other/params")})
+
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		ready := meta.FindStatusCondition(get().Status.Conditions, awsv1alpha1.ConditionTypeReady)
+		Expect(ready).NotTo(BeNil())
+		Expect(ready.Reason).To(Equal(awsv1alpha1.ReasonNotOwned))
+		Expect(ready.Message).To(ContainSubstring("other/params"))
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("ModifyCacheParameterGroup"))
+		Expect(controllerutil.ContainsFinalizer(get(), cacheParameterGroupFinalizer)).To(BeFalse())
+		Expect(parameters()).To(BeEmpty())
+
+		// Deleting the CacheParameterGroup leaves the parameter group alone.
+		Expect(k8sClient.Delete(ctx, get())).To(Succeed())
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("DeleteCacheParameterGroup"))
+	})
+
+	It("manages an untagged parameter group only once it is imported", func() {
+		createExisting()
+
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		ready := meta.FindStatusCondition(get().Status.Conditions, awsv1alpha1.ConditionTypeReady)
+		Expect(ready).NotTo(BeNil())
+		Expect(ready.Reason).To(Equal(awsv1alpha1.ReasonNotOwned))
+		Expect(parameters()).To(BeEmpty())
+
+		current := get()
+		current.Annotations = map[string]string{awsv1alpha1.ImportAnnotation: "true"}
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parameters()).To(Equal(instance.Spec.Parameters))
+		Expect(controllerutil.ContainsFinalizer(get(), cacheParameterGroupFinalizer)).To(BeTrue())
+		output, err := fakeAPI.ListTagsForResource(ctx, &elasticache.ListTagsForResourceInput{ResourceName: get().Status.ARN})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tagValue(output.TagList, ownerTagKey)).To(Equal(ownerTagValue(current)))
+	})
+})
@@ -0,0 +1,363 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+)
+
+var cacheSnapshotFinalizer = elasticCacheFinalizer
+
+// CacheSnapshotReconciler reconciles a CacheSnapshot object
+type CacheSnapshotReconciler struct {
+	client.Client
+	AwsConfig aws.Config
+	Scheme    *runtime.Scheme
+
+	// NewElastiCacheClient builds the ElastiCache client, defaults to the
+	// client of the AWS SDK.
+	NewElastiCacheClient ElastiCacheClientFunc
+
+	// RequeueIntervals sets how often the AWS resources are polled.
+	RequeueIntervals RequeueIntervals
+
+	backoff requeueBackoff
+}
+
+//+kubebuilder:rbac:groups=aws.sergeyshevch.dev,resources=cachesnapshots,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=aws.sergeyshevch.dev,resources=cachesnapshots/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=aws.sergeyshevch.dev,resources=cachesnapshots/finalizers,verbs=update
+//+kubebuilder:rbac:groups=aws.sergeyshevch.dev,resources=elasticcaches;replicationgroups,verbs=get;list;watch
+
+// Reconcile takes a manual snapshot of the referenced cluster or replication
+// group, tracks its progress and exports it to Amazon S3 once it is available.
+func (r *CacheSnapshotReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	defer observeReconcileDuration("CacheSnapshot", time.Now())
+
+	instance := &awsv1alpha1.CacheSnapshot{}
+	err := r.Client.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	result, err := r.reconcileCacheSnapshot(ctx, instance)
+	if err != nil {
+		result, err = handleReconcileError(&instance.Status.Conditions, instance, &r.backoff, isSnapshotNotFound, err)
+		if statusErr := r.Status().Update(context.TODO(), instance); statusErr != nil {
+			log.FromContext(ctx).Error(statusErr, "unable to update CacheSnapshot status")
+		}
+		return result, err
+	}
+	r.backoff.reset(req.NamespacedName)
+	return result, nil
+}
+
+func (r *CacheSnapshotReconciler) reconcileCacheSnapshot(ctx context.Context, instance *awsv1alpha1.CacheSnapshot) (ctrl.Result, error) {
+	awsClient := newElastiCacheClient(r.NewElastiCacheClient, r.AwsConfig)
+
+	isCacheSnapshotMarkedToDeletion := instance.GetDeletionTimestamp() != nil
+	if isCacheSnapshotMarkedToDeletion {
+		return ctrl.Result{}, r.finalizeCacheSnapshot(ctx, awsClient, instance)
+	}
+
+	snapshot, err := r.getCacheSnapshot(awsClient, instance)
+	if err != nil {
+		if !isSnapshotNotFound(err) {
+			return ctrl.Result{}, err
+		}
+
+		// A snapshot that was already observed was deleted outside of the
+		// operator, taking a new one would not restore its data.
+		if instance.Status.ARN != nil {
+			setFailedConditions(&instance.Status.Conditions, instance,
+				fmt.Sprintf("snapshot %s no longer exists in AWS", aws.ToString(cacheSnapshotName(instance))))
+			return ctrl.Result{}, r.Status().Update(context.TODO(), instance)
+		}
+
+		cacheClusterId, replicationGroupId, ready, err := r.resolveSource(ctx, instance)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !ready {
+			err = r.Status().Update(context.TODO(), instance)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{RequeueAfter: r.RequeueIntervals.transitional()}, nil
+		}
+
+		// Record the name before the snapshot exists, so the CacheSnapshot
+		// keeps it even if the naming changes, and persist the finalizer so a
+		// deletion right after the creation does not leak the snapshot.
+		if instance.Status.SnapshotName == nil {
+			instance.Status.SnapshotName = cacheSnapshotName(instance)
+			err = r.Status().Update(context.TODO(), instance)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		err = r.addFinalizer(ctx, instance)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		snapshot, err = r.createCacheSnapshot(awsClient, instance, cacheClusterId, replicationGroupId)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	} else {
+		// Never touch a snapshot this CacheSnapshot did not take or import.
+		owned, err := r.ensureCacheSnapshotOwnership(awsClient, instance, snapshot)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !owned {
+			err = r.Status().Update(context.TODO(), instance)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{RequeueAfter: r.RequeueIntervals.stable()}, nil
+		}
+
+		err = r.addFinalizer(ctx, instance)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if export := instance.Spec.ExportTo; export != nil && instance.Status.Export == nil && aws.ToString(snapshot.SnapshotStatus) == "available" {
+		targetSnapshotName := aws.ToString(export.TargetSnapshotName)
+		if targetSnapshotName == "" {
+			targetSnapshotName = aws.ToString(cacheSnapshotName(instance))
+		}
+
+		snapshot, err = r.exportCacheSnapshot(awsClient, instance, export.TargetBucket, targetSnapshotName)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		instance.Status.Export = &awsv1alpha1.SnapshotExportStatus{
+			TargetBucket:       export.TargetBucket,
+			TargetSnapshotName: targetSnapshotName,
+			ExportTime:         metav1.Now(),
+		}
+	}
+
+	status := &instance.Status
+	status.ObservedGeneration = instance.GetGeneration()
+	status.SnapshotName = snapshot.SnapshotName
+	status.ARN = snapshot.ARN
+	status.SnapshotStatus = snapshot.SnapshotStatus
+	status.SnapshotSource = snapshot.SnapshotSource
+	status.CacheClusterId = snapshot.CacheClusterId
+	status.ReplicationGroupId = snapshot.ReplicationGroupId
+	status.Engine = snapshot.Engine
+	status.EngineVersion = snapshot.EngineVersion
+	status.CacheNodeType = snapshot.CacheNodeType
+	status.NumNodeGroups = snapshot.NumNodeGroups
+	setAwsResourceConditions(&status.Conditions, instance, aws.ToString(snapshot.SnapshotStatus))
+	now := metav1.Now()
+	status.LastSyncTime = &now
+
+	err = r.Status().Update(context.TODO(), instance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return r.RequeueIntervals.requeueAfter(aws.ToString(snapshot.SnapshotStatus)), nil
+}
+
+// finalizeCacheSnapshot deletes the snapshot of a CacheSnapshot that is being
+// deleted, unless the deletion policy retains it, and removes the finalizer.
+// Snapshots the CacheSnapshot does not own are left in place.
+func (r *CacheSnapshotReconciler) finalizeCacheSnapshot(ctx context.Context, awsClient ElastiCacheAPI, instance *awsv1alpha1.CacheSnapshot) error {
+	if !controllerutil.ContainsFinalizer(instance, cacheSnapshotFinalizer) {
+		return nil
+	}
+
+	if instance.Spec.DeletionPolicy != awsv1alpha1.DeletionPolicyRetain {
+		snapshot, err := r.getCacheSnapshot(awsClient, instance)
+		if err != nil && !isSnapshotNotFound(err) {
+			return err
+		}
+		if err == nil {
+			owned, err := r.ensureCacheSnapshotOwnership(awsClient, instance, snapshot)
+			if err != nil {
+				return err
+			}
+			if owned {
+				err = r.deleteCacheSnapshot(awsClient, instance)
+				if err != nil && !isSnapshotNotFound(err) {
+					return err
+				}
+			}
+		}
+	}
+
+	controllerutil.RemoveFinalizer(instance, cacheSnapshotFinalizer)
+	return r.Update(ctx, instance)
+}
+
+func (r *CacheSnapshotReconciler) addFinalizer(ctx context.Context, instance *awsv1alpha1.CacheSnapshot) error {
+	if controllerutil.ContainsFinalizer(instance, cacheSnapshotFinalizer) {
+		return nil
+	}
+	controllerutil.AddFinalizer(instance, cacheSnapshotFinalizer)
+	return r.Update(ctx, instance)
+}
+
+// resolveSource returns the identifier of the cluster or replication group
+// referenced by the CacheSnapshot spec. When the referenced object is missing
+// or not Ready yet, it records that in the status conditions and reports false.
+func (r *CacheSnapshotReconciler) resolveSource(ctx context.Context, instance *awsv1alpha1.CacheSnapshot) (*string, *string, bool, error) {
+	elasticCacheRef := instance.Spec.ElasticCacheRef
+	replicationGroupRef := instance.Spec.ReplicationGroupRef
+	if (elasticCacheRef == nil) == (replicationGroupRef == nil) {
+		return nil, nil, false, fmt.Errorf("exactly one of elasticCacheRef and replicationGroupRef must be set")
+	}
+
+	if elasticCacheRef != nil {
+		elasticCache := &awsv1alpha1.ElasticCache{}
+		ready, err := r.getReadyReference(ctx, instance, "ElasticCache", elasticCacheRef, elasticCache, &elasticCache.Status.Conditions)
+		if err != nil || !ready {
+			return nil, nil, false, err
+		}
+		return cacheClusterId(elasticCache), nil, true, nil
+	}
+
+	replicationGroup := &awsv1alpha1.ReplicationGroup{}
+	ready, err := r.getReadyReference(ctx, instance, "ReplicationGroup", replicationGroupRef, replicationGroup, &replicationGroup.Status.Conditions)
+	if err != nil || !ready {
+		return nil, nil, false, err
+	}
+	return nil, replicationGroupId(replicationGroup), true, nil
+}
+
+// getReadyReference reads the object referenced by ref into obj and reports
+// whether its Ready condition, read through conditions, is true.
+func (r *CacheSnapshotReconciler) getReadyReference(ctx context.Context, instance *awsv1alpha1.CacheSnapshot, kind string,
+	ref *awsv1alpha1.LocalObjectReference, obj client.Object, conditions *[]metav1.Condition) (bool, error) {
+	err := r.Get(ctx, client.ObjectKey{Namespace: instance.Namespace, Name: ref.Name}, obj)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			setWaitingForReferencesConditions(&instance.Status.Conditions, instance,
+				fmt.Sprintf("%s %s not found", kind, ref.Name))
+			return false, nil
+		}
+		return false, err
+	}
+
+	if !meta.IsStatusConditionTrue(*conditions, awsv1alpha1.ConditionTypeReady) {
+		setWaitingForReferencesConditions(&instance.Status.Conditions, instance,
+			fmt.Sprintf("%s %s is not Ready", kind, ref.Name))
+		return false, nil
+	}
+	return true, nil
+}
+
+func (r *CacheSnapshotReconciler) createCacheSnapshot(awsClient ElastiCacheAPI, cr *awsv1alpha1.CacheSnapshot,
+	cacheClusterId *string, replicationGroupId *string) (*types.Snapshot, error) {
+	params := &elasticache.CreateSnapshotInput{
+		SnapshotName:       cacheSnapshotName(cr),
+		CacheClusterId:     cacheClusterId,
+		ReplicationGroupId: replicationGroupId,
+		KmsKeyId:           cr.Spec.KmsKeyId,
+		Tags:               desiredTags(nil, cr.Spec.Tags, cr),
+	}
+
+	output, err := awsClient.CreateSnapshot(context.TODO(), params)
+	if err != nil {
+		return &types.Snapshot{}, err
+	}
+	return output.Snapshot, nil
+}
+
+// exportCacheSnapshot copies the snapshot into an Amazon S3 bucket. ElastiCache
+// reports the source snapshot as exporting until the copy completes.
+func (r *CacheSnapshotReconciler) exportCacheSnapshot(awsClient ElastiCacheAPI, cr *awsv1alpha1.CacheSnapshot,
+	targetBucket string, targetSnapshotName string) (*types.Snapshot, error) {
+	params := &elasticache.CopySnapshotInput{
+		SourceSnapshotName: cacheSnapshotName(cr),
+		TargetSnapshotName: &targetSnapshotName,
+		TargetBucket:       &targetBucket,
+		KmsKeyId:           cr.Spec.KmsKeyId,
+	}
+
+	output, err := awsClient.CopySnapshot(context.TODO(), params)
+	if err != nil {
+		return &types.Snapshot{}, err
+	}
+	return output.Snapshot, nil
+}
+
+func (r *CacheSnapshotReconciler) getCacheSnapshot(awsClient ElastiCacheAPI, cr *awsv1alpha1.CacheSnapshot) (*types.Snapshot, error) {
+	params := &elasticache.DescribeSnapshotsInput{
+		SnapshotName: cacheSnapshotName(cr),
+	}
+
+	output, err := awsClient.DescribeSnapshots(context.TODO(), params)
+	if err != nil {
+		return &types.Snapshot{}, err
+	}
+
+	if len(output.Snapshots) == 1 {
+		return &output.Snapshots[0], nil
+	}
+	return &types.Snapshot{}, &types.SnapshotNotFoundFault{
+		Message: aws.String(fmt.Sprintf("Snapshot %s not found.", aws.ToString(cacheSnapshotName(cr)))),
+	}
+}
+
+func (r *CacheSnapshotReconciler) deleteCacheSnapshot(awsClient ElastiCacheAPI, cr *awsv1alpha1.CacheSnapshot) error {
+	params := &elasticache.DeleteSnapshotInput{
+		SnapshotName: cacheSnapshotName(cr),
+	}
+
+	_, err := awsClient.DeleteSnapshot(context.TODO(), params)
+	return err
+}
+
+func isSnapshotNotFound(err error) bool {
+	var notFound *types.SnapshotNotFoundFault
+	return goerrors.As(err, &notFound)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *CacheSnapshotReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&awsv1alpha1.CacheSnapshot{}, specChanged()).
+		Complete(r)
+}
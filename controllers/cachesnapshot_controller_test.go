@@ -0,0 +1,295 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+	"github.com/sergeyshevch/cloud-resource-operator/internal/fake"
+)
+
+var _ = Describe("CacheSnapshot controller", func() {
+	var (
+		ctx          context.Context
+		fakeAPI      *fake.ElastiCache
+		reconciler   *CacheSnapshotReconciler
+		elasticCache *awsv1alpha1.ElasticCache
+		instance     *awsv1alpha1.CacheSnapshot
+		key          client.ObjectKey
+	)
+
+	reconcile := func() (ctrl.Result, error) {
+		return reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	}
+
+	get := func() *awsv1alpha1.CacheSnapshot {
+		current := &awsv1alpha1.CacheSnapshot{}
+		Expect(k8sClient.Get(ctx, key, current)).To(Succeed())
+		return current
+	}
+
+	describe := func() (types.Snapshot, bool) {
+		output, err := fakeAPI.DescribeSnapshots(ctx, &elasticache.DescribeSnapshotsInput{SnapshotName: aws.String(namespacedHashId(key.Namespace, key.Name))})
+		if isSnapshotNotFound(err) {
+			return types.Snapshot{}, false
+		}
+		Expect(err).NotTo(HaveOccurred())
+		return output.Snapshots[0], true
+	}
+
+	// createAvailable reconciles the CacheSnapshot until its snapshot is
+	// available.
+	createAvailable := func() {
+		markReady(ctx, elasticCache, &elasticCache.Status.Conditions)
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		fakeAPI.Tick()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(aws.ToString(get().Status.SnapshotStatus)).To(Equal("available"))
+		fakeAPI.ResetCalls()
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		fakeAPI = fake.New()
+		reconciler = &CacheSnapshotReconciler{
+			Client:               k8sClient,
+			Scheme:               scheme.Scheme,
+			NewElastiCacheClient: fakeClientFunc(fakeAPI),
+		}
+
+		elasticCache = &awsv1alpha1.ElasticCache{
+			Spec: awsv1alpha1.ElasticCacheSpec{
+				AWSConfig: &awsv1alpha1.ElasticCacheAwsConfig{
+					CacheNodeType: aws.String("cache.t3.micro"),
+					Engine:        aws.String("redis"),
+					NumCacheNodes: aws.Int32(1),
+				},
+			},
+		}
+		createTestObject(ctx, elasticCache, "cache-")
+		fakeAPI.PutCacheCluster(types.CacheCluster{
+			CacheClusterId:     aws.String(elasticCache.Name),
+			CacheClusterStatus: aws.String("available"),
+			CacheNodeType:      aws.String("cache.t3.micro"),
+			Engine:             aws.String("redis"),
+			EngineVersion:      aws.String("6.2.5"),
+			NumCacheNodes:      aws.Int32(1),
+		})
+
+		instance = &awsv1alpha1.CacheSnapshot{
+			Spec: awsv1alpha1.CacheSnapshotSpec{
+				ElasticCacheRef: &awsv1alpha1.LocalObjectReference{Name: elasticCache.Name},
+			},
+		}
+		key = createTestObject(ctx, instance, "snapshot-")
+	})
+
+	AfterEach(func() {
+		Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, elasticCache))).To(Succeed())
+		removeTestObject(ctx, key, &awsv1alpha1.CacheSnapshot{})
+	})
+
+	It("waits for the referenced ElasticCache to be Ready before taking the snapshot", func() {
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("CreateSnapshot"))
+		ready := meta.FindStatusCondition(get().Status.Conditions, awsv1alpha1.ConditionTypeReady)
+		Expect(ready).NotTo(BeNil())
+		Expect(ready.Reason).To(Equal(awsv1alpha1.ReasonWaitingForRefs))
+
+		markReady(ctx, elasticCache, &elasticCache.Status.Conditions)
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		snapshot, ok := describe()
+		Expect(ok).To(BeTrue())
+		Expect(aws.ToString(snapshot.CacheClusterId)).To(Equal(elasticCache.Name))
+		Expect(aws.ToString(snapshot.SnapshotSource)).To(Equal("manual"))
+		current := get()
+		Expect(controllerutil.ContainsFinalizer(current, cacheSnapshotFinalizer)).To(BeTrue())
+		Expect(aws.ToString(current.Status.SnapshotName)).To(Equal(namespacedHashId(key.Namespace, key.Name)))
+		Expect(aws.ToString(current.Status.SnapshotStatus)).To(Equal("creating"))
+		Expect(meta.IsStatusConditionTrue(current.Status.Conditions, awsv1alpha1.ConditionTypeReady)).To(BeFalse())
+
+		fakeAPI.Tick()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		current = get()
+		Expect(aws.ToString(current.Status.SnapshotStatus)).To(Equal("available"))
+		Expect(aws.ToString(current.Status.CacheClusterId)).To(Equal(elasticCache.Name))
+		Expect(aws.ToString(current.Status.Engine)).To(Equal("redis"))
+		Expect(meta.IsStatusConditionTrue(current.Status.Conditions, awsv1alpha1.ConditionTypeReady)).To(BeTrue())
+	})
+
+	It("takes a snapshot of a referenced ReplicationGroup", func() {
+		replicationGroup := &awsv1alpha1.ReplicationGroup{
+			Spec: awsv1alpha1.ReplicationGroupSpec{
+				AWSConfig: &awsv1alpha1.ReplicationGroupAwsConfig{
+					ReplicationGroupDescription: aws.String("test"),
+					CacheNodeType:               aws.String("cache.t3.micro"),
+				},
+			},
+		}
+		createTestObject(ctx, replicationGroup, "group-")
+		defer func() {
+			Expect(k8sClient.Delete(ctx, replicationGroup)).To(Succeed())
+		}()
+		markReady(ctx, replicationGroup, &replicationGroup.Status.Conditions)
+		_, err := fakeAPI.CreateReplicationGroup(ctx, &elasticache.CreateReplicationGroupInput{
+			ReplicationGroupId:          aws.String(replicationGroup.Name),
+			ReplicationGroupDescription: aws.String("test"),
+			CacheNodeType:               aws.String("cache.t3.micro"),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		fakeAPI.Tick()
+
+		current := get()
+		current.Spec.ElasticCacheRef = nil
+		current.Spec.ReplicationGroupRef = &awsv1alpha1.LocalObjectReference{Name: replicationGroup.Name}
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		snapshot, ok := describe()
+		Expect(ok).To(BeTrue())
+		Expect(aws.ToString(snapshot.ReplicationGroupId)).To(Equal(replicationGroup.Name))
+		Expect(aws.ToString(get().Status.ReplicationGroupId)).To(Equal(replicationGroup.Name))
+	})
+
+	It("exports the snapshot to S3 once it is available", func() {
+		current := get()
+		current.Spec.ExportTo = &awsv1alpha1.SnapshotExport{TargetBucket: "backups"}
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+
+		markReady(ctx, elasticCache, &elasticCache.Status.Conditions)
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("CopySnapshot"))
+
+		fakeAPI.Tick()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).To(ContainElement("CopySnapshot"))
+		current = get()
+		Expect(current.Status.Export).NotTo(BeNil())
+		Expect(current.Status.Export.TargetBucket).To(Equal("backups"))
+		Expect(current.Status.Export.TargetSnapshotName).To(Equal(namespacedHashId(key.Namespace, key.Name)))
+		Expect(aws.ToString(current.Status.SnapshotStatus)).To(Equal("exporting"))
+
+		// The snapshot is only exported once.
+		fakeAPI.Tick()
+		fakeAPI.ResetCalls()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("CopySnapshot"))
+		Expect(aws.ToString(get().Status.SnapshotStatus)).To(Equal("available"))
+	})
+
+	It("deletes the snapshot with the CacheSnapshot by default", func() {
+		createAvailable()
+
+		Expect(k8sClient.Delete(ctx, get())).To(Succeed())
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).To(ContainElement("DeleteSnapshot"))
+		err = k8sClient.Get(ctx, key, &awsv1alpha1.CacheSnapshot{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+
+		fakeAPI.Tick()
+		_, ok := describe()
+		Expect(ok).To(BeFalse())
+	})
+
+	It("keeps the snapshot when the deletion policy is Retain", func() {
+		current := get()
+		current.Spec.DeletionPolicy = awsv1alpha1.DeletionPolicyRetain
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+		createAvailable()
+
+		Expect(k8sClient.Delete(ctx, get())).To(Succeed())
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("DeleteSnapshot"))
+		err = k8sClient.Get(ctx, key, &awsv1alpha1.CacheSnapshot{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+
+		fakeAPI.Tick()
+		_, ok := describe()
+		Expect(ok).To(BeTrue())
+	})
+
+	It("does not take a new snapshot when the snapshot is deleted outside of the operator", func() {
+		createAvailable()
+		_, err := fakeAPI.DeleteSnapshot(ctx, &elasticache.DeleteSnapshotInput{
+			SnapshotName: aws.String(namespacedHashId(key.Namespace, key.Name)),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		fakeAPI.Tick()
+
+		result, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{}))
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("CreateSnapshot"))
+		ready := meta.FindStatusCondition(get().Status.Conditions, awsv1alpha1.ConditionTypeReady)
+		Expect(ready).NotTo(BeNil())
+		Expect(ready.Reason).To(Equal(awsv1alpha1.ReasonFailed))
+		Expect(meta.IsStatusConditionTrue(get().Status.Conditions, awsv1alpha1.ConditionTypeError)).To(BeTrue())
+	})
+
+	It("refuses to manage a snapshot it does not own", func() {
+		_, err := fakeAPI.CreateSnapshot(ctx, &elasticache.CreateSnapshotInput{
+			SnapshotName:   aws.String(namespacedHashId(key.Namespace, key.Name)),
+			CacheClusterId: aws.String(elasticCache.Name),
+			Tags:           []types.Tag{{Key: aws.String(ownerTagKey), Value: aws.String("other/snapshot")}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		fakeAPI.Tick()
+		fakeAPI.ResetCalls()
+
+		markReady(ctx, elasticCache, &elasticCache.Status.Conditions)
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		ready := meta.FindStatusCondition(get().Status.Conditions, awsv1alpha1.ConditionTypeReady)
+		Expect(ready).NotTo(BeNil())
+		Expect(ready.Reason).To(Equal(awsv1alpha1.ReasonNotOwned))
+		Expect(ready.Message).To(ContainSubstring("other/snapshot"))
+		Expect(controllerutil.ContainsFinalizer(get(), cacheSnapshotFinalizer)).To(BeFalse())
+
+		// Deleting the CacheSnapshot leaves the snapshot alone.
+		Expect(k8sClient.Delete(ctx, get())).To(Succeed())
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("DeleteSnapshot"))
+		_, ok := describe()
+		Expect(ok).To(BeTrue())
+	})
+})
@@ -0,0 +1,376 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+)
+
+// Labels and annotations set on the CacheSnapshots created by a schedule.
+var snapshotScheduleLabel = "aws.sergeyshevch.dev/snapshot-schedule"
+var snapshotRuleLabel = "aws.sergeyshevch.dev/snapshot-rule"
+var scheduledTimeAnnotation = "aws.sergeyshevch.dev/scheduled-at"
+
+// maxMissedRuns caps how many missed runs of a rule are walked through before
+// skipping ahead, like the CronJob controller does.
+const maxMissedRuns = 100
+
+// CacheSnapshotScheduleReconciler reconciles a CacheSnapshotSchedule object
+type CacheSnapshotScheduleReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// now returns the current time, defaults to time.Now.
+	now func() time.Time
+}
+
+//+kubebuilder:rbac:groups=aws.sergeyshevch.dev,resources=cachesnapshotschedules,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=aws.sergeyshevch.dev,resources=cachesnapshotschedules/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=aws.sergeyshevch.dev,resources=cachesnapshotschedules/finalizers,verbs=update
+//+kubebuilder:rbac:groups=aws.sergeyshevch.dev,resources=cachesnapshots,verbs=get;list;watch;create;delete
+
+// Reconcile creates a CacheSnapshot for every rule whose schedule is due and
+// deletes the snapshots of a rule that fall out of its retention. Snapshots
+// are not owned by the schedule, so deleting it keeps the existing snapshots.
+func (r *CacheSnapshotScheduleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	defer observeReconcileDuration("CacheSnapshotSchedule", time.Now())
+
+	instance := &awsv1alpha1.CacheSnapshotSchedule{}
+	err := r.Client.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	result, err := r.reconcileCacheSnapshotSchedule(ctx, instance)
+	if err != nil {
+		setReconcileErrorConditions(&instance.Status.Conditions, instance, err)
+		if statusErr := r.Status().Update(context.TODO(), instance); statusErr != nil {
+			log.FromContext(ctx).Error(statusErr, "unable to update CacheSnapshotSchedule status")
+		}
+		return ctrl.Result{}, err
+	}
+	return result, nil
+}
+
+func (r *CacheSnapshotScheduleReconciler) reconcileCacheSnapshotSchedule(ctx context.Context, instance *awsv1alpha1.CacheSnapshotSchedule) (ctrl.Result, error) {
+	if instance.GetDeletionTimestamp() != nil {
+		return ctrl.Result{}, nil
+	}
+
+	// An invalid spec cannot be fixed by retrying, wait for it to change
+	// instead.
+	schedules, err := parseRuleSchedules(instance)
+	if err != nil {
+		setFailedConditions(&instance.Status.Conditions, instance, err.Error())
+		instance.Status.ObservedGeneration = instance.GetGeneration()
+		return ctrl.Result{}, r.Status().Update(context.TODO(), instance)
+	}
+
+	snapshots := &awsv1alpha1.CacheSnapshotList{}
+	err = r.List(ctx, snapshots,
+		client.InNamespace(instance.Namespace),
+		client.MatchingLabels{snapshotScheduleLabel: instance.Name})
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	now := time.Now()
+	if r.now != nil {
+		now = r.now()
+	}
+	var requeueAfter time.Duration
+	var ruleStatuses []awsv1alpha1.SnapshotScheduleRuleStatus
+	for i, rule := range instance.Spec.Rules {
+		schedule := schedules[i]
+		ruleSnapshots := snapshotsOfRule(snapshots.Items, rule.Name)
+		lastScheduleTime := lastScheduleTimeOfRule(instance, rule.Name, ruleSnapshots)
+
+		earliest := lastScheduleTime
+		if deadline := instance.Spec.StartingDeadlineSeconds; deadline != nil {
+			if cutoff := now.Add(-time.Duration(*deadline) * time.Second); cutoff.After(earliest) {
+				earliest = cutoff
+			}
+		}
+		if missed := lastMissedRun(schedule, earliest, now); !missed.IsZero() && !instance.Spec.Suspend {
+			snapshot, err := r.createScheduledSnapshot(ctx, instance, rule.Name, missed)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			ruleSnapshots = append([]awsv1alpha1.CacheSnapshot{*snapshot}, ruleSnapshots...)
+			lastScheduleTime = missed
+		}
+
+		kept, expired := applyRetention(rule, ruleSnapshots, now)
+		for i := range expired {
+			err = r.Delete(ctx, &expired[i])
+			if err != nil && !errors.IsNotFound(err) {
+				return ctrl.Result{}, err
+			}
+		}
+
+		next := schedule.Next(now)
+		if requeueAfter == 0 || next.Sub(now) < requeueAfter {
+			requeueAfter = next.Sub(now)
+		}
+
+		ruleStatus := awsv1alpha1.SnapshotScheduleRuleStatus{
+			Name:             rule.Name,
+			NextScheduleTime: &metav1.Time{Time: next},
+		}
+		if !lastScheduleTime.Equal(instance.CreationTimestamp.Time) {
+			ruleStatus.LastScheduleTime = &metav1.Time{Time: lastScheduleTime}
+		}
+		for _, snapshot := range kept {
+			ruleStatus.Snapshots = append(ruleStatus.Snapshots, snapshot.Name)
+		}
+		ruleStatuses = append(ruleStatuses, ruleStatus)
+	}
+
+	status := &instance.Status
+	status.ObservedGeneration = instance.GetGeneration()
+	status.Rules = ruleStatuses
+	setScheduleConditions(&status.Conditions, instance)
+	nowTime := metav1.NewTime(now)
+	status.LastSyncTime = &nowTime
+
+	err = r.Status().Update(context.TODO(), instance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// parseRuleSchedules validates the spec of the schedule and parses the
+// schedules of its rules in order.
+func parseRuleSchedules(instance *awsv1alpha1.CacheSnapshotSchedule) ([]cron.Schedule, error) {
+	if (instance.Spec.ElasticCacheRef == nil) == (instance.Spec.ReplicationGroupRef == nil) {
+		return nil, fmt.Errorf("exactly one of elasticCacheRef and replicationGroupRef must be set")
+	}
+
+	schedules := make([]cron.Schedule, len(instance.Spec.Rules))
+	for i, rule := range instance.Spec.Rules {
+		schedule, err := cron.ParseStandard(rule.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s has an invalid schedule %q: %w", rule.Name, rule.Schedule, err)
+		}
+		schedules[i] = schedule
+	}
+	return schedules, nil
+}
+
+// createScheduledSnapshot creates the CacheSnapshot of a rule for the run
+// scheduled at scheduledTime. Its name is derived from the run so a retried
+// reconciliation does not create a second snapshot, while the snapshot in AWS
+// is named after the namespace as well, see cacheSnapshotName. An existing
+// CacheSnapshot of that name is only taken as the run when the schedule
+// created it.
+func (r *CacheSnapshotScheduleReconciler) createScheduledSnapshot(ctx context.Context, instance *awsv1alpha1.CacheSnapshotSchedule,
+	ruleName string, scheduledTime time.Time) (*awsv1alpha1.CacheSnapshot, error) {
+	snapshot := &awsv1alpha1.CacheSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s-%d", instance.Name, ruleName, scheduledTime.Unix()),
+			Namespace: instance.Namespace,
+			Labels: map[string]string{
+				snapshotScheduleLabel: instance.Name,
+				snapshotRuleLabel:     ruleName,
+			},
+			Annotations: map[string]string{
+				scheduledTimeAnnotation: scheduledTime.UTC().Format(time.RFC3339),
+			},
+		},
+		Spec: awsv1alpha1.CacheSnapshotSpec{
+			ElasticCacheRef:     instance.Spec.ElasticCacheRef,
+			ReplicationGroupRef: instance.Spec.ReplicationGroupRef,
+			KmsKeyId:            instance.Spec.KmsKeyId,
+			Tags:                instance.Spec.Tags,
+			DeletionPolicy:      awsv1alpha1.DeletionPolicyDelete,
+		},
+	}
+
+	err := r.Create(ctx, snapshot)
+	if errors.IsAlreadyExists(err) {
+		existing := &awsv1alpha1.CacheSnapshot{}
+		err = r.Get(ctx, client.ObjectKeyFromObject(snapshot), existing)
+		if err != nil {
+			return nil, err
+		}
+		if existing.Labels[snapshotScheduleLabel] != instance.Name || existing.Labels[snapshotRuleLabel] != ruleName {
+			return nil, fmt.Errorf("CacheSnapshot %s already exists and was not created by rule %s", existing.Name, ruleName)
+		}
+		return existing, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// snapshotsOfRule returns the snapshots created by the named rule that are not
+// being deleted, newest first.
+func snapshotsOfRule(snapshots []awsv1alpha1.CacheSnapshot, ruleName string) []awsv1alpha1.CacheSnapshot {
+	var result []awsv1alpha1.CacheSnapshot
+	for _, snapshot := range snapshots {
+		if snapshot.Labels[snapshotRuleLabel] != ruleName || snapshot.GetDeletionTimestamp() != nil {
+			continue
+		}
+		result = append(result, snapshot)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return scheduledTime(&result[i]).After(scheduledTime(&result[j]))
+	})
+	return result
+}
+
+// scheduledTime returns the time the snapshot was scheduled for, falling back
+// to its creation time.
+func scheduledTime(snapshot *awsv1alpha1.CacheSnapshot) time.Time {
+	if value, ok := snapshot.Annotations[scheduledTimeAnnotation]; ok {
+		if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+			return parsed
+		}
+	}
+	return snapshot.CreationTimestamp.Time
+}
+
+// lastScheduleTimeOfRule returns the latest run of the rule recorded in the
+// status or by its snapshots, or the creation time of the schedule when the
+// rule never ran.
+func lastScheduleTimeOfRule(instance *awsv1alpha1.CacheSnapshotSchedule, ruleName string, ruleSnapshots []awsv1alpha1.CacheSnapshot) time.Time {
+	last := instance.CreationTimestamp.Time
+	for _, ruleStatus := range instance.Status.Rules {
+		if ruleStatus.Name == ruleName && ruleStatus.LastScheduleTime != nil && ruleStatus.LastScheduleTime.After(last) {
+			last = ruleStatus.LastScheduleTime.Time
+		}
+	}
+	if len(ruleSnapshots) > 0 {
+		if newest := scheduledTime(&ruleSnapshots[0]); newest.After(last) {
+			last = newest
+		}
+	}
+	return last
+}
+
+// lastMissedRun returns the latest run of schedule after last that is not in
+// the future, or the zero time when no run is due. Only the latest of several
+// missed runs is taken. After maxMissedRuns runs the walk skips ahead to one
+// interval before now, which finds the latest run of regular schedules.
+func lastMissedRun(schedule cron.Schedule, last time.Time, now time.Time) time.Time {
+	var missed time.Time
+	runs := 0
+	for t := schedule.Next(last); !t.After(now); t = schedule.Next(t) {
+		missed = t
+		if runs++; runs == maxMissedRuns {
+			if skipTo := now.Add(-schedule.Next(t).Sub(t)); skipTo.After(t) {
+				t = skipTo
+			}
+		}
+	}
+	return missed
+}
+
+// applyRetention splits snapshots, sorted newest first, into the ones kept by
+// the KeepLast and KeepWithin rules and the expired ones.
+func applyRetention(rule awsv1alpha1.SnapshotScheduleRule, snapshots []awsv1alpha1.CacheSnapshot,
+	now time.Time) ([]awsv1alpha1.CacheSnapshot, []awsv1alpha1.CacheSnapshot) {
+	if rule.KeepLast == nil && rule.KeepWithin == nil {
+		return snapshots, nil
+	}
+
+	var kept, expired []awsv1alpha1.CacheSnapshot
+	for i, snapshot := range snapshots {
+		keep := rule.KeepLast != nil && int32(i) < *rule.KeepLast
+		if rule.KeepWithin != nil && now.Sub(scheduledTime(&snapshot)) < rule.KeepWithin.Duration {
+			keep = true
+		}
+		if keep {
+			kept = append(kept, snapshot)
+		} else {
+			expired = append(expired, snapshot)
+		}
+	}
+	return kept, expired
+}
+
+// setScheduleConditions records a successful reconciliation of the schedule.
+func setScheduleConditions(conditions *[]metav1.Condition, instance *awsv1alpha1.CacheSnapshotSchedule) {
+	generation := instance.GetGeneration()
+	ready := metav1.Condition{
+		Type:               awsv1alpha1.ConditionTypeReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             awsv1alpha1.ReasonAvailable,
+		ObservedGeneration: generation,
+		Message:            "Snapshots are taken on schedule",
+	}
+	if instance.Spec.Suspend {
+		ready.Status = metav1.ConditionFalse
+		ready.Reason = awsv1alpha1.ReasonSuspended
+		ready.Message = "Snapshots are suspended"
+	}
+
+	meta.SetStatusCondition(conditions, ready)
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               awsv1alpha1.ConditionTypeSynced,
+		Status:             metav1.ConditionTrue,
+		Reason:             awsv1alpha1.ReasonReconcileSuccess,
+		ObservedGeneration: generation,
+	})
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               awsv1alpha1.ConditionTypeError,
+		Status:             metav1.ConditionFalse,
+		Reason:             awsv1alpha1.ReasonReconcileSuccess,
+		ObservedGeneration: generation,
+	})
+}
+
+// findScheduleForSnapshot maps a CacheSnapshot to the schedule that created it.
+func (r *CacheSnapshotScheduleReconciler) findScheduleForSnapshot(snapshot client.Object) []reconcile.Request {
+	name, ok := snapshot.GetLabels()[snapshotScheduleLabel]
+	if !ok {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: snapshot.GetNamespace(), Name: name}}}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *CacheSnapshotScheduleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&awsv1alpha1.CacheSnapshotSchedule{}, specChanged()).
+		Watches(&source.Kind{Type: &awsv1alpha1.CacheSnapshot{}}, handler.EnqueueRequestsFromMapFunc(r.findScheduleForSnapshot)).
+		Complete(r)
+}
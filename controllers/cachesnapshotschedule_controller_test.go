@@ -0,0 +1,358 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/robfig/cron/v3"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+)
+
+var _ = Describe("CacheSnapshotSchedule controller", func() {
+	const hourly = "0 * * * *"
+
+	var (
+		ctx        context.Context
+		reconciler *CacheSnapshotScheduleReconciler
+		instance   *awsv1alpha1.CacheSnapshotSchedule
+		key        client.ObjectKey
+		created    time.Time
+	)
+
+	reconcile := func() (ctrl.Result, error) {
+		return reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	}
+
+	get := func() *awsv1alpha1.CacheSnapshotSchedule {
+		current := &awsv1alpha1.CacheSnapshotSchedule{}
+		Expect(k8sClient.Get(ctx, key, current)).To(Succeed())
+		return current
+	}
+
+	update := func(mutate func(*awsv1alpha1.CacheSnapshotSchedule)) {
+		current := get()
+		mutate(current)
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+	}
+
+	// at sets the clock of the reconciler to the creation of the schedule plus
+	// elapsed.
+	at := func(elapsed time.Duration) time.Time {
+		now := created.Add(elapsed)
+		reconciler.now = func() time.Time { return now }
+		return now
+	}
+
+	// nextRun returns the first run of the hourly schedule after t.
+	nextRun := func(t time.Time) time.Time {
+		schedule, err := cron.ParseStandard(hourly)
+		Expect(err).NotTo(HaveOccurred())
+		return schedule.Next(t)
+	}
+
+	snapshots := func() []awsv1alpha1.CacheSnapshot {
+		list := &awsv1alpha1.CacheSnapshotList{}
+		Expect(k8sClient.List(ctx, list, client.InNamespace(key.Namespace),
+			client.MatchingLabels{snapshotScheduleLabel: key.Name})).To(Succeed())
+		return list.Items
+	}
+
+	// createSnapshot creates a snapshot of the rule as if it was taken at
+	// scheduledAt.
+	createSnapshot := func(scheduledAt time.Time) string {
+		snapshot := &awsv1alpha1.CacheSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        fmt.Sprintf("%s-hourly-%d", key.Name, scheduledAt.Unix()),
+				Namespace:   key.Namespace,
+				Labels:      map[string]string{snapshotScheduleLabel: key.Name, snapshotRuleLabel: "hourly"},
+				Annotations: map[string]string{scheduledTimeAnnotation: scheduledAt.UTC().Format(time.RFC3339)},
+			},
+			Spec: awsv1alpha1.CacheSnapshotSpec{
+				ElasticCacheRef: &awsv1alpha1.LocalObjectReference{Name: "cache"},
+			},
+		}
+		Expect(k8sClient.Create(ctx, snapshot)).To(Succeed())
+		return snapshot.Name
+	}
+
+	snapshotNames := func() []string {
+		var names []string
+		for _, snapshot := range snapshots() {
+			names = append(names, snapshot.Name)
+		}
+		return names
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		reconciler = &CacheSnapshotScheduleReconciler{
+			Client: k8sClient,
+			Scheme: scheme.Scheme,
+		}
+
+		instance = &awsv1alpha1.CacheSnapshotSchedule{
+			ObjectMeta: metav1.ObjectMeta{
+				// The API server sets its own creation time, the fake client
+				// keeps this one.
+				CreationTimestamp: metav1.NewTime(time.Now().Truncate(time.Second)),
+			},
+			Spec: awsv1alpha1.CacheSnapshotScheduleSpec{
+				ElasticCacheRef: &awsv1alpha1.LocalObjectReference{Name: "cache"},
+				Rules: []awsv1alpha1.SnapshotScheduleRule{
+					{Name: "hourly", Schedule: hourly},
+				},
+			},
+		}
+		key = createTestObject(ctx, instance, "schedule-")
+		created = get().CreationTimestamp.Time
+	})
+
+	AfterEach(func() {
+		items := snapshots()
+		for i := range items {
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &items[i]))).To(Succeed())
+		}
+		Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, get()))).To(Succeed())
+	})
+
+	It("waits for the first run of the schedule", func() {
+		now := at(0)
+
+		result, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(snapshots()).To(BeEmpty())
+		Expect(result.RequeueAfter).To(Equal(nextRun(now).Sub(now)))
+
+		current := get()
+		Expect(current.Status.Rules).To(HaveLen(1))
+		Expect(current.Status.Rules[0].LastScheduleTime).To(BeNil())
+		Expect(current.Status.Rules[0].NextScheduleTime.Time).To(BeTemporally("==", nextRun(now)))
+		Expect(meta.IsStatusConditionTrue(current.Status.Conditions, awsv1alpha1.ConditionTypeReady)).To(BeTrue())
+	})
+
+	It("creates a CacheSnapshot when a run is due", func() {
+		run := nextRun(created)
+		now := at(run.Sub(created) + time.Minute)
+
+		result, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(nextRun(now).Sub(now)))
+
+		items := snapshots()
+		Expect(items).To(HaveLen(1))
+		snapshot := items[0]
+		Expect(snapshot.Name).To(Equal(fmt.Sprintf("%s-hourly-%d", key.Name, run.Unix())))
+		Expect(snapshot.Labels).To(HaveKeyWithValue(snapshotRuleLabel, "hourly"))
+		Expect(snapshot.Annotations).To(HaveKeyWithValue(scheduledTimeAnnotation, run.UTC().Format(time.RFC3339)))
+		Expect(snapshot.Spec.ElasticCacheRef).To(Equal(instance.Spec.ElasticCacheRef))
+		Expect(snapshot.Spec.DeletionPolicy).To(Equal(awsv1alpha1.DeletionPolicyDelete))
+		Expect(snapshot.OwnerReferences).To(BeEmpty())
+
+		ruleStatus := get().Status.Rules[0]
+		Expect(ruleStatus.LastScheduleTime.Time).To(BeTemporally("==", run))
+		Expect(ruleStatus.Snapshots).To(Equal([]string{snapshot.Name}))
+
+		// The run is only taken once.
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(snapshots()).To(HaveLen(1))
+	})
+
+	It("takes only the latest of several missed runs", func() {
+		first := nextRun(created)
+		latest := nextRun(nextRun(first))
+		at(latest.Sub(created) + time.Minute)
+
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(snapshotNames()).To(Equal([]string{fmt.Sprintf("%s-hourly-%d", key.Name, latest.Unix())}))
+	})
+
+	It("deletes the snapshots that fall out of the retention", func() {
+		update(func(current *awsv1alpha1.CacheSnapshotSchedule) {
+			current.Spec.Rules[0].KeepLast = aws.Int32(2)
+		})
+		createSnapshot(created.Add(-3 * time.Hour))
+		createSnapshot(created.Add(-2 * time.Hour))
+		old := createSnapshot(created.Add(-1 * time.Hour))
+
+		run := nextRun(created)
+		at(run.Sub(created) + time.Minute)
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		newest := fmt.Sprintf("%s-hourly-%d", key.Name, run.Unix())
+		Expect(snapshotNames()).To(ConsistOf(newest, old))
+		Expect(get().Status.Rules[0].Snapshots).To(Equal([]string{newest, old}))
+	})
+
+	It("does not take snapshots while it is suspended", func() {
+		update(func(current *awsv1alpha1.CacheSnapshotSchedule) {
+			current.Spec.Suspend = true
+		})
+		at(nextRun(created).Sub(created) + time.Minute)
+
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(snapshots()).To(BeEmpty())
+		ready := meta.FindStatusCondition(get().Status.Conditions, awsv1alpha1.ConditionTypeReady)
+		Expect(ready).NotTo(BeNil())
+		Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+		Expect(ready.Reason).To(Equal(awsv1alpha1.ReasonSuspended))
+	})
+
+	It("reports an invalid schedule without retrying", func() {
+		update(func(current *awsv1alpha1.CacheSnapshotSchedule) {
+			current.Spec.Rules[0].Schedule = "every hour"
+		})
+		at(time.Hour)
+
+		result, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{}))
+		ready := meta.FindStatusCondition(get().Status.Conditions, awsv1alpha1.ConditionTypeReady)
+		Expect(ready).NotTo(BeNil())
+		Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+		Expect(ready.Reason).To(Equal(awsv1alpha1.ReasonFailed))
+		Expect(ready.Message).To(ContainSubstring("rule hourly has an invalid schedule"))
+		Expect(snapshots()).To(BeEmpty())
+	})
+
+	It("skips runs missed by more than the starting deadline", func() {
+		missed := nextRun(created).Add(2 * time.Hour)
+		update(func(current *awsv1alpha1.CacheSnapshotSchedule) {
+			current.Spec.StartingDeadlineSeconds = aws.Int64(5 * 60)
+		})
+		at(missed.Add(10 * time.Minute).Sub(created))
+
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(snapshots()).To(BeEmpty())
+
+		update(func(current *awsv1alpha1.CacheSnapshotSchedule) {
+			current.Spec.StartingDeadlineSeconds = aws.Int64(15 * 60)
+		})
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(snapshotNames()).To(ConsistOf(fmt.Sprintf("%s-hourly-%d", key.Name, missed.Unix())))
+	})
+
+	It("does not take over a CacheSnapshot it did not create", func() {
+		run := nextRun(created)
+		foreign := &awsv1alpha1.CacheSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("%s-hourly-%d", key.Name, run.Unix()),
+				Namespace: key.Namespace,
+			},
+			Spec: awsv1alpha1.CacheSnapshotSpec{
+				ElasticCacheRef: &awsv1alpha1.LocalObjectReference{Name: "cache"},
+			},
+		}
+		Expect(k8sClient.Create(ctx, foreign)).To(Succeed())
+		defer removeTestObject(ctx, client.ObjectKeyFromObject(foreign), &awsv1alpha1.CacheSnapshot{})
+		at(run.Add(time.Minute).Sub(created))
+
+		_, err := reconcile()
+		Expect(err).To(MatchError(ContainSubstring("was not created by rule hourly")))
+		Expect(meta.IsStatusConditionFalse(get().Status.Conditions, awsv1alpha1.ConditionTypeSynced)).To(BeTrue())
+	})
+
+	It("maps a CacheSnapshot to the schedule that created it", func() {
+		name := createSnapshot(created)
+		snapshot := &awsv1alpha1.CacheSnapshot{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: key.Namespace, Name: name}, snapshot)).To(Succeed())
+		Expect(reconciler.findScheduleForSnapshot(snapshot)).To(ConsistOf(ctrl.Request{NamespacedName: key}))
+
+		Expect(reconciler.findScheduleForSnapshot(&awsv1alpha1.CacheSnapshot{})).To(BeEmpty())
+	})
+})
+
+var _ = Describe("applyRetention", func() {
+	now := time.Date(2021, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	// snapshotsEvery returns count snapshots taken every interval before now,
+	// newest first.
+	snapshotsEvery := func(interval time.Duration, count int) []awsv1alpha1.CacheSnapshot {
+		var snapshots []awsv1alpha1.CacheSnapshot
+		for i := 1; i <= count; i++ {
+			snapshots = append(snapshots, awsv1alpha1.CacheSnapshot{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: fmt.Sprintf("snapshot-%d", i),
+					Annotations: map[string]string{
+						scheduledTimeAnnotation: now.Add(-time.Duration(i) * interval).Format(time.RFC3339),
+					},
+				},
+			})
+		}
+		return snapshots
+	}
+
+	names := func(snapshots []awsv1alpha1.CacheSnapshot) []string {
+		var result []string
+		for _, snapshot := range snapshots {
+			result = append(result, snapshot.Name)
+		}
+		return result
+	}
+
+	DescribeTable("splits the snapshots of a rule",
+		func(rule awsv1alpha1.SnapshotScheduleRule, kept []string, expired []string) {
+			gotKept, gotExpired := applyRetention(rule, snapshotsEvery(time.Hour, 4), now)
+			Expect(names(gotKept)).To(Equal(kept))
+			Expect(names(gotExpired)).To(Equal(expired))
+		},
+		Entry("without retention", awsv1alpha1.SnapshotScheduleRule{},
+			[]string{"snapshot-1", "snapshot-2", "snapshot-3", "snapshot-4"}, nil),
+		Entry("keeping the last snapshots", awsv1alpha1.SnapshotScheduleRule{KeepLast: aws.Int32(1)},
+			[]string{"snapshot-1"}, []string{"snapshot-2", "snapshot-3", "snapshot-4"}),
+		Entry("keeping recent snapshots", awsv1alpha1.SnapshotScheduleRule{KeepWithin: &metav1.Duration{Duration: 150 * time.Minute}},
+			[]string{"snapshot-1", "snapshot-2"}, []string{"snapshot-3", "snapshot-4"}),
+		Entry("keeping the union of both rules", awsv1alpha1.SnapshotScheduleRule{KeepLast: aws.Int32(3), KeepWithin: &metav1.Duration{Duration: 90 * time.Minute}},
+			[]string{"snapshot-1", "snapshot-2", "snapshot-3"}, []string{"snapshot-4"}),
+		Entry("keeping nothing", awsv1alpha1.SnapshotScheduleRule{KeepLast: aws.Int32(0)},
+			nil, []string{"snapshot-1", "snapshot-2", "snapshot-3", "snapshot-4"}),
+	)
+})
+
+var _ = Describe("lastMissedRun", func() {
+	now := time.Date(2021, 7, 1, 12, 0, 30, 0, time.UTC)
+
+	DescribeTable("finds the latest run that is due",
+		func(spec string, last time.Time, want time.Time) {
+			schedule, err := cron.ParseStandard(spec)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lastMissedRun(schedule, last, now)).To(Equal(want))
+		},
+		Entry("no run when none is due", "0 * * * *", now.Add(-30*time.Second), time.Time{}),
+		Entry("the only missed run", "0 * * * *", now.Add(-time.Hour), time.Date(2021, 7, 1, 12, 0, 0, 0, time.UTC)),
+		Entry("the latest of several missed runs", "0 * * * *", now.Add(-5*time.Hour), time.Date(2021, 7, 1, 12, 0, 0, 0, time.UTC)),
+		Entry("the latest run after a long downtime", "* * * * *", now.AddDate(-1, 0, 0), time.Date(2021, 7, 1, 12, 0, 0, 0, time.UTC)),
+	)
+})
@@ -0,0 +1,249 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+)
+
+var cacheSubnetGroupFinalizer = elasticCacheFinalizer
+
+// CacheSubnetGroupReconciler reconciles a CacheSubnetGroup object
+type CacheSubnetGroupReconciler struct {
+	client.Client
+	AwsConfig aws.Config
+	Scheme    *runtime.Scheme
+
+	// NewElastiCacheClient builds the ElastiCache client, defaults to the
+	// client of the AWS SDK.
+	NewElastiCacheClient ElastiCacheClientFunc
+
+	// RequeueIntervals sets how often the AWS resources are polled.
+	RequeueIntervals RequeueIntervals
+
+	backoff requeueBackoff
+}
+
+//+kubebuilder:rbac:groups=aws.sergeyshevch.dev,resources=cachesubnetgroups,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=aws.sergeyshevch.dev,resources=cachesubnetgroups/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=aws.sergeyshevch.dev,resources=cachesubnetgroups/finalizers,verbs=update
+
+// Reconcile creates the cache subnet group and keeps its description and
+// subnets in line with the spec.
+func (r *CacheSubnetGroupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	defer observeReconcileDuration("CacheSubnetGroup", time.Now())
+
+	instance := &awsv1alpha1.CacheSubnetGroup{}
+	err := r.Client.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	result, err := r.reconcileCacheSubnetGroup(ctx, instance)
+	if err != nil {
+		result, err = handleReconcileError(&instance.Status.Conditions, instance, &r.backoff, isCacheSubnetGroupNotFound, err)
+		if statusErr := r.Status().Update(context.TODO(), instance); statusErr != nil {
+			log.FromContext(ctx).Error(statusErr, "unable to update CacheSubnetGroup status")
+		}
+		return result, err
+	}
+	r.backoff.reset(req.NamespacedName)
+	return result, nil
+}
+
+func (r *CacheSubnetGroupReconciler) reconcileCacheSubnetGroup(ctx context.Context, instance *awsv1alpha1.CacheSubnetGroup) (ctrl.Result, error) {
+	awsClient := newElastiCacheClient(r.NewElastiCacheClient, r.AwsConfig)
+
+	isCacheSubnetGroupMarkedToDeletion := instance.GetDeletionTimestamp() != nil
+	if isCacheSubnetGroupMarkedToDeletion {
+		if controllerutil.ContainsFinalizer(instance, cacheSubnetGroupFinalizer) {
+			err := r.deleteCacheSubnetGroup(awsClient, instance)
+			if err != nil && !isCacheSubnetGroupNotFound(err) {
+				return ctrl.Result{}, err
+			}
+
+			controllerutil.RemoveFinalizer(instance, cacheSubnetGroupFinalizer)
+			err = r.Update(ctx, instance)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if !controllerutil.ContainsFinalizer(instance, cacheSubnetGroupFinalizer) {
+		controllerutil.AddFinalizer(instance, cacheSubnetGroupFinalizer)
+		err := r.Update(ctx, instance)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	subnetGroup, err := r.getCacheSubnetGroup(awsClient, instance)
+	if err != nil {
+		if !isCacheSubnetGroupNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		subnetGroup, err = r.createCacheSubnetGroup(awsClient, instance)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	} else if isCacheSubnetGroupChanged(instance, subnetGroup) {
+		subnetGroup, err = r.patchCacheSubnetGroup(awsClient, instance)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	status := &instance.Status
+	status.ObservedGeneration = instance.GetGeneration()
+	status.ARN = subnetGroup.ARN
+	status.CacheSubnetGroupName = subnetGroup.CacheSubnetGroupName
+	status.VpcId = subnetGroup.VpcId
+	status.Subnets = nil
+	for _, subnet := range subnetGroup.Subnets {
+		observed := awsv1alpha1.Subnet{SubnetIdentifier: subnet.SubnetIdentifier}
+		if subnet.SubnetAvailabilityZone != nil {
+			observed.SubnetAvailabilityZone = subnet.SubnetAvailabilityZone.Name
+		}
+		status.Subnets = append(status.Subnets, observed)
+	}
+	setAwsResourceConditions(&status.Conditions, instance, "available")
+	now := metav1.Now()
+	status.LastSyncTime = &now
+
+	err = r.Status().Update(context.TODO(), instance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: r.RequeueIntervals.stable()}, nil
+}
+
+// isCacheSubnetGroupChanged reports whether the description or the subnets of
+// the subnet group in AWS differ from the spec.
+func isCacheSubnetGroupChanged(cr *awsv1alpha1.CacheSubnetGroup, subnetGroup *types.CacheSubnetGroup) bool {
+	if aws.ToString(subnetGroup.CacheSubnetGroupDescription) != cr.Spec.Description {
+		return true
+	}
+
+	current := make([]string, 0, len(subnetGroup.Subnets))
+	for _, subnet := range subnetGroup.Subnets {
+		current = append(current, aws.ToString(subnet.SubnetIdentifier))
+	}
+	desired := append([]string(nil), cr.Spec.SubnetIds...)
+	sort.Strings(current)
+	sort.Strings(desired)
+
+	if len(current) != len(desired) {
+		return true
+	}
+	for i := range current {
+		if current[i] != desired[i] {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *CacheSubnetGroupReconciler) createCacheSubnetGroup(awsClient ElastiCacheAPI, cr *awsv1alpha1.CacheSubnetGroup) (*types.CacheSubnetGroup, error) {
+	params := &elasticache.CreateCacheSubnetGroupInput{
+		CacheSubnetGroupName:        &cr.Name,
+		CacheSubnetGroupDescription: &cr.Spec.Description,
+		SubnetIds:                   cr.Spec.SubnetIds,
+		Tags:                        convertTags(cr.Spec.Tags),
+	}
+
+	output, err := awsClient.CreateCacheSubnetGroup(context.TODO(), params)
+	if err != nil {
+		return &types.CacheSubnetGroup{}, err
+	}
+	return output.CacheSubnetGroup, nil
+}
+
+func (r *CacheSubnetGroupReconciler) patchCacheSubnetGroup(awsClient ElastiCacheAPI, cr *awsv1alpha1.CacheSubnetGroup) (*types.CacheSubnetGroup, error) {
+	params := &elasticache.ModifyCacheSubnetGroupInput{
+		CacheSubnetGroupName:        &cr.Name,
+		CacheSubnetGroupDescription: &cr.Spec.Description,
+		SubnetIds:                   cr.Spec.SubnetIds,
+	}
+
+	output, err := awsClient.ModifyCacheSubnetGroup(context.TODO(), params)
+	if err != nil {
+		return &types.CacheSubnetGroup{}, err
+	}
+	return output.CacheSubnetGroup, nil
+}
+
+func (r *CacheSubnetGroupReconciler) getCacheSubnetGroup(awsClient ElastiCacheAPI, cr *awsv1alpha1.CacheSubnetGroup) (*types.CacheSubnetGroup, error) {
+	params := &elasticache.DescribeCacheSubnetGroupsInput{
+		CacheSubnetGroupName: &cr.Name,
+	}
+
+	output, err := awsClient.DescribeCacheSubnetGroups(context.TODO(), params)
+	if err != nil {
+		return &types.CacheSubnetGroup{}, err
+	}
+
+	if len(output.CacheSubnetGroups) == 1 {
+		return &output.CacheSubnetGroups[0], nil
+	}
+	return &types.CacheSubnetGroup{}, &types.CacheSubnetGroupNotFoundFault{
+		Message: aws.String(fmt.Sprintf("CacheSubnetGroup %s not found.", cr.Name)),
+	}
+}
+
+func (r *CacheSubnetGroupReconciler) deleteCacheSubnetGroup(awsClient ElastiCacheAPI, cr *awsv1alpha1.CacheSubnetGroup) error {
+	params := &elasticache.DeleteCacheSubnetGroupInput{
+		CacheSubnetGroupName: &cr.Name,
+	}
+
+	_, err := awsClient.DeleteCacheSubnetGroup(context.TODO(), params)
+	return err
+}
+
+func isCacheSubnetGroupNotFound(err error) bool {
+	var notFound *types.CacheSubnetGroupNotFoundFault
+	return goerrors.As(err, &notFound)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *CacheSubnetGroupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&awsv1alpha1.CacheSubnetGroup{}, specChanged()).
+		Complete(r)
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+	"github.com/sergeyshevch/cloud-resource-operator/internal/fake"
+)
+
+var _ = Describe("CacheSubnetGroup controller", func() {
+	var (
+		ctx        context.Context
+		fakeAPI    *fake.ElastiCache
+		reconciler *CacheSubnetGroupReconciler
+		instance   *awsv1alpha1.CacheSubnetGroup
+		key        client.ObjectKey
+	)
+
+	reconcile := func() (ctrl.Result, error) {
+		return reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	}
+
+	get := func() *awsv1alpha1.CacheSubnetGroup {
+		current := &awsv1alpha1.CacheSubnetGroup{}
+		Expect(k8sClient.Get(ctx, key, current)).To(Succeed())
+		return current
+	}
+
+	describe := func() types.CacheSubnetGroup {
+		output, err := fakeAPI.DescribeCacheSubnetGroups(ctx, &elasticache.DescribeCacheSubnetGroupsInput{
+			CacheSubnetGroupName: aws.String(key.Name),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		return output.CacheSubnetGroups[0]
+	}
+
+	subnetIds := func(group types.CacheSubnetGroup) []string {
+		var ids []string
+		for _, subnet := range group.Subnets {
+			ids = append(ids, aws.ToString(subnet.SubnetIdentifier))
+		}
+		return ids
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		fakeAPI = fake.New()
+		reconciler = &CacheSubnetGroupReconciler{
+			Client:               k8sClient,
+			Scheme:               scheme.Scheme,
+			NewElastiCacheClient: fakeClientFunc(fakeAPI),
+		}
+
+		instance = &awsv1alpha1.CacheSubnetGroup{
+			Spec: awsv1alpha1.CacheSubnetGroupSpec{
+				Description: "test",
+				SubnetIds:   []string{"subnet-a", "subnet-b"},
+			},
+		}
+		key = createTestObject(ctx, instance, "subnets-")
+	})
+
+	AfterEach(func() {
+		removeTestObject(ctx, key, &awsv1alpha1.CacheSubnetGroup{})
+	})
+
+	It("creates the subnet group and becomes Ready", func() {
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(subnetIds(describe())).To(Equal([]string{"subnet-a", "subnet-b"}))
+
+		current := get()
+		Expect(controllerutil.ContainsFinalizer(current, cacheSubnetGroupFinalizer)).To(BeTrue())
+		Expect(aws.ToString(current.Status.CacheSubnetGroupName)).To(Equal(key.Name))
+		Expect(current.Status.VpcId).NotTo(BeNil())
+		Expect(current.Status.Subnets).To(HaveLen(2))
+		Expect(meta.IsStatusConditionTrue(current.Status.Conditions, awsv1alpha1.ConditionTypeReady)).To(BeTrue())
+	})
+
+	It("modifies the subnet group when the spec changes", func() {
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		fakeAPI.ResetCalls()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("ModifyCacheSubnetGroup"))
+
+		current := get()
+		current.Spec.Description = "changed"
+		current.Spec.SubnetIds = []string{"subnet-b", "subnet-c"}
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		group := describe()
+		Expect(aws.ToString(group.CacheSubnetGroupDescription)).To(Equal("changed"))
+		Expect(subnetIds(group)).To(Equal([]string{"subnet-b", "subnet-c"}))
+		Expect(get().Status.Subnets).To(HaveLen(2))
+	})
+
+	It("does not retry subnets AWS rejects", func() {
+		current := get()
+		current.Spec.SubnetIds = []string{"invalid"}
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+
+		result, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{}))
+		Expect(meta.IsStatusConditionFalse(get().Status.Conditions, awsv1alpha1.ConditionTypeSynced)).To(BeTrue())
+	})
+
+	It("deletes the subnet group before removing the finalizer", func() {
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Delete(ctx, get())).To(Succeed())
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = fakeAPI.DescribeCacheSubnetGroups(ctx, &elasticache.DescribeCacheSubnetGroupsInput{
+			CacheSubnetGroupName: aws.String(key.Name),
+		})
+		Expect(isCacheSubnetGroupNotFound(err)).To(BeTrue())
+		err = k8sClient.Get(ctx, key, &awsv1alpha1.CacheSubnetGroup{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("keeps the finalizer while the subnet group is in use", func() {
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		fakeAPI.PutCacheCluster(types.CacheCluster{
+			CacheClusterId:       aws.String("user"),
+			CacheClusterStatus:   aws.String("available"),
+			CacheSubnetGroupName: aws.String(key.Name),
+		})
+
+		Expect(k8sClient.Delete(ctx, get())).To(Succeed())
+		result, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+		Expect(controllerutil.ContainsFinalizer(get(), cacheSubnetGroupFinalizer)).To(BeTrue())
+	})
+
+	It("lets referencing ElasticCaches wait until it is Ready", func() {
+		elasticCacheReconciler := &ElasticCacheReconciler{
+			Client:               k8sClient,
+			Scheme:               scheme.Scheme,
+			Recorder:             record.NewFakeRecorder(100),
+			NewElastiCacheClient: fakeClientFunc(fakeAPI),
+		}
+		elasticCache := &awsv1alpha1.ElasticCache{
+			Spec: awsv1alpha1.ElasticCacheSpec{
+				AWSConfig: &awsv1alpha1.ElasticCacheAwsConfig{
+					CacheNodeType:  aws.String("cache.t3.micro"),
+					Engine:         aws.String("redis"),
+					NumCacheNodes:  aws.Int32(1),
+					SubnetGroupRef: &awsv1alpha1.LocalObjectReference{Name: key.Name},
+				},
+			},
+		}
+		elasticCacheKey := createTestObject(ctx, elasticCache, "cache-")
+		defer removeTestObject(ctx, elasticCacheKey, &awsv1alpha1.ElasticCache{})
+
+		_, err := elasticCacheReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: elasticCacheKey})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("CreateCacheCluster"))
+		Expect(k8sClient.Get(ctx, elasticCacheKey, elasticCache)).To(Succeed())
+		ready := meta.FindStatusCondition(elasticCache.Status.Conditions, awsv1alpha1.ConditionTypeReady)
+		Expect(ready).NotTo(BeNil())
+		Expect(ready.Reason).To(Equal(awsv1alpha1.ReasonWaitingForRefs))
+
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		_, err = elasticCacheReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: elasticCacheKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Get(ctx, elasticCacheKey, elasticCache)).To(Succeed())
+		cluster, ok := fakeAPI.CacheCluster(aws.ToString(cacheClusterId(elasticCache)))
+		Expect(ok).To(BeTrue())
+		Expect(aws.ToString(cluster.CacheSubnetGroupName)).To(Equal(key.Name))
+	})
+})
@@ -0,0 +1,178 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+)
+
+// setAwsResourceConditions derives the Ready, Synced, Deleting and Error
+// conditions of obj from the state of its AWS resource as reported by AWS,
+// e.g. "available" or "creating".
+func setAwsResourceConditions(conditions *[]metav1.Condition, obj metav1.Object, resourceStatus string) {
+	generation := obj.GetGeneration()
+	ready := metav1.Condition{
+		Type:               awsv1alpha1.ConditionTypeReady,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: generation,
+		Message:            fmt.Sprintf("AWS resource is %s", resourceStatus),
+	}
+	deleting := metav1.Condition{
+		Type:               awsv1alpha1.ConditionTypeDeleting,
+		Status:             metav1.ConditionFalse,
+		Reason:             awsv1alpha1.ReasonAvailable,
+		ObservedGeneration: generation,
+	}
+	failed := false
+
+	switch resourceStatus {
+	case "available", "active":
+		ready.Status = metav1.ConditionTrue
+		ready.Reason = awsv1alpha1.ReasonAvailable
+	case "creating", "restoring":
+		ready.Reason = awsv1alpha1.ReasonProvisioning
+	case "deleting", "deleted":
+		ready.Reason = awsv1alpha1.ReasonDeleting
+	case "create-failed", "restore-failed", "incompatible-network", "failed":
+		ready.Reason = awsv1alpha1.ReasonFailed
+		failed = true
+	default:
+		ready.Reason = awsv1alpha1.ReasonModifying
+	}
+
+	if obj.GetDeletionTimestamp() != nil || ready.Reason == awsv1alpha1.ReasonDeleting {
+		deleting.Status = metav1.ConditionTrue
+		deleting.Reason = awsv1alpha1.ReasonDeleting
+		deleting.Message = "AWS resource deletion is in progress"
+	}
+
+	meta.SetStatusCondition(conditions, ready)
+	meta.SetStatusCondition(conditions, deleting)
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               awsv1alpha1.ConditionTypeSynced,
+		Status:             metav1.ConditionTrue,
+		Reason:             awsv1alpha1.ReasonReconcileSuccess,
+		ObservedGeneration: generation,
+	})
+	if failed {
+		meta.SetStatusCondition(conditions, metav1.Condition{
+			Type:               awsv1alpha1.ConditionTypeError,
+			Status:             metav1.ConditionTrue,
+			Reason:             awsv1alpha1.ReasonFailed,
+			ObservedGeneration: generation,
+			Message:            ready.Message,
+		})
+	} else {
+		meta.SetStatusCondition(conditions, metav1.Condition{
+			Type:               awsv1alpha1.ConditionTypeError,
+			Status:             metav1.ConditionFalse,
+			Reason:             awsv1alpha1.ReasonReconcileSuccess,
+			ObservedGeneration: generation,
+		})
+	}
+}
+
+// setReconcileErrorConditions records a failed reconciliation of obj.
+func setReconcileErrorConditions(conditions *[]metav1.Condition, obj metav1.Object, reconcileErr error) {
+	generation := obj.GetGeneration()
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               awsv1alpha1.ConditionTypeSynced,
+		Status:             metav1.ConditionFalse,
+		Reason:             awsv1alpha1.ReasonReconcileError,
+		ObservedGeneration: generation,
+		Message:            reconcileErr.Error(),
+	})
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               awsv1alpha1.ConditionTypeError,
+		Status:             metav1.ConditionTrue,
+		Reason:             awsv1alpha1.ReasonReconcileError,
+		ObservedGeneration: generation,
+		Message:            reconcileErr.Error(),
+	})
+}
+
+// setTerminalErrorConditions records a reconciliation of obj that failed with
+// an AWS error retrying cannot fix.
+func setTerminalErrorConditions(conditions *[]metav1.Condition, obj metav1.Object, reconcileErr error) {
+	generation := obj.GetGeneration()
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               awsv1alpha1.ConditionTypeSynced,
+		Status:             metav1.ConditionFalse,
+		Reason:             awsv1alpha1.ReasonFailed,
+		ObservedGeneration: generation,
+		Message:            reconcileErr.Error(),
+	})
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               awsv1alpha1.ConditionTypeError,
+		Status:             metav1.ConditionTrue,
+		Reason:             awsv1alpha1.ReasonFailed,
+		ObservedGeneration: generation,
+		Message:            reconcileErr.Error(),
+	})
+}
+
+// setFailedConditions records that obj cannot make progress for a reason
+// retrying does not fix.
+func setFailedConditions(conditions *[]metav1.Condition, obj metav1.Object, message string) {
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               awsv1alpha1.ConditionTypeReady,
+		Status:             metav1.ConditionFalse,
+		Reason:             awsv1alpha1.ReasonFailed,
+		ObservedGeneration: obj.GetGeneration(),
+		Message:            message,
+	})
+	setTerminalErrorConditions(conditions, obj, errors.New(message))
+}
+
+// setNotOwnedConditions records that obj refuses to manage an existing AWS
+// resource it has not created or imported, or to overwrite an existing object
+// it does not control.
+func setNotOwnedConditions(conditions *[]metav1.Condition, obj metav1.Object, message string) {
+	generation := obj.GetGeneration()
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               awsv1alpha1.ConditionTypeReady,
+		Status:             metav1.ConditionFalse,
+		Reason:             awsv1alpha1.ReasonNotOwned,
+		ObservedGeneration: generation,
+		Message:            message,
+	})
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               awsv1alpha1.ConditionTypeSynced,
+		Status:             metav1.ConditionFalse,
+		Reason:             awsv1alpha1.ReasonNotOwned,
+		ObservedGeneration: generation,
+		Message:            message,
+	})
+}
+
+// setWaitingForReferencesConditions records that obj cannot be provisioned yet
+// because a referenced resource is missing or not Ready.
+func setWaitingForReferencesConditions(conditions *[]metav1.Condition, obj metav1.Object, message string) {
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               awsv1alpha1.ConditionTypeReady,
+		Status:             metav1.ConditionFalse,
+		Reason:             awsv1alpha1.ReasonWaitingForRefs,
+		ObservedGeneration: obj.GetGeneration(),
+		Message:            message,
+	})
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+)
+
+// Keys of the connection details published into Secrets and ConfigMaps.
+const (
+	connectionKeyEndpoint              = "endpoint"
+	connectionKeyReaderEndpoint        = "readerEndpoint"
+	connectionKeyPort                  = "port"
+	connectionKeyConfigurationEndpoint = "configurationEndpoint"
+	connectionKeyNodeEndpoints         = "nodeEndpoints"
+	connectionKeyEngine                = "engine"
+	connectionKeyEngineVersion         = "engineVersion"
+	connectionKeyAuthToken             = "authToken"
+)
+
+// connectionDetails holds the values that are published for a cache. Sensitive
+// values are only written into the Secret.
+type connectionDetails struct {
+	Public    map[string]string
+	Sensitive map[string]string
+}
+
+// clusterConnectionDetails builds the connection details of a cache cluster
+// described by AWS.
+func clusterConnectionDetails(cluster *types.CacheCluster, authToken *string) connectionDetails {
+	details := connectionDetails{
+		Public: map[string]string{
+			connectionKeyEngine:        aws.ToString(cluster.Engine),
+			connectionKeyEngineVersion: aws.ToString(cluster.EngineVersion),
+		},
+		Sensitive: map[string]string{},
+	}
+
+	var nodeEndpoints []string
+	for _, node := range cluster.CacheNodes {
+		if node.Endpoint == nil || node.Endpoint.Address == nil {
+			continue
+		}
+		nodeEndpoints = append(nodeEndpoints, formatEndpoint(node.Endpoint))
+		if _, ok := details.Public[connectionKeyEndpoint]; !ok {
+			details.Public[connectionKeyEndpoint] = aws.ToString(node.Endpoint.Address)
+			details.Public[connectionKeyPort] = strconv.Itoa(int(node.Endpoint.Port))
+		}
+	}
+	details.Public[connectionKeyNodeEndpoints] = strings.Join(nodeEndpoints, ",")
+
+	if endpoint := cluster.ConfigurationEndpoint; endpoint != nil && endpoint.Address != nil {
+		details.Public[connectionKeyConfigurationEndpoint] = formatEndpoint(endpoint)
+		details.Public[connectionKeyEndpoint] = aws.ToString(endpoint.Address)
+		details.Public[connectionKeyPort] = strconv.Itoa(int(endpoint.Port))
+	}
+
+	if authToken != nil {
+		details.Sensitive[connectionKeyAuthToken] = *authToken
+	}
+
+	return details
+}
+
+// replicationGroupConnectionDetails builds the connection details of a
+// replication group described by AWS. Cluster mode enabled groups are reached
+// through their configuration endpoint, all others through the primary and
+// reader endpoints of their single node group.
+func replicationGroupConnectionDetails(replicationGroup *types.ReplicationGroup, engine string, authToken *string) connectionDetails {
+	details := connectionDetails{
+		Public: map[string]string{
+			connectionKeyEngine: engine,
+		},
+		Sensitive: map[string]string{},
+	}
+
+	var nodeEndpoints []string
+	for _, nodeGroup := range replicationGroup.NodeGroups {
+		for _, member := range nodeGroup.NodeGroupMembers {
+			if member.ReadEndpoint != nil && member.ReadEndpoint.Address != nil {
+				nodeEndpoints = append(nodeEndpoints, formatEndpoint(member.ReadEndpoint))
+			}
+		}
+	}
+	details.Public[connectionKeyNodeEndpoints] = strings.Join(nodeEndpoints, ",")
+
+	if endpoint := replicationGroup.ConfigurationEndpoint; endpoint != nil && endpoint.Address != nil {
+		details.Public[connectionKeyConfigurationEndpoint] = formatEndpoint(endpoint)
+		details.Public[connectionKeyEndpoint] = aws.ToString(endpoint.Address)
+		details.Public[connectionKeyPort] = strconv.Itoa(int(endpoint.Port))
+	} else if len(replicationGroup.NodeGroups) > 0 {
+		nodeGroup := replicationGroup.NodeGroups[0]
+		if endpoint := nodeGroup.PrimaryEndpoint; endpoint != nil && endpoint.Address != nil {
+			details.Public[connectionKeyEndpoint] = aws.ToString(endpoint.Address)
+			details.Public[connectionKeyPort] = strconv.Itoa(int(endpoint.Port))
+		}
+		if endpoint := nodeGroup.ReaderEndpoint; endpoint != nil && endpoint.Address != nil {
+			details.Public[connectionKeyReaderEndpoint] = aws.ToString(endpoint.Address)
+		}
+	}
+
+	if authToken != nil {
+		details.Sensitive[connectionKeyAuthToken] = *authToken
+	}
+
+	return details
+}
+
+func formatEndpoint(endpoint *types.Endpoint) string {
+	return fmt.Sprintf("%s:%d", aws.ToString(endpoint.Address), endpoint.Port)
+}
+
+// notControlledError reports an existing object that connection details are
+// not published into because owner does not control it.
+type notControlledError struct {
+	kind  string
+	name  string
+	owner client.Object
+}
+
+func (e *notControlledError) Error() string {
+	return fmt.Sprintf("%s %s already exists and is not controlled by %s, delete it or choose another name",
+		e.kind, e.name, e.owner.GetName())
+}
+
+func isNotControlled(err error) bool {
+	var notControlled *notControlledError
+	return goerrors.As(err, &notControlled)
+}
+
+// publishConnectionDetails creates or updates the Secret and the optional
+// ConfigMap named by target. Both objects are owned by owner so they are removed
+// together with it. Existing objects owner does not control are left alone and
+// a notControlledError is returned.
+func publishConnectionDetails(ctx context.Context, c client.Client, scheme *runtime.Scheme, owner client.Object,
+	target *awsv1alpha1.ConnectionDetailsTarget, details connectionDetails) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      target.SecretName,
+			Namespace: owner.GetNamespace(),
+		},
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, c, secret, func() error {
+		if secret.ResourceVersion != "" && !metav1.IsControlledBy(secret, owner) {
+			return &notControlledError{kind: "Secret", name: secret.Name, owner: owner}
+		}
+		secret.Type = corev1.SecretTypeOpaque
+		secret.StringData = nil
+		secret.Data = map[string][]byte{}
+		for key, value := range details.Public {
+			secret.Data[key] = []byte(value)
+		}
+		for key, value := range details.Sensitive {
+			secret.Data[key] = []byte(value)
+		}
+		return controllerutil.SetControllerReference(owner, secret, scheme)
+	})
+	if err != nil {
+		return err
+	}
+
+	if target.ConfigMapName == nil {
+		return nil
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      *target.ConfigMapName,
+			Namespace: owner.GetNamespace(),
+		},
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, c, configMap, func() error {
+		if configMap.ResourceVersion != "" && !metav1.IsControlledBy(configMap, owner) {
+			return &notControlledError{kind: "ConfigMap", name: configMap.Name, owner: owner}
+		}
+		configMap.Data = map[string]string{}
+		for key, value := range details.Public {
+			configMap.Data[key] = value
+		}
+		return controllerutil.SetControllerReference(owner, configMap, scheme)
+	})
+	return err
+}
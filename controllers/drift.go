@@ -0,0 +1,282 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+
+	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+)
+
+// diffCacheCluster compares the modifiable settings of the spec with the cache
+// cluster described by AWS. It returns the names of the fields that drifted and
+// a modification changing only those fields. Fields that are not set in the
+// spec and changes that are already pending are not reported, except for log
+// deliveries which are disabled when the spec does not list them.
+func diffCacheCluster(config *awsv1alpha1.ElasticCacheAwsConfig, cluster *types.CacheCluster) ([]string, *elasticache.ModifyCacheClusterInput) {
+	var drifted []string
+	params := &elasticache.ModifyCacheClusterInput{
+		CacheClusterId:   cluster.CacheClusterId,
+		ApplyImmediately: true,
+	}
+	pending := cluster.PendingModifiedValues
+	if pending == nil {
+		pending = &types.PendingModifiedValues{}
+	}
+
+	if config.CacheNodeType != nil && *config.CacheNodeType != aws.ToString(cluster.CacheNodeType) &&
+		*config.CacheNodeType != aws.ToString(pending.CacheNodeType) {
+		drifted = append(drifted, "cacheNodeType")
+		params.CacheNodeType = config.CacheNodeType
+	}
+
+	if config.EngineVersion != nil && !engineVersionMatches(*config.EngineVersion, aws.ToString(cluster.EngineVersion)) &&
+		!engineVersionMatches(*config.EngineVersion, aws.ToString(pending.EngineVersion)) {
+		drifted = append(drifted, "engineVersion")
+		params.EngineVersion = config.EngineVersion
+	}
+
+	if config.NumCacheNodes != nil && *config.NumCacheNodes != aws.ToInt32(cluster.NumCacheNodes) &&
+		(pending.NumCacheNodes == nil || *config.NumCacheNodes != *pending.NumCacheNodes) {
+		drifted = append(drifted, "numCacheNodes")
+		params.NumCacheNodes = config.NumCacheNodes
+		params.CacheNodeIdsToRemove = cacheNodeIdsToRemove(cluster, *config.NumCacheNodes)
+	}
+
+	if config.SecurityGroupIds != nil {
+		var current []string
+		for _, group := range cluster.SecurityGroups {
+			current = append(current, aws.ToString(group.SecurityGroupId))
+		}
+		if !sameStrings(config.SecurityGroupIds, current) {
+			drifted = append(drifted, "securityGroupIds")
+			params.SecurityGroupIds = config.SecurityGroupIds
+		}
+	}
+
+	if config.CacheSecurityGroupNames != nil {
+		var current []string
+		for _, group := range cluster.CacheSecurityGroups {
+			current = append(current, aws.ToString(group.CacheSecurityGroupName))
+		}
+		if !sameStrings(config.CacheSecurityGroupNames, current) {
+			drifted = append(drifted, "cacheSecurityGroupNames")
+			params.CacheSecurityGroupNames = config.CacheSecurityGroupNames
+		}
+	}
+
+	if config.CacheParameterGroupName != nil {
+		current := ""
+		if cluster.CacheParameterGroup != nil {
+			current = aws.ToString(cluster.CacheParameterGroup.CacheParameterGroupName)
+		}
+		if *config.CacheParameterGroupName != current {
+			drifted = append(drifted, "cacheParameterGroupName")
+			params.CacheParameterGroupName = config.CacheParameterGroupName
+		}
+	}
+
+	// AWS returns maintenance windows in lower case.
+	if config.PreferredMaintenanceWindow != nil &&
+		!strings.EqualFold(*config.PreferredMaintenanceWindow, aws.ToString(cluster.PreferredMaintenanceWindow)) {
+		drifted = append(drifted, "preferredMaintenanceWindow")
+		params.PreferredMaintenanceWindow = config.PreferredMaintenanceWindow
+	}
+
+	if config.SnapshotWindow != nil && *config.SnapshotWindow != aws.ToString(cluster.SnapshotWindow) {
+		drifted = append(drifted, "snapshotWindow")
+		params.SnapshotWindow = config.SnapshotWindow
+	}
+
+	if config.SnapshotRetentionLimit != nil && *config.SnapshotRetentionLimit != aws.ToInt32(cluster.SnapshotRetentionLimit) {
+		drifted = append(drifted, "snapshotRetentionLimit")
+		params.SnapshotRetentionLimit = config.SnapshotRetentionLimit
+	}
+
+	if config.NotificationTopicArn != nil {
+		current := ""
+		if cluster.NotificationConfiguration != nil {
+			current = aws.ToString(cluster.NotificationConfiguration.TopicArn)
+		}
+		if *config.NotificationTopicArn != current {
+			drifted = append(drifted, "notificationTopicArn")
+			params.NotificationTopicArn = config.NotificationTopicArn
+		}
+	}
+
+	if requests := diffLogDelivery(config.LogDeliveryConfigurations, cluster); len(requests) > 0 {
+		drifted = append(drifted, "logDeliveryConfigurations")
+		params.LogDeliveryConfigurations = requests
+	}
+
+	return drifted, params
+}
+
+// diffReplicationGroup compares the modifiable settings of the spec with the
+// replication group described by AWS. Settings that only member clusters
+// report, like the engine version, are compared with member, which may be nil
+// while the group has no members. It follows the rules of diffCacheCluster.
+func diffReplicationGroup(config *awsv1alpha1.ReplicationGroupAwsConfig, group *types.ReplicationGroup,
+	member *types.CacheCluster) ([]string, *elasticache.ModifyReplicationGroupInput) {
+	var drifted []string
+	params := &elasticache.ModifyReplicationGroupInput{
+		ReplicationGroupId: group.ReplicationGroupId,
+		ApplyImmediately:   true,
+	}
+	pending := group.PendingModifiedValues
+	if pending == nil {
+		pending = &types.ReplicationGroupPendingModifiedValues{}
+	}
+
+	if config.ReplicationGroupDescription != nil && *config.ReplicationGroupDescription != aws.ToString(group.Description) {
+		drifted = append(drifted, "replicationGroupDescription")
+		params.ReplicationGroupDescription = config.ReplicationGroupDescription
+	}
+
+	if config.CacheNodeType != nil && *config.CacheNodeType != aws.ToString(group.CacheNodeType) {
+		drifted = append(drifted, "cacheNodeType")
+		params.CacheNodeType = config.CacheNodeType
+	}
+
+	if config.AutomaticFailoverEnabled != nil {
+		current := group.AutomaticFailover == types.AutomaticFailoverStatusEnabled ||
+			group.AutomaticFailover == types.AutomaticFailoverStatusEnabling
+		if pending.AutomaticFailoverStatus != "" {
+			current = pending.AutomaticFailoverStatus == types.PendingAutomaticFailoverStatusEnabled
+		}
+		if *config.AutomaticFailoverEnabled != current {
+			drifted = append(drifted, "automaticFailoverEnabled")
+			params.AutomaticFailoverEnabled = config.AutomaticFailoverEnabled
+		}
+	}
+
+	if config.MultiAZEnabled != nil && *config.MultiAZEnabled != (group.MultiAZ == types.MultiAZStatusEnabled) {
+		drifted = append(drifted, "multiAZEnabled")
+		params.MultiAZEnabled = config.MultiAZEnabled
+	}
+
+	if config.SnapshotWindow != nil && *config.SnapshotWindow != aws.ToString(group.SnapshotWindow) {
+		drifted = append(drifted, "snapshotWindow")
+		params.SnapshotWindow = config.SnapshotWindow
+	}
+
+	if config.SnapshotRetentionLimit != nil && *config.SnapshotRetentionLimit != aws.ToInt32(group.SnapshotRetentionLimit) {
+		drifted = append(drifted, "snapshotRetentionLimit")
+		params.SnapshotRetentionLimit = config.SnapshotRetentionLimit
+	}
+
+	if member == nil {
+		return drifted, params
+	}
+
+	if config.EngineVersion != nil && !engineVersionMatches(*config.EngineVersion, aws.ToString(member.EngineVersion)) {
+		drifted = append(drifted, "engineVersion")
+		params.EngineVersion = config.EngineVersion
+	}
+
+	if config.AutoMinorVersionUpgrade != nil && *config.AutoMinorVersionUpgrade != member.AutoMinorVersionUpgrade {
+		drifted = append(drifted, "autoMinorVersionUpgrade")
+		params.AutoMinorVersionUpgrade = config.AutoMinorVersionUpgrade
+	}
+
+	if config.SecurityGroupIds != nil {
+		var current []string
+		for _, group := range member.SecurityGroups {
+			current = append(current, aws.ToString(group.SecurityGroupId))
+		}
+		if !sameStrings(config.SecurityGroupIds, current) {
+			drifted = append(drifted, "securityGroupIds")
+			params.SecurityGroupIds = config.SecurityGroupIds
+		}
+	}
+
+	if config.CacheSecurityGroupNames != nil {
+		var current []string
+		for _, group := range member.CacheSecurityGroups {
+			current = append(current, aws.ToString(group.CacheSecurityGroupName))
+		}
+		if !sameStrings(config.CacheSecurityGroupNames, current) {
+			drifted = append(drifted, "cacheSecurityGroupNames")
+			params.CacheSecurityGroupNames = config.CacheSecurityGroupNames
+		}
+	}
+
+	if config.CacheParameterGroupName != nil {
+		current := ""
+		if member.CacheParameterGroup != nil {
+			current = aws.ToString(member.CacheParameterGroup.CacheParameterGroupName)
+		}
+		if *config.CacheParameterGroupName != current {
+			drifted = append(drifted, "cacheParameterGroupName")
+			params.CacheParameterGroupName = config.CacheParameterGroupName
+		}
+	}
+
+	// AWS returns maintenance windows in lower case.
+	if config.PreferredMaintenanceWindow != nil &&
+		!strings.EqualFold(*config.PreferredMaintenanceWindow, aws.ToString(member.PreferredMaintenanceWindow)) {
+		drifted = append(drifted, "preferredMaintenanceWindow")
+		params.PreferredMaintenanceWindow = config.PreferredMaintenanceWindow
+	}
+
+	if config.NotificationTopicArn != nil {
+		current := ""
+		if member.NotificationConfiguration != nil {
+			current = aws.ToString(member.NotificationConfiguration.TopicArn)
+		}
+		if *config.NotificationTopicArn != current {
+			drifted = append(drifted, "notificationTopicArn")
+			params.NotificationTopicArn = config.NotificationTopicArn
+		}
+	}
+
+	return drifted, params
+}
+
+// engineVersionMatches reports whether the engine version reported by AWS
+// satisfies the desired one. A desired version ending in ".x", e.g. "6.x",
+// matches every version with the same prefix.
+func engineVersionMatches(desired string, actual string) bool {
+	if strings.HasSuffix(desired, ".x") {
+		return strings.HasPrefix(actual, strings.TrimSuffix(desired, "x"))
+	}
+	return desired == actual
+}
+
+// cacheNodeIdsToRemove returns the nodes removed when the cluster shrinks to
+// numCacheNodes nodes, starting with the highest node identifiers.
+func cacheNodeIdsToRemove(cluster *types.CacheCluster, numCacheNodes int32) []string {
+	var nodeIds []string
+	for _, node := range cluster.CacheNodes {
+		nodeIds = append(nodeIds, aws.ToString(node.CacheNodeId))
+	}
+	if int32(len(nodeIds)) <= numCacheNodes {
+		return nil
+	}
+	sort.Strings(nodeIds)
+	return nodeIds[numCacheNodes:]
+}
+
+// sameStrings reports whether a and b contain the same values in any order.
+func sameStrings(a []string, b []string) bool {
+	return len(stringsDifference(a, b)) == 0 && len(stringsDifference(b, a)) == 0
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+)
+
+var _ = Describe("Cache cluster drift", func() {
+	cluster := func(modify func(*types.CacheCluster)) *types.CacheCluster {
+		cluster := &types.CacheCluster{
+			CacheClusterId:             aws.String("cache"),
+			EngineVersion:              aws.String("6.2.6"),
+			NumCacheNodes:              aws.Int32(1),
+			CacheNodes:                 []types.CacheNode{{CacheNodeId: aws.String("0001")}},
+			SecurityGroups:             []types.SecurityGroupMembership{{SecurityGroupId: aws.String("sg-a")}, {SecurityGroupId: aws.String("sg-b")}},
+			CacheSecurityGroups:        []types.CacheSecurityGroupMembership{{CacheSecurityGroupName: aws.String("default")}},
+			PreferredMaintenanceWindow: aws.String("sun:05:00-sun:06:00"),
+		}
+		if modify != nil {
+			modify(cluster)
+		}
+		return cluster
+	}
+
+	modification := func(modify func(*elasticache.ModifyCacheClusterInput)) *elasticache.ModifyCacheClusterInput {
+		params := &elasticache.ModifyCacheClusterInput{
+			CacheClusterId:   aws.String("cache"),
+			ApplyImmediately: true,
+		}
+		if modify != nil {
+			modify(params)
+		}
+		return params
+	}
+
+	nodes := func(ids ...string) func(*types.CacheCluster) {
+		return func(cluster *types.CacheCluster) {
+			cluster.NumCacheNodes = aws.Int32(int32(len(ids)))
+			cluster.CacheNodes = nil
+			for _, id := range ids {
+				cluster.CacheNodes = append(cluster.CacheNodes, types.CacheNode{CacheNodeId: aws.String(id)})
+			}
+		}
+	}
+
+	slowLog := awsv1alpha1.LogDeliveryConfiguration{
+		LogType:        awsv1alpha1.LogTypeSlowLog,
+		CloudWatchLogs: &awsv1alpha1.CloudWatchLogsDestination{LogGroup: "slow"},
+	}
+	slowLogDelivery := func(logGroup string, status types.LogDeliveryConfigurationStatus) func(*types.CacheCluster) {
+		return func(cluster *types.CacheCluster) {
+			cluster.LogDeliveryConfigurations = []types.LogDeliveryConfiguration{{
+				LogType:         types.LogTypeSlowLog,
+				LogFormat:       types.LogFormatJson,
+				DestinationType: types.DestinationTypeCloudWatchLogs,
+				DestinationDetails: &types.DestinationDetails{
+					CloudWatchLogsDetails: &types.CloudWatchLogsDestinationDetails{LogGroup: aws.String(logGroup)},
+				},
+				Status: status,
+			}}
+		}
+	}
+
+	DescribeTable("diffCacheCluster",
+		func(config awsv1alpha1.ElasticCacheAwsConfig, current *types.CacheCluster, drifted []string, params *elasticache.ModifyCacheClusterInput) {
+			gotDrifted, gotParams := diffCacheCluster(&config, current)
+			if drifted == nil {
+				Expect(gotDrifted).To(BeEmpty())
+			} else {
+				Expect(gotDrifted).To(Equal(drifted))
+			}
+			Expect(gotParams).To(Equal(params))
+		},
+		Entry("ignores fields the spec does not set",
+			awsv1alpha1.ElasticCacheAwsConfig{}, cluster(nil), nil, modification(nil)),
+
+		Entry("matches an exact engine version",
+			awsv1alpha1.ElasticCacheAwsConfig{EngineVersion: aws.String("6.2.6")}, cluster(nil), nil, modification(nil)),
+		Entry("matches an engine version by its .x prefix",
+			awsv1alpha1.ElasticCacheAwsConfig{EngineVersion: aws.String("6.x")}, cluster(nil), nil, modification(nil)),
+		Entry("does not match another major version with a .x prefix",
+			awsv1alpha1.ElasticCacheAwsConfig{EngineVersion: aws.String("6.x")},
+			cluster(func(cluster *types.CacheCluster) { cluster.EngineVersion = aws.String("5.0.6") }),
+			[]string{"engineVersion"},
+			modification(func(params *elasticache.ModifyCacheClusterInput) { params.EngineVersion = aws.String("6.x") })),
+		Entry("does not match a longer major version with a .x prefix",
+			awsv1alpha1.ElasticCacheAwsConfig{EngineVersion: aws.String("6.x")},
+			cluster(func(cluster *types.CacheCluster) { cluster.EngineVersion = aws.String("60.1") }),
+			[]string{"engineVersion"},
+			modification(func(params *elasticache.ModifyCacheClusterInput) { params.EngineVersion = aws.String("6.x") })),
+		Entry("does not repeat a pending engine version upgrade",
+			awsv1alpha1.ElasticCacheAwsConfig{EngineVersion: aws.String("6.x")},
+			cluster(func(cluster *types.CacheCluster) {
+				cluster.EngineVersion = aws.String("5.0.6")
+				cluster.PendingModifiedValues = &types.PendingModifiedValues{EngineVersion: aws.String("6.2.6")}
+			}),
+			nil, modification(nil)),
+
+		Entry("removes the highest nodes when the cluster shrinks",
+			awsv1alpha1.ElasticCacheAwsConfig{NumCacheNodes: aws.Int32(1)},
+			cluster(nodes("0003", "0001", "0002")),
+			[]string{"numCacheNodes"},
+			modification(func(params *elasticache.ModifyCacheClusterInput) {
+				params.NumCacheNodes = aws.Int32(1)
+				params.CacheNodeIdsToRemove = []string{"0002", "0003"}
+			})),
+		Entry("removes no nodes when the cluster grows",
+			awsv1alpha1.ElasticCacheAwsConfig{NumCacheNodes: aws.Int32(3)},
+			cluster(nodes("0001")),
+			[]string{"numCacheNodes"},
+			modification(func(params *elasticache.ModifyCacheClusterInput) { params.NumCacheNodes = aws.Int32(3) })),
+		Entry("does not repeat a pending node count change",
+			awsv1alpha1.ElasticCacheAwsConfig{NumCacheNodes: aws.Int32(1)},
+			cluster(func(cluster *types.CacheCluster) {
+				nodes("0001", "0002")(cluster)
+				cluster.PendingModifiedValues = &types.PendingModifiedValues{NumCacheNodes: aws.Int32(1)}
+			}),
+			nil, modification(nil)),
+
+		Entry("matches security group IDs in any order",
+			awsv1alpha1.ElasticCacheAwsConfig{SecurityGroupIds: []string{"sg-b", "sg-a"}}, cluster(nil), nil, modification(nil)),
+		Entry("reports changed security group IDs",
+			awsv1alpha1.ElasticCacheAwsConfig{SecurityGroupIds: []string{"sg-a", "sg-c"}}, cluster(nil),
+			[]string{"securityGroupIds"},
+			modification(func(params *elasticache.ModifyCacheClusterInput) { params.SecurityGroupIds = []string{"sg-a", "sg-c"} })),
+		Entry("reports removed security group IDs",
+			awsv1alpha1.ElasticCacheAwsConfig{SecurityGroupIds: []string{"sg-a"}}, cluster(nil),
+			[]string{"securityGroupIds"},
+			modification(func(params *elasticache.ModifyCacheClusterInput) { params.SecurityGroupIds = []string{"sg-a"} })),
+		Entry("matches cache security group names",
+			awsv1alpha1.ElasticCacheAwsConfig{CacheSecurityGroupNames: []string{"default"}}, cluster(nil), nil, modification(nil)),
+		Entry("reports changed cache security group names",
+			awsv1alpha1.ElasticCacheAwsConfig{CacheSecurityGroupNames: []string{"default", "app"}}, cluster(nil),
+			[]string{"cacheSecurityGroupNames"},
+			modification(func(params *elasticache.ModifyCacheClusterInput) {
+				params.CacheSecurityGroupNames = []string{"default", "app"}
+			})),
+
+		Entry("matches the maintenance window in any case",
+			awsv1alpha1.ElasticCacheAwsConfig{PreferredMaintenanceWindow: aws.String("Sun:05:00-Sun:06:00")}, cluster(nil),
+			nil, modification(nil)),
+		Entry("reports a changed maintenance window",
+			awsv1alpha1.ElasticCacheAwsConfig{PreferredMaintenanceWindow: aws.String("Mon:05:00-Mon:06:00")}, cluster(nil),
+			[]string{"preferredMaintenanceWindow"},
+			modification(func(params *elasticache.ModifyCacheClusterInput) {
+				params.PreferredMaintenanceWindow = aws.String("Mon:05:00-Mon:06:00")
+			})),
+
+		Entry("matches an enabled log delivery",
+			awsv1alpha1.ElasticCacheAwsConfig{LogDeliveryConfigurations: []awsv1alpha1.LogDeliveryConfiguration{slowLog}},
+			cluster(slowLogDelivery("slow", types.LogDeliveryConfigurationStatusActive)),
+			nil, modification(nil)),
+		Entry("enables a missing log delivery",
+			awsv1alpha1.ElasticCacheAwsConfig{LogDeliveryConfigurations: []awsv1alpha1.LogDeliveryConfiguration{slowLog}},
+			cluster(nil),
+			[]string{"logDeliveryConfigurations"},
+			modification(func(params *elasticache.ModifyCacheClusterInput) {
+				params.LogDeliveryConfigurations = logDeliveryRequests([]awsv1alpha1.LogDeliveryConfiguration{slowLog})
+			})),
+		Entry("enables a log delivery to another destination",
+			awsv1alpha1.ElasticCacheAwsConfig{LogDeliveryConfigurations: []awsv1alpha1.LogDeliveryConfiguration{slowLog}},
+			cluster(slowLogDelivery("other", types.LogDeliveryConfigurationStatusActive)),
+			[]string{"logDeliveryConfigurations"},
+			modification(func(params *elasticache.ModifyCacheClusterInput) {
+				params.LogDeliveryConfigurations = logDeliveryRequests([]awsv1alpha1.LogDeliveryConfiguration{slowLog})
+			})),
+		Entry("disables a log delivery the spec does not list",
+			awsv1alpha1.ElasticCacheAwsConfig{},
+			cluster(slowLogDelivery("slow", types.LogDeliveryConfigurationStatusActive)),
+			[]string{"logDeliveryConfigurations"},
+			modification(func(params *elasticache.ModifyCacheClusterInput) {
+				params.LogDeliveryConfigurations = []types.LogDeliveryConfigurationRequest{{
+					Enabled: aws.Bool(false),
+					LogType: types.LogTypeSlowLog,
+				}}
+			})),
+		Entry("does not disable a log delivery twice",
+			awsv1alpha1.ElasticCacheAwsConfig{},
+			cluster(slowLogDelivery("slow", types.LogDeliveryConfigurationStatusDisabling)),
+			nil, modification(nil)),
+	)
+})
@@ -0,0 +1,89 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+)
+
+// ElastiCacheAPI is the subset of the ElastiCache API used by the controllers.
+// It is implemented by *elasticache.Client and by the in-memory fake used in
+// tests.
+type ElastiCacheAPI interface {
+	AddTagsToResource(ctx context.Context, params *elasticache.AddTagsToResourceInput, optFns ...func(*elasticache.Options)) (*elasticache.AddTagsToResourceOutput, error)
+	ListTagsForResource(ctx context.Context, params *elasticache.ListTagsForResourceInput, optFns ...func(*elasticache.Options)) (*elasticache.ListTagsForResourceOutput, error)
+	RemoveTagsFromResource(ctx context.Context, params *elasticache.RemoveTagsFromResourceInput, optFns ...func(*elasticache.Options)) (*elasticache.RemoveTagsFromResourceOutput, error)
+
+	CreateCacheCluster(ctx context.Context, params *elasticache.CreateCacheClusterInput, optFns ...func(*elasticache.Options)) (*elasticache.CreateCacheClusterOutput, error)
+	DescribeCacheClusters(ctx context.Context, params *elasticache.DescribeCacheClustersInput, optFns ...func(*elasticache.Options)) (*elasticache.DescribeCacheClustersOutput, error)
+	ModifyCacheCluster(ctx context.Context, params *elasticache.ModifyCacheClusterInput, optFns ...func(*elasticache.Options)) (*elasticache.ModifyCacheClusterOutput, error)
+	DeleteCacheCluster(ctx context.Context, params *elasticache.DeleteCacheClusterInput, optFns ...func(*elasticache.Options)) (*elasticache.DeleteCacheClusterOutput, error)
+
+	CreateReplicationGroup(ctx context.Context, params *elasticache.CreateReplicationGroupInput, optFns ...func(*elasticache.Options)) (*elasticache.CreateReplicationGroupOutput, error)
+	DescribeReplicationGroups(ctx context.Context, params *elasticache.DescribeReplicationGroupsInput, optFns ...func(*elasticache.Options)) (*elasticache.DescribeReplicationGroupsOutput, error)
+	ModifyReplicationGroup(ctx context.Context, params *elasticache.ModifyReplicationGroupInput, optFns ...func(*elasticache.Options)) (*elasticache.ModifyReplicationGroupOutput, error)
+	ModifyReplicationGroupShardConfiguration(ctx context.Context, params *elasticache.ModifyReplicationGroupShardConfigurationInput, optFns ...func(*elasticache.Options)) (*elasticache.ModifyReplicationGroupShardConfigurationOutput, error)
+	IncreaseReplicaCount(ctx context.Context, params *elasticache.IncreaseReplicaCountInput, optFns ...func(*elasticache.Options)) (*elasticache.IncreaseReplicaCountOutput, error)
+	DecreaseReplicaCount(ctx context.Context, params *elasticache.DecreaseReplicaCountInput, optFns ...func(*elasticache.Options)) (*elasticache.DecreaseReplicaCountOutput, error)
+	DeleteReplicationGroup(ctx context.Context, params *elasticache.DeleteReplicationGroupInput, optFns ...func(*elasticache.Options)) (*elasticache.DeleteReplicationGroupOutput, error)
+
+	CreateCacheParameterGroup(ctx context.Context, params *elasticache.CreateCacheParameterGroupInput, optFns ...func(*elasticache.Options)) (*elasticache.CreateCacheParameterGroupOutput, error)
+	DescribeCacheParameters(ctx context.Context, params *elasticache.DescribeCacheParametersInput, optFns ...func(*elasticache.Options)) (*elasticache.DescribeCacheParametersOutput, error)
+	DescribeCacheParameterGroups(ctx context.Context, params *elasticache.DescribeCacheParameterGroupsInput, optFns ...func(*elasticache.Options)) (*elasticache.DescribeCacheParameterGroupsOutput, error)
+	ModifyCacheParameterGroup(ctx context.Context, params *elasticache.ModifyCacheParameterGroupInput, optFns ...func(*elasticache.Options)) (*elasticache.ModifyCacheParameterGroupOutput, error)
+	ResetCacheParameterGroup(ctx context.Context, params *elasticache.ResetCacheParameterGroupInput, optFns ...func(*elasticache.Options)) (*elasticache.ResetCacheParameterGroupOutput, error)
+	DeleteCacheParameterGroup(ctx context.Context, params *elasticache.DeleteCacheParameterGroupInput, optFns ...func(*elasticache.Options)) (*elasticache.DeleteCacheParameterGroupOutput, error)
+
+	CreateCacheSubnetGroup(ctx context.Context, params *elasticache.CreateCacheSubnetGroupInput, optFns ...func(*elasticache.Options)) (*elasticache.CreateCacheSubnetGroupOutput, error)
+	DescribeCacheSubnetGroups(ctx context.Context, params *elasticache.DescribeCacheSubnetGroupsInput, optFns ...func(*elasticache.Options)) (*elasticache.DescribeCacheSubnetGroupsOutput, error)
+	ModifyCacheSubnetGroup(ctx context.Context, params *elasticache.ModifyCacheSubnetGroupInput, optFns ...func(*elasticache.Options)) (*elasticache.ModifyCacheSubnetGroupOutput, error)
+	DeleteCacheSubnetGroup(ctx context.Context, params *elasticache.DeleteCacheSubnetGroupInput, optFns ...func(*elasticache.Options)) (*elasticache.DeleteCacheSubnetGroupOutput, error)
+
+	CreateUser(ctx context.Context, params *elasticache.CreateUserInput, optFns ...func(*elasticache.Options)) (*elasticache.CreateUserOutput, error)
+	DescribeUsers(ctx context.Context, params *elasticache.DescribeUsersInput, optFns ...func(*elasticache.Options)) (*elasticache.DescribeUsersOutput, error)
+	ModifyUser(ctx context.Context, params *elasticache.ModifyUserInput, optFns ...func(*elasticache.Options)) (*elasticache.ModifyUserOutput, error)
+	DeleteUser(ctx context.Context, params *elasticache.DeleteUserInput, optFns ...func(*elasticache.Options)) (*elasticache.DeleteUserOutput, error)
+
+	CreateUserGroup(ctx context.Context, params *elasticache.CreateUserGroupInput, optFns ...func(*elasticache.Options)) (*elasticache.CreateUserGroupOutput, error)
+	DescribeUserGroups(ctx context.Context, params *elasticache.DescribeUserGroupsInput, optFns ...func(*elasticache.Options)) (*elasticache.DescribeUserGroupsOutput, error)
+	ModifyUserGroup(ctx context.Context, params *elasticache.ModifyUserGroupInput, optFns ...func(*elasticache.Options)) (*elasticache.ModifyUserGroupOutput, error)
+	DeleteUserGroup(ctx context.Context, params *elasticache.DeleteUserGroupInput, optFns ...func(*elasticache.Options)) (*elasticache.DeleteUserGroupOutput, error)
+
+	CreateSnapshot(ctx context.Context, params *elasticache.CreateSnapshotInput, optFns ...func(*elasticache.Options)) (*elasticache.CreateSnapshotOutput, error)
+	DescribeSnapshots(ctx context.Context, params *elasticache.DescribeSnapshotsInput, optFns ...func(*elasticache.Options)) (*elasticache.DescribeSnapshotsOutput, error)
+	CopySnapshot(ctx context.Context, params *elasticache.CopySnapshotInput, optFns ...func(*elasticache.Options)) (*elasticache.CopySnapshotOutput, error)
+	DeleteSnapshot(ctx context.Context, params *elasticache.DeleteSnapshotInput, optFns ...func(*elasticache.Options)) (*elasticache.DeleteSnapshotOutput, error)
+}
+
+var _ ElastiCacheAPI = &elasticache.Client{}
+
+// ElastiCacheClientFunc builds the ElastiCache client for an AWS configuration.
+type ElastiCacheClientFunc func(cfg aws.Config) ElastiCacheAPI
+
+// newElastiCacheClient returns the client built by newClient, or the client of
+// the AWS SDK when newClient is nil. Calls of clients built from cfg are
+// recorded in the AWS API call metrics.
+func newElastiCacheClient(newClient ElastiCacheClientFunc, cfg aws.Config) ElastiCacheAPI {
+	cfg = withAPICallMetrics(cfg)
+	if newClient != nil {
+		return newClient(cfg)
+	}
+	return elasticache.NewFromConfig(cfg)
+}
@@ -18,49 +18,76 @@ package controllers
 
 import (
 	"context"
-	"encoding/base64"
+	goerrors "errors"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/elasticache"
 	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
-	"time"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
 )
 
-var awsResource = schema.GroupResource{Group: "aws.sergeyshevch.dev", Resource: "AwsResource"}
 var elasticCacheFinalizer = "aws.serveyshevch.dev/finalizer"
-var lastAppliedSpecAnnotation = "aws.sergeyshevch.dev/last-applied"
+var authTokenSecretRefField = ".spec.awsConfig.authTokenSecretRef.name"
+var subnetGroupRefField = ".spec.awsConfig.subnetGroupRef.name"
+var providerConfigRefField = ".spec.providerConfigRef.name"
+
+// authTokenSecretKey is the key of the Secret the deprecated authToken is moved
+// to.
+const authTokenSecretKey = "authToken"
 
 // ElasticCacheReconciler reconciles a ElasticCache object
 type ElasticCacheReconciler struct {
 	client.Client
 	AwsConfig aws.Config
 	Scheme    *runtime.Scheme
+	Recorder  record.EventRecorder
+
+	// NewElastiCacheClient builds the ElastiCache client, defaults to the
+	// client of the AWS SDK.
+	NewElastiCacheClient ElastiCacheClientFunc
+
+	// RequeueIntervals sets how often the AWS resources are polled.
+	RequeueIntervals RequeueIntervals
+
+	// DefaultTags are added to every cache cluster, tags of the spec take
+	// precedence over them.
+	DefaultTags map[string]string
+
+	providerClients providerClients
+	backoff         requeueBackoff
 }
 
 //+kubebuilder:rbac:groups=aws.sergeyshevch.dev,resources=elasticcaches,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=aws.sergeyshevch.dev,resources=elasticcaches/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=aws.sergeyshevch.dev,resources=elasticcaches/finalizers,verbs=update
-
-// Reconcile is part of the main kubernetes reconciliation loop which aims to
-// move the current state of the cluster closer to the desired state.
-// TODO(user): Modify the Reconcile function to compare the state specified by
-// the ElasticCache object against the actual cluster state, and then
-// perform operations to make the cluster state reflect the state specified by
-// the user.
-//
-// For more details, check Reconcile and its Result here:
-// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.9.2/pkg/reconcile
+//+kubebuilder:rbac:groups=aws.sergeyshevch.dev,resources=cachesubnetgroups,verbs=get;list;watch
+//+kubebuilder:rbac:groups=aws.sergeyshevch.dev,resources=providerconfigs,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile creates the cache cluster of the ElasticCache, keeps it in line
+// with the spec and reports its state in the status.
 func (r *ElasticCacheReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	_ = log.FromContext(ctx)
+	defer observeReconcileDuration("ElasticCache", time.Now())
 
 	instance := &awsv1alpha1.ElasticCache{}
 	err := r.Client.Get(ctx, req.NamespacedName, instance)
@@ -71,53 +98,179 @@ func (r *ElasticCacheReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return ctrl.Result{}, err
 	}
 
-	awsClient := elasticache.NewFromConfig(r.AwsConfig)
+	result, err := r.reconcileElasticCache(ctx, instance)
+	if err != nil {
+		reason := awsv1alpha1.ReasonReconcileError
+		if classifyAwsError(err) == awsErrorTerminal {
+			reason = awsv1alpha1.ReasonFailed
+		}
+		r.Recorder.Event(instance, corev1.EventTypeWarning, reason, err.Error())
+		result, err = handleReconcileError(&instance.Status.Conditions, instance, &r.backoff, isCacheClusterNotFound, err)
+		if statusErr := r.Status().Update(context.TODO(), instance); statusErr != nil {
+			log.FromContext(ctx).Error(statusErr, "unable to update ElasticCache status")
+		}
+		return result, err
+	}
+	r.backoff.reset(req.NamespacedName)
+	return result, nil
+}
+
+func (r *ElasticCacheReconciler) reconcileElasticCache(ctx context.Context, instance *awsv1alpha1.ElasticCache) (ctrl.Result, error) {
+	awsClient, ready, err := r.resolveAwsClient(ctx, instance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !ready {
+		err = r.Status().Update(context.TODO(), instance)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: r.RequeueIntervals.transitional()}, nil
+	}
+
+	isElasticCacheMarkedToDeletion := instance.GetDeletionTimestamp() != nil
+	if isElasticCacheMarkedToDeletion {
+		return r.finalizeElasticCache(ctx, awsClient, instance)
+	}
+
+	// An illegal identifier cannot be fixed by retrying, wait for the spec to
+	// change instead.
+	err = validateCacheClusterId(aws.ToString(cacheClusterId(instance)))
+	if err != nil {
+		r.Recorder.Event(instance, corev1.EventTypeWarning, awsv1alpha1.ReasonReconcileError, err.Error())
+		setReconcileErrorConditions(&instance.Status.Conditions, instance, err)
+		return ctrl.Result{}, r.Status().Update(context.TODO(), instance)
+	}
+
+	err = r.migrateAuthToken(ctx, instance)
+	if isNotControlled(err) {
+		r.Recorder.Event(instance, corev1.EventTypeWarning, "NotOwned", err.Error())
+		setNotOwnedConditions(&instance.Status.Conditions, instance, err.Error())
+		return ctrl.Result{}, r.Status().Update(context.TODO(), instance)
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	authToken, err := r.resolveAuthToken(ctx, instance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if authToken != nil {
+		// Like the identifier, an invalid token is only fixed by changing the
+		// Secret, which triggers a new reconciliation.
+		err = validateAuthToken(*authToken)
+		if err != nil {
+			r.Recorder.Event(instance, corev1.EventTypeWarning, awsv1alpha1.ReasonReconcileError, err.Error())
+			setReconcileErrorConditions(&instance.Status.Conditions, instance, err)
+			return ctrl.Result{}, r.Status().Update(context.TODO(), instance)
+		}
+	}
 
 	// Process elasticCache cluster
 	cacheCluster, err := r.getElasticCacheCluster(awsClient, instance)
 	if err != nil {
-		if errors.IsNotFound(err) {
-			cacheCluster, err = r.createElasticCacheCluster(awsClient, instance)
+		if isCacheClusterNotFound(err) {
+			if hasImportAnnotation(instance) {
+				r.refuseCluster(instance, fmt.Sprintf("cache cluster %s to import was not found", aws.ToString(cacheClusterId(instance))))
+				err = r.Status().Update(context.TODO(), instance)
+				if err != nil {
+					return ctrl.Result{}, err
+				}
+				return ctrl.Result{RequeueAfter: r.RequeueIntervals.stable()}, nil
+			}
+
+			cacheSubnetGroupName, ready, err := r.resolveSubnetGroupName(ctx, instance)
 			if err != nil {
 				return ctrl.Result{}, err
 			}
+			if !ready {
+				err = r.Status().Update(context.TODO(), instance)
+				if err != nil {
+					return ctrl.Result{}, err
+				}
+				return ctrl.Result{RequeueAfter: r.RequeueIntervals.transitional()}, nil
+			}
 
-			// Update cluster status
-			err = r.updateClusterStatus(cacheCluster, instance)
+			// Persist the finalizer before the cluster exists, a deletion
+			// right after the creation would otherwise leak the cluster.
+			if !controllerutil.ContainsFinalizer(instance, elasticCacheFinalizer) {
+				controllerutil.AddFinalizer(instance, elasticCacheFinalizer)
+				err = r.Update(ctx, instance)
+				if err != nil {
+					return ctrl.Result{}, err
+				}
+			}
+
+			cacheCluster, err = r.createElasticCacheCluster(awsClient, instance, authToken, cacheSubnetGroupName)
 			if err != nil {
 				return ctrl.Result{}, err
 			}
+			r.Recorder.Eventf(instance, corev1.EventTypeNormal, "CreateRequested",
+				"Requested creation of cache cluster %s", aws.ToString(cacheClusterId(instance)))
+			instance.Status.AuthTokenHash = authTokenHash(instance, authToken)
 
-			err = r.SetLastAppliedAnnotation(instance)
+			// Update cluster status
+			err = r.updateClusterStatus(cacheCluster, instance)
 			if err != nil {
 				return ctrl.Result{}, err
 			}
 
-			// Cluster setup time
-			return ctrl.Result{RequeueAfter: time.Minute * 2}, nil
+			return r.RequeueIntervals.requeueAfter(aws.ToString(cacheCluster.CacheClusterStatus)), nil
 		}
 		return ctrl.Result{}, err
 	} else {
-		needPatch, err := isPatchNeeded(instance)
+		// Never touch a cluster this ElasticCache did not create or import,
+		// its status would otherwise claim it on the next reconciliation.
+		owned, err := r.ensureClusterOwnership(awsClient, instance, cacheCluster, authToken)
 		if err != nil {
-			return ctrl.Result{}, nil
+			return ctrl.Result{}, err
 		}
-		if needPatch {
-			cacheCluster, err = r.patchElasticCacheCluster(awsClient, instance)
+		if !owned {
+			err = r.Status().Update(context.TODO(), instance)
 			if err != nil {
 				return ctrl.Result{}, err
 			}
+			return ctrl.Result{RequeueAfter: r.RequeueIntervals.stable()}, nil
+		}
 
-			// Update cluster status
-			err = r.updateClusterStatus(cacheCluster, instance)
+		drifted, params := diffCacheCluster(instance.Spec.AWSConfig, cacheCluster)
+		recordDriftDetections("ElasticCache", instance.Status.DriftedFields, drifted)
+		instance.Status.DriftedFields = drifted
+
+		// Only send the token when the referenced secret changed, so the
+		// cluster keeps its token on unrelated modifications.
+		var rotatedAuthToken *string
+		if hash := authTokenHash(instance, authToken); hash != instance.Status.AuthTokenHash {
+			rotatedAuthToken = authToken
+		}
+
+		// Tags are not part of ModifyCacheCluster, they are reconciled on
+		// their own.
+		if aws.ToString(cacheCluster.CacheClusterStatus) == "available" {
+			changed, err := reconcileTags(awsClient, cacheCluster.ARN, desiredTags(r.DefaultTags, instance.Spec.AWSConfig.Tags, instance))
 			if err != nil {
 				return ctrl.Result{}, err
 			}
+			if changed {
+				r.Recorder.Eventf(instance, corev1.EventTypeNormal, "TagsUpdated",
+					"Updated the tags of cache cluster %s", aws.ToString(cacheCluster.CacheClusterId))
+			}
+		}
 
-			err = r.SetLastAppliedAnnotation(instance)
+		// AWS rejects every modification while the cluster is not available.
+		if aws.ToString(cacheCluster.CacheClusterStatus) == "available" && (len(drifted) > 0 || rotatedAuthToken != nil) {
+			cacheCluster, err = r.patchElasticCacheCluster(awsClient, instance, params, rotatedAuthToken)
 			if err != nil {
 				return ctrl.Result{}, err
 			}
+			changed := drifted
+			if rotatedAuthToken != nil {
+				changed = append(append([]string(nil), drifted...), "authToken")
+			}
+			r.Recorder.Eventf(instance, corev1.EventTypeNormal, "Modified",
+				"Modified cache cluster %s: %s", aws.ToString(cacheCluster.CacheClusterId), strings.Join(changed, ", "))
+			instance.Status.AuthTokenHash = authTokenHash(instance, authToken)
 		}
 	}
 
@@ -127,19 +280,16 @@ func (r *ElasticCacheReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return ctrl.Result{}, err
 	}
 
-	isElasticCacheMarkedToDeletion := instance.GetDeletionTimestamp() != nil
-	if isElasticCacheMarkedToDeletion {
-		if controllerutil.ContainsFinalizer(instance, elasticCacheFinalizer) {
-			err = r.deleteElasticCacheCluster(awsClient, instance)
-			if err != nil {
-				return ctrl.Result{}, err
-			}
-
-			controllerutil.RemoveFinalizer(instance, elasticCacheFinalizer)
-			err = r.Update(ctx, instance)
-			if err != nil {
-				return ctrl.Result{}, err
-			}
+	if instance.Spec.WriteConnectionDetailsTo != nil && aws.ToString(cacheCluster.CacheClusterStatus) == "available" {
+		details := clusterConnectionDetails(cacheCluster, authToken)
+		err = publishConnectionDetails(ctx, r.Client, r.Scheme, instance, instance.Spec.WriteConnectionDetailsTo, details)
+		if isNotControlled(err) {
+			r.Recorder.Event(instance, corev1.EventTypeWarning, "NotOwned", err.Error())
+			setNotOwnedConditions(&instance.Status.Conditions, instance, err.Error())
+			err = r.Status().Update(context.TODO(), instance)
+		}
+		if err != nil {
+			return ctrl.Result{}, err
 		}
 	}
 
@@ -151,50 +301,193 @@ func (r *ElasticCacheReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		}
 	}
 
-	return ctrl.Result{RequeueAfter: time.Second * 60}, nil
+	return r.RequeueIntervals.requeueAfter(aws.ToString(cacheCluster.CacheClusterStatus)), nil
 }
 
-func (r *ElasticCacheReconciler) updateClusterStatus(cluster *types.CacheCluster, instance *awsv1alpha1.ElasticCache) error {
-	if instance.Status.CacheClusterStatus != cluster.CacheClusterStatus {
-		instance.Status.CacheClusterStatus = cluster.CacheClusterStatus
-		err := r.Status().Update(context.TODO(), instance)
+// finalizeElasticCache applies the deletion policy of an ElasticCache that is
+// being deleted. The finalizer is kept until the cache cluster, and with it the
+// final snapshot, is gone from AWS.
+func (r *ElasticCacheReconciler) finalizeElasticCache(ctx context.Context, awsClient ElastiCacheAPI, instance *awsv1alpha1.ElasticCache) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(instance, elasticCacheFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	clusterId := aws.ToString(cacheClusterId(instance))
+	policy := instance.Spec.DeletionPolicy
+	if policy == "" {
+		policy = awsv1alpha1.DeletionPolicyDelete
+	}
+
+	if policy == awsv1alpha1.DeletionPolicyRetain {
+		r.Recorder.Eventf(instance, corev1.EventTypeNormal, "DeletionSkipped",
+			"Retained cache cluster %s in AWS as requested by the deletion policy", clusterId)
+		return ctrl.Result{}, r.removeFinalizer(ctx, instance)
+	}
+
+	cacheCluster, err := r.getElasticCacheCluster(awsClient, instance)
+	if err != nil {
+		if !isCacheClusterNotFound(err) {
+			return ctrl.Result{}, err
+		}
+
+		if snapshot := instance.Status.FinalSnapshotIdentifier; snapshot != nil {
+			r.Recorder.Eventf(instance, corev1.EventTypeNormal, "Deleted",
+				"Deleted cache cluster %s after taking final snapshot %s", clusterId, *snapshot)
+		} else {
+			r.Recorder.Eventf(instance, corev1.EventTypeNormal, "Deleted", "Deleted cache cluster %s", clusterId)
+		}
+		return ctrl.Result{}, r.removeFinalizer(ctx, instance)
+	}
+
+	clusterStatus := aws.ToString(cacheCluster.CacheClusterStatus)
+	if clusterStatus != "deleting" && isTransitionalStatus(clusterStatus) {
+		// AWS rejects the deletion until the cluster has finished its current
+		// transition, e.g. its creation.
+		err = r.updateClusterStatus(cacheCluster, instance)
 		if err != nil {
-			return err
+			return ctrl.Result{}, err
 		}
+		return ctrl.Result{RequeueAfter: r.RequeueIntervals.transitional()}, nil
 	}
-	return nil
-}
 
-func isPatchNeeded(cr *awsv1alpha1.ElasticCache) (bool, error) {
-	marshaled, err  := json.Marshal(cr.Spec)
+	if clusterStatus != "deleting" {
+		var finalSnapshotIdentifier *string
+		if policy == awsv1alpha1.DeletionPolicySnapshot {
+			finalSnapshotIdentifier = aws.String(fmt.Sprintf("%s-final-%d", clusterId, instance.GetDeletionTimestamp().Unix()))
+		}
+
+		cacheCluster, err = r.deleteElasticCacheCluster(awsClient, instance, finalSnapshotIdentifier)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		instance.Status.FinalSnapshotIdentifier = finalSnapshotIdentifier
+		if finalSnapshotIdentifier != nil {
+			r.Recorder.Eventf(instance, corev1.EventTypeNormal, "DeletionStarted",
+				"Deleting cache cluster %s with final snapshot %s", clusterId, *finalSnapshotIdentifier)
+		} else {
+			r.Recorder.Eventf(instance, corev1.EventTypeNormal, "DeletionStarted", "Deleting cache cluster %s", clusterId)
+		}
+	}
+
+	err = r.updateClusterStatus(cacheCluster, instance)
 	if err != nil {
-		return false, err
+		return ctrl.Result{}, err
 	}
 
-	original := cr.GetAnnotations()[lastAppliedSpecAnnotation]
-	current := base64.StdEncoding.EncodeToString(marshaled)
+	return ctrl.Result{RequeueAfter: r.RequeueIntervals.transitional()}, nil
+}
 
-	return original != current, nil
+func (r *ElasticCacheReconciler) removeFinalizer(ctx context.Context, instance *awsv1alpha1.ElasticCache) error {
+	controllerutil.RemoveFinalizer(instance, elasticCacheFinalizer)
+	return r.Update(ctx, instance)
 }
 
-func (r *ElasticCacheReconciler) patchElasticCacheCluster(awsClient *elasticache.Client, cr *awsv1alpha1.ElasticCache) (*types.CacheCluster, error) {
-	params := &elasticache.ModifyCacheClusterInput{
-		CacheClusterId:             &cr.Name,
-		AZMode:                     cr.Spec.AWSConfig.AZMode,
-		ApplyImmediately:           true,
-		AuthToken:                  cr.Spec.AWSConfig.AuthToken,
-		AuthTokenUpdateStrategy:    cr.Spec.AWSConfig.AuthTokenUpdateStrategy,
-		CacheNodeType:              cr.Spec.AWSConfig.CacheNodeType,
-		CacheParameterGroupName:    cr.Spec.AWSConfig.CacheParameterGroupName,
-		CacheSecurityGroupNames:    cr.Spec.AWSConfig.CacheSecurityGroupNames,
-		EngineVersion:              cr.Spec.AWSConfig.EngineVersion,
-		//LogDeliveryConfigurations:  cr.Spec.AWSConfig.LogDeliveryConfigurations,
-		NotificationTopicArn:       cr.Spec.AWSConfig.NotificationTopicArn,
-		NumCacheNodes:              cr.Spec.AWSConfig.NumCacheNodes,
-		PreferredMaintenanceWindow: cr.Spec.AWSConfig.PreferredMaintenanceWindow,
-		SecurityGroupIds:           cr.Spec.AWSConfig.SecurityGroupIds,
-		SnapshotRetentionLimit:     cr.Spec.AWSConfig.SnapshotRetentionLimit,
-		SnapshotWindow:             cr.Spec.AWSConfig.SnapshotWindow,
+// updateClusterStatus copies the observed state of the cache cluster returned by
+// AWS into the ElasticCache status and refreshes its conditions.
+func (r *ElasticCacheReconciler) updateClusterStatus(cluster *types.CacheCluster, instance *awsv1alpha1.ElasticCache) error {
+	status := &instance.Status
+	r.recordStateTransition(instance, aws.ToString(status.CacheClusterStatus), cluster)
+
+	status.ObservedGeneration = instance.GetGeneration()
+	status.CacheClusterId = cluster.CacheClusterId
+	status.CacheClusterStatus = cluster.CacheClusterStatus
+	status.ARN = cluster.ARN
+	status.Engine = cluster.Engine
+	status.EngineVersion = cluster.EngineVersion
+	status.CacheNodeType = cluster.CacheNodeType
+	status.NumCacheNodes = cluster.NumCacheNodes
+	status.PreferredAvailabilityZone = cluster.PreferredAvailabilityZone
+	status.ConfigurationEndpoint = convertEndpoint(cluster.ConfigurationEndpoint)
+
+	status.CacheNodes = nil
+	for _, node := range cluster.CacheNodes {
+		cacheNode := awsv1alpha1.CacheNode{
+			CacheNodeId:              node.CacheNodeId,
+			CacheNodeStatus:          node.CacheNodeStatus,
+			CustomerAvailabilityZone: node.CustomerAvailabilityZone,
+			CustomerOutpostArn:       node.CustomerOutpostArn,
+			Endpoint:                 convertEndpoint(node.Endpoint),
+			ParameterGroupStatus:     node.ParameterGroupStatus,
+		}
+		if node.CacheNodeCreateTime != nil {
+			createTime := metav1.NewTime(*node.CacheNodeCreateTime)
+			cacheNode.CacheNodeCreateTime = &createTime
+		}
+		status.CacheNodes = append(status.CacheNodes, cacheNode)
+	}
+
+	status.PendingModifiedValues = nil
+	if pending := cluster.PendingModifiedValues; pending != nil {
+		status.PendingModifiedValues = &awsv1alpha1.PendingModifiedValues{
+			AuthTokenStatus:      pending.AuthTokenStatus,
+			CacheNodeIdsToRemove: pending.CacheNodeIdsToRemove,
+			CacheNodeType:        pending.CacheNodeType,
+			EngineVersion:        pending.EngineVersion,
+			NumCacheNodes:        pending.NumCacheNodes,
+		}
+	}
+
+	status.LogDeliveryConfigurations = logDeliveryStatuses(cluster.LogDeliveryConfigurations)
+
+	setAwsResourceConditions(&instance.Status.Conditions, instance, aws.ToString(cluster.CacheClusterStatus))
+
+	now := metav1.Now()
+	status.LastSyncTime = &now
+
+	return r.Status().Update(context.TODO(), instance)
+}
+
+// recordStateTransition emits an event when the state of the cache cluster
+// reported by AWS differs from the one last recorded in the status. The first
+// observation is covered by the CreateRequested or Adopted event. The time a new
+// cluster took to become available is recorded as well.
+func (r *ElasticCacheReconciler) recordStateTransition(instance *awsv1alpha1.ElasticCache, from string, cluster *types.CacheCluster) {
+	to := aws.ToString(cluster.CacheClusterStatus)
+	if from == "" || from == to {
+		return
+	}
+
+	if from == "creating" && to == "available" && cluster.CacheClusterCreateTime != nil {
+		timeToAvailable.WithLabelValues(aws.ToString(cluster.Engine)).Observe(time.Since(*cluster.CacheClusterCreateTime).Seconds())
+	}
+
+	eventType := corev1.EventTypeNormal
+	if !isTransitionalStatus(to) && to != "available" {
+		eventType = corev1.EventTypeWarning
+	}
+	r.Recorder.Eventf(instance, eventType, "StateChanged",
+		"Cache cluster %s changed from %s to %s", aws.ToString(cacheClusterId(instance)), from, to)
+}
+
+func convertTags(tags []awsv1alpha1.Tag) []types.Tag {
+	var result []types.Tag
+	for _, tag := range tags {
+		result = append(result, types.Tag{Key: tag.Key, Value: tag.Value})
+	}
+	return result
+}
+
+func convertEndpoint(endpoint *types.Endpoint) *awsv1alpha1.Endpoint {
+	if endpoint == nil {
+		return nil
+	}
+	return &awsv1alpha1.Endpoint{
+		Address: endpoint.Address,
+		Port:    endpoint.Port,
+	}
+}
+
+// patchElasticCacheCluster applies the modification computed by
+// diffCacheCluster, rotating the auth token when one is given.
+func (r *ElasticCacheReconciler) patchElasticCacheCluster(awsClient ElastiCacheAPI, cr *awsv1alpha1.ElasticCache,
+	params *elasticache.ModifyCacheClusterInput, authToken *string) (*types.CacheCluster, error) {
+	if authToken != nil {
+		params.AuthToken = authToken
+		params.AuthTokenUpdateStrategy = cr.Spec.AWSConfig.AuthTokenUpdateStrategy
+		if params.AuthTokenUpdateStrategy == "" {
+			params.AuthTokenUpdateStrategy = types.AuthTokenUpdateStrategyTypeRotate
+		}
 	}
 
 	output, err := awsClient.ModifyCacheCluster(context.TODO(), params)
@@ -204,18 +497,18 @@ func (r *ElasticCacheReconciler) patchElasticCacheCluster(awsClient *elasticache
 	return output.CacheCluster, nil
 }
 
-func (r *ElasticCacheReconciler) createElasticCacheCluster(awsClient *elasticache.Client, cr *awsv1alpha1.ElasticCache) (*types.CacheCluster, error) {
+func (r *ElasticCacheReconciler) createElasticCacheCluster(awsClient ElastiCacheAPI, cr *awsv1alpha1.ElasticCache, authToken *string, cacheSubnetGroupName *string) (*types.CacheCluster, error) {
 	params := &elasticache.CreateCacheClusterInput{
-		CacheClusterId:             &cr.Name,
+		CacheClusterId:             cacheClusterId(cr),
 		AZMode:                     cr.Spec.AWSConfig.AZMode,
-		AuthToken:                  cr.Spec.AWSConfig.AuthToken,
+		AuthToken:                  authToken,
 		CacheNodeType:              cr.Spec.AWSConfig.CacheNodeType,
 		CacheParameterGroupName:    cr.Spec.AWSConfig.CacheParameterGroupName,
 		CacheSecurityGroupNames:    cr.Spec.AWSConfig.CacheSecurityGroupNames,
-		CacheSubnetGroupName:       cr.Spec.AWSConfig.CacheSubnetGroupName,
+		CacheSubnetGroupName:       cacheSubnetGroupName,
 		Engine:                     cr.Spec.AWSConfig.Engine,
 		EngineVersion:              cr.Spec.AWSConfig.EngineVersion,
-		//LogDeliveryConfigurations:  cr.Spec.AWSConfig.LogDeliveryConfigurations,
+		LogDeliveryConfigurations:  logDeliveryRequests(cr.Spec.AWSConfig.LogDeliveryConfigurations),
 		NotificationTopicArn:       cr.Spec.AWSConfig.NotificationTopicArn,
 		NumCacheNodes:              cr.Spec.AWSConfig.NumCacheNodes,
 		OutpostMode:                cr.Spec.AWSConfig.OutpostMode,
@@ -231,7 +524,7 @@ func (r *ElasticCacheReconciler) createElasticCacheCluster(awsClient *elasticach
 		SnapshotName:               cr.Spec.AWSConfig.SnapshotName,
 		SnapshotRetentionLimit:     cr.Spec.AWSConfig.SnapshotRetentionLimit,
 		SnapshotWindow:             cr.Spec.AWSConfig.SnapshotWindow,
-		Tags:                       cr.Spec.AWSConfig.Tags,
+		Tags:                       desiredTags(r.DefaultTags, cr.Spec.AWSConfig.Tags, cr),
 	}
 
 	output, err := awsClient.CreateCacheCluster(context.TODO(), params)
@@ -242,9 +535,10 @@ func (r *ElasticCacheReconciler) createElasticCacheCluster(awsClient *elasticach
 
 }
 
-func (r *ElasticCacheReconciler) getElasticCacheCluster(awsClient *elasticache.Client, cr *awsv1alpha1.ElasticCache) (*types.CacheCluster, error) {
+func (r *ElasticCacheReconciler) getElasticCacheCluster(awsClient ElastiCacheAPI, cr *awsv1alpha1.ElasticCache) (*types.CacheCluster, error) {
 	params := &elasticache.DescribeCacheClustersInput{
-		CacheClusterId: &cr.Name,
+		CacheClusterId:    cacheClusterId(cr),
+		ShowCacheNodeInfo: aws.Bool(true),
 	}
 
 	output, err := awsClient.DescribeCacheClusters(context.TODO(), params)
@@ -257,32 +551,269 @@ func (r *ElasticCacheReconciler) getElasticCacheCluster(awsClient *elasticache.C
 	if len(clusters) == 1 {
 		return &clusters[0], nil
 	} else {
-		return &types.CacheCluster{}, errors.NewNotFound(awsResource, "ElasticCacheCluster")
+		return &types.CacheCluster{}, &types.CacheClusterNotFoundFault{
+			Message: aws.String(fmt.Sprintf("CacheCluster %s not found.", aws.ToString(params.CacheClusterId))),
+		}
 	}
 }
 
-func (r *ElasticCacheReconciler) deleteElasticCacheCluster(awsClient *elasticache.Client, cr *awsv1alpha1.ElasticCache) error {
+func (r *ElasticCacheReconciler) deleteElasticCacheCluster(awsClient ElastiCacheAPI, cr *awsv1alpha1.ElasticCache, finalSnapshotIdentifier *string) (*types.CacheCluster, error) {
 	params := &elasticache.DeleteCacheClusterInput{
-		CacheClusterId: &cr.Name,
+		CacheClusterId:          cacheClusterId(cr),
+		FinalSnapshotIdentifier: finalSnapshotIdentifier,
+	}
+
+	output, err := awsClient.DeleteCacheCluster(context.TODO(), params)
+	if err != nil {
+		return &types.CacheCluster{}, err
+	}
+	return output.CacheCluster, nil
+}
+
+func isCacheClusterNotFound(err error) bool {
+	var notFound *types.CacheClusterNotFoundFault
+	return goerrors.As(err, &notFound)
+}
+
+// resolveAuthToken reads the auth token referenced by the ElasticCache spec. It
+// returns nil when no token is configured.
+func (r *ElasticCacheReconciler) resolveAuthToken(ctx context.Context, instance *awsv1alpha1.ElasticCache) (*string, error) {
+	selector := instance.Spec.AWSConfig.AuthTokenSecretRef
+	if selector == nil {
+		return nil, nil
+	}
+
+	authToken, err := getSecretValue(ctx, r.Client, instance.Namespace, selector)
+	if err != nil {
+		return nil, err
+	}
+	return &authToken, nil
+}
+
+// migrateAuthToken moves the deprecated plaintext auth token of the spec to a
+// Secret owned by the ElasticCache and references it in authTokenSecretRef
+// instead.
+func (r *ElasticCacheReconciler) migrateAuthToken(ctx context.Context, instance *awsv1alpha1.ElasticCache) error {
+	authToken := instance.Spec.AWSConfig.AuthToken
+	if authToken == nil {
+		return nil
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instance.Name + "-auth-token",
+			Namespace: instance.Namespace,
+		},
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		if secret.ResourceVersion != "" && !metav1.IsControlledBy(secret, instance) {
+			return &notControlledError{kind: "Secret", name: secret.Name, owner: instance}
+		}
+		secret.Type = corev1.SecretTypeOpaque
+		secret.Data = map[string][]byte{authTokenSecretKey: []byte(*authToken)}
+		return controllerutil.SetControllerReference(instance, secret, r.Scheme)
+	})
+	if err != nil {
+		return err
+	}
+
+	instance.Spec.AWSConfig.AuthToken = nil
+	instance.Spec.AWSConfig.AuthTokenSecretRef = &awsv1alpha1.SecretKeySelector{Name: secret.Name, Key: authTokenSecretKey}
+	err = r.Update(ctx, instance)
+	if err != nil {
+		return err
+	}
+	r.Recorder.Eventf(instance, corev1.EventTypeNormal, "AuthTokenMigrated",
+		"Moved the deprecated authToken to Secret %s", secret.Name)
+
+	// A cluster created from the plaintext token already uses it, recording
+	// its hash keeps the migration from rotating the token.
+	if instance.Status.AuthTokenHash == "" && instance.Status.CacheClusterId != nil {
+		instance.Status.AuthTokenHash = hashSecretValue(instance, *authToken)
+	}
+	return nil
+}
+
+// resolveAwsClient returns the elasticache client for the ProviderConfig
+// referenced by the ElasticCache, or for the configuration of the operator when
+// none is referenced. It returns false when the ProviderConfig or its
+// credentials do not exist yet, or when the ProviderConfig does not select the
+// namespace of the ElasticCache.
+func (r *ElasticCacheReconciler) resolveAwsClient(ctx context.Context, instance *awsv1alpha1.ElasticCache) (ElastiCacheAPI, bool, error) {
+	ref := instance.Spec.ProviderConfigRef
+	if ref == nil {
+		return newElastiCacheClient(r.NewElastiCacheClient, r.AwsConfig), true, nil
+	}
+
+	providerConfig := &awsv1alpha1.ProviderConfig{}
+	key := client.ObjectKey{Name: ref.Name}
+	err := r.Get(ctx, key, providerConfig)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			r.providerClients.evict(key)
+			setWaitingForReferencesConditions(&instance.Status.Conditions, instance,
+				fmt.Sprintf("ProviderConfig %s not found", ref.Name))
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	selected, err := providerConfigSelectsNamespace(ctx, r.Client, providerConfig, instance.Namespace)
+	if err != nil {
+		return nil, false, err
+	}
+	if !selected {
+		setWaitingForReferencesConditions(&instance.Status.Conditions, instance,
+			fmt.Sprintf("ProviderConfig %s does not select namespace %s", ref.Name, instance.Namespace))
+		return nil, false, nil
+	}
+
+	awsClient, err := r.providerClients.elasticCacheClient(ctx, r.Client, r.AwsConfig, r.NewElastiCacheClient, providerConfig)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			setWaitingForReferencesConditions(&instance.Status.Conditions, instance,
+				fmt.Sprintf("credentials of ProviderConfig %s not found", ref.Name))
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return awsClient, true, nil
+}
+
+// resolveSubnetGroupName returns the AWS name of the cache subnet group used by
+// the ElasticCache. When the spec references a CacheSubnetGroup that is missing
+// or not Ready yet, it records that in the status conditions and reports false.
+func (r *ElasticCacheReconciler) resolveSubnetGroupName(ctx context.Context, instance *awsv1alpha1.ElasticCache) (*string, bool, error) {
+	ref := instance.Spec.AWSConfig.SubnetGroupRef
+	if ref == nil {
+		return instance.Spec.AWSConfig.CacheSubnetGroupName, true, nil
+	}
+
+	subnetGroup := &awsv1alpha1.CacheSubnetGroup{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: instance.Namespace, Name: ref.Name}, subnetGroup)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			setWaitingForReferencesConditions(&instance.Status.Conditions, instance,
+				fmt.Sprintf("CacheSubnetGroup %s not found", ref.Name))
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	if !meta.IsStatusConditionTrue(subnetGroup.Status.Conditions, awsv1alpha1.ConditionTypeReady) || subnetGroup.Status.CacheSubnetGroupName == nil {
+		setWaitingForReferencesConditions(&instance.Status.Conditions, instance,
+			fmt.Sprintf("CacheSubnetGroup %s is not Ready", ref.Name))
+		return nil, false, nil
+	}
+	return subnetGroup.Status.CacheSubnetGroupName, true, nil
+}
+
+// findElasticCachesForSubnetGroup maps a CacheSubnetGroup to the ElasticCaches
+// referencing it, so clusters waiting for it are created once it is Ready.
+func (r *ElasticCacheReconciler) findElasticCachesForSubnetGroup(subnetGroup client.Object) []reconcile.Request {
+	elasticCaches := &awsv1alpha1.ElasticCacheList{}
+	err := r.List(context.TODO(), elasticCaches,
+		client.InNamespace(subnetGroup.GetNamespace()),
+		client.MatchingFields{subnetGroupRefField: subnetGroup.GetName()})
+	if err != nil {
+		return nil
+	}
+
+	requests := make([]reconcile.Request, len(elasticCaches.Items))
+	for i := range elasticCaches.Items {
+		requests[i] = reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&elasticCaches.Items[i])}
+	}
+	return requests
+}
+
+// findElasticCachesForProviderConfig maps a ProviderConfig to the ElasticCaches
+// referencing it, so clusters waiting for it are reconciled once it exists. It
+// also evicts the cached client of a deleted ProviderConfig, which may no
+// longer be referenced by any ElasticCache.
+func (r *ElasticCacheReconciler) findElasticCachesForProviderConfig(providerConfig client.Object) []reconcile.Request {
+	key := client.ObjectKeyFromObject(providerConfig)
+	err := r.Get(context.TODO(), key, &awsv1alpha1.ProviderConfig{})
+	if errors.IsNotFound(err) {
+		r.providerClients.evict(key)
 	}
 
-	_, err := awsClient.DeleteCacheCluster(context.TODO(), params)
-	return err
+	elasticCaches := &awsv1alpha1.ElasticCacheList{}
+	err = r.List(context.TODO(), elasticCaches, client.MatchingFields{providerConfigRefField: providerConfig.GetName()})
+	if err != nil {
+		return nil
+	}
+
+	requests := make([]reconcile.Request, len(elasticCaches.Items))
+	for i := range elasticCaches.Items {
+		requests[i] = reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&elasticCaches.Items[i])}
+	}
+	return requests
+}
+
+// findElasticCachesForSecret maps a Secret to the ElasticCaches referencing it
+// as their auth token, so token rotations are picked up immediately.
+func (r *ElasticCacheReconciler) findElasticCachesForSecret(secret client.Object) []reconcile.Request {
+	elasticCaches := &awsv1alpha1.ElasticCacheList{}
+	err := r.List(context.TODO(), elasticCaches,
+		client.InNamespace(secret.GetNamespace()),
+		client.MatchingFields{authTokenSecretRefField: secret.GetName()})
+	if err != nil {
+		return nil
+	}
+
+	requests := make([]reconcile.Request, len(elasticCaches.Items))
+	for i := range elasticCaches.Items {
+		requests[i] = reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&elasticCaches.Items[i])}
+	}
+	return requests
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ElasticCacheReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&awsv1alpha1.ElasticCache{}).
-		Complete(r)
-}
+	err := mgr.GetFieldIndexer().IndexField(context.Background(), &awsv1alpha1.ElasticCache{}, authTokenSecretRefField, func(obj client.Object) []string {
+		elasticCache := obj.(*awsv1alpha1.ElasticCache)
+		if elasticCache.Spec.AWSConfig == nil || elasticCache.Spec.AWSConfig.AuthTokenSecretRef == nil {
+			return nil
+		}
+		return []string{elasticCache.Spec.AWSConfig.AuthTokenSecretRef.Name}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = mgr.GetFieldIndexer().IndexField(context.Background(), &awsv1alpha1.ElasticCache{}, subnetGroupRefField, func(obj client.Object) []string {
+		elasticCache := obj.(*awsv1alpha1.ElasticCache)
+		if elasticCache.Spec.AWSConfig == nil || elasticCache.Spec.AWSConfig.SubnetGroupRef == nil {
+			return nil
+		}
+		return []string{elasticCache.Spec.AWSConfig.SubnetGroupRef.Name}
+	})
+	if err != nil {
+		return err
+	}
 
-func (r *ElasticCacheReconciler) SetLastAppliedAnnotation(instance *awsv1alpha1.ElasticCache) error {
-	marshaled, err  := json.Marshal(instance.Spec)
+	err = mgr.GetFieldIndexer().IndexField(context.Background(), &awsv1alpha1.ElasticCache{}, providerConfigRefField, func(obj client.Object) []string {
+		elasticCache := obj.(*awsv1alpha1.ElasticCache)
+		if elasticCache.Spec.ProviderConfigRef == nil {
+			return nil
+		}
+		return []string{elasticCache.Spec.ProviderConfigRef.Name}
+	})
 	if err != nil {
 		return err
 	}
-	instance.SetAnnotations(map[string]string{lastAppliedSpecAnnotation: base64.StdEncoding.EncodeToString(marshaled)})
-	err = r.Update(context.TODO(), instance)
-	return err
+
+	err = registerElasticCacheClusterMetrics(mgr.GetClient())
+	if err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&awsv1alpha1.ElasticCache{}, specChanged()).
+		Owns(&corev1.Secret{}).
+		Owns(&corev1.ConfigMap{}).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(r.findElasticCachesForSecret)).
+		Watches(&source.Kind{Type: &awsv1alpha1.CacheSubnetGroup{}}, handler.EnqueueRequestsFromMapFunc(r.findElasticCachesForSubnetGroup)).
+		Watches(&source.Kind{Type: &awsv1alpha1.ProviderConfig{}}, handler.EnqueueRequestsFromMapFunc(r.findElasticCachesForProviderConfig)).
+		Complete(r)
 }
@@ -0,0 +1,648 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	"github.com/aws/smithy-go"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+	"github.com/sergeyshevch/cloud-resource-operator/internal/fake"
+)
+
+var _ ElastiCacheAPI = &fake.ElastiCache{}
+
+var _ = Describe("ElasticCache controller", func() {
+	var (
+		ctx        context.Context
+		fakeAPI    *fake.ElastiCache
+		reconciler *ElasticCacheReconciler
+		instance   *awsv1alpha1.ElasticCache
+		key        client.ObjectKey
+	)
+
+	reconcile := func() (ctrl.Result, error) {
+		return reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	}
+
+	get := func() *awsv1alpha1.ElasticCache {
+		current := &awsv1alpha1.ElasticCache{}
+		Expect(k8sClient.Get(ctx, key, current)).To(Succeed())
+		return current
+	}
+
+	isReady := func() bool {
+		return meta.IsStatusConditionTrue(get().Status.Conditions, awsv1alpha1.ConditionTypeReady)
+	}
+
+	// createAvailable reconciles the ElasticCache until its cache cluster is
+	// available and the finalizer is in place.
+	createAvailable := func() {
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		fakeAPI.Tick()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(isReady()).To(BeTrue())
+		fakeAPI.ResetCalls()
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		fakeAPI = fake.New()
+		reconciler = &ElasticCacheReconciler{
+			Client:               k8sClient,
+			Scheme:               scheme.Scheme,
+			Recorder:             record.NewFakeRecorder(100),
+			NewElastiCacheClient: fakeClientFunc(fakeAPI),
+		}
+
+		instance = &awsv1alpha1.ElasticCache{
+			Spec: awsv1alpha1.ElasticCacheSpec{
+				AWSConfig: &awsv1alpha1.ElasticCacheAwsConfig{
+					CacheNodeType:          aws.String("cache.t3.micro"),
+					Engine:                 aws.String("redis"),
+					EngineVersion:          aws.String("6.x"),
+					NumCacheNodes:          aws.Int32(1),
+					SnapshotRetentionLimit: aws.Int32(1),
+				},
+			},
+		}
+		key = createTestObject(ctx, instance, "cache-")
+	})
+
+	AfterEach(func() {
+		removeTestObject(ctx, key, &awsv1alpha1.ElasticCache{})
+	})
+
+	It("creates the cache cluster and becomes Ready once it is available", func() {
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		cluster, ok := fakeAPI.CacheCluster(key.Name)
+		Expect(ok).To(BeTrue())
+		Expect(aws.ToString(cluster.CacheClusterStatus)).To(Equal("creating"))
+		Expect(aws.ToString(cluster.EngineVersion)).To(Equal("6.0"))
+		Expect(aws.ToString(get().Status.CacheClusterStatus)).To(Equal("creating"))
+		Expect(controllerutil.ContainsFinalizer(get(), elasticCacheFinalizer)).To(BeTrue())
+		Expect(isReady()).To(BeFalse())
+
+		fakeAPI.Tick()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		current := get()
+		Expect(aws.ToString(current.Status.CacheClusterStatus)).To(Equal("available"))
+		Expect(current.Status.CacheNodes).To(HaveLen(1))
+		Expect(current.Status.CacheNodes[0].Endpoint).NotTo(BeNil())
+		Expect(controllerutil.ContainsFinalizer(current, elasticCacheFinalizer)).To(BeTrue())
+		Expect(isReady()).To(BeTrue())
+	})
+
+	It("requeues with backoff when the cache cluster can not be created yet", func() {
+		for i := 0; i < 2; i++ {
+			fakeAPI.InjectError("CreateCacheCluster", &types.InsufficientCacheClusterCapacityFault{
+				Message: aws.String("insufficient capacity"),
+			})
+		}
+
+		first, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(first.RequeueAfter).To(BeNumerically(">", 0))
+
+		_, ok := fakeAPI.CacheCluster(key.Name)
+		Expect(ok).To(BeFalse())
+		synced := meta.FindStatusCondition(get().Status.Conditions, awsv1alpha1.ConditionTypeSynced)
+		Expect(synced).NotTo(BeNil())
+		Expect(synced.Status).To(Equal(metav1.ConditionFalse))
+		Expect(synced.Reason).To(Equal(awsv1alpha1.ReasonReconcileError))
+		Expect(synced.Message).To(ContainSubstring("insufficient capacity"))
+
+		second, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(second.RequeueAfter).To(BeNumerically(">", first.RequeueAfter))
+
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		_, ok = fakeAPI.CacheCluster(key.Name)
+		Expect(ok).To(BeTrue())
+	})
+
+	It("does not retry when AWS rejects the cache cluster", func() {
+		fakeAPI.InjectError("CreateCacheCluster", &smithy.GenericAPIError{
+			Code:    "InvalidParameterCombination",
+			Message: "invalid parameter combination",
+		})
+
+		result, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{}))
+
+		errorCondition := meta.FindStatusCondition(get().Status.Conditions, awsv1alpha1.ConditionTypeError)
+		Expect(errorCondition).NotTo(BeNil())
+		Expect(errorCondition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(errorCondition.Reason).To(Equal(awsv1alpha1.ReasonFailed))
+		Expect(errorCondition.Message).To(ContainSubstring("invalid parameter combination"))
+	})
+
+	It("modifies the cache cluster when the spec changes", func() {
+		createAvailable()
+
+		current := get()
+		current.Spec.AWSConfig.CacheNodeType = aws.String("cache.t3.small")
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).To(ContainElement("ModifyCacheCluster"))
+		Expect(get().Status.DriftedFields).To(ConsistOf("cacheNodeType"))
+		Expect(aws.ToString(get().Status.CacheClusterStatus)).To(Equal("modifying"))
+
+		fakeAPI.Tick()
+		fakeAPI.ResetCalls()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		cluster, _ := fakeAPI.CacheCluster(key.Name)
+		Expect(aws.ToString(cluster.CacheNodeType)).To(Equal("cache.t3.small"))
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("ModifyCacheCluster"))
+		Expect(get().Status.DriftedFields).To(BeEmpty())
+		Expect(isReady()).To(BeTrue())
+	})
+
+	It("waits for the cache cluster to become available before modifying it", func() {
+		reconciler.RequeueIntervals = RequeueIntervals{Transitional: time.Second, Stable: time.Hour}
+
+		result, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(time.Second))
+
+		current := get()
+		current.Spec.AWSConfig.CacheNodeType = aws.String("cache.t3.small")
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+
+		result, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(time.Second))
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("ModifyCacheCluster"))
+		Expect(get().Status.DriftedFields).To(ConsistOf("cacheNodeType"))
+
+		fakeAPI.Tick()
+		result, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(time.Second))
+		Expect(fakeAPI.Calls()).To(ContainElement("ModifyCacheCluster"))
+
+		fakeAPI.Tick()
+		result, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(time.Hour))
+		Expect(isReady()).To(BeTrue())
+	})
+
+	It("polls the cache cluster at the configured intervals", func() {
+		reconciler.RequeueIntervals = RequeueIntervals{Transitional: 7 * time.Second, Stable: 3 * time.Minute}
+
+		result, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(7 * time.Second))
+
+		fakeAPI.Tick()
+		result, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(3 * time.Minute))
+
+		// The status updates of every pass must not trigger the next one,
+		// otherwise the intervals above are never waited for.
+		oldObject := get()
+		newObject := oldObject.DeepCopy()
+		newObject.Status.LastSyncTime = &metav1.Time{Time: oldObject.Status.LastSyncTime.Add(time.Minute)}
+		Expect(specChangedPredicate.Update(event.UpdateEvent{ObjectOld: oldObject, ObjectNew: newObject})).To(BeFalse())
+
+		newObject = oldObject.DeepCopy()
+		newObject.Generation++
+		Expect(specChangedPredicate.Update(event.UpdateEvent{ObjectOld: oldObject, ObjectNew: newObject})).To(BeTrue())
+
+		newObject = oldObject.DeepCopy()
+		newObject.Annotations = map[string]string{"example.com/touched": "true"}
+		Expect(specChangedPredicate.Update(event.UpdateEvent{ObjectOld: oldObject, ObjectNew: newObject})).To(BeTrue())
+	})
+
+	It("publishes connection details without taking over objects it does not control", func() {
+		current := get()
+		current.Spec.WriteConnectionDetailsTo = &awsv1alpha1.ConnectionDetailsTarget{
+			SecretName:    key.Name + "-conn",
+			ConfigMapName: aws.String(key.Name + "-conn"),
+		}
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+		existing := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name + "-conn", Namespace: key.Namespace},
+			Data:       map[string]string{"unrelated": "value"},
+		}
+		Expect(k8sClient.Create(ctx, existing)).To(Succeed())
+		defer func() {
+			Expect(k8sClient.Delete(ctx, existing)).To(Succeed())
+		}()
+
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		fakeAPI.Tick()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		secret := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: key.Namespace, Name: key.Name + "-conn"}, secret)).To(Succeed())
+		Expect(metav1.IsControlledBy(secret, get())).To(BeTrue())
+		Expect(secret.Data).To(HaveKey("endpoint"))
+
+		configMap := &corev1.ConfigMap{}
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(existing), configMap)).To(Succeed())
+		Expect(configMap.OwnerReferences).To(BeEmpty())
+		Expect(configMap.Data).To(Equal(map[string]string{"unrelated": "value"}))
+
+		ready := meta.FindStatusCondition(get().Status.Conditions, awsv1alpha1.ConditionTypeReady)
+		Expect(ready).NotTo(BeNil())
+		Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+		Expect(ready.Reason).To(Equal(awsv1alpha1.ReasonNotOwned))
+		Expect(ready.Message).To(ContainSubstring("ConfigMap " + key.Name + "-conn"))
+	})
+
+	It("reconciles the tags of the cache cluster", func() {
+		reconciler.DefaultTags = map[string]string{"team": "platform", "env": "dev"}
+		current := get()
+		current.Spec.AWSConfig.Tags = []awsv1alpha1.Tag{{Key: aws.String("env"), Value: aws.String("prod")}}
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+		createAvailable()
+
+		tags := func() map[string]string {
+			cluster, _ := fakeAPI.CacheCluster(key.Name)
+			output, err := fakeAPI.ListTagsForResource(ctx, &elasticache.ListTagsForResourceInput{ResourceName: cluster.ARN})
+			Expect(err).NotTo(HaveOccurred())
+			values := map[string]string{}
+			for _, tag := range output.TagList {
+				values[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+			}
+			return values
+		}
+		Expect(tags()).To(Equal(map[string]string{
+			"team":                            "platform",
+			"env":                             "prod",
+			"aws.sergeyshevch.dev/owner":      key.Namespace + "/" + key.Name,
+			"aws.sergeyshevch.dev/name":       key.Name,
+			"aws.sergeyshevch.dev/namespace":  key.Namespace,
+			"aws.sergeyshevch.dev/uid":        string(current.UID),
+			"aws.sergeyshevch.dev/managed-by": "cloud-resource-operator",
+		}))
+
+		current = get()
+		current.Spec.AWSConfig.Tags = []awsv1alpha1.Tag{{Key: aws.String("cost-center"), Value: aws.String("42")}}
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+		cluster, _ := fakeAPI.CacheCluster(key.Name)
+		_, err := fakeAPI.AddTagsToResource(ctx, &elasticache.AddTagsToResourceInput{
+			ResourceName: cluster.ARN,
+			Tags: []types.Tag{
+				{Key: aws.String("manual"), Value: aws.String("true")},
+				{Key: aws.String("aws:cloudformation:stack-name"), Value: aws.String("stack")},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tags()).To(HaveKeyWithValue("cost-center", "42"))
+		Expect(tags()).To(HaveKeyWithValue("env", "dev"))
+		Expect(tags()).To(HaveKeyWithValue("aws:cloudformation:stack-name", "stack"))
+		Expect(tags()).NotTo(HaveKey("manual"))
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("ModifyCacheCluster"))
+	})
+
+	It("configures the log delivery of the cache cluster", func() {
+		current := get()
+		current.Spec.AWSConfig.LogDeliveryConfigurations = []awsv1alpha1.LogDeliveryConfiguration{{
+			LogType:        awsv1alpha1.LogTypeSlowLog,
+			LogFormat:      awsv1alpha1.LogFormatText,
+			CloudWatchLogs: &awsv1alpha1.CloudWatchLogsDestination{LogGroup: "slow-log"},
+		}}
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+		createAvailable()
+
+		Expect(get().Status.LogDeliveryConfigurations).To(ConsistOf(awsv1alpha1.LogDeliveryStatus{
+			LogType:         awsv1alpha1.LogTypeSlowLog,
+			LogFormat:       awsv1alpha1.LogFormatText,
+			DestinationType: "cloudwatch-logs",
+			Destination:     "slow-log",
+			Status:          "active",
+		}))
+
+		current = get()
+		current.Spec.AWSConfig.LogDeliveryConfigurations = []awsv1alpha1.LogDeliveryConfiguration{{
+			LogType:         awsv1alpha1.LogTypeEngineLog,
+			LogFormat:       awsv1alpha1.LogFormatJSON,
+			KinesisFirehose: &awsv1alpha1.KinesisFirehoseDestination{DeliveryStream: "engine-log"},
+		}}
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).To(ContainElement("ModifyCacheCluster"))
+
+		fakeAPI.Tick()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(get().Status.LogDeliveryConfigurations).To(ConsistOf(awsv1alpha1.LogDeliveryStatus{
+			LogType:         awsv1alpha1.LogTypeEngineLog,
+			LogFormat:       awsv1alpha1.LogFormatJSON,
+			DestinationType: "kinesis-firehose",
+			Destination:     "engine-log",
+			Status:          "active",
+		}))
+	})
+
+	It("reverts changes made to the cache cluster outside of the operator", func() {
+		createAvailable()
+
+		_, err := fakeAPI.ModifyCacheCluster(ctx, &elasticache.ModifyCacheClusterInput{
+			CacheClusterId:         aws.String(key.Name),
+			SnapshotRetentionLimit: aws.Int32(7),
+			ApplyImmediately:       true,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		fakeAPI.Tick()
+		fakeAPI.ResetCalls()
+
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).To(ContainElement("ModifyCacheCluster"))
+		Expect(get().Status.DriftedFields).To(ConsistOf("snapshotRetentionLimit"))
+
+		cluster, _ := fakeAPI.CacheCluster(key.Name)
+		Expect(aws.ToInt32(cluster.SnapshotRetentionLimit)).To(Equal(int32(1)))
+	})
+
+	It("records events for the lifecycle of the cache cluster", func() {
+		fakeAPI.InjectError("CreateCacheCluster", &types.InsufficientCacheClusterCapacityFault{
+			Message: aws.String("insufficient capacity"),
+		})
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recordedEvents(reconciler.Recorder)).To(ConsistOf(HavePrefix("Warning ReconcileError")))
+
+		createAvailable()
+		Expect(recordedEvents(reconciler.Recorder)).To(ConsistOf(
+			HavePrefix("Normal CreateRequested"),
+			And(HavePrefix("Normal StateChanged"), HaveSuffix("from creating to available")),
+		))
+
+		current := get()
+		current.Spec.AWSConfig.CacheNodeType = aws.String("cache.t3.small")
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recordedEvents(reconciler.Recorder)).To(ConsistOf(
+			And(HavePrefix("Normal Modified"), HaveSuffix("cacheNodeType")),
+			And(HavePrefix("Normal StateChanged"), HaveSuffix("from available to modifying")),
+		))
+
+		Expect(k8sClient.Delete(ctx, get())).To(Succeed())
+		fakeAPI.Tick()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		fakeAPI.Tick()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recordedEvents(reconciler.Recorder)).To(ConsistOf(
+			HavePrefix("Normal DeletionStarted"),
+			And(HavePrefix("Normal StateChanged"), HaveSuffix("from modifying to deleting")),
+			HavePrefix("Normal Deleted"),
+		))
+	})
+
+	It("records metrics of the cache cluster", func() {
+		timeToAvailable.Reset()
+		createAvailable()
+		Expect(testutil.CollectAndCount(timeToAvailable)).To(Equal(1))
+		Expect(testutil.CollectAndCount(reconcileDuration)).To(BeNumerically(">", 0))
+
+		collector := &elasticCacheClusterCollector{reader: k8sClient}
+		Expect(testutil.CollectAndCount(collector)).To(BeNumerically(">", 0))
+
+		drifts := driftDetectionsTotal.WithLabelValues("ElasticCache", "snapshotRetentionLimit")
+		before := testutil.ToFloat64(drifts)
+		_, err := fakeAPI.ModifyCacheCluster(ctx, &elasticache.ModifyCacheClusterInput{
+			CacheClusterId:         aws.String(key.Name),
+			SnapshotRetentionLimit: aws.Int32(7),
+			ApplyImmediately:       true,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		fakeAPI.Tick()
+
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(testutil.ToFloat64(drifts)).To(Equal(before + 1))
+
+		// The drift is only counted once while it is being reverted.
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(testutil.ToFloat64(drifts)).To(Equal(before + 1))
+	})
+
+	It("rotates the auth token when the referenced Secret changes", func() {
+		secret := &corev1.Secret{
+			Data: map[string][]byte{"token": []byte("0123456789abcdef0123")},
+		}
+		secretKey := createTestObject(ctx, secret, "auth-")
+		defer func() {
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, secret))).To(Succeed())
+		}()
+		current := get()
+		current.Spec.AWSConfig.AuthTokenSecretRef = &awsv1alpha1.SecretKeySelector{Name: secret.Name, Key: "token"}
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+
+		createAvailable()
+		cluster, ok := fakeAPI.CacheCluster(key.Name)
+		Expect(ok).To(BeTrue())
+		Expect(aws.ToBool(cluster.AuthTokenEnabled)).To(BeTrue())
+		hash := get().Status.AuthTokenHash
+		Expect(hash).NotTo(BeEmpty())
+
+		Expect(k8sClient.Get(ctx, secretKey, secret)).To(Succeed())
+		secret.Data["token"] = []byte("fedcba9876543210fedc")
+		Expect(k8sClient.Update(ctx, secret)).To(Succeed())
+
+		var modify *elasticache.ModifyCacheClusterInput
+		reconciler.NewElastiCacheClient = func(aws.Config) ElastiCacheAPI {
+			return &modifyCacheClusterRecorder{ElastiCache: fakeAPI, input: &modify}
+		}
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(modify).NotTo(BeNil())
+		Expect(aws.ToString(modify.AuthToken)).To(Equal("fedcba9876543210fedc"))
+		Expect(modify.AuthTokenUpdateStrategy).To(Equal(types.AuthTokenUpdateStrategyTypeRotate))
+		Expect(get().Status.AuthTokenHash).NotTo(Equal(hash))
+	})
+
+	It("moves the deprecated auth token to a Secret", func() {
+		current := get()
+		current.Spec.AWSConfig.AuthToken = aws.String("0123456789abcdef0123")
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		current = get()
+		Expect(current.Spec.AWSConfig.AuthToken).To(BeNil())
+		Expect(current.Spec.AWSConfig.AuthTokenSecretRef).To(Equal(&awsv1alpha1.SecretKeySelector{
+			Name: key.Name + "-auth-token",
+			Key:  authTokenSecretKey,
+		}))
+		secret := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: key.Namespace, Name: key.Name + "-auth-token"}, secret)).To(Succeed())
+		Expect(metav1.IsControlledBy(secret, current)).To(BeTrue())
+		Expect(secret.Data).To(HaveKeyWithValue(authTokenSecretKey, []byte("0123456789abcdef0123")))
+		Expect(recordedEvents(reconciler.Recorder)).To(ContainElement(HavePrefix("Normal AuthTokenMigrated")))
+
+		cluster, ok := fakeAPI.CacheCluster(key.Name)
+		Expect(ok).To(BeTrue())
+		Expect(aws.ToBool(cluster.AuthTokenEnabled)).To(BeTrue())
+		Expect(current.Status.AuthTokenHash).To(Equal(hashSecretValue(current, "0123456789abcdef0123")))
+	})
+
+	It("does not rotate the token of a cluster created with the deprecated auth token", func() {
+		createAvailable()
+
+		// Clusters created before the token moved to Secrets have no hash.
+		current := get()
+		current.Spec.AWSConfig.AuthToken = aws.String("0123456789abcdef0123")
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("ModifyCacheCluster"))
+		Expect(get().Status.AuthTokenHash).To(Equal(hashSecretValue(current, "0123456789abcdef0123")))
+	})
+
+	It("deletes the cache cluster before removing the finalizer", func() {
+		createAvailable()
+
+		Expect(k8sClient.Delete(ctx, get())).To(Succeed())
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		cluster, ok := fakeAPI.CacheCluster(key.Name)
+		Expect(ok).To(BeTrue())
+		Expect(aws.ToString(cluster.CacheClusterStatus)).To(Equal("deleting"))
+		Expect(controllerutil.ContainsFinalizer(get(), elasticCacheFinalizer)).To(BeTrue())
+
+		fakeAPI.Tick()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		_, ok = fakeAPI.CacheCluster(key.Name)
+		Expect(ok).To(BeFalse())
+		err = k8sClient.Get(ctx, key, &awsv1alpha1.ElasticCache{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("retains the cache cluster when the deletion policy is Retain", func() {
+		current := get()
+		current.Spec.DeletionPolicy = awsv1alpha1.DeletionPolicyRetain
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+		createAvailable()
+
+		Expect(k8sClient.Delete(ctx, get())).To(Succeed())
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("DeleteCacheCluster"))
+		cluster, ok := fakeAPI.CacheCluster(key.Name)
+		Expect(ok).To(BeTrue())
+		Expect(aws.ToString(cluster.CacheClusterStatus)).To(Equal("available"))
+		err = k8sClient.Get(ctx, key, &awsv1alpha1.ElasticCache{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("takes a final snapshot when the deletion policy is Snapshot", func() {
+		current := get()
+		current.Spec.DeletionPolicy = awsv1alpha1.DeletionPolicySnapshot
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+		createAvailable()
+
+		var deleteInput *elasticache.DeleteCacheClusterInput
+		reconciler.NewElastiCacheClient = func(aws.Config) ElastiCacheAPI {
+			return &deleteCacheClusterRecorder{ElastiCache: fakeAPI, input: &deleteInput}
+		}
+		Expect(k8sClient.Delete(ctx, get())).To(Succeed())
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		current = get()
+		finalSnapshot := fmt.Sprintf("%s-final-%d", key.Name, current.GetDeletionTimestamp().Unix())
+		Expect(deleteInput).NotTo(BeNil())
+		Expect(aws.ToString(deleteInput.FinalSnapshotIdentifier)).To(Equal(finalSnapshot))
+		Expect(aws.ToString(current.Status.FinalSnapshotIdentifier)).To(Equal(finalSnapshot))
+
+		fakeAPI.Tick()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		_, err = fakeAPI.DescribeSnapshots(ctx, &elasticache.DescribeSnapshotsInput{SnapshotName: aws.String(finalSnapshot)})
+		Expect(err).NotTo(HaveOccurred())
+		err = k8sClient.Get(ctx, key, &awsv1alpha1.ElasticCache{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+})
+
+// modifyCacheClusterRecorder records the input of the last ModifyCacheCluster
+// call.
+type modifyCacheClusterRecorder struct {
+	*fake.ElastiCache
+	input **elasticache.ModifyCacheClusterInput
+}
+
+func (r *modifyCacheClusterRecorder) ModifyCacheCluster(ctx context.Context, params *elasticache.ModifyCacheClusterInput, optFns ...func(*elasticache.Options)) (*elasticache.ModifyCacheClusterOutput, error) {
+	*r.input = params
+	return r.ElastiCache.ModifyCacheCluster(ctx, params, optFns...)
+}
+
+// deleteCacheClusterRecorder records the input of the last DeleteCacheCluster
+// call.
+type deleteCacheClusterRecorder struct {
+	*fake.ElastiCache
+	input **elasticache.DeleteCacheClusterInput
+}
+
+func (r *deleteCacheClusterRecorder) DeleteCacheCluster(ctx context.Context, params *elasticache.DeleteCacheClusterInput, optFns ...func(*elasticache.Options)) (*elasticache.DeleteCacheClusterOutput, error) {
+	*r.input = params
+	return r.ElastiCache.DeleteCacheCluster(ctx, params, optFns...)
+}
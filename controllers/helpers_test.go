@@ -0,0 +1,93 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+	"github.com/sergeyshevch/cloud-resource-operator/internal/fake"
+)
+
+// testNamespace is the namespace of the objects created by the specs.
+const testNamespace = "default"
+
+// fakeClientFunc returns an ElastiCacheClientFunc handing out api for any
+// configuration.
+func fakeClientFunc(api *fake.ElastiCache) ElastiCacheClientFunc {
+	return func(aws.Config) ElastiCacheAPI {
+		return api
+	}
+}
+
+// createTestObject creates obj in the test namespace under a name generated
+// from prefix and returns its key.
+func createTestObject(ctx context.Context, obj client.Object, prefix string) client.ObjectKey {
+	obj.SetGenerateName(prefix)
+	obj.SetNamespace(testNamespace)
+	Expect(k8sClient.Create(ctx, obj)).To(Succeed())
+	return client.ObjectKeyFromObject(obj)
+}
+
+// removeTestObject deletes the object stored under key, removing its
+// finalizers first so the specs do not depend on the reconciler to clean up.
+// obj only selects the kind.
+func removeTestObject(ctx context.Context, key client.ObjectKey, obj client.Object) {
+	err := k8sClient.Get(ctx, key, obj)
+	if errors.IsNotFound(err) {
+		return
+	}
+	Expect(err).NotTo(HaveOccurred())
+	if len(obj.GetFinalizers()) > 0 {
+		obj.SetFinalizers(nil)
+		Expect(k8sClient.Update(ctx, obj)).To(Succeed())
+	}
+	Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, obj))).To(Succeed())
+}
+
+// markReady sets the Ready condition of a referenced object, standing in for
+// its own reconciler.
+func markReady(ctx context.Context, obj client.Object, conditions *[]metav1.Condition) {
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:   awsv1alpha1.ConditionTypeReady,
+		Status: metav1.ConditionTrue,
+		Reason: "Available",
+	})
+	Expect(k8sClient.Status().Update(ctx, obj)).To(Succeed())
+}
+
+// recordedEvents drains the events recorded so far by recorder.
+func recordedEvents(recorder record.EventRecorder) []string {
+	events := recorder.(*record.FakeRecorder).Events
+	var recorded []string
+	for {
+		select {
+		case event := <-events:
+			recorded = append(recorded, event)
+		default:
+			return recorded
+		}
+	}
+}
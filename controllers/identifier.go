@@ -0,0 +1,142 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+)
+
+// maxCacheClusterIdLength is the longest cache cluster identifier accepted.
+// ElastiCache allows longer identifiers for standalone clusters, but member
+// clusters of replication groups are limited to 40 characters.
+const maxCacheClusterIdLength = 40
+
+// cacheClusterIdHashLength is the number of hex characters of the hash used
+// by the NamespacedHash naming strategy.
+const cacheClusterIdHashLength = 8
+
+var cacheClusterIdPattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9-]*$`)
+var invalidCacheClusterIdChars = regexp.MustCompile(`[^a-z0-9-]+`)
+var repeatedHyphens = regexp.MustCompile(`-{2,}`)
+
+// cacheClusterId returns the identifier of the cache cluster managed by the
+// ElasticCache. Once a cluster was observed its identifier is taken from the
+// status, so later spec changes do not orphan it.
+func cacheClusterId(cr *awsv1alpha1.ElasticCache) *string {
+	if aws.ToString(cr.Status.CacheClusterId) != "" {
+		return cr.Status.CacheClusterId
+	}
+	id := desiredCacheClusterId(cr)
+	return &id
+}
+
+// desiredCacheClusterId derives the cache cluster identifier from the spec.
+func desiredCacheClusterId(cr *awsv1alpha1.ElasticCache) string {
+	if aws.ToString(cr.Spec.ExternalName) != "" {
+		return *cr.Spec.ExternalName
+	}
+	if cr.Spec.NamingStrategy == awsv1alpha1.ClusterNamingStrategyNamespacedHash {
+		return namespacedHashId(cr.Namespace, cr.Name)
+	}
+	return cr.Name
+}
+
+// replicationGroupId returns the identifier of the replication group managed by
+// the ReplicationGroup, following the rules of cacheClusterId.
+func replicationGroupId(cr *awsv1alpha1.ReplicationGroup) *string {
+	if aws.ToString(cr.Status.ReplicationGroupId) != "" {
+		return cr.Status.ReplicationGroupId
+	}
+	id := desiredReplicationGroupId(cr)
+	return &id
+}
+
+// desiredReplicationGroupId derives the replication group identifier from the
+// spec.
+func desiredReplicationGroupId(cr *awsv1alpha1.ReplicationGroup) string {
+	if aws.ToString(cr.Spec.ExternalName) != "" {
+		return *cr.Spec.ExternalName
+	}
+	if cr.Spec.NamingStrategy == awsv1alpha1.ClusterNamingStrategyNamespacedHash {
+		return namespacedHashId(cr.Namespace, cr.Name)
+	}
+	return cr.Name
+}
+
+// cacheSnapshotName returns the name of the snapshot taken by the
+// CacheSnapshot: the name recorded in the status, or the namespaced name of
+// the CacheSnapshot before the snapshot is taken. Snapshots are named after
+// the namespace so equally named CacheSnapshots do not share a snapshot.
+func cacheSnapshotName(cr *awsv1alpha1.CacheSnapshot) *string {
+	if aws.ToString(cr.Status.SnapshotName) != "" {
+		return cr.Status.SnapshotName
+	}
+	name := namespacedHashId(cr.Namespace, cr.Name)
+	return &name
+}
+
+// namespacedHashId returns "<namespace>-<name>-<hash>", shortened to a legal
+// cache cluster identifier. The hash keeps identifiers unique when the readable
+// part is truncated or sanitized.
+func namespacedHashId(namespace string, name string) string {
+	sum := sha256.Sum256([]byte(namespace + "/" + name))
+	hash := hex.EncodeToString(sum[:])[:cacheClusterIdHashLength]
+
+	prefix := invalidCacheClusterIdChars.ReplaceAllString(strings.ToLower(namespace+"-"+name), "-")
+	prefix = repeatedHyphens.ReplaceAllString(prefix, "-")
+	if prefix == "" || prefix[0] < 'a' || prefix[0] > 'z' {
+		prefix = "c" + prefix
+	}
+	if maxPrefix := maxCacheClusterIdLength - cacheClusterIdHashLength - 1; len(prefix) > maxPrefix {
+		prefix = prefix[:maxPrefix]
+	}
+	prefix = strings.TrimRight(prefix, "-")
+
+	return prefix + "-" + hash
+}
+
+// validateCacheClusterId returns an error when id is not a legal cache cluster
+// identifier.
+func validateCacheClusterId(id string) error {
+	return validateIdentifier("cache cluster", id)
+}
+
+// validateReplicationGroupId returns an error when id is not a legal
+// replication group identifier, which follows the rules of cache clusters.
+func validateReplicationGroupId(id string) error {
+	return validateIdentifier("replication group", id)
+}
+
+func validateIdentifier(kind string, id string) error {
+	switch {
+	case len(id) == 0 || len(id) > maxCacheClusterIdLength:
+		return fmt.Errorf("%s identifier %q must contain from 1 to %d characters", kind, id, maxCacheClusterIdLength)
+	case !cacheClusterIdPattern.MatchString(id):
+		return fmt.Errorf("%s identifier %q must start with a letter and contain only alphanumeric characters or hyphens", kind, id)
+	case strings.HasSuffix(id, "-") || strings.Contains(id, "--"):
+		return fmt.Errorf("%s identifier %q cannot end with a hyphen or contain two consecutive hyphens", kind, id)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+)
+
+var _ = Describe("Cache cluster identifiers", func() {
+	elasticCache := func(strategy awsv1alpha1.ClusterNamingStrategy, externalName *string) *awsv1alpha1.ElasticCache {
+		return &awsv1alpha1.ElasticCache{
+			ObjectMeta: metav1.ObjectMeta{Name: "cache", Namespace: "team-a"},
+			Spec: awsv1alpha1.ElasticCacheSpec{
+				NamingStrategy: strategy,
+				ExternalName:   externalName,
+			},
+		}
+	}
+
+	DescribeTable("desiredCacheClusterId",
+		func(cr *awsv1alpha1.ElasticCache, want string) {
+			Expect(desiredCacheClusterId(cr)).To(Equal(want))
+		},
+		Entry("the name by default", elasticCache("", nil), "cache"),
+		Entry("the name", elasticCache(awsv1alpha1.ClusterNamingStrategyName, nil), "cache"),
+		Entry("the namespace, the name and a hash",
+			elasticCache(awsv1alpha1.ClusterNamingStrategyNamespacedHash, nil), namespacedHashId("team-a", "cache")),
+		Entry("the external name over the strategy",
+			elasticCache(awsv1alpha1.ClusterNamingStrategyNamespacedHash, aws.String("legacy")), "legacy"),
+		Entry("the strategy when the external name is empty",
+			elasticCache(awsv1alpha1.ClusterNamingStrategyName, aws.String("")), "cache"),
+	)
+
+	It("keeps the observed identifier when the spec changes", func() {
+		cr := elasticCache(awsv1alpha1.ClusterNamingStrategyNamespacedHash, nil)
+		cr.Status.CacheClusterId = aws.String("cache")
+		Expect(aws.ToString(cacheClusterId(cr))).To(Equal("cache"))
+
+		cr.Status.CacheClusterId = nil
+		Expect(aws.ToString(cacheClusterId(cr))).To(Equal(namespacedHashId("team-a", "cache")))
+	})
+
+	DescribeTable("namespacedHashId",
+		func(namespace string, name string, prefix string) {
+			id := namespacedHashId(namespace, name)
+			Expect(id).To(HavePrefix(prefix + "-"))
+			Expect(id).To(HaveLen(len(prefix) + 1 + cacheClusterIdHashLength))
+			Expect(validateCacheClusterId(id)).To(Succeed())
+			Expect(namespacedHashId(namespace, name)).To(Equal(id))
+		},
+		Entry("joins the namespace and the name", "team-a", "cache", "team-a-cache"),
+		Entry("lowercases", "Team-A", "Cache", "team-a-cache"),
+		Entry("replaces illegal characters", "team_a", "cache.v2", "team-a-cache-v2"),
+		Entry("collapses hyphens", "team--a", "-cache", "team-a-cache"),
+		Entry("starts with a letter", "1team", "cache", "c1team-cache"),
+		Entry("truncates long names", "production", strings.Repeat("session-", 6), "production-session-session-sess"),
+		Entry("does not end the prefix with a hyphen", "production", "sessions-cache-data-primary", "production-sessions-cache-data"),
+	)
+
+	It("keeps the identifiers of truncated names unique", func() {
+		long := strings.Repeat("a", 50)
+		Expect(namespacedHashId("default", long+"-one")).NotTo(Equal(namespacedHashId("default", long+"-two")))
+		Expect(namespacedHashId("team-a", "cache")).NotTo(Equal(namespacedHashId("team", "a-cache")))
+	})
+
+	DescribeTable("validateCacheClusterId",
+		func(id string, valid bool) {
+			if valid {
+				Expect(validateCacheClusterId(id)).To(Succeed())
+			} else {
+				Expect(validateCacheClusterId(id)).NotTo(Succeed())
+			}
+		},
+		Entry("a name", "cache", true),
+		Entry("hyphens and digits", "cache-01", true),
+		Entry("40 characters", strings.Repeat("a", 40), true),
+		Entry("an empty identifier", "", false),
+		Entry("41 characters", strings.Repeat("a", 41), false),
+		Entry("a leading digit", "1cache", false),
+		Entry("a leading hyphen", "-cache", false),
+		Entry("an underscore", "cache_01", false),
+		Entry("a trailing hyphen", "cache-", false),
+		Entry("consecutive hyphens", "cache--01", false),
+	)
+})
+
+var _ = Describe("Replication group identifiers", func() {
+	It("follows the naming strategy until the identifier is observed", func() {
+		cr := &awsv1alpha1.ReplicationGroup{
+			ObjectMeta: metav1.ObjectMeta{Name: "group", Namespace: "team-a"},
+		}
+		Expect(aws.ToString(replicationGroupId(cr))).To(Equal("group"))
+
+		cr.Spec.NamingStrategy = awsv1alpha1.ClusterNamingStrategyNamespacedHash
+		Expect(aws.ToString(replicationGroupId(cr))).To(Equal(namespacedHashId("team-a", "group")))
+
+		cr.Spec.ExternalName = aws.String("legacy")
+		Expect(aws.ToString(replicationGroupId(cr))).To(Equal("legacy"))
+
+		cr.Status.ReplicationGroupId = aws.String("group")
+		Expect(aws.ToString(replicationGroupId(cr))).To(Equal("group"))
+	})
+})
@@ -0,0 +1,154 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+
+	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+)
+
+// logDeliveryRequests returns the requests enabling the log deliveries of the
+// spec.
+func logDeliveryRequests(configs []awsv1alpha1.LogDeliveryConfiguration) []types.LogDeliveryConfigurationRequest {
+	var requests []types.LogDeliveryConfigurationRequest
+	for _, config := range configs {
+		destinationType, details := logDeliveryDestination(config)
+		requests = append(requests, types.LogDeliveryConfigurationRequest{
+			DestinationDetails: details,
+			DestinationType:    destinationType,
+			Enabled:            aws.Bool(true),
+			LogFormat:          types.LogFormat(logFormat(config)),
+			LogType:            types.LogType(config.LogType),
+		})
+	}
+	return requests
+}
+
+// diffLogDelivery returns the requests that make the log deliveries of the
+// cluster match the spec: deliveries that are missing or differ are enabled,
+// deliveries of logs that are not listed are disabled.
+func diffLogDelivery(configs []awsv1alpha1.LogDeliveryConfiguration, cluster *types.CacheCluster) []types.LogDeliveryConfigurationRequest {
+	current := map[types.LogType]types.LogDeliveryConfiguration{}
+	for _, delivery := range cluster.LogDeliveryConfigurations {
+		if delivery.Status != types.LogDeliveryConfigurationStatusDisabling {
+			current[delivery.LogType] = delivery
+		}
+	}
+
+	var requests []types.LogDeliveryConfigurationRequest
+	desired := map[types.LogType]bool{}
+	for i, config := range configs {
+		logType := types.LogType(config.LogType)
+		desired[logType] = true
+		delivery, ok := current[logType]
+		destinationType, details := logDeliveryDestination(config)
+		if ok && delivery.DestinationType == destinationType && delivery.LogFormat == types.LogFormat(logFormat(config)) &&
+			destinationName(delivery.DestinationDetails) == destinationName(details) {
+			continue
+		}
+		requests = append(requests, logDeliveryRequests(configs[i:i+1])...)
+	}
+	for _, delivery := range cluster.LogDeliveryConfigurations {
+		if _, ok := current[delivery.LogType]; ok && !desired[delivery.LogType] {
+			requests = append(requests, types.LogDeliveryConfigurationRequest{
+				Enabled: aws.Bool(false),
+				LogType: delivery.LogType,
+			})
+		}
+	}
+	return requests
+}
+
+// logDeliveryStatuses converts the log deliveries of the cluster for the
+// status.
+func logDeliveryStatuses(deliveries []types.LogDeliveryConfiguration) []awsv1alpha1.LogDeliveryStatus {
+	var statuses []awsv1alpha1.LogDeliveryStatus
+	for _, delivery := range deliveries {
+		statuses = append(statuses, awsv1alpha1.LogDeliveryStatus{
+			LogType:         awsv1alpha1.LogType(delivery.LogType),
+			LogFormat:       awsv1alpha1.LogFormat(delivery.LogFormat),
+			DestinationType: string(delivery.DestinationType),
+			Destination:     destinationName(delivery.DestinationDetails),
+			Status:          string(delivery.Status),
+			Message:         delivery.Message,
+		})
+	}
+	return statuses
+}
+
+// backfillLogDelivery copies the log deliveries of an imported cache cluster
+// into the spec when it lists none, so adopting the cluster keeps them.
+func backfillLogDelivery(config *awsv1alpha1.ElasticCacheAwsConfig, cluster *types.CacheCluster) {
+	if config.LogDeliveryConfigurations != nil {
+		return
+	}
+	for _, delivery := range cluster.LogDeliveryConfigurations {
+		if delivery.Status == types.LogDeliveryConfigurationStatusDisabling {
+			continue
+		}
+		logDelivery := awsv1alpha1.LogDeliveryConfiguration{
+			LogType:   awsv1alpha1.LogType(delivery.LogType),
+			LogFormat: awsv1alpha1.LogFormat(delivery.LogFormat),
+		}
+		name := destinationName(delivery.DestinationDetails)
+		switch delivery.DestinationType {
+		case types.DestinationTypeCloudWatchLogs:
+			logDelivery.CloudWatchLogs = &awsv1alpha1.CloudWatchLogsDestination{LogGroup: name}
+		case types.DestinationTypeKinesisFirehose:
+			logDelivery.KinesisFirehose = &awsv1alpha1.KinesisFirehoseDestination{DeliveryStream: name}
+		default:
+			continue
+		}
+		config.LogDeliveryConfigurations = append(config.LogDeliveryConfigurations, logDelivery)
+	}
+}
+
+func logDeliveryDestination(config awsv1alpha1.LogDeliveryConfiguration) (types.DestinationType, *types.DestinationDetails) {
+	switch {
+	case config.CloudWatchLogs != nil:
+		return types.DestinationTypeCloudWatchLogs, &types.DestinationDetails{
+			CloudWatchLogsDetails: &types.CloudWatchLogsDestinationDetails{LogGroup: aws.String(config.CloudWatchLogs.LogGroup)},
+		}
+	case config.KinesisFirehose != nil:
+		return types.DestinationTypeKinesisFirehose, &types.DestinationDetails{
+			KinesisFirehoseDetails: &types.KinesisFirehoseDestinationDetails{DeliveryStream: aws.String(config.KinesisFirehose.DeliveryStream)},
+		}
+	}
+	return "", nil
+}
+
+// destinationName returns the log group or delivery stream of details.
+func destinationName(details *types.DestinationDetails) string {
+	switch {
+	case details == nil:
+		return ""
+	case details.CloudWatchLogsDetails != nil:
+		return aws.ToString(details.CloudWatchLogsDetails.LogGroup)
+	case details.KinesisFirehoseDetails != nil:
+		return aws.ToString(details.KinesisFirehoseDetails.DeliveryStream)
+	}
+	return ""
+}
+
+func logFormat(config awsv1alpha1.LogDeliveryConfiguration) awsv1alpha1.LogFormat {
+	if config.LogFormat == "" {
+		return awsv1alpha1.LogFormatJSON
+	}
+	return config.LogFormat
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	goerrors "errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+)
+
+const metricsNamespace = "cloud_resource_operator"
+
+var (
+	awsAPICallsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "aws_api_calls_total",
+		Help:      "Number of calls to the AWS API by service and operation.",
+	}, []string{"service", "operation"})
+
+	awsAPICallErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "aws_api_call_errors_total",
+		Help:      "Number of failed calls to the AWS API by service, operation and error code.",
+	}, []string{"service", "operation", "code"})
+
+	awsAPICallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "aws_api_call_duration_seconds",
+		Help:      "Duration of calls to the AWS API including retries by service and operation.",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 10),
+	}, []string{"service", "operation"})
+
+	reconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "reconcile_duration_seconds",
+		Help:      "Duration of reconciliations by kind of resource.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12),
+	}, []string{"kind"})
+
+	driftDetectionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "cluster_drift_detections_total",
+		Help:      "Number of fields of cache clusters and replication groups found to differ from the spec in AWS by kind of resource and field.",
+	}, []string{"kind", "field"})
+
+	timeToAvailable = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "time_to_available_seconds",
+		Help:      "Time from the creation of a cache cluster in AWS until it was observed available, by engine.",
+		Buckets:   prometheus.ExponentialBuckets(60, 1.5, 10),
+	}, []string{"engine"})
+
+	elasticCacheClustersDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "", "elasticcache_clusters"),
+		"Number of cache clusters managed by ElasticCaches by state, engine and node type.",
+		[]string{"status", "engine", "node_type"}, nil,
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		awsAPICallsTotal,
+		awsAPICallErrorsTotal,
+		awsAPICallDuration,
+		reconcileDuration,
+		driftDetectionsTotal,
+		timeToAvailable,
+	)
+}
+
+// withAPICallMetrics returns a copy of cfg whose clients record the calls they
+// make in the AWS API call metrics.
+func withAPICallMetrics(cfg aws.Config) aws.Config {
+	cfg = cfg.Copy()
+	// Copy is shallow, limit the capacity so append never writes into the
+	// options of cfg.
+	cfg.APIOptions = append(cfg.APIOptions[:len(cfg.APIOptions):len(cfg.APIOptions)], func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("APICallMetrics", recordAPICall), middleware.After)
+	})
+	return cfg
+}
+
+// recordAPICall is an initialize middleware, it observes a call of an AWS
+// operation once all of its attempts are done.
+func recordAPICall(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (
+	middleware.InitializeOutput, middleware.Metadata, error) {
+	service := awsmiddleware.GetServiceID(ctx)
+	operation := awsmiddleware.GetOperationName(ctx)
+	start := time.Now()
+
+	out, metadata, err := next.HandleInitialize(ctx, in)
+
+	awsAPICallsTotal.WithLabelValues(service, operation).Inc()
+	awsAPICallDuration.WithLabelValues(service, operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		code := "Unknown"
+		var apiErr smithy.APIError
+		if goerrors.As(err, &apiErr) {
+			code = apiErr.ErrorCode()
+		}
+		awsAPICallErrorsTotal.WithLabelValues(service, operation, code).Inc()
+	}
+	return out, metadata, err
+}
+
+// observeReconcileDuration records the duration of a reconciliation of kind
+// started at start, it is meant to be deferred.
+func observeReconcileDuration(kind string, start time.Time) {
+	reconcileDuration.WithLabelValues(kind).Observe(time.Since(start).Seconds())
+}
+
+// recordDriftDetections counts the fields of drifted that were not drifted
+// yet when the resource was last observed. Only the ElasticCache and
+// ReplicationGroup reconcilers report the fields that drifted.
+func recordDriftDetections(kind string, previous []string, drifted []string) {
+	known := map[string]bool{}
+	for _, field := range previous {
+		known[field] = true
+	}
+	for _, field := range drifted {
+		if !known[field] {
+			driftDetectionsTotal.WithLabelValues(kind, field).Inc()
+		}
+	}
+}
+
+// elasticCacheClusterCollector counts the cache clusters of all ElasticCaches
+// on every scrape, so clusters of deleted ElasticCaches do not linger.
+type elasticCacheClusterCollector struct {
+	reader client.Reader
+}
+
+func (c *elasticCacheClusterCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- elasticCacheClustersDesc
+}
+
+func (c *elasticCacheClusterCollector) Collect(ch chan<- prometheus.Metric) {
+	list := &awsv1alpha1.ElasticCacheList{}
+	if err := c.reader.List(context.TODO(), list); err != nil {
+		ch <- prometheus.NewInvalidMetric(elasticCacheClustersDesc, err)
+		return
+	}
+
+	counts := map[[3]string]int{}
+	for _, instance := range list.Items {
+		if instance.Status.CacheClusterStatus == nil {
+			continue
+		}
+		counts[[3]string{
+			aws.ToString(instance.Status.CacheClusterStatus),
+			aws.ToString(instance.Status.Engine),
+			aws.ToString(instance.Status.CacheNodeType),
+		}]++
+	}
+	for labels, count := range counts {
+		ch <- prometheus.MustNewConstMetric(elasticCacheClustersDesc, prometheus.GaugeValue, float64(count), labels[:]...)
+	}
+}
+
+// registerElasticCacheClusterMetrics registers the collector of the managed
+// cache clusters, reading ElasticCaches through reader.
+func registerElasticCacheClusterMetrics(reader client.Reader) error {
+	err := metrics.Registry.Register(&elasticCacheClusterCollector{reader: reader})
+	if goerrors.As(err, &prometheus.AlreadyRegisteredError{}) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,33 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// specChangedPredicate only passes changes of the generation or the annotations
+// of the reconciled object. The controllers update the status of the objects
+// they reconcile on every pass, passing those updates would start the next pass
+// right away instead of after the requeue interval.
+var specChangedPredicate = predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{})
+
+// specChanged returns specChangedPredicate as an option of For.
+func specChanged() builder.Predicates {
+	return builder.WithPredicates(specChangedPredicate)
+}
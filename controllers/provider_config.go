@@ -0,0 +1,178 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+)
+
+// defaultWebIdentityTokenFile is the token mounted by EKS for IAM roles for
+// service accounts.
+var defaultWebIdentityTokenFile = "/var/run/secrets/eks.amazonaws.com/serviceaccount/token"
+
+// providerClients caches the elasticache clients built for ProviderConfigs so
+// their credentials are reused across reconciliations. A client is rebuilt when
+// the ProviderConfig or its credentials Secret changes and evicted when the
+// ProviderConfig is deleted.
+type providerClients struct {
+	mu      sync.Mutex
+	clients map[types.NamespacedName]providerClient
+}
+
+type providerClient struct {
+	// version combines the resource versions of the ProviderConfig and its
+	// credentials Secret the client was built from.
+	version string
+	client  ElastiCacheAPI
+}
+
+// elasticCacheClient returns the client built by newClient for providerConfig,
+// which overrides the region and credentials of base.
+func (c *providerClients) elasticCacheClient(ctx context.Context, kubeClient client.Client, base aws.Config,
+	newClient ElastiCacheClientFunc, providerConfig *awsv1alpha1.ProviderConfig) (ElastiCacheAPI, error) {
+	version := providerConfig.ResourceVersion
+
+	var secret *corev1.Secret
+	if providerConfig.Spec.Credentials.Source == awsv1alpha1.CredentialsSourceSecret {
+		ref := providerConfig.Spec.Credentials.SecretRef
+		if ref == nil {
+			return nil, fmt.Errorf("ProviderConfig %s has no secretRef for credentials source Secret", providerConfig.Name)
+		}
+		secret = &corev1.Secret{}
+		err := kubeClient.Get(ctx, client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}, secret)
+		if err != nil {
+			return nil, err
+		}
+		version += "/" + secret.ResourceVersion
+	}
+
+	key := client.ObjectKeyFromObject(providerConfig)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cached, ok := c.clients[key]; ok && cached.version == version {
+		return cached.client, nil
+	}
+
+	cfg, err := providerAwsConfig(base, providerConfig, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.clients == nil {
+		c.clients = map[types.NamespacedName]providerClient{}
+	}
+	awsClient := newElastiCacheClient(newClient, cfg)
+	c.clients[key] = providerClient{version: version, client: awsClient}
+	return awsClient, nil
+}
+
+// evict forgets the client built for the ProviderConfig with the given key.
+func (c *providerClients) evict(key types.NamespacedName) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.clients, key)
+}
+
+// providerConfigSelectsNamespace reports whether resources in namespace may
+// reference providerConfig.
+func providerConfigSelectsNamespace(ctx context.Context, kubeClient client.Client, providerConfig *awsv1alpha1.ProviderConfig,
+	namespace string) (bool, error) {
+	if providerConfig.Spec.NamespaceSelector == nil {
+		return true, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(providerConfig.Spec.NamespaceSelector)
+	if err != nil {
+		return false, fmt.Errorf("invalid namespaceSelector of ProviderConfig %s: %w", providerConfig.Name, err)
+	}
+
+	ns := &corev1.Namespace{}
+	err = kubeClient.Get(ctx, client.ObjectKey{Name: namespace}, ns)
+	if err != nil {
+		return false, err
+	}
+	return selector.Matches(labels.Set(ns.Labels)), nil
+}
+
+// providerAwsConfig returns a copy of base using the region and credentials of
+// providerConfig. secret holds the static credentials when the ProviderConfig
+// uses them.
+func providerAwsConfig(base aws.Config, providerConfig *awsv1alpha1.ProviderConfig, secret *corev1.Secret) (aws.Config, error) {
+	cfg := base.Copy()
+	spec := providerConfig.Spec
+	if spec.Region != nil {
+		cfg.Region = *spec.Region
+	}
+
+	switch spec.Credentials.Source {
+	case "", awsv1alpha1.CredentialsSourceDefault:
+	case awsv1alpha1.CredentialsSourceSecret:
+		accessKeyId := string(secret.Data[awsv1alpha1.CredentialsKeyAccessKeyId])
+		secretAccessKey := string(secret.Data[awsv1alpha1.CredentialsKeySecretAccessKey])
+		if accessKeyId == "" || secretAccessKey == "" {
+			return aws.Config{}, fmt.Errorf("secret %s/%s must contain %s and %s", secret.Namespace, secret.Name,
+				awsv1alpha1.CredentialsKeyAccessKeyId, awsv1alpha1.CredentialsKeySecretAccessKey)
+		}
+		sessionToken := string(secret.Data[awsv1alpha1.CredentialsKeySessionToken])
+		cfg.Credentials = aws.NewCredentialsCache(credentials.NewStaticCredentialsProvider(accessKeyId, secretAccessKey, sessionToken))
+	case awsv1alpha1.CredentialsSourceWebIdentity:
+		webIdentity := spec.Credentials.WebIdentity
+		if webIdentity == nil {
+			return aws.Config{}, fmt.Errorf("ProviderConfig %s has no webIdentity for credentials source WebIdentity", providerConfig.Name)
+		}
+		tokenFile := webIdentity.TokenFile
+		if tokenFile == "" {
+			tokenFile = defaultWebIdentityTokenFile
+		}
+		provider := stscreds.NewWebIdentityRoleProvider(sts.NewFromConfig(cfg), webIdentity.RoleArn, stscreds.IdentityTokenFile(tokenFile),
+			func(options *stscreds.WebIdentityRoleOptions) {
+				options.RoleSessionName = aws.ToString(webIdentity.RoleSessionName)
+			})
+		cfg.Credentials = aws.NewCredentialsCache(provider)
+	default:
+		return aws.Config{}, fmt.Errorf("unknown credentials source %q", spec.Credentials.Source)
+	}
+
+	if assumeRole := spec.AssumeRole; assumeRole != nil {
+		provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), assumeRole.RoleArn,
+			func(options *stscreds.AssumeRoleOptions) {
+				options.ExternalID = assumeRole.ExternalId
+				if assumeRole.RoleSessionName != nil {
+					options.RoleSessionName = *assumeRole.RoleSessionName
+				}
+			})
+		cfg.Credentials = aws.NewCredentialsCache(provider)
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+	"github.com/sergeyshevch/cloud-resource-operator/internal/fake"
+)
+
+var _ = Describe("ProviderConfig clients", func() {
+	var (
+		ctx            context.Context
+		reconciler     *ElasticCacheReconciler
+		clients        *providerClients
+		builds         []aws.Config
+		newClient      ElastiCacheClientFunc
+		secret         *corev1.Secret
+		providerConfig *awsv1alpha1.ProviderConfig
+	)
+
+	get := func() ElastiCacheAPI {
+		current := &awsv1alpha1.ProviderConfig{}
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(providerConfig), current)).To(Succeed())
+		awsClient, err := clients.elasticCacheClient(ctx, k8sClient, aws.Config{Region: "us-east-1"}, newClient, current)
+		Expect(err).NotTo(HaveOccurred())
+		return awsClient
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		reconciler = &ElasticCacheReconciler{Client: k8sClient}
+		clients = &reconciler.providerClients
+		builds = nil
+		newClient = func(cfg aws.Config) ElastiCacheAPI {
+			builds = append(builds, cfg)
+			return fake.New()
+		}
+
+		secret = &corev1.Secret{
+			Data: map[string][]byte{
+				awsv1alpha1.CredentialsKeyAccessKeyId:     []byte("AKIAOLD"),
+				awsv1alpha1.CredentialsKeySecretAccessKey: []byte("old"),
+			},
+		}
+		createTestObject(ctx, secret, "credentials-")
+
+		providerConfig = &awsv1alpha1.ProviderConfig{
+			ObjectMeta: metav1.ObjectMeta{GenerateName: "provider-"},
+			Spec: awsv1alpha1.ProviderConfigSpec{
+				Region: aws.String("eu-west-1"),
+				Credentials: awsv1alpha1.ProviderCredentials{
+					Source:    awsv1alpha1.CredentialsSourceSecret,
+					SecretRef: &awsv1alpha1.SecretReference{Name: secret.Name, Namespace: secret.Namespace},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, providerConfig)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, providerConfig))).To(Succeed())
+		Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, secret))).To(Succeed())
+	})
+
+	It("reuses the client until the credentials Secret changes", func() {
+		first := get()
+		Expect(get()).To(BeIdenticalTo(first))
+		Expect(builds).To(HaveLen(1))
+		Expect(builds[0].Region).To(Equal("eu-west-1"))
+
+		current := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(secret), current)).To(Succeed())
+		current.Data[awsv1alpha1.CredentialsKeyAccessKeyId] = []byte("AKIANEW")
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+
+		second := get()
+		Expect(second).NotTo(BeIdenticalTo(first))
+		Expect(builds).To(HaveLen(2))
+		credentials, err := builds[1].Credentials.Retrieve(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(credentials.AccessKeyID).To(Equal("AKIANEW"))
+		Expect(get()).To(BeIdenticalTo(second))
+	})
+
+	It("rebuilds the client when the ProviderConfig changes", func() {
+		first := get()
+
+		current := &awsv1alpha1.ProviderConfig{}
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(providerConfig), current)).To(Succeed())
+		current.Spec.Region = aws.String("eu-central-1")
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+
+		Expect(get()).NotTo(BeIdenticalTo(first))
+		Expect(builds).To(HaveLen(2))
+		Expect(builds[1].Region).To(Equal("eu-central-1"))
+	})
+
+	It("is only referenced from the namespaces it selects", func() {
+		selected, err := providerConfigSelectsNamespace(ctx, k8sClient, providerConfig, "unlabeled")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(selected).To(BeTrue())
+
+		team := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "team-", Labels: map[string]string{"team": "a"}}}
+		Expect(k8sClient.Create(ctx, team)).To(Succeed())
+		other := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "team-", Labels: map[string]string{"team": "b"}}}
+		Expect(k8sClient.Create(ctx, other)).To(Succeed())
+
+		providerConfig.Spec.NamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}}
+		selected, err = providerConfigSelectsNamespace(ctx, k8sClient, providerConfig, team.Name)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(selected).To(BeTrue())
+		selected, err = providerConfigSelectsNamespace(ctx, k8sClient, providerConfig, other.Name)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(selected).To(BeFalse())
+	})
+
+	It("evicts the client of a deleted ProviderConfig", func() {
+		get()
+		Expect(clients.clients).To(HaveKey(client.ObjectKeyFromObject(providerConfig)))
+
+		Expect(k8sClient.Delete(ctx, providerConfig)).To(Succeed())
+		reconciler.findElasticCachesForProviderConfig(providerConfig)
+		Expect(clients.clients).NotTo(HaveKey(client.ObjectKeyFromObject(providerConfig)))
+	})
+})
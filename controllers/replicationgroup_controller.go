@@ -0,0 +1,699 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+)
+
+var replicationGroupFinalizer = elasticCacheFinalizer
+var userGroupRefsField = ".spec.awsConfig.userGroupRefs.name"
+
+// ReplicationGroupReconciler reconciles a ReplicationGroup object
+type ReplicationGroupReconciler struct {
+	client.Client
+	AwsConfig aws.Config
+	Scheme    *runtime.Scheme
+
+	// NewElastiCacheClient builds the ElastiCache client, defaults to the
+	// client of the AWS SDK.
+	NewElastiCacheClient ElastiCacheClientFunc
+
+	// RequeueIntervals sets how often the AWS resources are polled.
+	RequeueIntervals RequeueIntervals
+
+	// DefaultTags are added to every replication group, tags of the spec take
+	// precedence over them.
+	DefaultTags map[string]string
+
+	backoff requeueBackoff
+}
+
+//+kubebuilder:rbac:groups=aws.sergeyshevch.dev,resources=replicationgroups,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=aws.sergeyshevch.dev,resources=replicationgroups/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=aws.sergeyshevch.dev,resources=replicationgroups/finalizers,verbs=update
+//+kubebuilder:rbac:groups=aws.sergeyshevch.dev,resources=usergroups,verbs=get;list;watch
+
+// Reconcile creates, modifies and deletes the ElastiCache replication group
+// described by a ReplicationGroup object. Shard and replica counts are
+// compared against the observed replication group and changed online once
+// it is available.
+func (r *ReplicationGroupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	defer observeReconcileDuration("ReplicationGroup", time.Now())
+
+	instance := &awsv1alpha1.ReplicationGroup{}
+	err := r.Client.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	result, err := r.reconcileReplicationGroup(ctx, instance)
+	if err != nil {
+		result, err = handleReconcileError(&instance.Status.Conditions, instance, &r.backoff, isReplicationGroupNotFound, err)
+		if statusErr := r.Status().Update(context.TODO(), instance); statusErr != nil {
+			log.FromContext(ctx).Error(statusErr, "unable to update ReplicationGroup status")
+		}
+		return result, err
+	}
+	r.backoff.reset(req.NamespacedName)
+	return result, nil
+}
+
+func (r *ReplicationGroupReconciler) reconcileReplicationGroup(ctx context.Context, instance *awsv1alpha1.ReplicationGroup) (ctrl.Result, error) {
+	awsClient := newElastiCacheClient(r.NewElastiCacheClient, r.AwsConfig)
+
+	isReplicationGroupMarkedToDeletion := instance.GetDeletionTimestamp() != nil
+	if isReplicationGroupMarkedToDeletion {
+		return r.finalizeReplicationGroup(ctx, awsClient, instance)
+	}
+
+	// An illegal identifier cannot be fixed by retrying, wait for the spec to
+	// change instead.
+	err := validateReplicationGroupId(aws.ToString(replicationGroupId(instance)))
+	if err != nil {
+		setReconcileErrorConditions(&instance.Status.Conditions, instance, err)
+		return ctrl.Result{}, r.Status().Update(context.TODO(), instance)
+	}
+
+	authToken, err := r.resolveAuthToken(ctx, instance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	userGroupIds, userGroupsReady, err := r.resolveUserGroupIds(ctx, instance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	replicationGroup, err := r.getReplicationGroup(awsClient, instance)
+	if err != nil {
+		if isReplicationGroupNotFound(err) {
+			if !userGroupsReady {
+				err = r.Status().Update(context.TODO(), instance)
+				if err != nil {
+					return ctrl.Result{}, err
+				}
+				return ctrl.Result{RequeueAfter: r.RequeueIntervals.transitional()}, nil
+			}
+
+			// Persist the finalizer before the replication group exists, a
+			// deletion right after the creation would otherwise leak it.
+			err = r.addFinalizer(ctx, instance)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+
+			replicationGroup, err = r.createReplicationGroup(awsClient, instance, authToken, userGroupIds)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			instance.Status.AuthTokenHash = authTokenHash(instance, authToken)
+
+			err = r.updateReplicationGroupStatus(replicationGroup, instance)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+
+			return r.RequeueIntervals.requeueAfter(aws.ToString(replicationGroup.Status)), nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Never touch a replication group this ReplicationGroup did not create or
+	// import.
+	owned, err := r.ensureReplicationGroupOwnership(awsClient, instance, replicationGroup)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !owned {
+		err = r.Status().Update(context.TODO(), instance)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: r.RequeueIntervals.stable()}, nil
+	}
+
+	err = r.addFinalizer(ctx, instance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	member, err := r.getPrimaryMemberCluster(awsClient, replicationGroup)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	drifted, params := diffReplicationGroup(instance.Spec.AWSConfig, replicationGroup, member)
+	recordDriftDetections("ReplicationGroup", instance.Status.DriftedFields, drifted)
+	instance.Status.DriftedFields = drifted
+
+	// Tags are not part of ModifyReplicationGroup, they are reconciled on
+	// their own.
+	if aws.ToString(replicationGroup.Status) == "available" {
+		_, err = reconcileTags(awsClient, replicationGroup.ARN, desiredTags(r.DefaultTags, instance.Spec.AWSConfig.Tags, instance))
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// AWS rejects every modification while the replication group is not available.
+	if aws.ToString(replicationGroup.Status) == "available" {
+		replicationGroup, err = r.applyReplicationGroupChanges(awsClient, instance, replicationGroup, params, len(drifted) > 0,
+			authToken, userGroupIds, userGroupsReady)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	err = r.updateReplicationGroupStatus(replicationGroup, instance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if instance.Spec.WriteConnectionDetailsTo != nil && aws.ToString(replicationGroup.Status) == "available" {
+		engine := aws.ToString(instance.Spec.AWSConfig.Engine)
+		if engine == "" {
+			engine = "redis"
+		}
+		details := replicationGroupConnectionDetails(replicationGroup, engine, authToken)
+		err = publishConnectionDetails(ctx, r.Client, r.Scheme, instance, instance.Spec.WriteConnectionDetailsTo, details)
+		if isNotControlled(err) {
+			setNotOwnedConditions(&instance.Status.Conditions, instance, err.Error())
+			err = r.Status().Update(context.TODO(), instance)
+		}
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return r.RequeueIntervals.requeueAfter(aws.ToString(replicationGroup.Status)), nil
+}
+
+// finalizeReplicationGroup deletes the replication group of a ReplicationGroup
+// that is being deleted. The finalizer is kept until the replication group is
+// gone from AWS. Replication groups the ReplicationGroup does not own are left
+// in place.
+func (r *ReplicationGroupReconciler) finalizeReplicationGroup(ctx context.Context, awsClient ElastiCacheAPI, instance *awsv1alpha1.ReplicationGroup) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(instance, replicationGroupFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	replicationGroup, err := r.getReplicationGroup(awsClient, instance)
+	if err != nil {
+		if !isReplicationGroupNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, r.removeFinalizer(ctx, instance)
+	}
+
+	owned, err := r.ensureReplicationGroupOwnership(awsClient, instance, replicationGroup)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !owned {
+		return ctrl.Result{}, r.removeFinalizer(ctx, instance)
+	}
+
+	groupStatus := aws.ToString(replicationGroup.Status)
+	if groupStatus != "deleting" && isTransitionalStatus(groupStatus) {
+		// AWS rejects the deletion until the replication group has finished
+		// its current transition, e.g. its creation.
+		err = r.updateReplicationGroupStatus(replicationGroup, instance)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: r.RequeueIntervals.transitional()}, nil
+	}
+
+	if groupStatus != "deleting" {
+		replicationGroup, err = r.deleteReplicationGroup(awsClient, instance)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	err = r.updateReplicationGroupStatus(replicationGroup, instance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: r.RequeueIntervals.transitional()}, nil
+}
+
+func (r *ReplicationGroupReconciler) addFinalizer(ctx context.Context, instance *awsv1alpha1.ReplicationGroup) error {
+	if controllerutil.ContainsFinalizer(instance, replicationGroupFinalizer) {
+		return nil
+	}
+	controllerutil.AddFinalizer(instance, replicationGroupFinalizer)
+	return r.Update(ctx, instance)
+}
+
+func (r *ReplicationGroupReconciler) removeFinalizer(ctx context.Context, instance *awsv1alpha1.ReplicationGroup) error {
+	controllerutil.RemoveFinalizer(instance, replicationGroupFinalizer)
+	return r.Update(ctx, instance)
+}
+
+// applyReplicationGroupChanges issues at most one modification per call since
+// each of them moves the replication group into the modifying state: drifted
+// settings and token rotations first, then user group membership, then online
+// resharding, then replica count changes. User groups are left untouched while
+// any referenced UserGroup is not Ready.
+func (r *ReplicationGroupReconciler) applyReplicationGroupChanges(awsClient ElastiCacheAPI, instance *awsv1alpha1.ReplicationGroup,
+	replicationGroup *types.ReplicationGroup, params *elasticache.ModifyReplicationGroupInput, drifted bool,
+	authToken *string, userGroupIds []string, userGroupsReady bool) (*types.ReplicationGroup, error) {
+	// Only send the token when the referenced secret changed, so the group
+	// keeps its token on unrelated modifications.
+	var rotatedAuthToken *string
+	if hash := authTokenHash(instance, authToken); hash != instance.Status.AuthTokenHash {
+		rotatedAuthToken = authToken
+	}
+
+	if drifted || rotatedAuthToken != nil {
+		replicationGroup, err := r.patchReplicationGroup(awsClient, instance, params, rotatedAuthToken)
+		if err != nil {
+			return nil, err
+		}
+		instance.Status.AuthTokenHash = authTokenHash(instance, authToken)
+		return replicationGroup, nil
+	}
+
+	if userGroupsReady {
+		toAdd := stringsDifference(userGroupIds, replicationGroup.UserGroupIds)
+		toRemove := stringsDifference(replicationGroup.UserGroupIds, userGroupIds)
+		if len(toAdd) > 0 || len(toRemove) > 0 {
+			output, err := awsClient.ModifyReplicationGroup(context.TODO(), &elasticache.ModifyReplicationGroupInput{
+				ApplyImmediately:     true,
+				ReplicationGroupId:   replicationGroup.ReplicationGroupId,
+				UserGroupIdsToAdd:    toAdd,
+				UserGroupIdsToRemove: toRemove,
+			})
+			if err != nil {
+				return nil, err
+			}
+			return output.ReplicationGroup, nil
+		}
+	}
+
+	config := instance.Spec.AWSConfig
+	currentNodeGroups := int32(len(replicationGroup.NodeGroups))
+	if aws.ToBool(replicationGroup.ClusterEnabled) && config.NumNodeGroups != nil && *config.NumNodeGroups != currentNodeGroups {
+		return r.reshardReplicationGroup(awsClient, instance, replicationGroup)
+	}
+
+	desiredReplicas, ok := desiredReplicaCount(config, replicationGroup)
+	if ok && currentNodeGroups > 0 {
+		currentReplicas := int32(len(replicationGroup.NodeGroups[0].NodeGroupMembers)) - 1
+		if desiredReplicas > currentReplicas {
+			output, err := awsClient.IncreaseReplicaCount(context.TODO(), &elasticache.IncreaseReplicaCountInput{
+				ApplyImmediately:   true,
+				ReplicationGroupId: replicationGroup.ReplicationGroupId,
+				NewReplicaCount:    &desiredReplicas,
+			})
+			if err != nil {
+				return nil, err
+			}
+			return output.ReplicationGroup, nil
+		}
+		if desiredReplicas < currentReplicas {
+			output, err := awsClient.DecreaseReplicaCount(context.TODO(), &elasticache.DecreaseReplicaCountInput{
+				ApplyImmediately:   true,
+				ReplicationGroupId: replicationGroup.ReplicationGroupId,
+				NewReplicaCount:    &desiredReplicas,
+			})
+			if err != nil {
+				return nil, err
+			}
+			return output.ReplicationGroup, nil
+		}
+	}
+
+	return replicationGroup, nil
+}
+
+// desiredReplicaCount returns the number of replicas per node group requested
+// by the spec. Cluster mode disabled groups may express it as NumCacheClusters.
+func desiredReplicaCount(config *awsv1alpha1.ReplicationGroupAwsConfig, replicationGroup *types.ReplicationGroup) (int32, bool) {
+	if config.ReplicasPerNodeGroup != nil {
+		return *config.ReplicasPerNodeGroup, true
+	}
+	if config.NumCacheClusters != nil && !aws.ToBool(replicationGroup.ClusterEnabled) {
+		return *config.NumCacheClusters - 1, true
+	}
+	return 0, false
+}
+
+// reshardReplicationGroup changes the number of node groups of a cluster mode
+// enabled replication group. When scaling in, the node groups with the lowest
+// identifiers are retained.
+func (r *ReplicationGroupReconciler) reshardReplicationGroup(awsClient ElastiCacheAPI, instance *awsv1alpha1.ReplicationGroup,
+	replicationGroup *types.ReplicationGroup) (*types.ReplicationGroup, error) {
+	desired := *instance.Spec.AWSConfig.NumNodeGroups
+	params := &elasticache.ModifyReplicationGroupShardConfigurationInput{
+		ApplyImmediately:   true,
+		NodeGroupCount:     desired,
+		ReplicationGroupId: replicationGroup.ReplicationGroupId,
+	}
+
+	if desired < int32(len(replicationGroup.NodeGroups)) {
+		var nodeGroupIds []string
+		for _, nodeGroup := range replicationGroup.NodeGroups {
+			nodeGroupIds = append(nodeGroupIds, aws.ToString(nodeGroup.NodeGroupId))
+		}
+		sort.Strings(nodeGroupIds)
+		params.NodeGroupsToRetain = nodeGroupIds[:desired]
+	}
+
+	output, err := awsClient.ModifyReplicationGroupShardConfiguration(context.TODO(), params)
+	if err != nil {
+		return nil, err
+	}
+	return output.ReplicationGroup, nil
+}
+
+// updateReplicationGroupStatus copies the observed state of the replication
+// group returned by AWS into the ReplicationGroup status and refreshes its
+// conditions.
+func (r *ReplicationGroupReconciler) updateReplicationGroupStatus(replicationGroup *types.ReplicationGroup, instance *awsv1alpha1.ReplicationGroup) error {
+	status := &instance.Status
+
+	status.ObservedGeneration = instance.GetGeneration()
+	status.ReplicationGroupId = replicationGroup.ReplicationGroupId
+	status.Status = replicationGroup.Status
+	status.ARN = replicationGroup.ARN
+	status.CacheNodeType = replicationGroup.CacheNodeType
+	status.ClusterEnabled = replicationGroup.ClusterEnabled
+	status.AutomaticFailover = replicationGroup.AutomaticFailover
+	status.MultiAZ = replicationGroup.MultiAZ
+	status.AtRestEncryptionEnabled = replicationGroup.AtRestEncryptionEnabled
+	status.TransitEncryptionEnabled = replicationGroup.TransitEncryptionEnabled
+	status.AuthTokenEnabled = replicationGroup.AuthTokenEnabled
+	status.UserGroupIds = replicationGroup.UserGroupIds
+	status.ConfigurationEndpoint = convertEndpoint(replicationGroup.ConfigurationEndpoint)
+	status.MemberClusters = replicationGroup.MemberClusters
+
+	status.NodeGroups = nil
+	for _, nodeGroup := range replicationGroup.NodeGroups {
+		group := awsv1alpha1.NodeGroup{
+			NodeGroupId:     nodeGroup.NodeGroupId,
+			Status:          nodeGroup.Status,
+			PrimaryEndpoint: convertEndpoint(nodeGroup.PrimaryEndpoint),
+			ReaderEndpoint:  convertEndpoint(nodeGroup.ReaderEndpoint),
+			Slots:           nodeGroup.Slots,
+		}
+		for _, member := range nodeGroup.NodeGroupMembers {
+			group.NodeGroupMembers = append(group.NodeGroupMembers, awsv1alpha1.NodeGroupMember{
+				CacheClusterId:            member.CacheClusterId,
+				CacheNodeId:               member.CacheNodeId,
+				CurrentRole:               member.CurrentRole,
+				PreferredAvailabilityZone: member.PreferredAvailabilityZone,
+				ReadEndpoint:              convertEndpoint(member.ReadEndpoint),
+			})
+		}
+		status.NodeGroups = append(status.NodeGroups, group)
+	}
+
+	status.PendingModifiedValues = nil
+	if pending := replicationGroup.PendingModifiedValues; pending != nil {
+		status.PendingModifiedValues = &awsv1alpha1.ReplicationGroupPendingModifiedValues{
+			AuthTokenStatus:         pending.AuthTokenStatus,
+			AutomaticFailoverStatus: pending.AutomaticFailoverStatus,
+			PrimaryClusterId:        pending.PrimaryClusterId,
+			Resharding:              pending.Resharding != nil,
+		}
+	}
+
+	setAwsResourceConditions(&status.Conditions, instance, aws.ToString(replicationGroup.Status))
+
+	now := metav1.Now()
+	status.LastSyncTime = &now
+
+	return r.Status().Update(context.TODO(), instance)
+}
+
+func (r *ReplicationGroupReconciler) patchReplicationGroup(awsClient ElastiCacheAPI, cr *awsv1alpha1.ReplicationGroup,
+	params *elasticache.ModifyReplicationGroupInput, authToken *string) (*types.ReplicationGroup, error) {
+	if authToken != nil {
+		params.AuthToken = authToken
+		params.AuthTokenUpdateStrategy = cr.Spec.AWSConfig.AuthTokenUpdateStrategy
+		if params.AuthTokenUpdateStrategy == "" {
+			params.AuthTokenUpdateStrategy = types.AuthTokenUpdateStrategyTypeRotate
+		}
+	}
+
+	output, err := awsClient.ModifyReplicationGroup(context.TODO(), params)
+	if err != nil {
+		return &types.ReplicationGroup{}, err
+	}
+	return output.ReplicationGroup, nil
+}
+
+func (r *ReplicationGroupReconciler) createReplicationGroup(awsClient ElastiCacheAPI, cr *awsv1alpha1.ReplicationGroup, authToken *string, userGroupIds []string) (*types.ReplicationGroup, error) {
+	params := &elasticache.CreateReplicationGroupInput{
+		ReplicationGroupId:          replicationGroupId(cr),
+		ReplicationGroupDescription: cr.Spec.AWSConfig.ReplicationGroupDescription,
+		AtRestEncryptionEnabled:     cr.Spec.AWSConfig.AtRestEncryptionEnabled,
+		AuthToken:                   authToken,
+		AutoMinorVersionUpgrade:     cr.Spec.AWSConfig.AutoMinorVersionUpgrade,
+		AutomaticFailoverEnabled:    cr.Spec.AWSConfig.AutomaticFailoverEnabled,
+		CacheNodeType:               cr.Spec.AWSConfig.CacheNodeType,
+		CacheParameterGroupName:     cr.Spec.AWSConfig.CacheParameterGroupName,
+		CacheSecurityGroupNames:     cr.Spec.AWSConfig.CacheSecurityGroupNames,
+		CacheSubnetGroupName:        cr.Spec.AWSConfig.CacheSubnetGroupName,
+		Engine:                      cr.Spec.AWSConfig.Engine,
+		EngineVersion:               cr.Spec.AWSConfig.EngineVersion,
+		KmsKeyId:                    cr.Spec.AWSConfig.KmsKeyId,
+		MultiAZEnabled:              cr.Spec.AWSConfig.MultiAZEnabled,
+		NotificationTopicArn:        cr.Spec.AWSConfig.NotificationTopicArn,
+		NumCacheClusters:            cr.Spec.AWSConfig.NumCacheClusters,
+		NumNodeGroups:               cr.Spec.AWSConfig.NumNodeGroups,
+		Port:                        cr.Spec.AWSConfig.Port,
+		PreferredCacheClusterAZs:    cr.Spec.AWSConfig.PreferredCacheClusterAZs,
+		PreferredMaintenanceWindow:  cr.Spec.AWSConfig.PreferredMaintenanceWindow,
+		ReplicasPerNodeGroup:        cr.Spec.AWSConfig.ReplicasPerNodeGroup,
+		SecurityGroupIds:            cr.Spec.AWSConfig.SecurityGroupIds,
+		SnapshotArns:                cr.Spec.AWSConfig.SnapshotArns,
+		SnapshotName:                cr.Spec.AWSConfig.SnapshotName,
+		SnapshotRetentionLimit:      cr.Spec.AWSConfig.SnapshotRetentionLimit,
+		SnapshotWindow:              cr.Spec.AWSConfig.SnapshotWindow,
+		Tags:                        desiredTags(r.DefaultTags, cr.Spec.AWSConfig.Tags, cr),
+		TransitEncryptionEnabled:    cr.Spec.AWSConfig.TransitEncryptionEnabled,
+		UserGroupIds:                userGroupIds,
+	}
+
+	output, err := awsClient.CreateReplicationGroup(context.TODO(), params)
+	if err != nil {
+		return &types.ReplicationGroup{}, err
+	}
+	return output.ReplicationGroup, nil
+}
+
+func (r *ReplicationGroupReconciler) getReplicationGroup(awsClient ElastiCacheAPI, cr *awsv1alpha1.ReplicationGroup) (*types.ReplicationGroup, error) {
+	params := &elasticache.DescribeReplicationGroupsInput{
+		ReplicationGroupId: replicationGroupId(cr),
+	}
+
+	output, err := awsClient.DescribeReplicationGroups(context.TODO(), params)
+	if err != nil {
+		return &types.ReplicationGroup{}, err
+	}
+
+	if len(output.ReplicationGroups) == 1 {
+		return &output.ReplicationGroups[0], nil
+	}
+	return &types.ReplicationGroup{}, &types.ReplicationGroupNotFoundFault{
+		Message: aws.String(fmt.Sprintf("ReplicationGroup %s not found.", aws.ToString(replicationGroupId(cr)))),
+	}
+}
+
+// getPrimaryMemberCluster describes the first member cluster of the
+// replication group, which reports the settings the group does not. It returns
+// nil when the group has no member clusters.
+func (r *ReplicationGroupReconciler) getPrimaryMemberCluster(awsClient ElastiCacheAPI, replicationGroup *types.ReplicationGroup) (*types.CacheCluster, error) {
+	if len(replicationGroup.MemberClusters) == 0 {
+		return nil, nil
+	}
+
+	output, err := awsClient.DescribeCacheClusters(context.TODO(), &elasticache.DescribeCacheClustersInput{
+		CacheClusterId: aws.String(replicationGroup.MemberClusters[0]),
+	})
+	if err != nil {
+		if isCacheClusterNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(output.CacheClusters) == 0 {
+		return nil, nil
+	}
+	return &output.CacheClusters[0], nil
+}
+
+func (r *ReplicationGroupReconciler) deleteReplicationGroup(awsClient ElastiCacheAPI, cr *awsv1alpha1.ReplicationGroup) (*types.ReplicationGroup, error) {
+	params := &elasticache.DeleteReplicationGroupInput{
+		ReplicationGroupId: replicationGroupId(cr),
+	}
+
+	output, err := awsClient.DeleteReplicationGroup(context.TODO(), params)
+	if err != nil {
+		return &types.ReplicationGroup{}, err
+	}
+	return output.ReplicationGroup, nil
+}
+
+func isReplicationGroupNotFound(err error) bool {
+	var notFound *types.ReplicationGroupNotFoundFault
+	return goerrors.As(err, &notFound)
+}
+
+// resolveAuthToken reads the auth token referenced by the ReplicationGroup
+// spec. It returns nil when no token is configured.
+func (r *ReplicationGroupReconciler) resolveAuthToken(ctx context.Context, instance *awsv1alpha1.ReplicationGroup) (*string, error) {
+	selector := instance.Spec.AWSConfig.AuthTokenSecretRef
+	if selector == nil {
+		return nil, nil
+	}
+
+	authToken, err := getSecretValue(ctx, r.Client, instance.Namespace, selector)
+	if err != nil {
+		return nil, err
+	}
+	return &authToken, nil
+}
+
+// resolveUserGroupIds returns the sorted IDs of the user groups referenced by
+// the ReplicationGroup spec. When a referenced UserGroup is missing or not Ready
+// yet, it records that in the status conditions and reports false.
+func (r *ReplicationGroupReconciler) resolveUserGroupIds(ctx context.Context, instance *awsv1alpha1.ReplicationGroup) ([]string, bool, error) {
+	var userGroupIds []string
+	for _, ref := range instance.Spec.AWSConfig.UserGroupRefs {
+		userGroup := &awsv1alpha1.UserGroup{}
+		err := r.Get(ctx, client.ObjectKey{Namespace: instance.Namespace, Name: ref.Name}, userGroup)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				setWaitingForReferencesConditions(&instance.Status.Conditions, instance,
+					fmt.Sprintf("UserGroup %s not found", ref.Name))
+				return nil, false, nil
+			}
+			return nil, false, err
+		}
+
+		if !meta.IsStatusConditionTrue(userGroup.Status.Conditions, awsv1alpha1.ConditionTypeReady) || userGroup.Status.UserGroupId == nil {
+			setWaitingForReferencesConditions(&instance.Status.Conditions, instance,
+				fmt.Sprintf("UserGroup %s is not Ready", ref.Name))
+			return nil, false, nil
+		}
+		userGroupIds = append(userGroupIds, *userGroup.Status.UserGroupId)
+	}
+	sort.Strings(userGroupIds)
+	return userGroupIds, true, nil
+}
+
+// findReplicationGroupsForUserGroup maps a UserGroup to the ReplicationGroups
+// referencing it.
+func (r *ReplicationGroupReconciler) findReplicationGroupsForUserGroup(userGroup client.Object) []reconcile.Request {
+	replicationGroups := &awsv1alpha1.ReplicationGroupList{}
+	err := r.List(context.TODO(), replicationGroups,
+		client.InNamespace(userGroup.GetNamespace()),
+		client.MatchingFields{userGroupRefsField: userGroup.GetName()})
+	if err != nil {
+		return nil
+	}
+
+	requests := make([]reconcile.Request, len(replicationGroups.Items))
+	for i := range replicationGroups.Items {
+		requests[i] = reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&replicationGroups.Items[i])}
+	}
+	return requests
+}
+
+// findReplicationGroupsForSecret maps a Secret to the ReplicationGroups
+// referencing it as their auth token.
+func (r *ReplicationGroupReconciler) findReplicationGroupsForSecret(secret client.Object) []reconcile.Request {
+	replicationGroups := &awsv1alpha1.ReplicationGroupList{}
+	err := r.List(context.TODO(), replicationGroups,
+		client.InNamespace(secret.GetNamespace()),
+		client.MatchingFields{authTokenSecretRefField: secret.GetName()})
+	if err != nil {
+		return nil
+	}
+
+	requests := make([]reconcile.Request, len(replicationGroups.Items))
+	for i := range replicationGroups.Items {
+		requests[i] = reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&replicationGroups.Items[i])}
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ReplicationGroupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	err := mgr.GetFieldIndexer().IndexField(context.Background(), &awsv1alpha1.ReplicationGroup{}, authTokenSecretRefField, func(obj client.Object) []string {
+		replicationGroup := obj.(*awsv1alpha1.ReplicationGroup)
+		if replicationGroup.Spec.AWSConfig == nil || replicationGroup.Spec.AWSConfig.AuthTokenSecretRef == nil {
+			return nil
+		}
+		return []string{replicationGroup.Spec.AWSConfig.AuthTokenSecretRef.Name}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = mgr.GetFieldIndexer().IndexField(context.Background(), &awsv1alpha1.ReplicationGroup{}, userGroupRefsField, func(obj client.Object) []string {
+		replicationGroup := obj.(*awsv1alpha1.ReplicationGroup)
+		if replicationGroup.Spec.AWSConfig == nil {
+			return nil
+		}
+		var names []string
+		for _, ref := range replicationGroup.Spec.AWSConfig.UserGroupRefs {
+			names = append(names, ref.Name)
+		}
+		return names
+	})
+	if err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&awsv1alpha1.ReplicationGroup{}, specChanged()).
+		Owns(&corev1.Secret{}).
+		Owns(&corev1.ConfigMap{}).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(r.findReplicationGroupsForSecret)).
+		Watches(&source.Kind{Type: &awsv1alpha1.UserGroup{}}, handler.EnqueueRequestsFromMapFunc(r.findReplicationGroupsForUserGroup)).
+		Complete(r)
+}
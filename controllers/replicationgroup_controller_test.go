@@ -0,0 +1,450 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+	"github.com/sergeyshevch/cloud-resource-operator/internal/fake"
+)
+
+var _ = Describe("ReplicationGroup controller", func() {
+	var (
+		ctx        context.Context
+		fakeAPI    *fake.ElastiCache
+		reconciler *ReplicationGroupReconciler
+		instance   *awsv1alpha1.ReplicationGroup
+		key        client.ObjectKey
+	)
+
+	reconcile := func() (ctrl.Result, error) {
+		return reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	}
+
+	get := func() *awsv1alpha1.ReplicationGroup {
+		current := &awsv1alpha1.ReplicationGroup{}
+		Expect(k8sClient.Get(ctx, key, current)).To(Succeed())
+		return current
+	}
+
+	isReady := func() bool {
+		return meta.IsStatusConditionTrue(get().Status.Conditions, awsv1alpha1.ConditionTypeReady)
+	}
+
+	// createAvailable reconciles the ReplicationGroup until its replication
+	// group is available.
+	createAvailable := func() {
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		fakeAPI.Tick()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(isReady()).To(BeTrue())
+		fakeAPI.ResetCalls()
+	}
+
+	update := func(mutate func(*awsv1alpha1.ReplicationGroupAwsConfig)) {
+		current := get()
+		mutate(current.Spec.AWSConfig)
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+	}
+
+	// count returns how often operation was called since the calls were last
+	// reset.
+	count := func(operation string) int {
+		calls := 0
+		for _, call := range fakeAPI.Calls() {
+			if call == operation {
+				calls++
+			}
+		}
+		return calls
+	}
+
+	// createExisting creates a replication group with the identifier of the
+	// ReplicationGroup outside of the operator.
+	createExisting := func(tags ...types.Tag) {
+		_, err := fakeAPI.CreateReplicationGroup(ctx, &elasticache.CreateReplicationGroupInput{
+			ReplicationGroupId:          aws.String(key.Name),
+			ReplicationGroupDescription: aws.String("existing"),
+			CacheNodeType:               aws.String("cache.t3.micro"),
+			Tags:                        tags,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		fakeAPI.Tick()
+		fakeAPI.ResetCalls()
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		fakeAPI = fake.New()
+		reconciler = &ReplicationGroupReconciler{
+			Client:               k8sClient,
+			Scheme:               scheme.Scheme,
+			NewElastiCacheClient: fakeClientFunc(fakeAPI),
+		}
+
+		instance = &awsv1alpha1.ReplicationGroup{
+			Spec: awsv1alpha1.ReplicationGroupSpec{
+				AWSConfig: &awsv1alpha1.ReplicationGroupAwsConfig{
+					ReplicationGroupDescription: aws.String("test"),
+					CacheNodeType:               aws.String("cache.t3.micro"),
+					Engine:                      aws.String("redis"),
+					NumCacheClusters:            aws.Int32(2),
+				},
+			},
+		}
+		key = createTestObject(ctx, instance, "group-")
+	})
+
+	AfterEach(func() {
+		removeTestObject(ctx, key, &awsv1alpha1.ReplicationGroup{})
+	})
+
+	It("creates the replication group and becomes Ready once it is available", func() {
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		group, ok := fakeAPI.ReplicationGroup(key.Name)
+		Expect(ok).To(BeTrue())
+		Expect(aws.ToString(group.Status)).To(Equal("creating"))
+		Expect(controllerutil.ContainsFinalizer(get(), replicationGroupFinalizer)).To(BeTrue())
+		Expect(isReady()).To(BeFalse())
+
+		fakeAPI.Tick()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		current := get()
+		Expect(aws.ToString(current.Status.Status)).To(Equal("available"))
+		Expect(current.Status.NodeGroups).To(HaveLen(1))
+		Expect(current.Status.NodeGroups[0].NodeGroupMembers).To(HaveLen(2))
+		Expect(isReady()).To(BeTrue())
+	})
+
+	It("reconciles the tags of the replication group", func() {
+		reconciler.DefaultTags = map[string]string{"team": "platform", "env": "dev"}
+		current := get()
+		current.Spec.AWSConfig.Tags = []awsv1alpha1.Tag{{Key: aws.String("env"), Value: aws.String("prod")}}
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+
+		tags := func() map[string]string {
+			group, _ := fakeAPI.ReplicationGroup(key.Name)
+			output, err := fakeAPI.ListTagsForResource(ctx, &elasticache.ListTagsForResourceInput{ResourceName: group.ARN})
+			Expect(err).NotTo(HaveOccurred())
+			values := map[string]string{}
+			for _, tag := range output.TagList {
+				values[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+			}
+			return values
+		}
+
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tags()).To(Equal(map[string]string{
+			"team":                            "platform",
+			"env":                             "prod",
+			"aws.sergeyshevch.dev/owner":      key.Namespace + "/" + key.Name,
+			"aws.sergeyshevch.dev/name":       key.Name,
+			"aws.sergeyshevch.dev/namespace":  key.Namespace,
+			"aws.sergeyshevch.dev/uid":        string(current.UID),
+			"aws.sergeyshevch.dev/managed-by": "cloud-resource-operator",
+		}))
+
+		fakeAPI.Tick()
+		group, _ := fakeAPI.ReplicationGroup(key.Name)
+		_, err = fakeAPI.AddTagsToResource(ctx, &elasticache.AddTagsToResourceInput{
+			ResourceName: group.ARN,
+			Tags:         []types.Tag{{Key: aws.String("manual"), Value: aws.String("true")}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tags()).NotTo(HaveKey("manual"))
+		Expect(tags()).To(HaveKeyWithValue("team", "platform"))
+	})
+
+	It("modifies the replication group when the spec changes", func() {
+		createAvailable()
+
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("ModifyReplicationGroup"))
+
+		update(func(config *awsv1alpha1.ReplicationGroupAwsConfig) {
+			config.SnapshotRetentionLimit = aws.Int32(5)
+		})
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(count("ModifyReplicationGroup")).To(Equal(1))
+
+		fakeAPI.Tick()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(count("ModifyReplicationGroup")).To(Equal(1))
+		group, _ := fakeAPI.ReplicationGroup(key.Name)
+		Expect(aws.ToInt32(group.SnapshotRetentionLimit)).To(Equal(int32(5)))
+	})
+
+	It("reverts settings changed outside of the operator", func() {
+		createAvailable()
+
+		drifts := driftDetectionsTotal.WithLabelValues("ReplicationGroup", "replicationGroupDescription")
+		before := testutil.ToFloat64(drifts)
+		_, err := fakeAPI.ModifyReplicationGroup(ctx, &elasticache.ModifyReplicationGroupInput{
+			ReplicationGroupId:          aws.String(key.Name),
+			ReplicationGroupDescription: aws.String("changed"),
+			ApplyImmediately:            true,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		fakeAPI.Tick()
+		fakeAPI.ResetCalls()
+
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(count("ModifyReplicationGroup")).To(Equal(1))
+		Expect(get().Status.DriftedFields).To(ConsistOf("replicationGroupDescription"))
+		Expect(testutil.ToFloat64(drifts)).To(Equal(before + 1))
+
+		fakeAPI.Tick()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(count("ModifyReplicationGroup")).To(Equal(1))
+		Expect(get().Status.DriftedFields).To(BeEmpty())
+		group, _ := fakeAPI.ReplicationGroup(key.Name)
+		Expect(aws.ToString(group.Description)).To(Equal("test"))
+	})
+
+	It("names the replication group after its namespace with the NamespacedHash strategy", func() {
+		current := get()
+		current.Spec.NamingStrategy = awsv1alpha1.ClusterNamingStrategyNamespacedHash
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+		createAvailable()
+
+		id := namespacedHashId(key.Namespace, key.Name)
+		_, ok := fakeAPI.ReplicationGroup(id)
+		Expect(ok).To(BeTrue())
+		Expect(aws.ToString(get().Status.ReplicationGroupId)).To(Equal(id))
+
+		// The observed identifier is kept when the strategy changes.
+		current = get()
+		current.Spec.NamingStrategy = awsv1alpha1.ClusterNamingStrategyName
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("CreateReplicationGroup"))
+	})
+
+	It("changes the number of replicas", func() {
+		createAvailable()
+
+		// The replica count is not a modifiable setting, it is changed without
+		// a ModifyReplicationGroup.
+		update(func(config *awsv1alpha1.ReplicationGroupAwsConfig) {
+			config.NumCacheClusters = aws.Int32(3)
+		})
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).To(ContainElement("IncreaseReplicaCount"))
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("ModifyReplicationGroup"))
+
+		fakeAPI.Tick()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(get().Status.NodeGroups[0].NodeGroupMembers).To(HaveLen(3))
+
+		update(func(config *awsv1alpha1.ReplicationGroupAwsConfig) {
+			config.NumCacheClusters = aws.Int32(1)
+		})
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).To(ContainElement("DecreaseReplicaCount"))
+
+		fakeAPI.Tick()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(get().Status.NodeGroups[0].NodeGroupMembers).To(HaveLen(1))
+	})
+
+	It("applies one change per reconciliation", func() {
+		createAvailable()
+
+		update(func(config *awsv1alpha1.ReplicationGroupAwsConfig) {
+			config.SnapshotRetentionLimit = aws.Int32(5)
+			config.NumCacheClusters = aws.Int32(3)
+		})
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).To(ContainElement("ModifyReplicationGroup"))
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("IncreaseReplicaCount"))
+
+		// Nothing else is sent while the replication group is modifying.
+		fakeAPI.ResetCalls()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("ModifyReplicationGroup"))
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("IncreaseReplicaCount"))
+
+		fakeAPI.Tick()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).To(ContainElement("IncreaseReplicaCount"))
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("ModifyReplicationGroup"))
+	})
+
+	It("reshards a cluster mode enabled replication group online", func() {
+		update(func(config *awsv1alpha1.ReplicationGroupAwsConfig) {
+			config.NumCacheClusters = nil
+			config.NumNodeGroups = aws.Int32(2)
+			config.ReplicasPerNodeGroup = aws.Int32(1)
+			config.AutomaticFailoverEnabled = aws.Bool(true)
+		})
+		createAvailable()
+		Expect(get().Status.NodeGroups).To(HaveLen(2))
+		firstNodeGroup := aws.ToString(get().Status.NodeGroups[0].NodeGroupId)
+
+		// reshard applies the new number of node groups and waits for the
+		// replication group to become available again.
+		reshard := func(numNodeGroups int32) {
+			update(func(config *awsv1alpha1.ReplicationGroupAwsConfig) {
+				config.NumNodeGroups = aws.Int32(numNodeGroups)
+			})
+			fakeAPI.ResetCalls()
+			_, err := reconcile()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeAPI.Calls()).To(ContainElement("ModifyReplicationGroupShardConfiguration"))
+			fakeAPI.Tick()
+			_, err = reconcile()
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		reshard(3)
+		Expect(get().Status.NodeGroups).To(HaveLen(3))
+
+		// The node groups with the lowest identifiers are retained.
+		reshard(1)
+		nodeGroups := get().Status.NodeGroups
+		Expect(nodeGroups).To(HaveLen(1))
+		Expect(aws.ToString(nodeGroups[0].NodeGroupId)).To(Equal(firstNodeGroup))
+		Expect(nodeGroups[0].NodeGroupMembers).To(HaveLen(2))
+	})
+
+	It("deletes the replication group before removing the finalizer", func() {
+		createAvailable()
+
+		Expect(k8sClient.Delete(ctx, get())).To(Succeed())
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		group, ok := fakeAPI.ReplicationGroup(key.Name)
+		Expect(ok).To(BeTrue())
+		Expect(aws.ToString(group.Status)).To(Equal("deleting"))
+		Expect(controllerutil.ContainsFinalizer(get(), replicationGroupFinalizer)).To(BeTrue())
+
+		// The deletion is only requested once.
+		fakeAPI.ResetCalls()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("DeleteReplicationGroup"))
+		Expect(controllerutil.ContainsFinalizer(get(), replicationGroupFinalizer)).To(BeTrue())
+
+		fakeAPI.Tick()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		_, ok = fakeAPI.ReplicationGroup(key.Name)
+		Expect(ok).To(BeFalse())
+		err = k8sClient.Get(ctx, key, &awsv1alpha1.ReplicationGroup{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("waits for the replication group to be created before deleting it", func() {
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Delete(ctx, get())).To(Succeed())
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("DeleteReplicationGroup"))
+
+		fakeAPI.Tick()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).To(ContainElement("DeleteReplicationGroup"))
+		Expect(controllerutil.ContainsFinalizer(get(), replicationGroupFinalizer)).To(BeTrue())
+	})
+
+	It("refuses to manage a replication group it does not own", func() {
+		createExisting(types.Tag{Key: aws.String(ownerTagKey), Value: aws.String("other/group")})
+
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		ready := meta.FindStatusCondition(get().Status.Conditions, awsv1alpha1.ConditionTypeReady)
+		Expect(ready).NotTo(BeNil())
+		Expect(ready.Reason).To(Equal(awsv1alpha1.ReasonNotOwned))
+		Expect(ready.Message).To(ContainSubstring("other/group"))
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("ModifyReplicationGroup"))
+		Expect(controllerutil.ContainsFinalizer(get(), replicationGroupFinalizer)).To(BeFalse())
+
+		// Deleting the ReplicationGroup leaves the replication group alone.
+		Expect(k8sClient.Delete(ctx, get())).To(Succeed())
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("DeleteReplicationGroup"))
+		_, ok := fakeAPI.ReplicationGroup(key.Name)
+		Expect(ok).To(BeTrue())
+		err = k8sClient.Get(ctx, key, &awsv1alpha1.ReplicationGroup{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("refuses to manage an untagged replication group unless it is imported", func() {
+		createExisting()
+
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		ready := meta.FindStatusCondition(get().Status.Conditions, awsv1alpha1.ConditionTypeReady)
+		Expect(ready).NotTo(BeNil())
+		Expect(ready.Reason).To(Equal(awsv1alpha1.ReasonNotOwned))
+
+		current := get()
+		current.Annotations = map[string]string{awsv1alpha1.ImportAnnotation: "true"}
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		group, _ := fakeAPI.ReplicationGroup(key.Name)
+		output, err := fakeAPI.ListTagsForResource(ctx, &elasticache.ListTagsForResourceInput{ResourceName: group.ARN})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tagValue(output.TagList, ownerTagKey)).To(Equal(ownerTagValue(current)))
+		Expect(meta.FindStatusCondition(get().Status.Conditions, awsv1alpha1.ConditionTypeReady).Reason).NotTo(Equal(awsv1alpha1.ReasonNotOwned))
+	})
+})
@@ -0,0 +1,74 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// Default intervals between reconciliations of AWS resources.
+const (
+	DefaultTransitionalRequeueInterval = 15 * time.Second
+	DefaultStableRequeueInterval       = 5 * time.Minute
+)
+
+// RequeueIntervals sets how often AWS resources are polled for their state.
+// Zero values fall back to the defaults.
+type RequeueIntervals struct {
+	// Transitional applies while a resource is being created, modified or
+	// deleted, to pick up the end of the transition quickly.
+	Transitional time.Duration
+
+	// Stable applies while a resource is available or failed, to detect
+	// changes made outside of the operator.
+	Stable time.Duration
+}
+
+// requeueAfter returns the result that polls a resource in resourceStatus,
+// e.g. "available" or "modifying", again.
+func (i RequeueIntervals) requeueAfter(resourceStatus string) ctrl.Result {
+	if isTransitionalStatus(resourceStatus) {
+		return ctrl.Result{RequeueAfter: i.transitional()}
+	}
+	return ctrl.Result{RequeueAfter: i.stable()}
+}
+
+func (i RequeueIntervals) transitional() time.Duration {
+	if i.Transitional <= 0 {
+		return DefaultTransitionalRequeueInterval
+	}
+	return i.Transitional
+}
+
+func (i RequeueIntervals) stable() time.Duration {
+	if i.Stable <= 0 {
+		return DefaultStableRequeueInterval
+	}
+	return i.Stable
+}
+
+// isTransitionalStatus tells whether AWS is still working on a resource in
+// resourceStatus. AWS rejects modifications of such resources.
+func isTransitionalStatus(resourceStatus string) bool {
+	switch resourceStatus {
+	case "available", "active", "create-failed", "restore-failed", "incompatible-network", "failed":
+		return false
+	}
+	return true
+}
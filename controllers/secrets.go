@@ -0,0 +1,79 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+)
+
+// getSecretValue returns the value stored under selector.Key of the Secret
+// selector.Name in the given namespace.
+func getSecretValue(ctx context.Context, c client.Client, namespace string, selector *awsv1alpha1.SecretKeySelector) (string, error) {
+	secret := &corev1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: selector.Name}, secret)
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := secret.Data[selector.Key]
+	if !ok {
+		return "", fmt.Errorf("key %q not found in secret %s/%s", selector.Key, namespace, selector.Name)
+	}
+	return string(value), nil
+}
+
+// hashSecretValue returns a hash of value salted with the UID of the owning
+// resource, suitable for detecting changes without exposing the value.
+func hashSecretValue(owner client.Object, value string) string {
+	sum := sha256.Sum256([]byte(string(owner.GetUID()) + "/" + value))
+	return hex.EncodeToString(sum[:])
+}
+
+// authTokenHash returns the hash of an auth token recorded in the status of
+// owner, or an empty string when no token is configured.
+func authTokenHash(owner client.Object, authToken *string) string {
+	if authToken == nil {
+		return ""
+	}
+	return hashSecretValue(owner, *authToken)
+}
+
+// validateAuthToken checks the constraints ElastiCache places on a Redis AUTH
+// token: 16 to 128 printable ASCII characters where the only permitted special
+// characters are !, &, #, $, ^, <, > and -.
+func validateAuthToken(authToken string) error {
+	if len(authToken) < 16 || len(authToken) > 128 {
+		return fmt.Errorf("auth token must be between 16 and 128 characters long, got %d", len(authToken))
+	}
+	for _, c := range authToken {
+		isAlphanumeric := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+		if !isAlphanumeric && !strings.ContainsRune("!&#$^<>-", c) {
+			return fmt.Errorf("auth token contains the character %q, only letters, digits and !&#$^<>- are permitted", c)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+)
+
+// Keys of the tags the operator puts on every resource it manages, next to the
+// owner tag.
+var (
+	nameTagKey      = awsv1alpha1.OperatorTagPrefix + "name"
+	namespaceTagKey = awsv1alpha1.OperatorTagPrefix + "namespace"
+	uidTagKey       = awsv1alpha1.OperatorTagPrefix + "uid"
+	managedByTagKey = awsv1alpha1.OperatorTagPrefix + "managed-by"
+)
+
+// managedByTagValue identifies the operator in the managed-by tag.
+var managedByTagValue = "cloud-resource-operator"
+
+// operatorTags returns the tags identifying obj as the manager of its AWS
+// resource.
+func operatorTags(obj client.Object) []types.Tag {
+	return []types.Tag{
+		ownerTag(obj),
+		{Key: aws.String(nameTagKey), Value: aws.String(obj.GetName())},
+		{Key: aws.String(namespaceTagKey), Value: aws.String(obj.GetNamespace())},
+		{Key: aws.String(uidTagKey), Value: aws.String(string(obj.GetUID()))},
+		{Key: aws.String(managedByTagKey), Value: aws.String(managedByTagValue)},
+	}
+}
+
+// desiredTags returns the tags the AWS resource of obj should carry, sorted by
+// key: the operator-wide defaults, overridden by the tags of the spec, overridden
+// by the operator tags.
+func desiredTags(defaults map[string]string, specTags []awsv1alpha1.Tag, obj client.Object) []types.Tag {
+	values := map[string]string{}
+	for key, value := range defaults {
+		values[key] = value
+	}
+	for _, tag := range specTags {
+		values[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	for _, tag := range operatorTags(obj) {
+		values[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+
+	var tags []types.Tag
+	for key, value := range values {
+		tags = append(tags, types.Tag{Key: aws.String(key), Value: aws.String(value)})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return aws.ToString(tags[i].Key) < aws.ToString(tags[j].Key)
+	})
+	return tags
+}
+
+// isSystemTag tells whether the tag key is reserved by AWS, such tags can be
+// neither added nor removed.
+func isSystemTag(key string) bool {
+	return strings.HasPrefix(key, "aws:")
+}
+
+// listTags returns the tags of the resource with the given ARN.
+func listTags(awsClient ElastiCacheAPI, arn *string) ([]types.Tag, error) {
+	output, err := awsClient.ListTagsForResource(context.TODO(), &elasticache.ListTagsForResourceInput{
+		ResourceName: arn,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output.TagList, nil
+}
+
+// tagValue returns the value of the tag with the given key, or an empty string
+// when there is none.
+func tagValue(tags []types.Tag, key string) string {
+	for _, tag := range tags {
+		if aws.ToString(tag.Key) == key {
+			return aws.ToString(tag.Value)
+		}
+	}
+	return ""
+}
+
+// reconcileTags makes the tags of the resource with the given ARN match desired.
+// Tags reserved by AWS are left alone. It reports whether any tag was changed.
+func reconcileTags(awsClient ElastiCacheAPI, arn *string, desired []types.Tag) (bool, error) {
+	current, err := listTags(awsClient, arn)
+	if err != nil {
+		return false, err
+	}
+
+	currentValues := map[string]string{}
+	for _, tag := range current {
+		currentValues[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	desiredKeys := map[string]bool{}
+	var toAdd []types.Tag
+	for _, tag := range desired {
+		key := aws.ToString(tag.Key)
+		desiredKeys[key] = true
+		if value, ok := currentValues[key]; !ok || value != aws.ToString(tag.Value) {
+			toAdd = append(toAdd, tag)
+		}
+	}
+	var toRemove []string
+	for _, tag := range current {
+		key := aws.ToString(tag.Key)
+		if !desiredKeys[key] && !isSystemTag(key) {
+			toRemove = append(toRemove, key)
+		}
+	}
+
+	if len(toAdd) > 0 {
+		_, err = awsClient.AddTagsToResource(context.TODO(), &elasticache.AddTagsToResourceInput{
+			ResourceName: arn,
+			Tags:         toAdd,
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+	if len(toRemove) > 0 {
+		_, err = awsClient.RemoveTagsFromResource(context.TODO(), &elasticache.RemoveTagsFromResourceInput{
+			ResourceName: arn,
+			TagKeys:      toRemove,
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+	return len(toAdd) > 0 || len(toRemove) > 0, nil
+}
+
+// backfillTags copies the tags of an imported resource into the tags of the
+// spec when it sets none, so adopting the resource does not remove them.
+func backfillTags(config *awsv1alpha1.ElasticCacheAwsConfig, tags []types.Tag) {
+	if config.Tags != nil {
+		return
+	}
+	for _, tag := range tags {
+		key := aws.ToString(tag.Key)
+		if isSystemTag(key) || strings.HasPrefix(key, awsv1alpha1.OperatorTagPrefix) {
+			continue
+		}
+		config.Tags = append(config.Tags, awsv1alpha1.Tag{Key: tag.Key, Value: tag.Value})
+	}
+}
@@ -0,0 +1,391 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+)
+
+var userFinalizer = elasticCacheFinalizer
+var passwordSecretRefsField = ".spec.passwordSecretRefs.name"
+
+// UserReconciler reconciles a User object
+type UserReconciler struct {
+	client.Client
+	AwsConfig aws.Config
+	Scheme    *runtime.Scheme
+
+	// NewElastiCacheClient builds the ElastiCache client, defaults to the
+	// client of the AWS SDK.
+	NewElastiCacheClient ElastiCacheClientFunc
+
+	// RequeueIntervals sets how often the AWS resources are polled.
+	RequeueIntervals RequeueIntervals
+
+	backoff requeueBackoff
+}
+
+//+kubebuilder:rbac:groups=aws.sergeyshevch.dev,resources=users,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=aws.sergeyshevch.dev,resources=users/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=aws.sergeyshevch.dev,resources=users/finalizers,verbs=update
+
+// Reconcile creates the ElastiCache user and keeps its access string and
+// passwords in line with the spec and the referenced Secrets.
+func (r *UserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	defer observeReconcileDuration("User", time.Now())
+
+	instance := &awsv1alpha1.User{}
+	err := r.Client.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	result, err := r.reconcileUser(ctx, instance)
+	if err != nil {
+		result, err = handleReconcileError(&instance.Status.Conditions, instance, &r.backoff, isUserNotFound, err)
+		if statusErr := r.Status().Update(context.TODO(), instance); statusErr != nil {
+			log.FromContext(ctx).Error(statusErr, "unable to update User status")
+		}
+		return result, err
+	}
+	r.backoff.reset(req.NamespacedName)
+	return result, nil
+}
+
+func (r *UserReconciler) reconcileUser(ctx context.Context, instance *awsv1alpha1.User) (ctrl.Result, error) {
+	awsClient := newElastiCacheClient(r.NewElastiCacheClient, r.AwsConfig)
+
+	isUserMarkedToDeletion := instance.GetDeletionTimestamp() != nil
+	if isUserMarkedToDeletion {
+		return ctrl.Result{}, r.finalizeUser(ctx, awsClient, instance)
+	}
+
+	passwords, err := r.resolvePasswords(ctx, instance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	passwordsHash := ""
+	if len(passwords) > 0 {
+		passwordsHash = hashSecretValue(instance, strings.Join(passwords, "\n"))
+	}
+
+	user, err := r.getUser(awsClient, instance)
+	if err != nil {
+		if !isUserNotFound(err) {
+			return ctrl.Result{}, err
+		}
+
+		// Persist the finalizer before the user exists, a deletion right
+		// after the creation would otherwise leak it.
+		err = r.addFinalizer(ctx, instance)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		user, err = r.createUser(awsClient, instance, passwords)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		instance.Status.PasswordsHash = passwordsHash
+		instance.Status.AccessStringHash = accessStringHash(instance)
+	} else {
+		// Never touch a user this User did not create or import.
+		owned, err := r.ensureUserOwnership(awsClient, instance, user)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !owned {
+			err = r.Status().Update(context.TODO(), instance)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{RequeueAfter: r.RequeueIntervals.stable()}, nil
+		}
+
+		err = r.addFinalizer(ctx, instance)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if aws.ToString(user.Status) == "active" {
+		// Only send the passwords when the referenced secrets changed, so
+		// unrelated modifications keep the current passwords.
+		var rotatedPasswords []string
+		if passwordsHash != instance.Status.PasswordsHash {
+			rotatedPasswords = passwords
+		}
+
+		if rotatedPasswords != nil || isUserChanged(instance, user) {
+			user, err = r.patchUser(awsClient, instance, rotatedPasswords)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			instance.Status.PasswordsHash = passwordsHash
+			instance.Status.AccessStringHash = accessStringHash(instance)
+		}
+	}
+
+	status := &instance.Status
+	status.ObservedGeneration = instance.GetGeneration()
+	status.UserId = user.UserId
+	status.ARN = user.ARN
+	status.Status = user.Status
+	status.AccessString = user.AccessString
+	status.UserGroupIds = user.UserGroupIds
+	status.AuthenticationType = ""
+	status.PasswordCount = nil
+	if user.Authentication != nil {
+		status.AuthenticationType = string(user.Authentication.Type)
+		status.PasswordCount = user.Authentication.PasswordCount
+	}
+	setAwsResourceConditions(&status.Conditions, instance, aws.ToString(user.Status))
+	now := metav1.Now()
+	status.LastSyncTime = &now
+
+	err = r.Status().Update(context.TODO(), instance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return r.RequeueIntervals.requeueAfter(aws.ToString(user.Status)), nil
+}
+
+// isUserChanged reports whether the access string or the authentication mode
+// of the user in AWS differ from the spec. AWS reports access strings in a
+// normalized form, so the access string is compared with the hash of the one
+// last sent and with the normalized value observed afterwards.
+func isUserChanged(cr *awsv1alpha1.User, user *types.User) bool {
+	if accessStringHash(cr) != cr.Status.AccessStringHash {
+		return true
+	}
+	if aws.ToString(user.AccessString) != aws.ToString(cr.Status.AccessString) {
+		return true
+	}
+	noPassword := user.Authentication != nil && user.Authentication.Type == types.AuthenticationTypeNoPassword
+	return noPassword != cr.Spec.NoPasswordRequired
+}
+
+// accessStringHash returns the hash of the access string of the spec recorded
+// in the status of the User.
+func accessStringHash(cr *awsv1alpha1.User) string {
+	return hashSecretValue(cr, cr.Spec.AccessString)
+}
+
+// finalizeUser deletes the user of a User that is being deleted and removes
+// the finalizer. Users the User does not own are left in place.
+func (r *UserReconciler) finalizeUser(ctx context.Context, awsClient ElastiCacheAPI, instance *awsv1alpha1.User) error {
+	if !controllerutil.ContainsFinalizer(instance, userFinalizer) {
+		return nil
+	}
+
+	user, err := r.getUser(awsClient, instance)
+	if err != nil && !isUserNotFound(err) {
+		return err
+	}
+	if err == nil {
+		owned, err := r.ensureUserOwnership(awsClient, instance, user)
+		if err != nil {
+			return err
+		}
+		if owned {
+			err = r.deleteUser(awsClient, instance)
+			if err != nil && !isUserNotFound(err) {
+				return err
+			}
+		}
+	}
+
+	controllerutil.RemoveFinalizer(instance, userFinalizer)
+	return r.Update(ctx, instance)
+}
+
+func (r *UserReconciler) addFinalizer(ctx context.Context, instance *awsv1alpha1.User) error {
+	if controllerutil.ContainsFinalizer(instance, userFinalizer) {
+		return nil
+	}
+	controllerutil.AddFinalizer(instance, userFinalizer)
+	return r.Update(ctx, instance)
+}
+
+func (r *UserReconciler) createUser(awsClient ElastiCacheAPI, cr *awsv1alpha1.User, passwords []string) (*types.User, error) {
+	params := &elasticache.CreateUserInput{
+		UserId:             &cr.Name,
+		UserName:           &cr.Spec.UserName,
+		AccessString:       &cr.Spec.AccessString,
+		Engine:             aws.String(userEngine(cr.Spec.Engine)),
+		NoPasswordRequired: aws.Bool(cr.Spec.NoPasswordRequired),
+		Passwords:          passwords,
+		Tags:               desiredTags(nil, cr.Spec.Tags, cr),
+	}
+
+	output, err := awsClient.CreateUser(context.TODO(), params)
+	if err != nil {
+		return &types.User{}, err
+	}
+	return &types.User{
+		ARN:            output.ARN,
+		AccessString:   output.AccessString,
+		Authentication: output.Authentication,
+		Engine:         output.Engine,
+		Status:         output.Status,
+		UserGroupIds:   output.UserGroupIds,
+		UserId:         output.UserId,
+		UserName:       output.UserName,
+	}, nil
+}
+
+func (r *UserReconciler) patchUser(awsClient ElastiCacheAPI, cr *awsv1alpha1.User, passwords []string) (*types.User, error) {
+	params := &elasticache.ModifyUserInput{
+		UserId:       &cr.Name,
+		AccessString: &cr.Spec.AccessString,
+		Passwords:    passwords,
+	}
+	// AWS rejects NoPasswordRequired=false without passwords, the
+	// authentication is left alone unless it changes.
+	if cr.Spec.NoPasswordRequired || passwords != nil {
+		params.NoPasswordRequired = aws.Bool(cr.Spec.NoPasswordRequired)
+	}
+
+	output, err := awsClient.ModifyUser(context.TODO(), params)
+	if err != nil {
+		return &types.User{}, err
+	}
+	return &types.User{
+		ARN:            output.ARN,
+		AccessString:   output.AccessString,
+		Authentication: output.Authentication,
+		Engine:         output.Engine,
+		Status:         output.Status,
+		UserGroupIds:   output.UserGroupIds,
+		UserId:         output.UserId,
+		UserName:       output.UserName,
+	}, nil
+}
+
+func (r *UserReconciler) getUser(awsClient ElastiCacheAPI, cr *awsv1alpha1.User) (*types.User, error) {
+	params := &elasticache.DescribeUsersInput{
+		UserId: &cr.Name,
+	}
+
+	output, err := awsClient.DescribeUsers(context.TODO(), params)
+	if err != nil {
+		return &types.User{}, err
+	}
+
+	if len(output.Users) == 1 {
+		return &output.Users[0], nil
+	}
+	return &types.User{}, &types.UserNotFoundFault{
+		Message: aws.String(fmt.Sprintf("User %s not found.", cr.Name)),
+	}
+}
+
+func (r *UserReconciler) deleteUser(awsClient ElastiCacheAPI, cr *awsv1alpha1.User) error {
+	params := &elasticache.DeleteUserInput{
+		UserId: &cr.Name,
+	}
+
+	_, err := awsClient.DeleteUser(context.TODO(), params)
+	return err
+}
+
+func isUserNotFound(err error) bool {
+	var notFound *types.UserNotFoundFault
+	return goerrors.As(err, &notFound)
+}
+
+func userEngine(engine string) string {
+	if engine == "" {
+		return "redis"
+	}
+	return engine
+}
+
+// resolvePasswords reads the passwords referenced by the User spec in order.
+func (r *UserReconciler) resolvePasswords(ctx context.Context, instance *awsv1alpha1.User) ([]string, error) {
+	var passwords []string
+	for i := range instance.Spec.PasswordSecretRefs {
+		password, err := getSecretValue(ctx, r.Client, instance.Namespace, &instance.Spec.PasswordSecretRefs[i])
+		if err != nil {
+			return nil, err
+		}
+		passwords = append(passwords, password)
+	}
+	return passwords, nil
+}
+
+// findUsersForSecret maps a Secret to the Users referencing it as one of
+// their passwords.
+func (r *UserReconciler) findUsersForSecret(secret client.Object) []reconcile.Request {
+	users := &awsv1alpha1.UserList{}
+	err := r.List(context.TODO(), users,
+		client.InNamespace(secret.GetNamespace()),
+		client.MatchingFields{passwordSecretRefsField: secret.GetName()})
+	if err != nil {
+		return nil
+	}
+
+	requests := make([]reconcile.Request, len(users.Items))
+	for i := range users.Items {
+		requests[i] = reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&users.Items[i])}
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *UserReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	err := mgr.GetFieldIndexer().IndexField(context.Background(), &awsv1alpha1.User{}, passwordSecretRefsField, func(obj client.Object) []string {
+		user := obj.(*awsv1alpha1.User)
+		var names []string
+		for _, selector := range user.Spec.PasswordSecretRefs {
+			names = append(names, selector.Name)
+		}
+		return names
+	})
+	if err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&awsv1alpha1.User{}, specChanged()).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(r.findUsersForSecret)).
+		Complete(r)
+}
@@ -0,0 +1,280 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+	"github.com/sergeyshevch/cloud-resource-operator/internal/fake"
+)
+
+var _ = Describe("User controller", func() {
+	var (
+		ctx        context.Context
+		fakeAPI    *fake.ElastiCache
+		reconciler *UserReconciler
+		secret     *corev1.Secret
+		instance   *awsv1alpha1.User
+		key        client.ObjectKey
+	)
+
+	reconcile := func() (ctrl.Result, error) {
+		return reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	}
+
+	get := func() *awsv1alpha1.User {
+		current := &awsv1alpha1.User{}
+		Expect(k8sClient.Get(ctx, key, current)).To(Succeed())
+		return current
+	}
+
+	describe := func() types.User {
+		output, err := fakeAPI.DescribeUsers(ctx, &elasticache.DescribeUsersInput{UserId: aws.String(key.Name)})
+		Expect(err).NotTo(HaveOccurred())
+		return output.Users[0]
+	}
+
+	// createActive reconciles the User until its user is active.
+	createActive := func() {
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		fakeAPI.Tick()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(meta.IsStatusConditionTrue(get().Status.Conditions, awsv1alpha1.ConditionTypeReady)).To(BeTrue())
+		fakeAPI.ResetCalls()
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		fakeAPI = fake.New()
+		reconciler = &UserReconciler{
+			Client:               k8sClient,
+			Scheme:               scheme.Scheme,
+			NewElastiCacheClient: fakeClientFunc(fakeAPI),
+		}
+
+		secret = &corev1.Secret{
+			Data: map[string][]byte{"password": []byte("0123456789abcdef0123")},
+		}
+		createTestObject(ctx, secret, "passwords-")
+
+		instance = &awsv1alpha1.User{
+			Spec: awsv1alpha1.UserSpec{
+				UserName:           "app",
+				AccessString:       "on ~app:* +@read",
+				PasswordSecretRefs: []awsv1alpha1.SecretKeySelector{{Name: secret.Name, Key: "password"}},
+			},
+		}
+		key = createTestObject(ctx, instance, "user-")
+	})
+
+	AfterEach(func() {
+		Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, secret))).To(Succeed())
+		removeTestObject(ctx, key, &awsv1alpha1.User{})
+	})
+
+	It("creates the user with the passwords of the referenced Secrets", func() {
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		user := describe()
+		Expect(aws.ToString(user.UserName)).To(Equal("app"))
+		Expect(aws.ToString(user.AccessString)).To(Equal("on ~app:* &* +@read"))
+		Expect(aws.ToString(user.Engine)).To(Equal("redis"))
+
+		current := get()
+		Expect(controllerutil.ContainsFinalizer(current, userFinalizer)).To(BeTrue())
+		Expect(current.Status.AuthenticationType).To(Equal(string(types.AuthenticationTypePassword)))
+		Expect(aws.ToInt32(current.Status.PasswordCount)).To(Equal(int32(1)))
+		Expect(current.Status.PasswordsHash).NotTo(BeEmpty())
+		Expect(current.Status.PasswordsHash).NotTo(ContainSubstring("0123456789abcdef0123"))
+		Expect(meta.IsStatusConditionTrue(current.Status.Conditions, awsv1alpha1.ConditionTypeReady)).To(BeFalse())
+
+		fakeAPI.Tick()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(aws.ToString(get().Status.Status)).To(Equal("active"))
+		Expect(meta.IsStatusConditionTrue(get().Status.Conditions, awsv1alpha1.ConditionTypeReady)).To(BeTrue())
+	})
+
+	It("creates a user without password", func() {
+		current := get()
+		current.Spec.PasswordSecretRefs = nil
+		current.Spec.NoPasswordRequired = true
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(describe().Authentication.Type).To(Equal(types.AuthenticationTypeNoPassword))
+		Expect(get().Status.PasswordsHash).To(BeEmpty())
+	})
+
+	It("does not create the user while a password Secret is missing", func() {
+		Expect(k8sClient.Delete(ctx, secret)).To(Succeed())
+
+		_, err := reconcile()
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("CreateUser"))
+		Expect(meta.IsStatusConditionFalse(get().Status.Conditions, awsv1alpha1.ConditionTypeSynced)).To(BeTrue())
+	})
+
+	It("modifies the access string without resending the passwords", func() {
+		createActive()
+
+		// AWS reports the access string normalized, which is not a change.
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("ModifyUser"))
+
+		current := get()
+		current.Spec.AccessString = "on ~app:* +@all"
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+
+		var modify *elasticache.ModifyUserInput
+		reconciler.NewElastiCacheClient = func(aws.Config) ElastiCacheAPI {
+			return &modifyUserRecorder{ElastiCache: fakeAPI, input: &modify}
+		}
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(modify).NotTo(BeNil())
+		Expect(modify.Passwords).To(BeEmpty())
+		Expect(aws.ToString(describe().AccessString)).To(Equal("on ~app:* &* +@all"))
+	})
+
+	It("reverts access strings changed outside of the operator", func() {
+		createActive()
+
+		_, err := fakeAPI.ModifyUser(ctx, &elasticache.ModifyUserInput{
+			UserId:       aws.String(key.Name),
+			AccessString: aws.String("on ~* +@all"),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		fakeAPI.Tick()
+		fakeAPI.ResetCalls()
+
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).To(ContainElement("ModifyUser"))
+		Expect(aws.ToString(describe().AccessString)).To(Equal("on ~app:* &* +@read"))
+
+		fakeAPI.Tick()
+		fakeAPI.ResetCalls()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("ModifyUser"))
+	})
+
+	It("switches the user to no-password mode", func() {
+		createActive()
+
+		current := get()
+		current.Spec.PasswordSecretRefs = nil
+		current.Spec.NoPasswordRequired = true
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(describe().Authentication.Type).To(Equal(types.AuthenticationTypeNoPassword))
+		Expect(get().Status.PasswordsHash).To(BeEmpty())
+	})
+
+	It("rotates the passwords when the referenced Secret changes", func() {
+		createActive()
+		hash := get().Status.PasswordsHash
+
+		secret.Data["password"] = []byte("fedcba9876543210fedc")
+		Expect(k8sClient.Update(ctx, secret)).To(Succeed())
+
+		var modify *elasticache.ModifyUserInput
+		reconciler.NewElastiCacheClient = func(aws.Config) ElastiCacheAPI {
+			return &modifyUserRecorder{ElastiCache: fakeAPI, input: &modify}
+		}
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(modify).NotTo(BeNil())
+		Expect(modify.Passwords).To(Equal([]string{"fedcba9876543210fedc"}))
+		Expect(get().Status.PasswordsHash).NotTo(Equal(hash))
+	})
+
+	It("refuses to manage a user it does not own", func() {
+		_, err := fakeAPI.CreateUser(ctx, &elasticache.CreateUserInput{
+			UserId:             aws.String(key.Name),
+			UserName:           aws.String("other"),
+			AccessString:       aws.String("on ~* +@all"),
+			Engine:             aws.String("redis"),
+			NoPasswordRequired: aws.Bool(true),
+			Tags:               []types.Tag{{Key: aws.String(ownerTagKey), Value: aws.String("other/user")}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		fakeAPI.Tick()
+		fakeAPI.ResetCalls()
+
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		ready := meta.FindStatusCondition(get().Status.Conditions, awsv1alpha1.ConditionTypeReady)
+		Expect(ready).NotTo(BeNil())
+		Expect(ready.Reason).To(Equal(awsv1alpha1.ReasonNotOwned))
+		Expect(ready.Message).To(ContainSubstring("other/user"))
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("ModifyUser"))
+		Expect(controllerutil.ContainsFinalizer(get(), userFinalizer)).To(BeFalse())
+
+		// Deleting the User leaves the user alone.
+		Expect(k8sClient.Delete(ctx, get())).To(Succeed())
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("DeleteUser"))
+		Expect(aws.ToString(describe().Status)).To(Equal("active"))
+	})
+
+	It("deletes the user before removing the finalizer", func() {
+		createActive()
+
+		Expect(k8sClient.Delete(ctx, get())).To(Succeed())
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(aws.ToString(describe().Status)).To(Equal("deleting"))
+		err = k8sClient.Get(ctx, key, &awsv1alpha1.User{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+})
+
+// modifyUserRecorder records the input of the last ModifyUser call.
+type modifyUserRecorder struct {
+	*fake.ElastiCache
+	input **elasticache.ModifyUserInput
+}
+
+func (r *modifyUserRecorder) ModifyUser(ctx context.Context, params *elasticache.ModifyUserInput, optFns ...func(*elasticache.Options)) (*elasticache.ModifyUserOutput, error) {
+	*r.input = params
+	return r.ElastiCache.ModifyUser(ctx, params, optFns...)
+}
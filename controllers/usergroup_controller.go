@@ -0,0 +1,338 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+)
+
+var userGroupFinalizer = elasticCacheFinalizer
+var userRefsField = ".spec.userRefs.name"
+
+// UserGroupReconciler reconciles a UserGroup object
+type UserGroupReconciler struct {
+	client.Client
+	AwsConfig aws.Config
+	Scheme    *runtime.Scheme
+
+	// NewElastiCacheClient builds the ElastiCache client, defaults to the
+	// client of the AWS SDK.
+	NewElastiCacheClient ElastiCacheClientFunc
+
+	// RequeueIntervals sets how often the AWS resources are polled.
+	RequeueIntervals RequeueIntervals
+
+	backoff requeueBackoff
+}
+
+//+kubebuilder:rbac:groups=aws.sergeyshevch.dev,resources=usergroups,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=aws.sergeyshevch.dev,resources=usergroups/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=aws.sergeyshevch.dev,resources=usergroups/finalizers,verbs=update
+//+kubebuilder:rbac:groups=aws.sergeyshevch.dev,resources=users,verbs=get;list;watch
+
+// Reconcile creates the ElastiCache user group once all referenced Users are
+// Ready and adds or removes users so its membership matches the spec.
+func (r *UserGroupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	defer observeReconcileDuration("UserGroup", time.Now())
+
+	instance := &awsv1alpha1.UserGroup{}
+	err := r.Client.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	result, err := r.reconcileUserGroup(ctx, instance)
+	if err != nil {
+		result, err = handleReconcileError(&instance.Status.Conditions, instance, &r.backoff, isUserGroupNotFound, err)
+		if statusErr := r.Status().Update(context.TODO(), instance); statusErr != nil {
+			log.FromContext(ctx).Error(statusErr, "unable to update UserGroup status")
+		}
+		return result, err
+	}
+	r.backoff.reset(req.NamespacedName)
+	return result, nil
+}
+
+func (r *UserGroupReconciler) reconcileUserGroup(ctx context.Context, instance *awsv1alpha1.UserGroup) (ctrl.Result, error) {
+	awsClient := newElastiCacheClient(r.NewElastiCacheClient, r.AwsConfig)
+
+	isUserGroupMarkedToDeletion := instance.GetDeletionTimestamp() != nil
+	if isUserGroupMarkedToDeletion {
+		if controllerutil.ContainsFinalizer(instance, userGroupFinalizer) {
+			err := r.deleteUserGroup(awsClient, instance)
+			if err != nil && !isUserGroupNotFound(err) {
+				return ctrl.Result{}, err
+			}
+
+			controllerutil.RemoveFinalizer(instance, userGroupFinalizer)
+			err = r.Update(ctx, instance)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if !controllerutil.ContainsFinalizer(instance, userGroupFinalizer) {
+		controllerutil.AddFinalizer(instance, userGroupFinalizer)
+		err := r.Update(ctx, instance)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	userIds, ready, err := r.resolveUserIds(ctx, instance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !ready {
+		err = r.Status().Update(context.TODO(), instance)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: r.RequeueIntervals.transitional()}, nil
+	}
+
+	userGroup, err := r.getUserGroup(awsClient, instance)
+	if err != nil {
+		if !isUserGroupNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		userGroup, err = r.createUserGroup(awsClient, instance, userIds)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	} else if aws.ToString(userGroup.Status) == "active" {
+		toAdd := stringsDifference(userIds, userGroup.UserIds)
+		toRemove := stringsDifference(userGroup.UserIds, userIds)
+		if len(toAdd) > 0 || len(toRemove) > 0 {
+			userGroup, err = r.patchUserGroup(awsClient, instance, toAdd, toRemove)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	status := &instance.Status
+	status.ObservedGeneration = instance.GetGeneration()
+	status.UserGroupId = userGroup.UserGroupId
+	status.ARN = userGroup.ARN
+	status.Status = userGroup.Status
+	status.UserIds = userGroup.UserIds
+	status.ReplicationGroups = userGroup.ReplicationGroups
+	status.PendingChanges = nil
+	if pending := userGroup.PendingChanges; pending != nil {
+		status.PendingChanges = &awsv1alpha1.UserGroupPendingChanges{
+			UserIdsToAdd:    pending.UserIdsToAdd,
+			UserIdsToRemove: pending.UserIdsToRemove,
+		}
+	}
+	setAwsResourceConditions(&status.Conditions, instance, aws.ToString(userGroup.Status))
+	now := metav1.Now()
+	status.LastSyncTime = &now
+
+	err = r.Status().Update(context.TODO(), instance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return r.RequeueIntervals.requeueAfter(aws.ToString(userGroup.Status)), nil
+}
+
+// resolveUserIds returns the sorted IDs of all users that belong to the user
+// group. When a referenced User is missing or not Ready yet, it records that in
+// the status conditions and reports false.
+func (r *UserGroupReconciler) resolveUserIds(ctx context.Context, instance *awsv1alpha1.UserGroup) ([]string, bool, error) {
+	userIds := append([]string(nil), instance.Spec.UserIds...)
+	for _, ref := range instance.Spec.UserRefs {
+		user := &awsv1alpha1.User{}
+		err := r.Get(ctx, client.ObjectKey{Namespace: instance.Namespace, Name: ref.Name}, user)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				setWaitingForReferencesConditions(&instance.Status.Conditions, instance,
+					fmt.Sprintf("User %s not found", ref.Name))
+				return nil, false, nil
+			}
+			return nil, false, err
+		}
+
+		if !meta.IsStatusConditionTrue(user.Status.Conditions, awsv1alpha1.ConditionTypeReady) || user.Status.UserId == nil {
+			setWaitingForReferencesConditions(&instance.Status.Conditions, instance,
+				fmt.Sprintf("User %s is not Ready", ref.Name))
+			return nil, false, nil
+		}
+		userIds = append(userIds, *user.Status.UserId)
+	}
+	sort.Strings(userIds)
+	return userIds, true, nil
+}
+
+func (r *UserGroupReconciler) createUserGroup(awsClient ElastiCacheAPI, cr *awsv1alpha1.UserGroup, userIds []string) (*types.UserGroup, error) {
+	params := &elasticache.CreateUserGroupInput{
+		UserGroupId: &cr.Name,
+		Engine:      aws.String(userEngine(cr.Spec.Engine)),
+		UserIds:     userIds,
+		Tags:        convertTags(cr.Spec.Tags),
+	}
+
+	output, err := awsClient.CreateUserGroup(context.TODO(), params)
+	if err != nil {
+		return &types.UserGroup{}, err
+	}
+	return &types.UserGroup{
+		ARN:               output.ARN,
+		Engine:            output.Engine,
+		PendingChanges:    output.PendingChanges,
+		ReplicationGroups: output.ReplicationGroups,
+		Status:            output.Status,
+		UserGroupId:       output.UserGroupId,
+		UserIds:           output.UserIds,
+	}, nil
+}
+
+func (r *UserGroupReconciler) patchUserGroup(awsClient ElastiCacheAPI, cr *awsv1alpha1.UserGroup, toAdd, toRemove []string) (*types.UserGroup, error) {
+	params := &elasticache.ModifyUserGroupInput{
+		UserGroupId:     &cr.Name,
+		UserIdsToAdd:    toAdd,
+		UserIdsToRemove: toRemove,
+	}
+
+	output, err := awsClient.ModifyUserGroup(context.TODO(), params)
+	if err != nil {
+		return &types.UserGroup{}, err
+	}
+	return &types.UserGroup{
+		ARN:               output.ARN,
+		Engine:            output.Engine,
+		PendingChanges:    output.PendingChanges,
+		ReplicationGroups: output.ReplicationGroups,
+		Status:            output.Status,
+		UserGroupId:       output.UserGroupId,
+		UserIds:           output.UserIds,
+	}, nil
+}
+
+func (r *UserGroupReconciler) getUserGroup(awsClient ElastiCacheAPI, cr *awsv1alpha1.UserGroup) (*types.UserGroup, error) {
+	params := &elasticache.DescribeUserGroupsInput{
+		UserGroupId: &cr.Name,
+	}
+
+	output, err := awsClient.DescribeUserGroups(context.TODO(), params)
+	if err != nil {
+		return &types.UserGroup{}, err
+	}
+
+	if len(output.UserGroups) == 1 {
+		return &output.UserGroups[0], nil
+	}
+	return &types.UserGroup{}, &types.UserGroupNotFoundFault{
+		Message: aws.String(fmt.Sprintf("UserGroup %s not found.", cr.Name)),
+	}
+}
+
+func (r *UserGroupReconciler) deleteUserGroup(awsClient ElastiCacheAPI, cr *awsv1alpha1.UserGroup) error {
+	params := &elasticache.DeleteUserGroupInput{
+		UserGroupId: &cr.Name,
+	}
+
+	_, err := awsClient.DeleteUserGroup(context.TODO(), params)
+	return err
+}
+
+func isUserGroupNotFound(err error) bool {
+	var notFound *types.UserGroupNotFoundFault
+	return goerrors.As(err, &notFound)
+}
+
+// stringsDifference returns the sorted values of a that are not in b.
+func stringsDifference(a, b []string) []string {
+	present := make(map[string]bool, len(b))
+	for _, value := range b {
+		present[value] = true
+	}
+
+	var difference []string
+	for _, value := range a {
+		if !present[value] {
+			difference = append(difference, value)
+		}
+	}
+	sort.Strings(difference)
+	return difference
+}
+
+// findUserGroupsForUser maps a User to the UserGroups referencing it, so user
+// groups waiting for it are created once it is Ready.
+func (r *UserGroupReconciler) findUserGroupsForUser(user client.Object) []reconcile.Request {
+	userGroups := &awsv1alpha1.UserGroupList{}
+	err := r.List(context.TODO(), userGroups,
+		client.InNamespace(user.GetNamespace()),
+		client.MatchingFields{userRefsField: user.GetName()})
+	if err != nil {
+		return nil
+	}
+
+	requests := make([]reconcile.Request, len(userGroups.Items))
+	for i := range userGroups.Items {
+		requests[i] = reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&userGroups.Items[i])}
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *UserGroupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	err := mgr.GetFieldIndexer().IndexField(context.Background(), &awsv1alpha1.UserGroup{}, userRefsField, func(obj client.Object) []string {
+		userGroup := obj.(*awsv1alpha1.UserGroup)
+		var names []string
+		for _, ref := range userGroup.Spec.UserRefs {
+			names = append(names, ref.Name)
+		}
+		return names
+	})
+	if err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&awsv1alpha1.UserGroup{}, specChanged()).
+		Watches(&source.Kind{Type: &awsv1alpha1.User{}}, handler.EnqueueRequestsFromMapFunc(r.findUserGroupsForUser)).
+		Complete(r)
+}
@@ -0,0 +1,230 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+	"github.com/sergeyshevch/cloud-resource-operator/internal/fake"
+)
+
+var _ = Describe("UserGroup controller", func() {
+	var (
+		ctx            context.Context
+		fakeAPI        *fake.ElastiCache
+		reconciler     *UserGroupReconciler
+		userReconciler *UserReconciler
+		user           *awsv1alpha1.User
+		instance       *awsv1alpha1.UserGroup
+		key            client.ObjectKey
+	)
+
+	reconcile := func() (ctrl.Result, error) {
+		return reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	}
+
+	get := func() *awsv1alpha1.UserGroup {
+		current := &awsv1alpha1.UserGroup{}
+		Expect(k8sClient.Get(ctx, key, current)).To(Succeed())
+		return current
+	}
+
+	describe := func() types.UserGroup {
+		output, err := fakeAPI.DescribeUserGroups(ctx, &elasticache.DescribeUserGroupsInput{UserGroupId: aws.String(key.Name)})
+		Expect(err).NotTo(HaveOccurred())
+		return output.UserGroups[0]
+	}
+
+	// createUser creates a user outside of the operator.
+	createUser := func(id string) {
+		_, err := fakeAPI.CreateUser(ctx, &elasticache.CreateUserInput{
+			UserId:             aws.String(id),
+			UserName:           aws.String(id),
+			AccessString:       aws.String("on ~* +@all"),
+			Engine:             aws.String("redis"),
+			NoPasswordRequired: aws.Bool(true),
+		})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	// readyUser reconciles the referenced User until it is Ready.
+	readyUser := func() {
+		userKey := client.ObjectKeyFromObject(user)
+		_, err := userReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: userKey})
+		Expect(err).NotTo(HaveOccurred())
+		fakeAPI.Tick()
+		_, err = userReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: userKey})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(k8sClient.Get(ctx, userKey, user)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(user.Status.Conditions, awsv1alpha1.ConditionTypeReady)).To(BeTrue())
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		fakeAPI = fake.New()
+		newClient := fakeClientFunc(fakeAPI)
+		reconciler = &UserGroupReconciler{Client: k8sClient, Scheme: scheme.Scheme, NewElastiCacheClient: newClient}
+		userReconciler = &UserReconciler{Client: k8sClient, Scheme: scheme.Scheme, NewElastiCacheClient: newClient}
+
+		createUser("default")
+
+		user = &awsv1alpha1.User{
+			Spec: awsv1alpha1.UserSpec{
+				UserName:           "app",
+				AccessString:       "on ~app:* +@all",
+				NoPasswordRequired: true,
+			},
+		}
+		createTestObject(ctx, user, "user-")
+
+		instance = &awsv1alpha1.UserGroup{
+			Spec: awsv1alpha1.UserGroupSpec{
+				UserRefs: []awsv1alpha1.LocalObjectReference{{Name: user.Name}},
+				UserIds:  []string{"default"},
+			},
+		}
+		key = createTestObject(ctx, instance, "users-")
+	})
+
+	AfterEach(func() {
+		removeTestObject(ctx, key, &awsv1alpha1.UserGroup{})
+		removeTestObject(ctx, client.ObjectKeyFromObject(user), &awsv1alpha1.User{})
+	})
+
+	It("waits for the referenced Users to be Ready before creating the user group", func() {
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("CreateUserGroup"))
+		ready := meta.FindStatusCondition(get().Status.Conditions, awsv1alpha1.ConditionTypeReady)
+		Expect(ready).NotTo(BeNil())
+		Expect(ready.Reason).To(Equal(awsv1alpha1.ReasonWaitingForRefs))
+		Expect(ready.Message).To(ContainSubstring(user.Name))
+
+		readyUser()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(describe().UserIds).To(ConsistOf("default", user.Name))
+
+		fakeAPI.Tick()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		current := get()
+		Expect(controllerutil.ContainsFinalizer(current, userGroupFinalizer)).To(BeTrue())
+		Expect(aws.ToString(current.Status.Status)).To(Equal("active"))
+		Expect(current.Status.UserIds).To(ConsistOf("default", user.Name))
+		Expect(meta.IsStatusConditionTrue(current.Status.Conditions, awsv1alpha1.ConditionTypeReady)).To(BeTrue())
+	})
+
+	It("adds and removes users so the membership matches the spec", func() {
+		readyUser()
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		fakeAPI.Tick()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		createUser("reporting")
+		current := get()
+		current.Spec.UserRefs = nil
+		current.Spec.UserIds = []string{"default", "reporting"}
+		Expect(k8sClient.Update(ctx, current)).To(Succeed())
+
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		pending := get().Status.PendingChanges
+		Expect(pending).NotTo(BeNil())
+		Expect(pending.UserIdsToAdd).To(Equal([]string{"reporting"}))
+		Expect(pending.UserIdsToRemove).To(Equal([]string{user.Name}))
+
+		fakeAPI.Tick()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(describe().UserIds).To(ConsistOf("default", "reporting"))
+		Expect(get().Status.PendingChanges).To(BeNil())
+	})
+
+	It("lets referencing ReplicationGroups wait until it is Ready", func() {
+		replicationGroupReconciler := &ReplicationGroupReconciler{
+			Client:               k8sClient,
+			Scheme:               scheme.Scheme,
+			NewElastiCacheClient: fakeClientFunc(fakeAPI),
+		}
+		replicationGroup := &awsv1alpha1.ReplicationGroup{
+			Spec: awsv1alpha1.ReplicationGroupSpec{
+				AWSConfig: &awsv1alpha1.ReplicationGroupAwsConfig{
+					ReplicationGroupDescription: aws.String("test"),
+					CacheNodeType:               aws.String("cache.t3.micro"),
+					Engine:                      aws.String("redis"),
+					NumCacheClusters:            aws.Int32(1),
+					UserGroupRefs:               []awsv1alpha1.LocalObjectReference{{Name: key.Name}},
+				},
+			},
+		}
+		replicationGroupKey := createTestObject(ctx, replicationGroup, "group-")
+		defer removeTestObject(ctx, replicationGroupKey, &awsv1alpha1.ReplicationGroup{})
+
+		_, err := replicationGroupReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: replicationGroupKey})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeAPI.Calls()).NotTo(ContainElement("CreateReplicationGroup"))
+		Expect(k8sClient.Get(ctx, replicationGroupKey, replicationGroup)).To(Succeed())
+		ready := meta.FindStatusCondition(replicationGroup.Status.Conditions, awsv1alpha1.ConditionTypeReady)
+		Expect(ready).NotTo(BeNil())
+		Expect(ready.Reason).To(Equal(awsv1alpha1.ReasonWaitingForRefs))
+
+		readyUser()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		fakeAPI.Tick()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = replicationGroupReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: replicationGroupKey})
+		Expect(err).NotTo(HaveOccurred())
+		group, ok := fakeAPI.ReplicationGroup(replicationGroupKey.Name)
+		Expect(ok).To(BeTrue())
+		Expect(group.UserGroupIds).To(Equal([]string{key.Name}))
+	})
+
+	It("deletes the user group before removing the finalizer", func() {
+		readyUser()
+		_, err := reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		fakeAPI.Tick()
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Delete(ctx, get())).To(Succeed())
+		_, err = reconcile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(aws.ToString(describe().Status)).To(Equal("deleting"))
+		err = k8sClient.Get(ctx, key, &awsv1alpha1.UserGroup{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+})
@@ -0,0 +1,107 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package emulator
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	smithytime "github.com/aws/smithy-go/time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// decodeParameter stores a query parameter, split into the segments of its key,
+// in the input struct v. Members of lists are addressed by the name of the
+// member and their 1-based index, e.g. Tags.Tag.1.Key or
+// SecurityGroupIds.SecurityGroupId.2; the member name itself is not checked.
+func decodeParameter(v reflect.Value, path []string, value string) error {
+	switch {
+	case v.Kind() == reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodeParameter(v.Elem(), path, value)
+
+	case v.Type() == timeType:
+		if len(path) != 0 {
+			return fmt.Errorf("unexpected member %s", path[0])
+		}
+		t, err := smithytime.ParseDateTime(value)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+
+	case v.Kind() == reflect.Struct:
+		if len(path) == 0 {
+			return fmt.Errorf("a member of the structure is required")
+		}
+		field, ok := v.Type().FieldByName(path[0])
+		if !ok || field.PkgPath != "" || field.Anonymous {
+			return fmt.Errorf("unknown member %s", path[0])
+		}
+		return decodeParameter(v.FieldByIndex(field.Index), path[1:], value)
+
+	case v.Kind() == reflect.Slice:
+		if len(path) < 2 {
+			return fmt.Errorf("list members must be addressed as <member>.<index>")
+		}
+		index, err := strconv.Atoi(path[1])
+		if err != nil || index < 1 {
+			return fmt.Errorf("invalid list index %s", path[1])
+		}
+		if v.Len() < index {
+			grown := reflect.MakeSlice(v.Type(), index, index)
+			reflect.Copy(grown, v)
+			v.Set(grown)
+		}
+		return decodeParameter(v.Index(index-1), path[2:], value)
+	}
+
+	if len(path) != 0 {
+		return fmt.Errorf("unexpected member %s", path[0])
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
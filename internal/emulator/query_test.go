@@ -0,0 +1,144 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package emulator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+)
+
+func TestDecodeParameter(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   interface{}
+		params  map[string]string
+		want    interface{}
+		wantErr string
+	}{
+		{
+			name:  "scalars",
+			input: &elasticache.CreateCacheClusterInput{},
+			params: map[string]string{
+				"CacheClusterId":          "cache",
+				"NumCacheNodes":           "2",
+				"AutoMinorVersionUpgrade": "true",
+				"AZMode":                  "cross-az",
+			},
+			want: &elasticache.CreateCacheClusterInput{
+				CacheClusterId:          aws.String("cache"),
+				NumCacheNodes:           aws.Int32(2),
+				AutoMinorVersionUpgrade: aws.Bool(true),
+				AZMode:                  types.AZModeCrossAz,
+			},
+		},
+		{
+			name:  "lists addressed by index",
+			input: &elasticache.CreateCacheClusterInput{},
+			params: map[string]string{
+				"SecurityGroupIds.SecurityGroupId.2": "sg-2",
+				"SecurityGroupIds.SecurityGroupId.1": "sg-1",
+				"Tags.Tag.1.Key":                     "team",
+				"Tags.Tag.1.Value":                   "platform",
+			},
+			want: &elasticache.CreateCacheClusterInput{
+				SecurityGroupIds: []string{"sg-1", "sg-2"},
+				Tags:             []types.Tag{{Key: aws.String("team"), Value: aws.String("platform")}},
+			},
+		},
+		{
+			name:   "timestamps",
+			input:  &elasticache.DescribeEventsInput{},
+			params: map[string]string{"StartTime": "2021-08-01T10:00:00Z"},
+			want: &elasticache.DescribeEventsInput{
+				StartTime: aws.Time(time.Date(2021, 8, 1, 10, 0, 0, 0, time.UTC)),
+			},
+		},
+		{
+			name:    "an unknown member",
+			input:   &elasticache.CreateCacheClusterInput{},
+			params:  map[string]string{"CacheClusterName": "cache"},
+			wantErr: "unknown member CacheClusterName",
+		},
+		{
+			name:    "a member of a scalar",
+			input:   &elasticache.CreateCacheClusterInput{},
+			params:  map[string]string{"CacheClusterId.Value": "cache"},
+			wantErr: "unexpected member Value",
+		},
+		{
+			name:    "a structure without a member",
+			input:   &elasticache.CreateCacheClusterInput{},
+			params:  map[string]string{"Tags.Tag.1": "team"},
+			wantErr: "a member of the structure is required",
+		},
+		{
+			name:    "a list without an index",
+			input:   &elasticache.CreateCacheClusterInput{},
+			params:  map[string]string{"SecurityGroupIds": "sg-1"},
+			wantErr: "list members must be addressed",
+		},
+		{
+			name:    "a list index starting at 0",
+			input:   &elasticache.CreateCacheClusterInput{},
+			params:  map[string]string{"SecurityGroupIds.SecurityGroupId.0": "sg-1"},
+			wantErr: "invalid list index 0",
+		},
+		{
+			name:    "an invalid number",
+			input:   &elasticache.CreateCacheClusterInput{},
+			params:  map[string]string{"NumCacheNodes": "two"},
+			wantErr: "invalid syntax",
+		},
+		{
+			name:    "an invalid timestamp",
+			input:   &elasticache.DescribeEventsInput{},
+			params:  map[string]string{"StartTime": "yesterday"},
+			wantErr: "yesterday",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			for key, value := range tt.params {
+				err = decodeParameter(reflect.ValueOf(tt.input).Elem(), strings.Split(key, "."), value)
+				if err != nil {
+					break
+				}
+			}
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tt.input, tt.want) {
+				t.Errorf("decoded %+v, want %+v", tt.input, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package emulator serves the in-memory ElastiCache API of package fake over
+// HTTP using the query protocol of ElastiCache, so the operator binary can run
+// against it without an AWS account.
+//
+// Requests are dispatched by their Action parameter to the method of the same
+// name of *fake.ElastiCache. Signatures are not verified, any credentials are
+// accepted.
+package emulator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/smithy-go"
+	"github.com/go-logr/logr"
+
+	"github.com/sergeyshevch/cloud-resource-operator/internal/fake"
+)
+
+// apiVersion is the version of the ElastiCache API spoken by the emulator.
+const apiVersion = "2015-02-02"
+
+// xmlNamespace is the namespace of every response document.
+const xmlNamespace = "http://elasticache.amazonaws.com/doc/" + apiVersion + "/"
+
+// Paths of the endpoints used to control the emulator. They are not part of
+// the ElastiCache API.
+const (
+	tickPath   = "/_emulator/tick"
+	errorsPath = "/_emulator/errors"
+)
+
+// Options configure the behaviour of a Server.
+type Options struct {
+	// Latency is added to every API request before it is handled.
+	Latency time.Duration
+
+	// FailureRate is the probability, between 0 and 1, that an API request
+	// fails with a throttling error before it is handled.
+	FailureRate float64
+
+	// TransitionInterval is the time resources spend in a transitional state
+	// such as creating or modifying. When it is zero, resources only move on
+	// when a tick is requested through the control endpoint.
+	TransitionInterval time.Duration
+
+	// Log receives a line per handled request. Nothing is logged when it is
+	// nil.
+	Log logr.Logger
+}
+
+// Server is an http.Handler serving the ElastiCache API.
+type Server struct {
+	api     *fake.ElastiCache
+	options Options
+
+	mu     sync.Mutex
+	random *rand.Rand
+}
+
+// NewServer returns a Server serving api.
+func NewServer(api *fake.ElastiCache, options Options) *Server {
+	if options.Log == nil {
+		options.Log = logr.Discard()
+	}
+	return &Server{
+		api:     api,
+		options: options,
+		random:  rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Run moves the resources of the emulator through their transitions every
+// TransitionInterval until ctx is done.
+func (s *Server) Run(ctx context.Context) {
+	if s.options.TransitionInterval <= 0 {
+		<-ctx.Done()
+		return
+	}
+
+	ticker := time.NewTicker(s.options.TransitionInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.api.Tick()
+		}
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case tickPath:
+		s.serveTick(w, r)
+	case errorsPath:
+		s.serveInjectError(w, r)
+	default:
+		s.serveAPI(w, r)
+	}
+}
+
+// serveTick completes every transition in progress.
+func (s *Server) serveTick(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.api.Tick()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serveInjectError makes the next call of the operation named by the Action
+// form value fail with the error Code and Message.
+func (s *Server) serveInjectError(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	action, code := r.Form.Get("Action"), r.Form.Get("Code")
+	if _, ok := s.operation(action); !ok || code == "" {
+		http.Error(w, "a supported Action and an error Code are required", http.StatusBadRequest)
+		return
+	}
+
+	s.api.InjectError(action, &smithy.GenericAPIError{Code: code, Message: r.Form.Get("Message"), Fault: smithy.FaultClient})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serveAPI handles a request of the ElastiCache API.
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request) {
+	requestId := s.requestId()
+	if err := r.ParseForm(); err != nil {
+		writeError(w, requestId, &smithy.GenericAPIError{Code: "MalformedQueryString", Message: err.Error(), Fault: smithy.FaultClient})
+		return
+	}
+	action := r.Form.Get("Action")
+	s.options.Log.Info("handling request", "action", action, "requestId", requestId)
+
+	if s.options.Latency > 0 {
+		time.Sleep(s.options.Latency)
+	}
+	if s.fail() {
+		writeError(w, requestId, &smithy.GenericAPIError{Code: "Throttling", Message: "Rate exceeded", Fault: smithy.FaultClient})
+		return
+	}
+
+	method, ok := s.operation(action)
+	if !ok {
+		writeError(w, requestId, &smithy.GenericAPIError{
+			Code:    "InvalidAction",
+			Message: fmt.Sprintf("The action %s is not valid for this web service.", action),
+			Fault:   smithy.FaultClient,
+		})
+		return
+	}
+
+	input := reflect.New(method.Type().In(1).Elem())
+	for key, values := range r.Form {
+		if key == "Action" || key == "Version" || len(values) == 0 {
+			continue
+		}
+		if err := decodeParameter(input.Elem(), strings.Split(key, "."), values[0]); err != nil {
+			writeError(w, requestId, &smithy.GenericAPIError{
+				Code:    "InvalidParameterValue",
+				Message: fmt.Sprintf("Invalid parameter %s: %v", key, err),
+				Fault:   smithy.FaultClient,
+			})
+			return
+		}
+	}
+
+	results := method.Call([]reflect.Value{reflect.ValueOf(r.Context()), input})
+	if err, _ := results[1].Interface().(error); err != nil {
+		writeError(w, requestId, err)
+		return
+	}
+
+	body := &bytes.Buffer{}
+	fmt.Fprintf(body, "<%sResponse xmlns=%q><%sResult>", action, xmlNamespace, action)
+	encodeFields(body, results[0].Elem())
+	fmt.Fprintf(body, "</%sResult><ResponseMetadata><RequestId>%s</RequestId></ResponseMetadata></%sResponse>", action, requestId, action)
+
+	w.Header().Set("Content-Type", "text/xml")
+	w.Header().Set("X-Amzn-RequestId", requestId)
+	_, _ = w.Write(body.Bytes())
+}
+
+// operation returns the method of the fake implementing action. Only methods
+// with the signature of an API operation are returned.
+func (s *Server) operation(action string) (reflect.Value, bool) {
+	if action == "" {
+		return reflect.Value{}, false
+	}
+	method := reflect.ValueOf(s.api).MethodByName(action)
+	if !method.IsValid() {
+		return reflect.Value{}, false
+	}
+	t := method.Type()
+	if t.NumIn() != 3 || !t.IsVariadic() || t.NumOut() != 2 || t.In(1).Kind() != reflect.Ptr || t.In(1).Elem().Name() != action+"Input" {
+		return reflect.Value{}, false
+	}
+	return method, true
+}
+
+func (s *Server) fail() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.options.FailureRate > 0 && s.random.Float64() < s.options.FailureRate
+}
+
+func (s *Server) requestId() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fmt.Sprintf("%08x-%04x-%04x-%04x-%012x", s.random.Uint32(), s.random.Intn(1<<16), s.random.Intn(1<<16),
+		s.random.Intn(1<<16), s.random.Int63n(1<<48))
+}
+
+// writeError writes err as an ErrorResponse document. Errors carrying an error
+// code, like the typed faults of the ElastiCache API, keep their code so the
+// SDK returns the same typed fault to the caller.
+func writeError(w http.ResponseWriter, requestId string, err error) {
+	code, message, status := "InternalFailure", err.Error(), http.StatusInternalServerError
+	faultType := "Receiver"
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		code, message = apiErr.ErrorCode(), apiErr.ErrorMessage()
+		if apiErr.ErrorFault() != smithy.FaultServer {
+			status, faultType = http.StatusBadRequest, "Sender"
+		}
+	}
+
+	body := &bytes.Buffer{}
+	fmt.Fprintf(body, "<ErrorResponse xmlns=%q><Error><Type>%s</Type><Code>", xmlNamespace, faultType)
+	escape(body, code)
+	body.WriteString("</Code><Message>")
+	escape(body, message)
+	fmt.Fprintf(body, "</Message></Error><RequestId>%s</RequestId></ErrorResponse>", requestId)
+
+	w.Header().Set("Content-Type", "text/xml")
+	w.Header().Set("X-Amzn-RequestId", requestId)
+	w.WriteHeader(status)
+	_, _ = w.Write(body.Bytes())
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package emulator
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	"github.com/aws/smithy-go"
+
+	"github.com/sergeyshevch/cloud-resource-operator/internal/fake"
+)
+
+// newTestServer serves a new fake over HTTP and returns an SDK client talking
+// to it.
+func newTestServer(t *testing.T, options Options) (*httptest.Server, *elasticache.Client) {
+	t.Helper()
+	server := httptest.NewServer(NewServer(fake.New(), options))
+	t.Cleanup(server.Close)
+
+	client := elasticache.New(elasticache.Options{
+		Region:           "us-east-1",
+		Credentials:      credentials.NewStaticCredentialsProvider("key", "secret", ""),
+		EndpointResolver: elasticache.EndpointResolverFromURL(server.URL),
+		Retryer:          aws.NopRetryer{},
+	})
+	return server, client
+}
+
+func TestServerRoundTrip(t *testing.T) {
+	server, client := newTestServer(t, Options{})
+	ctx := context.Background()
+
+	created, err := client.CreateCacheCluster(ctx, &elasticache.CreateCacheClusterInput{
+		CacheClusterId: aws.String("cache"),
+		CacheNodeType:  aws.String("cache.t3.micro"),
+		Engine:         aws.String("redis"),
+		NumCacheNodes:  aws.Int32(1),
+		Tags:           []types.Tag{{Key: aws.String("team"), Value: aws.String("platform")}},
+	})
+	if err != nil {
+		t.Fatalf("CreateCacheCluster: %v", err)
+	}
+	if got := aws.ToString(created.CacheCluster.CacheClusterStatus); got != "creating" {
+		t.Errorf("status = %q, want creating", got)
+	}
+
+	resp, err := http.Post(server.URL+tickPath, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("tick returned %d", resp.StatusCode)
+	}
+
+	described, err := client.DescribeCacheClusters(ctx, &elasticache.DescribeCacheClustersInput{
+		CacheClusterId:    aws.String("cache"),
+		ShowCacheNodeInfo: aws.Bool(true),
+	})
+	if err != nil {
+		t.Fatalf("DescribeCacheClusters: %v", err)
+	}
+	if len(described.CacheClusters) != 1 {
+		t.Fatalf("described %d clusters, want 1", len(described.CacheClusters))
+	}
+	cluster := described.CacheClusters[0]
+	if got := aws.ToString(cluster.CacheClusterStatus); got != "available" {
+		t.Errorf("status = %q, want available", got)
+	}
+	if len(cluster.CacheNodes) != 1 || cluster.CacheNodes[0].Endpoint == nil {
+		t.Errorf("cache nodes = %+v, want one node with an endpoint", cluster.CacheNodes)
+	}
+
+	tags, err := client.ListTagsForResource(ctx, &elasticache.ListTagsForResourceInput{ResourceName: cluster.ARN})
+	if err != nil {
+		t.Fatalf("ListTagsForResource: %v", err)
+	}
+	if len(tags.TagList) != 1 || aws.ToString(tags.TagList[0].Key) != "team" {
+		t.Errorf("tags = %+v, want the team tag", tags.TagList)
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	server, client := newTestServer(t, Options{})
+	ctx := context.Background()
+
+	_, err := client.DescribeCacheClusters(ctx, &elasticache.DescribeCacheClustersInput{CacheClusterId: aws.String("missing")})
+	var notFound *types.CacheClusterNotFoundFault
+	if !errors.As(err, &notFound) {
+		t.Errorf("DescribeCacheClusters of a missing cluster: %v, want a CacheClusterNotFoundFault", err)
+	}
+
+	resp, err := http.PostForm(server.URL+errorsPath, url.Values{
+		"Action":  {"DescribeCacheClusters"},
+		"Code":    {"Throttling"},
+		"Message": {"slow down"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("injecting an error returned %d", resp.StatusCode)
+	}
+	_, err = client.DescribeCacheClusters(ctx, &elasticache.DescribeCacheClustersInput{})
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "Throttling" || apiErr.ErrorMessage() != "slow down" {
+		t.Errorf("DescribeCacheClusters after injecting an error: %v, want Throttling", err)
+	}
+	_, err = client.DescribeCacheClusters(ctx, &elasticache.DescribeCacheClustersInput{})
+	if err != nil {
+		t.Errorf("the injected error was returned more than once: %v", err)
+	}
+}
+
+func TestServerFailureRate(t *testing.T) {
+	_, client := newTestServer(t, Options{FailureRate: 1})
+
+	_, err := client.DescribeCacheClusters(context.Background(), &elasticache.DescribeCacheClustersInput{})
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "Throttling" {
+		t.Errorf("DescribeCacheClusters: %v, want Throttling", err)
+	}
+}
+
+func TestServerRejectsInvalidRequests(t *testing.T) {
+	server, _ := newTestServer(t, Options{})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		form       url.Values
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "an unknown action",
+			method:     http.MethodPost,
+			path:       "/",
+			form:       url.Values{"Action": {"DescribeEverything"}},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "<Code>InvalidAction</Code>",
+		},
+		{
+			name:       "a method that is not an operation",
+			method:     http.MethodPost,
+			path:       "/",
+			form:       url.Values{"Action": {"Tick"}},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "<Code>InvalidAction</Code>",
+		},
+		{
+			name:       "an unknown parameter",
+			method:     http.MethodPost,
+			path:       "/",
+			form:       url.Values{"Action": {"DescribeCacheClusters"}, "Version": {apiVersion}, "ClusterId": {"cache"}},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "<Code>InvalidParameterValue</Code>",
+		},
+		{
+			name:       "a tick that is not posted",
+			method:     http.MethodGet,
+			path:       tickPath,
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "an error for an unknown action",
+			method:     http.MethodPost,
+			path:       errorsPath,
+			form:       url.Values{"Action": {"DescribeEverything"}, "Code": {"Throttling"}},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "an error without a code",
+			method:     http.MethodPost,
+			path:       errorsPath,
+			form:       url.Values{"Action": {"DescribeCacheClusters"}},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, strings.NewReader(tt.form.Encode()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body := &strings.Builder{}
+			if _, err := io.Copy(body, resp.Body); err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if !strings.Contains(body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", body.String(), tt.wantBody)
+			}
+		})
+	}
+}
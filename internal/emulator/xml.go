@@ -0,0 +1,132 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package emulator
+
+import (
+	"bytes"
+	"encoding/xml"
+	"reflect"
+	"strconv"
+	"time"
+
+	smithytime "github.com/aws/smithy-go/time"
+)
+
+// listMembers holds the element names of list members that differ from the
+// default "member", keyed by the name of the structure and of the list field.
+// The SDK ignores list members with any other name.
+var listMembers = map[string]string{
+	"AddTagsToResourceOutput.TagList":                               "Tag",
+	"CacheCluster.CacheNodes":                                       "CacheNode",
+	"CacheCluster.CacheSecurityGroups":                              "CacheSecurityGroup",
+	"CacheCluster.LogDeliveryConfigurations":                        "LogDeliveryConfiguration",
+	"CacheNodeTypeSpecificParameter.CacheNodeTypeSpecificValues":    "CacheNodeTypeSpecificValue",
+	"CacheParameterGroupStatus.CacheNodeIdsToReboot":                "CacheNodeId",
+	"CacheSubnetGroup.Subnets":                                      "Subnet",
+	"DescribeCacheClustersOutput.CacheClusters":                     "CacheCluster",
+	"DescribeCacheParameterGroupsOutput.CacheParameterGroups":       "CacheParameterGroup",
+	"DescribeCacheParametersOutput.CacheNodeTypeSpecificParameters": "CacheNodeTypeSpecificParameter",
+	"DescribeCacheParametersOutput.Parameters":                      "Parameter",
+	"DescribeCacheSubnetGroupsOutput.CacheSubnetGroups":             "CacheSubnetGroup",
+	"DescribeReplicationGroupsOutput.ReplicationGroups":             "ReplicationGroup",
+	"DescribeSnapshotsOutput.Snapshots":                             "Snapshot",
+	"ListTagsForResourceOutput.TagList":                             "Tag",
+	"NodeGroup.NodeGroupMembers":                                    "NodeGroupMember",
+	"NodeGroupConfiguration.ReplicaAvailabilityZones":               "AvailabilityZone",
+	"NodeGroupConfiguration.ReplicaOutpostArns":                     "OutpostArn",
+	"PendingModifiedValues.CacheNodeIdsToRemove":                    "CacheNodeId",
+	"RemoveTagsFromResourceOutput.TagList":                          "Tag",
+	"ReplicationGroup.LogDeliveryConfigurations":                    "LogDeliveryConfiguration",
+	"ReplicationGroup.MemberClusters":                               "ClusterId",
+	"ReplicationGroup.MemberClustersOutpostArns":                    "ReplicationGroupOutpostArn",
+	"ReplicationGroup.NodeGroups":                                   "NodeGroup",
+	"Snapshot.NodeSnapshots":                                        "NodeSnapshot",
+}
+
+// encodeFields writes the exported fields of the structure v as XML elements
+// named like the fields. Nil pointers, empty lists and empty enums are left
+// out.
+func encodeFields(buf *bytes.Buffer, v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" || field.Anonymous || field.Name == "ResultMetadata" {
+			continue
+		}
+		encodeValue(buf, field.Name, t.Name()+"."+field.Name, v.Field(i))
+	}
+}
+
+// encodeValue writes v as the element name. key identifies the field holding v
+// in listMembers.
+func encodeValue(buf *bytes.Buffer, name string, key string, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return
+		}
+		if v.Elem().Kind() == reflect.String {
+			writeElement(buf, name, v.Elem().String())
+			return
+		}
+		encodeValue(buf, name, key, v.Elem())
+
+	case reflect.Struct:
+		if v.Type() == timeType {
+			writeElement(buf, name, smithytime.FormatDateTime(v.Interface().(time.Time)))
+			return
+		}
+		buf.WriteString("<" + name + ">")
+		encodeFields(buf, v)
+		buf.WriteString("</" + name + ">")
+
+	case reflect.Slice:
+		if v.Len() == 0 {
+			return
+		}
+		member, ok := listMembers[key]
+		if !ok {
+			member = "member"
+		}
+		buf.WriteString("<" + name + ">")
+		for i := 0; i < v.Len(); i++ {
+			encodeValue(buf, member, "", v.Index(i))
+		}
+		buf.WriteString("</" + name + ">")
+
+	case reflect.String:
+		if v.Len() > 0 {
+			writeElement(buf, name, v.String())
+		}
+	case reflect.Bool:
+		writeElement(buf, name, strconv.FormatBool(v.Bool()))
+	case reflect.Int32, reflect.Int64:
+		writeElement(buf, name, strconv.FormatInt(v.Int(), 10))
+	case reflect.Float64:
+		writeElement(buf, name, strconv.FormatFloat(v.Float(), 'f', -1, 64))
+	}
+}
+
+func writeElement(buf *bytes.Buffer, name string, value string) {
+	buf.WriteString("<" + name + ">")
+	escape(buf, value)
+	buf.WriteString("</" + name + ">")
+}
+
+func escape(buf *bytes.Buffer, value string) {
+	_ = xml.EscapeText(buf, []byte(value))
+}
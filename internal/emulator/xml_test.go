@@ -0,0 +1,95 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package emulator
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+)
+
+func TestEncodeFields(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{
+			name:  "strings",
+			value: types.Tag{Key: aws.String("team"), Value: aws.String("platform")},
+			want:  "<Key>team</Key><Value>platform</Value>",
+		},
+		{
+			name:  "escapes text",
+			value: types.Tag{Key: aws.String("a<b"), Value: aws.String("c&d")},
+			want:  "<Key>a&lt;b</Key><Value>c&amp;d</Value>",
+		},
+		{
+			name:  "leaves out nil pointers and empty enums",
+			value: types.CacheCluster{CacheClusterId: aws.String("cache")},
+			want: "<AutoMinorVersionUpgrade>false</AutoMinorVersionUpgrade><CacheClusterId>cache</CacheClusterId>" +
+				"<ReplicationGroupLogDeliveryEnabled>false</ReplicationGroupLogDeliveryEnabled>",
+		},
+		{
+			name: "numbers and booleans",
+			value: types.CacheCluster{
+				CacheClusterId:          aws.String("cache"),
+				NumCacheNodes:           aws.Int32(2),
+				AutoMinorVersionUpgrade: true,
+				AuthTokenEnabled:        aws.Bool(true),
+			},
+			want: "<AuthTokenEnabled>true</AuthTokenEnabled><AutoMinorVersionUpgrade>true</AutoMinorVersionUpgrade>" +
+				"<CacheClusterId>cache</CacheClusterId><NumCacheNodes>2</NumCacheNodes>" +
+				"<ReplicationGroupLogDeliveryEnabled>false</ReplicationGroupLogDeliveryEnabled>",
+		},
+		{
+			name:  "timestamps",
+			value: types.Event{Date: aws.Time(time.Date(2021, 8, 1, 10, 0, 0, 0, time.UTC)), SourceIdentifier: aws.String("cache")},
+			want:  "<Date>2021-08-01T10:00:00Z</Date><SourceIdentifier>cache</SourceIdentifier>",
+		},
+		{
+			name: "names list members like the SDK expects",
+			value: elasticache.ListTagsForResourceOutput{TagList: []types.Tag{
+				{Key: aws.String("team"), Value: aws.String("platform")},
+			}},
+			want: "<TagList><Tag><Key>team</Key><Value>platform</Value></Tag></TagList>",
+		},
+		{
+			name: "uses member for unlisted lists",
+			value: types.UserGroup{
+				UserGroupId: aws.String("group"),
+				UserIds:     []string{"default", "app"},
+			},
+			want: "<UserGroupId>group</UserGroupId><UserIds><member>default</member><member>app</member></UserIds>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			encodeFields(buf, reflect.ValueOf(tt.value))
+			if got := buf.String(); got != tt.want {
+				t.Errorf("encoded\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
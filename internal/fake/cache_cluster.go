@@ -0,0 +1,640 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+)
+
+const (
+	engineRedis     = "redis"
+	engineMemcached = "memcached"
+)
+
+// Settings ElastiCache chooses when a cluster is created without them.
+var (
+	defaultEngineVersions = map[string]string{
+		engineRedis:     "6.0.5",
+		engineMemcached: "1.6.6",
+	}
+	defaultPorts = map[string]int32{
+		engineRedis:     6379,
+		engineMemcached: 11211,
+	}
+	defaultMaintenanceWindow = "sun:05:00-sun:06:00"
+	defaultSnapshotWindow    = "06:30-07:30"
+)
+
+type cacheCluster struct {
+	cluster types.CacheCluster
+
+	// applyPending is set when the pending modifications are applied on the
+	// next Tick instead of in the maintenance window.
+	applyPending bool
+}
+
+// PutCacheCluster stores cluster as it is, replacing a cluster with the same
+// identifier. It can be used to seed clusters that were not created through
+// the API or to simulate changes made outside of the operator.
+func (f *ElastiCache) PutCacheCluster(cluster types.CacheCluster) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stored := &cacheCluster{}
+	clone(cluster, &stored.cluster)
+	if stored.cluster.ARN == nil {
+		stored.cluster.ARN = f.arn("cluster", aws.ToString(cluster.CacheClusterId))
+	}
+	if _, ok := f.tags[*stored.cluster.ARN]; !ok {
+		f.tags[*stored.cluster.ARN] = []types.Tag{}
+	}
+	f.cacheClusters[aws.ToString(cluster.CacheClusterId)] = stored
+}
+
+// CacheCluster returns the cluster with the given identifier, including its
+// nodes.
+func (f *ElastiCache) CacheCluster(cacheClusterId string) (types.CacheCluster, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stored, ok := f.cacheClusters[cacheClusterId]
+	if !ok {
+		return types.CacheCluster{}, false
+	}
+	var cluster types.CacheCluster
+	clone(stored.cluster, &cluster)
+	return cluster, true
+}
+
+// CreateCacheCluster implements the CreateCacheCluster operation.
+func (f *ElastiCache) CreateCacheCluster(_ context.Context, params *elasticache.CreateCacheClusterInput, _ ...func(*elasticache.Options)) (*elasticache.CreateCacheClusterOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "CreateCacheCluster"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	id := aws.ToString(params.CacheClusterId)
+	if id == "" {
+		return nil, operationError(operation, invalidParameterValue("The parameter CacheClusterId must be provided."))
+	}
+	if _, ok := f.cacheClusters[id]; ok {
+		return nil, operationError(operation, &types.CacheClusterAlreadyExistsFault{Message: aws.String("Cache cluster " + id + " already exists.")})
+	}
+
+	engine := aws.ToString(params.Engine)
+	cacheNodeType := params.CacheNodeType
+	numCacheNodes := aws.ToInt32(params.NumCacheNodes)
+	if replicationGroupId := params.ReplicationGroupId; replicationGroupId != nil {
+		group, ok := f.replicationGroups[*replicationGroupId]
+		if !ok {
+			return nil, operationError(operation, &types.ReplicationGroupNotFoundFault{Message: aws.String("Replication group " + *replicationGroupId + " not found.")})
+		}
+		engine = engineRedis
+		numCacheNodes = 1
+		if cacheNodeType == nil {
+			cacheNodeType = group.group.CacheNodeType
+		}
+	}
+
+	switch {
+	case engine != engineRedis && engine != engineMemcached:
+		return nil, operationError(operation, invalidParameterValue(fmt.Sprintf("Invalid engine %q, valid values are memcached and redis.", engine)))
+	case cacheNodeType == nil:
+		return nil, operationError(operation, invalidParameterValue("The parameter CacheNodeType must be provided."))
+	case numCacheNodes < 1:
+		return nil, operationError(operation, invalidParameterValue("The parameter NumCacheNodes must be at least 1."))
+	case engine == engineRedis && numCacheNodes != 1:
+		return nil, operationError(operation, invalidParameterValue("Cache clusters running redis must have exactly one node."))
+	case engine != engineRedis && params.AuthToken != nil:
+		return nil, operationError(operation, invalidParameterCombination("AuthToken is only supported for redis."))
+	case engine != engineRedis && len(params.LogDeliveryConfigurations) > 0:
+		return nil, operationError(operation, invalidParameterCombination("Log delivery is only supported for redis."))
+	}
+	if err := validateLogDeliveryRequests(params.LogDeliveryConfigurations); err != nil {
+		return nil, operationError(operation, err)
+	}
+	if name := params.CacheSubnetGroupName; name != nil {
+		if _, ok := f.subnetGroups[*name]; !ok {
+			return nil, operationError(operation, &types.CacheSubnetGroupNotFoundFault{Message: aws.String("Cache subnet group " + *name + " not found.")})
+		}
+	}
+	if name := params.CacheParameterGroupName; name != nil && !strings.HasPrefix(*name, "default.") {
+		if _, ok := f.parameterGroups[*name]; !ok {
+			return nil, operationError(operation, &types.CacheParameterGroupNotFoundFault{Message: aws.String("Cache parameter group " + *name + " not found.")})
+		}
+	}
+
+	engineVersion := resolveEngineVersion(engine, aws.ToString(params.EngineVersion))
+	port := defaultPorts[engine]
+	if params.Port != nil {
+		port = *params.Port
+	}
+	parameterGroupName := aws.ToString(params.CacheParameterGroupName)
+	if parameterGroupName == "" {
+		parameterGroupName = "default." + parameterGroupFamily(engine, engineVersion)
+	}
+	availabilityZone := aws.ToString(params.PreferredAvailabilityZone)
+	if availabilityZone == "" {
+		availabilityZone = f.Region + "a"
+	}
+	if params.AZMode == types.AZModeCrossAz {
+		availabilityZone = "Multiple"
+	}
+	maintenanceWindow := defaultMaintenanceWindow
+	if params.PreferredMaintenanceWindow != nil {
+		maintenanceWindow = strings.ToLower(*params.PreferredMaintenanceWindow)
+	}
+
+	now := time.Now()
+	cluster := types.CacheCluster{
+		ARN:                        f.arn("cluster", id),
+		AuthTokenEnabled:           aws.Bool(params.AuthToken != nil),
+		CacheClusterCreateTime:     &now,
+		CacheClusterId:             aws.String(id),
+		CacheClusterStatus:         aws.String(statusCreating),
+		CacheNodeType:              cacheNodeType,
+		CacheParameterGroup:        &types.CacheParameterGroupStatus{CacheParameterGroupName: aws.String(parameterGroupName), ParameterApplyStatus: aws.String("in-sync")},
+		CacheSecurityGroups:        cacheSecurityGroupMemberships(params.CacheSecurityGroupNames),
+		CacheSubnetGroupName:       params.CacheSubnetGroupName,
+		Engine:                     aws.String(engine),
+		EngineVersion:              aws.String(engineVersion),
+		NumCacheNodes:              aws.Int32(numCacheNodes),
+		PreferredAvailabilityZone:  aws.String(availabilityZone),
+		PreferredMaintenanceWindow: aws.String(maintenanceWindow),
+		PreferredOutpostArn:        params.PreferredOutpostArn,
+		ReplicationGroupId:         params.ReplicationGroupId,
+		SecurityGroups:             securityGroupMemberships(params.SecurityGroupIds),
+	}
+	if params.NotificationTopicArn != nil {
+		cluster.NotificationConfiguration = &types.NotificationConfiguration{TopicArn: params.NotificationTopicArn, TopicStatus: aws.String(statusActive)}
+	}
+	if engine == engineRedis {
+		cluster.SnapshotRetentionLimit = aws.Int32(aws.ToInt32(params.SnapshotRetentionLimit))
+		cluster.SnapshotWindow = aws.String(defaultSnapshotWindow)
+		if params.SnapshotWindow != nil {
+			cluster.SnapshotWindow = params.SnapshotWindow
+		}
+	}
+	for i := int32(1); i <= numCacheNodes; i++ {
+		cluster.CacheNodes = append(cluster.CacheNodes, f.newCacheNode(i, availabilityZone, port, now))
+	}
+	cluster.LogDeliveryConfigurations = applyLogDeliveryRequests(nil, params.LogDeliveryConfigurations)
+
+	f.cacheClusters[id] = &cacheCluster{cluster: cluster}
+	f.tags[*cluster.ARN] = cloneTags(params.Tags)
+
+	return &elasticache.CreateCacheClusterOutput{CacheCluster: f.describeCacheCluster(id, false)}, nil
+}
+
+// DescribeCacheClusters implements the DescribeCacheClusters operation. All
+// clusters are returned in a single page.
+func (f *ElastiCache) DescribeCacheClusters(_ context.Context, params *elasticache.DescribeCacheClustersInput, _ ...func(*elasticache.Options)) (*elasticache.DescribeCacheClustersOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "DescribeCacheClusters"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	showCacheNodeInfo := aws.ToBool(params.ShowCacheNodeInfo)
+	if id := params.CacheClusterId; id != nil {
+		if _, ok := f.cacheClusters[*id]; !ok {
+			if member, ok := f.describeMemberCluster(*id); ok {
+				return &elasticache.DescribeCacheClustersOutput{CacheClusters: []types.CacheCluster{*member}}, nil
+			}
+			return nil, operationError(operation, cacheClusterNotFound(*id))
+		}
+		return &elasticache.DescribeCacheClustersOutput{
+			CacheClusters: []types.CacheCluster{*f.describeCacheCluster(*id, showCacheNodeInfo)},
+		}, nil
+	}
+
+	ids := make([]string, 0, len(f.cacheClusters))
+	for id := range f.cacheClusters {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	output := &elasticache.DescribeCacheClustersOutput{CacheClusters: []types.CacheCluster{}}
+	for _, id := range ids {
+		output.CacheClusters = append(output.CacheClusters, *f.describeCacheCluster(id, showCacheNodeInfo))
+	}
+	return output, nil
+}
+
+// ModifyCacheCluster implements the ModifyCacheCluster operation. Changes of
+// the node type, engine version and number of nodes are pending until the next
+// Tick when they are applied immediately, and stay pending otherwise.
+func (f *ElastiCache) ModifyCacheCluster(_ context.Context, params *elasticache.ModifyCacheClusterInput, _ ...func(*elasticache.Options)) (*elasticache.ModifyCacheClusterOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "ModifyCacheCluster"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	id := aws.ToString(params.CacheClusterId)
+	stored, ok := f.cacheClusters[id]
+	if !ok {
+		return nil, operationError(operation, cacheClusterNotFound(id))
+	}
+	cluster := &stored.cluster
+	if status := aws.ToString(cluster.CacheClusterStatus); status != statusAvailable {
+		return nil, operationError(operation, &types.InvalidCacheClusterStateFault{
+			Message: aws.String(fmt.Sprintf("Cache cluster %s is not in available state, it is %s.", id, status)),
+		})
+	}
+
+	engine := aws.ToString(cluster.Engine)
+	current := aws.ToInt32(cluster.NumCacheNodes)
+	if numCacheNodes := params.NumCacheNodes; numCacheNodes != nil {
+		switch {
+		case engine == engineRedis && *numCacheNodes != 1:
+			return nil, operationError(operation, invalidParameterValue("Cache clusters running redis must have exactly one node."))
+		case *numCacheNodes < current && int32(len(params.CacheNodeIdsToRemove)) != current-*numCacheNodes:
+			return nil, operationError(operation, invalidParameterValue("CacheNodeIdsToRemove must name every node that is removed."))
+		}
+	}
+	if params.AuthToken != nil && engine != engineRedis {
+		return nil, operationError(operation, invalidParameterCombination("AuthToken is only supported for redis."))
+	}
+	if len(params.LogDeliveryConfigurations) > 0 && engine != engineRedis {
+		return nil, operationError(operation, invalidParameterCombination("Log delivery is only supported for redis."))
+	}
+	if err := validateLogDeliveryRequests(params.LogDeliveryConfigurations); err != nil {
+		return nil, operationError(operation, err)
+	}
+
+	pending := &types.PendingModifiedValues{}
+	if cluster.PendingModifiedValues != nil {
+		pending = cluster.PendingModifiedValues
+	}
+	changed := false
+	if params.CacheNodeType != nil {
+		pending.CacheNodeType = params.CacheNodeType
+		changed = true
+	}
+	if params.EngineVersion != nil {
+		pending.EngineVersion = aws.String(resolveEngineVersion(engine, *params.EngineVersion))
+		changed = true
+	}
+	if params.NumCacheNodes != nil && *params.NumCacheNodes != current {
+		pending.NumCacheNodes = params.NumCacheNodes
+		pending.CacheNodeIdsToRemove = params.CacheNodeIdsToRemove
+		changed = true
+	}
+	if params.AuthToken != nil {
+		pending.AuthTokenStatus = types.AuthTokenUpdateStatusRotating
+		if params.AuthTokenUpdateStrategy == types.AuthTokenUpdateStrategyTypeSet {
+			pending.AuthTokenStatus = types.AuthTokenUpdateStatusSetting
+		}
+		changed = true
+	}
+	if changed {
+		cluster.PendingModifiedValues = pending
+	}
+
+	if params.SecurityGroupIds != nil {
+		cluster.SecurityGroups = securityGroupMemberships(params.SecurityGroupIds)
+	}
+	if params.CacheSecurityGroupNames != nil {
+		cluster.CacheSecurityGroups = cacheSecurityGroupMemberships(params.CacheSecurityGroupNames)
+	}
+	if params.CacheParameterGroupName != nil {
+		cluster.CacheParameterGroup = &types.CacheParameterGroupStatus{
+			CacheParameterGroupName: params.CacheParameterGroupName,
+			ParameterApplyStatus:    aws.String("applying"),
+		}
+	}
+	if params.PreferredMaintenanceWindow != nil {
+		cluster.PreferredMaintenanceWindow = aws.String(strings.ToLower(*params.PreferredMaintenanceWindow))
+	}
+	if params.SnapshotWindow != nil {
+		cluster.SnapshotWindow = params.SnapshotWindow
+	}
+	if params.SnapshotRetentionLimit != nil {
+		cluster.SnapshotRetentionLimit = params.SnapshotRetentionLimit
+	}
+	if params.NotificationTopicArn != nil {
+		cluster.NotificationConfiguration = &types.NotificationConfiguration{TopicArn: params.NotificationTopicArn, TopicStatus: aws.String(statusActive)}
+	}
+
+	// Log delivery changes are applied right away.
+	if len(params.LogDeliveryConfigurations) > 0 {
+		cluster.LogDeliveryConfigurations = applyLogDeliveryRequests(cluster.LogDeliveryConfigurations, params.LogDeliveryConfigurations)
+		cluster.CacheClusterStatus = aws.String(statusModifying)
+	}
+
+	if params.ApplyImmediately {
+		cluster.CacheClusterStatus = aws.String(statusModifying)
+		stored.applyPending = true
+	}
+
+	return &elasticache.ModifyCacheClusterOutput{CacheCluster: f.describeCacheCluster(id, false)}, nil
+}
+
+// DeleteCacheCluster implements the DeleteCacheCluster operation. A final
+// snapshot is created right away, the cluster is removed on the next Tick.
+func (f *ElastiCache) DeleteCacheCluster(_ context.Context, params *elasticache.DeleteCacheClusterInput, _ ...func(*elasticache.Options)) (*elasticache.DeleteCacheClusterOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "DeleteCacheCluster"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	id := aws.ToString(params.CacheClusterId)
+	stored, ok := f.cacheClusters[id]
+	if !ok {
+		return nil, operationError(operation, cacheClusterNotFound(id))
+	}
+	cluster := &stored.cluster
+	if status := aws.ToString(cluster.CacheClusterStatus); status != statusAvailable {
+		return nil, operationError(operation, &types.InvalidCacheClusterStateFault{
+			Message: aws.String(fmt.Sprintf("Cache cluster %s is not in available state, it is %s.", id, status)),
+		})
+	}
+
+	if name := params.FinalSnapshotIdentifier; name != nil {
+		if aws.ToString(cluster.Engine) != engineRedis {
+			return nil, operationError(operation, invalidParameterCombination("Final snapshots are only supported for redis."))
+		}
+		if _, ok := f.snapshots[*name]; ok {
+			return nil, operationError(operation, &types.SnapshotAlreadyExistsFault{Message: aws.String("Snapshot " + *name + " already exists.")})
+		}
+		f.putSnapshot(f.clusterSnapshot(*name, cluster, "manual"))
+	}
+
+	cluster.CacheClusterStatus = aws.String(statusDeleting)
+	return &elasticache.DeleteCacheClusterOutput{CacheCluster: f.describeCacheCluster(id, false)}, nil
+}
+
+// tickCacheClusters moves every cache cluster on to its next stable state. It
+// must be called with f.mu held.
+func (f *ElastiCache) tickCacheClusters() {
+	for id, stored := range f.cacheClusters {
+		cluster := &stored.cluster
+		switch aws.ToString(cluster.CacheClusterStatus) {
+		case statusCreating:
+			for i := range cluster.CacheNodes {
+				f.makeNodeAvailable(cluster, &cluster.CacheNodes[i])
+			}
+			if aws.ToString(cluster.Engine) == engineMemcached && len(cluster.CacheNodes) > 0 {
+				cluster.ConfigurationEndpoint = f.endpoint(id+".cfg", cluster.CacheNodes[0].Endpoint.Port)
+			}
+			cluster.LogDeliveryConfigurations = settleLogDeliveries(cluster.LogDeliveryConfigurations)
+			cluster.CacheClusterStatus = aws.String(statusAvailable)
+		case statusModifying:
+			if stored.applyPending {
+				f.applyPendingModifiedValues(cluster)
+				stored.applyPending = false
+			}
+			if cluster.CacheParameterGroup != nil {
+				cluster.CacheParameterGroup.ParameterApplyStatus = aws.String("in-sync")
+			}
+			cluster.LogDeliveryConfigurations = settleLogDeliveries(cluster.LogDeliveryConfigurations)
+			cluster.CacheClusterStatus = aws.String(statusAvailable)
+		case statusDeleting:
+			delete(f.tags, aws.ToString(cluster.ARN))
+			delete(f.cacheClusters, id)
+		}
+	}
+}
+
+// applyPendingModifiedValues applies and clears the pending modifications of
+// cluster.
+func (f *ElastiCache) applyPendingModifiedValues(cluster *types.CacheCluster) {
+	pending := cluster.PendingModifiedValues
+	cluster.PendingModifiedValues = nil
+	if pending == nil {
+		return
+	}
+
+	if pending.CacheNodeType != nil {
+		cluster.CacheNodeType = pending.CacheNodeType
+	}
+	if pending.EngineVersion != nil {
+		cluster.EngineVersion = pending.EngineVersion
+	}
+	if pending.AuthTokenStatus != "" {
+		cluster.AuthTokenEnabled = aws.Bool(true)
+		now := time.Now()
+		cluster.AuthTokenLastModifiedDate = &now
+	}
+	if pending.NumCacheNodes == nil {
+		return
+	}
+
+	removed := map[string]bool{}
+	for _, nodeId := range pending.CacheNodeIdsToRemove {
+		removed[nodeId] = true
+	}
+	var nodes []types.CacheNode
+	for _, node := range cluster.CacheNodes {
+		if !removed[aws.ToString(node.CacheNodeId)] {
+			nodes = append(nodes, node)
+		}
+	}
+	port := defaultPorts[aws.ToString(cluster.Engine)]
+	if len(cluster.CacheNodes) > 0 && cluster.CacheNodes[0].Endpoint != nil {
+		port = cluster.CacheNodes[0].Endpoint.Port
+	}
+	now := time.Now()
+	for next := int32(len(cluster.CacheNodes)) + 1; int32(len(nodes)) < *pending.NumCacheNodes; next++ {
+		node := f.newCacheNode(next, aws.ToString(cluster.PreferredAvailabilityZone), port, now)
+		f.makeNodeAvailable(cluster, &node)
+		nodes = append(nodes, node)
+	}
+	cluster.CacheNodes = nodes
+	cluster.NumCacheNodes = pending.NumCacheNodes
+}
+
+// describeCacheCluster returns a copy of the stored cluster. It must be called
+// with f.mu held.
+func (f *ElastiCache) describeCacheCluster(id string, showCacheNodeInfo bool) *types.CacheCluster {
+	cluster := &types.CacheCluster{}
+	clone(f.cacheClusters[id].cluster, cluster)
+	if !showCacheNodeInfo {
+		cluster.CacheNodes = nil
+	}
+	return cluster
+}
+
+// newCacheNode returns a node that is being created. Its endpoint is only
+// known once it is available.
+func (f *ElastiCache) newCacheNode(index int32, availabilityZone string, port int32, createTime time.Time) types.CacheNode {
+	if availabilityZone == "" || availabilityZone == "Multiple" {
+		availabilityZone = f.Region + string(rune('a'+(index-1)%3))
+	}
+	return types.CacheNode{
+		CacheNodeCreateTime:      &createTime,
+		CacheNodeId:              aws.String(fmt.Sprintf("%04d", index)),
+		CacheNodeStatus:          aws.String(statusCreating),
+		CustomerAvailabilityZone: aws.String(availabilityZone),
+		Endpoint:                 &types.Endpoint{Port: port},
+		ParameterGroupStatus:     aws.String("in-sync"),
+	}
+}
+
+func (f *ElastiCache) makeNodeAvailable(cluster *types.CacheCluster, node *types.CacheNode) {
+	node.CacheNodeStatus = aws.String(statusAvailable)
+	node.Endpoint = f.endpoint(aws.ToString(cluster.CacheClusterId)+"."+aws.ToString(node.CacheNodeId), node.Endpoint.Port)
+}
+
+func cacheClusterNotFound(id string) error {
+	return &types.CacheClusterNotFoundFault{Message: aws.String("Cache cluster " + id + " not found.")}
+}
+
+func invalidParameterValue(message string) error {
+	return &types.InvalidParameterValueException{Message: aws.String(message)}
+}
+
+func invalidParameterCombination(message string) error {
+	return &types.InvalidParameterCombinationException{Message: aws.String(message)}
+}
+
+// resolveEngineVersion returns the version a cluster runs when version is
+// requested. Versions like "6.x" resolve to the first minor version.
+func resolveEngineVersion(engine string, version string) string {
+	if version == "" {
+		return defaultEngineVersions[engine]
+	}
+	if strings.HasSuffix(version, ".x") {
+		return strings.TrimSuffix(version, "x") + "0"
+	}
+	return version
+}
+
+// parameterGroupFamily returns the family of the default parameter group of an
+// engine version, e.g. redis6.x or memcached1.6.
+func parameterGroupFamily(engine string, version string) string {
+	parts := strings.Split(version, ".")
+	if engine == engineRedis && len(parts) > 0 {
+		if parts[0] >= "6" {
+			return engine + parts[0] + ".x"
+		}
+	}
+	if len(parts) > 1 {
+		return engine + parts[0] + "." + parts[1]
+	}
+	return engine + version
+}
+
+func securityGroupMemberships(ids []string) []types.SecurityGroupMembership {
+	var memberships []types.SecurityGroupMembership
+	for _, id := range ids {
+		memberships = append(memberships, types.SecurityGroupMembership{SecurityGroupId: aws.String(id), Status: aws.String(statusActive)})
+	}
+	return memberships
+}
+
+func cacheSecurityGroupMemberships(names []string) []types.CacheSecurityGroupMembership {
+	var memberships []types.CacheSecurityGroupMembership
+	for _, name := range names {
+		memberships = append(memberships, types.CacheSecurityGroupMembership{CacheSecurityGroupName: aws.String(name), Status: aws.String(statusActive)})
+	}
+	return memberships
+}
+
+// validateLogDeliveryRequests checks that every enabled log delivery request
+// names its log type, destination type and destination.
+func validateLogDeliveryRequests(requests []types.LogDeliveryConfigurationRequest) error {
+	for _, request := range requests {
+		if request.LogType == "" {
+			return invalidParameterValue("LogType is required.")
+		}
+		if request.Enabled != nil && !*request.Enabled {
+			continue
+		}
+		details := request.DestinationDetails
+		switch {
+		case request.DestinationType == types.DestinationTypeCloudWatchLogs &&
+			(details == nil || details.CloudWatchLogsDetails == nil || aws.ToString(details.CloudWatchLogsDetails.LogGroup) == ""):
+			return invalidParameterValue("CloudWatch Logs log group is required.")
+		case request.DestinationType == types.DestinationTypeKinesisFirehose &&
+			(details == nil || details.KinesisFirehoseDetails == nil || aws.ToString(details.KinesisFirehoseDetails.DeliveryStream) == ""):
+			return invalidParameterValue("Kinesis Firehose delivery stream is required.")
+		case request.DestinationType != types.DestinationTypeCloudWatchLogs && request.DestinationType != types.DestinationTypeKinesisFirehose:
+			return invalidParameterValue(fmt.Sprintf("Invalid destination type %s.", request.DestinationType))
+		}
+	}
+	return nil
+}
+
+// applyLogDeliveryRequests returns current with requests applied. Enabled log
+// types are enabling or modifying and disabled ones are disabling until the
+// next tick.
+func applyLogDeliveryRequests(current []types.LogDeliveryConfiguration, requests []types.LogDeliveryConfigurationRequest) []types.LogDeliveryConfiguration {
+	deliveries := append([]types.LogDeliveryConfiguration(nil), current...)
+	for _, request := range requests {
+		index := -1
+		for i := range deliveries {
+			if deliveries[i].LogType == request.LogType {
+				index = i
+			}
+		}
+		if request.Enabled != nil && !*request.Enabled {
+			if index >= 0 {
+				deliveries[index].Status = types.LogDeliveryConfigurationStatusDisabling
+			}
+			continue
+		}
+		delivery := types.LogDeliveryConfiguration{
+			DestinationDetails: request.DestinationDetails,
+			DestinationType:    request.DestinationType,
+			LogFormat:          request.LogFormat,
+			LogType:            request.LogType,
+			Status:             types.LogDeliveryConfigurationStatusEnabling,
+		}
+		if index < 0 {
+			deliveries = append(deliveries, delivery)
+			continue
+		}
+		delivery.Status = types.LogDeliveryConfigurationStatusModifying
+		deliveries[index] = delivery
+	}
+	return deliveries
+}
+
+// settleLogDeliveries completes pending log delivery changes.
+func settleLogDeliveries(current []types.LogDeliveryConfiguration) []types.LogDeliveryConfiguration {
+	var deliveries []types.LogDeliveryConfiguration
+	for _, delivery := range current {
+		switch delivery.Status {
+		case types.LogDeliveryConfigurationStatusDisabling:
+			continue
+		case types.LogDeliveryConfigurationStatusEnabling, types.LogDeliveryConfigurationStatusModifying:
+			delivery.Status = types.LogDeliveryConfigurationStatusActive
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries
+}
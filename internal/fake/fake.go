@@ -0,0 +1,259 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fake provides an in-memory implementation of the ElastiCache API. It
+// simulates the lifecycle of cache clusters, replication groups, users and
+// snapshots and returns the same typed errors as AWS, so controllers can be
+// exercised without an AWS account.
+//
+// Resources that are created, modified or deleted stay in their transitional
+// state (creating, modifying, deleting) until Tick is called, which moves every
+// resource on to its next stable state.
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	"github.com/aws/smithy-go"
+)
+
+// Resource states reported by ElastiCache.
+const (
+	statusAvailable = "available"
+	statusCreating  = "creating"
+	statusModifying = "modifying"
+	statusDeleting  = "deleting"
+	statusActive    = "active"
+)
+
+// ElastiCache is an in-memory ElastiCache API. The zero value is not usable,
+// create one with New.
+type ElastiCache struct {
+	// Region and AccountId are used to build the ARNs and endpoints of new
+	// resources.
+	Region    string
+	AccountId string
+
+	mu                sync.Mutex
+	cacheClusters     map[string]*cacheCluster
+	replicationGroups map[string]*replicationGroup
+	parameterGroups   map[string]*parameterGroup
+	subnetGroups      map[string]*types.CacheSubnetGroup
+	users             map[string]*types.User
+	userGroups        map[string]*userGroup
+	snapshots         map[string]*types.Snapshot
+	tags              map[string][]types.Tag
+	injectedErrors    map[string][]error
+	calls             []string
+}
+
+// New returns an empty ElastiCache in us-east-1.
+func New() *ElastiCache {
+	return &ElastiCache{
+		Region:            "us-east-1",
+		AccountId:         "123456789012",
+		cacheClusters:     map[string]*cacheCluster{},
+		replicationGroups: map[string]*replicationGroup{},
+		parameterGroups:   map[string]*parameterGroup{},
+		subnetGroups:      map[string]*types.CacheSubnetGroup{},
+		users:             map[string]*types.User{},
+		userGroups:        map[string]*userGroup{},
+		snapshots:         map[string]*types.Snapshot{},
+		tags:              map[string][]types.Tag{},
+		injectedErrors:    map[string][]error{},
+	}
+}
+
+// Tick completes every transition in progress. Created and modified resources
+// become available with their pending modifications applied, and deleted
+// resources are removed.
+func (f *ElastiCache) Tick() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.tickCacheClusters()
+	f.tickReplicationGroups()
+	f.tickUsers()
+	f.tickSnapshots()
+}
+
+// InjectError makes the next call of operation, e.g. "CreateCacheCluster",
+// fail with err. Errors injected for the same operation are returned in order.
+func (f *ElastiCache) InjectError(operation string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.injectedErrors[operation] = append(f.injectedErrors[operation], err)
+}
+
+// Calls returns the names of the operations called so far, in order.
+func (f *ElastiCache) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]string(nil), f.calls...)
+}
+
+// ResetCalls forgets the operations called so far.
+func (f *ElastiCache) ResetCalls() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = nil
+}
+
+// call records a call of operation and returns the error injected for it, if
+// any. It must be called with f.mu held.
+func (f *ElastiCache) call(operation string) error {
+	f.calls = append(f.calls, operation)
+
+	injected := f.injectedErrors[operation]
+	if len(injected) == 0 {
+		return nil
+	}
+	f.injectedErrors[operation] = injected[1:]
+	return operationError(operation, injected[0])
+}
+
+// operationError wraps err the way the AWS SDK does, so callers can use
+// errors.As to look for the typed fault.
+func operationError(operation string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &smithy.OperationError{ServiceID: elasticache.ServiceID, OperationName: operation, Err: err}
+}
+
+func (f *ElastiCache) arn(resourceType string, name string) *string {
+	return aws.String(fmt.Sprintf("arn:aws:elasticache:%s:%s:%s:%s", f.Region, f.AccountId, resourceType, name))
+}
+
+// endpoint returns the endpoint of a node or of a cluster configuration.
+func (f *ElastiCache) endpoint(name string, port int32) *types.Endpoint {
+	return &types.Endpoint{
+		Address: aws.String(fmt.Sprintf("%s.fake.%s.cache.amazonaws.com", name, f.Region)),
+		Port:    port,
+	}
+}
+
+// AddTagsToResource implements the AddTagsToResource operation.
+func (f *ElastiCache) AddTagsToResource(_ context.Context, params *elasticache.AddTagsToResourceInput, _ ...func(*elasticache.Options)) (*elasticache.AddTagsToResourceOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("AddTagsToResource"); err != nil {
+		return nil, err
+	}
+	arn := aws.ToString(params.ResourceName)
+	current, ok := f.tags[arn]
+	if !ok {
+		return nil, operationError("AddTagsToResource", &types.InvalidARNFault{Message: aws.String("unknown resource " + arn)})
+	}
+
+	f.tags[arn] = mergeTags(current, params.Tags)
+	return &elasticache.AddTagsToResourceOutput{TagList: cloneTags(f.tags[arn])}, nil
+}
+
+// ListTagsForResource implements the ListTagsForResource operation.
+func (f *ElastiCache) ListTagsForResource(_ context.Context, params *elasticache.ListTagsForResourceInput, _ ...func(*elasticache.Options)) (*elasticache.ListTagsForResourceOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("ListTagsForResource"); err != nil {
+		return nil, err
+	}
+	arn := aws.ToString(params.ResourceName)
+	current, ok := f.tags[arn]
+	if !ok {
+		return nil, operationError("ListTagsForResource", &types.InvalidARNFault{Message: aws.String("unknown resource " + arn)})
+	}
+	return &elasticache.ListTagsForResourceOutput{TagList: cloneTags(current)}, nil
+}
+
+// RemoveTagsFromResource implements the RemoveTagsFromResource operation.
+func (f *ElastiCache) RemoveTagsFromResource(_ context.Context, params *elasticache.RemoveTagsFromResourceInput, _ ...func(*elasticache.Options)) (*elasticache.RemoveTagsFromResourceOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("RemoveTagsFromResource"); err != nil {
+		return nil, err
+	}
+	arn := aws.ToString(params.ResourceName)
+	current, ok := f.tags[arn]
+	if !ok {
+		return nil, operationError("RemoveTagsFromResource", &types.InvalidARNFault{Message: aws.String("unknown resource " + arn)})
+	}
+
+	remaining := []types.Tag{}
+	for _, tag := range current {
+		removed := false
+		for _, key := range params.TagKeys {
+			if aws.ToString(tag.Key) == key {
+				removed = true
+			}
+		}
+		if !removed {
+			remaining = append(remaining, tag)
+		}
+	}
+	f.tags[arn] = remaining
+	return &elasticache.RemoveTagsFromResourceOutput{TagList: cloneTags(remaining)}, nil
+}
+
+// mergeTags returns tags with the values of updates, replacing tags with the
+// same key.
+func mergeTags(tags []types.Tag, updates []types.Tag) []types.Tag {
+	merged := cloneTags(tags)
+	for _, update := range updates {
+		replaced := false
+		for i := range merged {
+			if aws.ToString(merged[i].Key) == aws.ToString(update.Key) {
+				merged[i].Value = update.Value
+				replaced = true
+			}
+		}
+		if !replaced {
+			merged = append(merged, types.Tag{Key: update.Key, Value: update.Value})
+		}
+	}
+	return merged
+}
+
+func cloneTags(tags []types.Tag) []types.Tag {
+	cloned := []types.Tag{}
+	for _, tag := range tags {
+		cloned = append(cloned, types.Tag{Key: aws.String(aws.ToString(tag.Key)), Value: tag.Value})
+	}
+	return cloned
+}
+
+// clone returns a deep copy of in through out, so callers cannot change the
+// state of the fake through the values it returns.
+func clone(in interface{}, out interface{}) {
+	data, err := json.Marshal(in)
+	if err != nil {
+		panic(err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		panic(err)
+	}
+}
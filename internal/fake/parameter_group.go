@@ -0,0 +1,270 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+)
+
+// maxParametersPerCall is the number of parameters ElastiCache accepts in a
+// single ModifyCacheParameterGroup or ResetCacheParameterGroup call.
+const maxParametersPerCall = 20
+
+type parameterGroup struct {
+	group types.CacheParameterGroup
+
+	// parameters holds the values set by the user. Engine defaults are not
+	// simulated.
+	parameters map[string]string
+}
+
+// CreateCacheParameterGroup implements the CreateCacheParameterGroup operation.
+func (f *ElastiCache) CreateCacheParameterGroup(_ context.Context, params *elasticache.CreateCacheParameterGroupInput, _ ...func(*elasticache.Options)) (*elasticache.CreateCacheParameterGroupOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "CreateCacheParameterGroup"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	name := aws.ToString(params.CacheParameterGroupName)
+	switch {
+	case name == "":
+		return nil, operationError(operation, invalidParameterValue("The parameter CacheParameterGroupName must be provided."))
+	case strings.HasPrefix(name, "default."):
+		return nil, operationError(operation, invalidParameterValue("Cache parameter group names can not start with default."))
+	case !strings.HasPrefix(aws.ToString(params.CacheParameterGroupFamily), engineRedis) && !strings.HasPrefix(aws.ToString(params.CacheParameterGroupFamily), engineMemcached):
+		return nil, operationError(operation, invalidParameterValue("Invalid cache parameter group family "+aws.ToString(params.CacheParameterGroupFamily)+"."))
+	}
+	if _, ok := f.parameterGroups[name]; ok {
+		return nil, operationError(operation, &types.CacheParameterGroupAlreadyExistsFault{Message: aws.String("Cache parameter group " + name + " already exists.")})
+	}
+
+	stored := &parameterGroup{
+		group: types.CacheParameterGroup{
+			ARN:                       f.arn("parametergroup", name),
+			CacheParameterGroupFamily: params.CacheParameterGroupFamily,
+			CacheParameterGroupName:   aws.String(name),
+			Description:               params.Description,
+			IsGlobal:                  false,
+		},
+		parameters: map[string]string{},
+	}
+	f.parameterGroups[name] = stored
+	f.tags[*stored.group.ARN] = cloneTags(params.Tags)
+
+	return &elasticache.CreateCacheParameterGroupOutput{CacheParameterGroup: f.describeParameterGroup(name)}, nil
+}
+
+// DescribeCacheParameterGroups implements the DescribeCacheParameterGroups
+// operation. All groups are returned in a single page.
+func (f *ElastiCache) DescribeCacheParameterGroups(_ context.Context, params *elasticache.DescribeCacheParameterGroupsInput, _ ...func(*elasticache.Options)) (*elasticache.DescribeCacheParameterGroupsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "DescribeCacheParameterGroups"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	if name := params.CacheParameterGroupName; name != nil {
+		if _, ok := f.parameterGroups[*name]; !ok {
+			return nil, operationError(operation, parameterGroupNotFound(*name))
+		}
+		return &elasticache.DescribeCacheParameterGroupsOutput{
+			CacheParameterGroups: []types.CacheParameterGroup{*f.describeParameterGroup(*name)},
+		}, nil
+	}
+
+	names := make([]string, 0, len(f.parameterGroups))
+	for name := range f.parameterGroups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	output := &elasticache.DescribeCacheParameterGroupsOutput{CacheParameterGroups: []types.CacheParameterGroup{}}
+	for _, name := range names {
+		output.CacheParameterGroups = append(output.CacheParameterGroups, *f.describeParameterGroup(name))
+	}
+	return output, nil
+}
+
+// DescribeCacheParameters implements the DescribeCacheParameters operation.
+// Only the parameters set by the user are known, they are returned in a single
+// page regardless of Source.
+func (f *ElastiCache) DescribeCacheParameters(_ context.Context, params *elasticache.DescribeCacheParametersInput, _ ...func(*elasticache.Options)) (*elasticache.DescribeCacheParametersOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "DescribeCacheParameters"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	name := aws.ToString(params.CacheParameterGroupName)
+	stored, ok := f.parameterGroups[name]
+	if !ok {
+		return nil, operationError(operation, parameterGroupNotFound(name))
+	}
+
+	parameterNames := make([]string, 0, len(stored.parameters))
+	for parameterName := range stored.parameters {
+		parameterNames = append(parameterNames, parameterName)
+	}
+	sort.Strings(parameterNames)
+
+	output := &elasticache.DescribeCacheParametersOutput{Parameters: []types.Parameter{}}
+	for _, parameterName := range parameterNames {
+		output.Parameters = append(output.Parameters, types.Parameter{
+			ParameterName:  aws.String(parameterName),
+			ParameterValue: aws.String(stored.parameters[parameterName]),
+			Source:         aws.String("user"),
+			IsModifiable:   true,
+		})
+	}
+	return output, nil
+}
+
+// ModifyCacheParameterGroup implements the ModifyCacheParameterGroup operation.
+func (f *ElastiCache) ModifyCacheParameterGroup(_ context.Context, params *elasticache.ModifyCacheParameterGroupInput, _ ...func(*elasticache.Options)) (*elasticache.ModifyCacheParameterGroupOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "ModifyCacheParameterGroup"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	name := aws.ToString(params.CacheParameterGroupName)
+	stored, err := f.modifiableParameterGroup(name, len(params.ParameterNameValues))
+	if err != nil {
+		return nil, operationError(operation, err)
+	}
+	if len(params.ParameterNameValues) == 0 {
+		return nil, operationError(operation, invalidParameterValue("At least one parameter must be provided."))
+	}
+
+	for _, parameter := range params.ParameterNameValues {
+		stored.parameters[aws.ToString(parameter.ParameterName)] = aws.ToString(parameter.ParameterValue)
+	}
+	return &elasticache.ModifyCacheParameterGroupOutput{CacheParameterGroupName: aws.String(name)}, nil
+}
+
+// ResetCacheParameterGroup implements the ResetCacheParameterGroup operation.
+func (f *ElastiCache) ResetCacheParameterGroup(_ context.Context, params *elasticache.ResetCacheParameterGroupInput, _ ...func(*elasticache.Options)) (*elasticache.ResetCacheParameterGroupOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "ResetCacheParameterGroup"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	name := aws.ToString(params.CacheParameterGroupName)
+	stored, err := f.modifiableParameterGroup(name, len(params.ParameterNameValues))
+	if err != nil {
+		return nil, operationError(operation, err)
+	}
+
+	switch {
+	case params.ResetAllParameters && len(params.ParameterNameValues) > 0:
+		return nil, operationError(operation, invalidParameterCombination("ResetAllParameters can not be used together with ParameterNameValues."))
+	case params.ResetAllParameters:
+		stored.parameters = map[string]string{}
+	case len(params.ParameterNameValues) == 0:
+		return nil, operationError(operation, invalidParameterValue("Either ResetAllParameters or ParameterNameValues must be provided."))
+	}
+	for _, parameter := range params.ParameterNameValues {
+		delete(stored.parameters, aws.ToString(parameter.ParameterName))
+	}
+	return &elasticache.ResetCacheParameterGroupOutput{CacheParameterGroupName: aws.String(name)}, nil
+}
+
+// DeleteCacheParameterGroup implements the DeleteCacheParameterGroup
+// operation. Groups used by a cache cluster or replication group can not be
+// deleted.
+func (f *ElastiCache) DeleteCacheParameterGroup(_ context.Context, params *elasticache.DeleteCacheParameterGroupInput, _ ...func(*elasticache.Options)) (*elasticache.DeleteCacheParameterGroupOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "DeleteCacheParameterGroup"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	name := aws.ToString(params.CacheParameterGroupName)
+	stored, err := f.modifiableParameterGroup(name, 0)
+	if err != nil {
+		return nil, operationError(operation, err)
+	}
+	if f.parameterGroupInUse(name) {
+		return nil, operationError(operation, &types.InvalidCacheParameterGroupStateFault{Message: aws.String("Cache parameter group " + name + " is in use.")})
+	}
+
+	delete(f.tags, aws.ToString(stored.group.ARN))
+	delete(f.parameterGroups, name)
+	return &elasticache.DeleteCacheParameterGroupOutput{}, nil
+}
+
+// modifiableParameterGroup returns the parameter group that can be changed by
+// a call with the given number of parameters. It must be called with f.mu held.
+func (f *ElastiCache) modifiableParameterGroup(name string, parameters int) (*parameterGroup, error) {
+	if strings.HasPrefix(name, "default.") {
+		return nil, &types.InvalidCacheParameterGroupStateFault{Message: aws.String("Default cache parameter groups can not be changed.")}
+	}
+	stored, ok := f.parameterGroups[name]
+	if !ok {
+		return nil, parameterGroupNotFound(name)
+	}
+	if parameters > maxParametersPerCall {
+		return nil, invalidParameterValue("At most 20 parameters can be changed in a single call.")
+	}
+	return stored, nil
+}
+
+func (f *ElastiCache) parameterGroupInUse(name string) bool {
+	for _, stored := range f.cacheClusters {
+		if group := stored.cluster.CacheParameterGroup; group != nil && aws.ToString(group.CacheParameterGroupName) == name {
+			return true
+		}
+	}
+	for _, stored := range f.replicationGroups {
+		if stored.parameterGroupName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// describeParameterGroup returns a copy of the stored parameter group. It must
+// be called with f.mu held.
+func (f *ElastiCache) describeParameterGroup(name string) *types.CacheParameterGroup {
+	group := &types.CacheParameterGroup{}
+	clone(f.parameterGroups[name].group, group)
+	return group
+}
+
+func parameterGroupNotFound(name string) error {
+	return &types.CacheParameterGroupNotFoundFault{Message: aws.String("Cache parameter group " + name + " not found.")}
+}
@@ -0,0 +1,719 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+)
+
+// maxReplicasPerNodeGroup is the number of read replicas a node group can have
+// in addition to its primary.
+const maxReplicasPerNodeGroup = 5
+
+type replicationGroup struct {
+	group types.ReplicationGroup
+
+	engineVersion      string
+	parameterGroupName string
+	subnetGroupName    string
+	port               int32
+	numNodeGroups      int32
+	replicas           int32
+
+	// The settings of the member clusters that are not reported by the group.
+	maintenanceWindow       string
+	securityGroupIds        []string
+	cacheSecurityGroupNames []string
+	notificationTopicArn    *string
+	autoMinorVersionUpgrade bool
+
+	// pending holds the modifications that are applied on the next Tick when
+	// applyPending is set. Like ElastiCache, only some of them are reported in
+	// the PendingModifiedValues of the group.
+	pending      replicationGroupChanges
+	applyPending bool
+}
+
+type replicationGroupChanges struct {
+	cacheNodeType *string
+	engineVersion *string
+	numNodeGroups *int32
+	replicas      *int32
+}
+
+// ReplicationGroup returns the replication group with the given identifier.
+func (f *ElastiCache) ReplicationGroup(replicationGroupId string) (types.ReplicationGroup, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stored, ok := f.replicationGroups[replicationGroupId]
+	if !ok {
+		return types.ReplicationGroup{}, false
+	}
+	var group types.ReplicationGroup
+	clone(stored.group, &group)
+	return group, true
+}
+
+// CreateReplicationGroup implements the CreateReplicationGroup operation.
+// Groups are created with cluster mode enabled when NumNodeGroups is set.
+// Member clusters are only returned by DescribeCacheClusters when they are
+// asked for by identifier.
+func (f *ElastiCache) CreateReplicationGroup(_ context.Context, params *elasticache.CreateReplicationGroupInput, _ ...func(*elasticache.Options)) (*elasticache.CreateReplicationGroupOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "CreateReplicationGroup"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	id := aws.ToString(params.ReplicationGroupId)
+	switch {
+	case id == "":
+		return nil, operationError(operation, invalidParameterValue("The parameter ReplicationGroupId must be provided."))
+	case aws.ToString(params.ReplicationGroupDescription) == "":
+		return nil, operationError(operation, invalidParameterValue("The parameter ReplicationGroupDescription must be provided."))
+	case params.CacheNodeType == nil:
+		return nil, operationError(operation, invalidParameterValue("The parameter CacheNodeType must be provided."))
+	case params.Engine != nil && *params.Engine != engineRedis:
+		return nil, operationError(operation, invalidParameterValue("Replication groups only support the redis engine."))
+	}
+	if _, ok := f.replicationGroups[id]; ok {
+		return nil, operationError(operation, &types.ReplicationGroupAlreadyExistsFault{Message: aws.String("Replication group " + id + " already exists.")})
+	}
+
+	clusterEnabled := params.NumNodeGroups != nil
+	numNodeGroups := int32(1)
+	replicas := int32(0)
+	if clusterEnabled {
+		numNodeGroups = *params.NumNodeGroups
+		replicas = aws.ToInt32(params.ReplicasPerNodeGroup)
+	} else if params.NumCacheClusters != nil {
+		replicas = *params.NumCacheClusters - 1
+	}
+	automaticFailover := aws.ToBool(params.AutomaticFailoverEnabled)
+	multiAZ := aws.ToBool(params.MultiAZEnabled)
+
+	switch {
+	case numNodeGroups < 1 || numNodeGroups > 500:
+		return nil, operationError(operation, invalidParameterValue("NumNodeGroups must be between 1 and 500."))
+	case replicas < 0 || replicas > maxReplicasPerNodeGroup:
+		return nil, operationError(operation, invalidParameterValue(fmt.Sprintf("A node group must have between 0 and %d replicas.", maxReplicasPerNodeGroup)))
+	case clusterEnabled && !automaticFailover:
+		return nil, operationError(operation, invalidParameterCombination("Automatic failover must be enabled for cluster mode enabled replication groups."))
+	case automaticFailover && replicas < 1:
+		return nil, operationError(operation, invalidParameterCombination("Automatic failover requires at least one read replica."))
+	case multiAZ && !automaticFailover:
+		return nil, operationError(operation, invalidParameterCombination("Multi-AZ requires automatic failover to be enabled."))
+	case params.AuthToken != nil && !aws.ToBool(params.TransitEncryptionEnabled):
+		return nil, operationError(operation, invalidParameterCombination("AuthToken requires TransitEncryptionEnabled."))
+	case params.AuthToken != nil && len(params.UserGroupIds) > 0:
+		return nil, operationError(operation, invalidParameterCombination("AuthToken and UserGroupIds can not be used together."))
+	}
+	if name := params.CacheSubnetGroupName; name != nil {
+		if _, ok := f.subnetGroups[*name]; !ok {
+			return nil, operationError(operation, &types.CacheSubnetGroupNotFoundFault{Message: aws.String("Cache subnet group " + *name + " not found.")})
+		}
+	}
+	if name := params.CacheParameterGroupName; name != nil && !strings.HasPrefix(*name, "default.") {
+		if _, ok := f.parameterGroups[*name]; !ok {
+			return nil, operationError(operation, &types.CacheParameterGroupNotFoundFault{Message: aws.String("Cache parameter group " + *name + " not found.")})
+		}
+	}
+	for _, userGroupId := range params.UserGroupIds {
+		if _, ok := f.userGroups[userGroupId]; !ok {
+			return nil, operationError(operation, userGroupNotFound(userGroupId))
+		}
+	}
+
+	port := defaultPorts[engineRedis]
+	if params.Port != nil {
+		port = *params.Port
+	}
+	snapshotWindow := defaultSnapshotWindow
+	if params.SnapshotWindow != nil {
+		snapshotWindow = *params.SnapshotWindow
+	}
+	maintenanceWindow := defaultMaintenanceWindow
+	if params.PreferredMaintenanceWindow != nil {
+		maintenanceWindow = strings.ToLower(*params.PreferredMaintenanceWindow)
+	}
+	autoMinorVersionUpgrade := true
+	if params.AutoMinorVersionUpgrade != nil {
+		autoMinorVersionUpgrade = *params.AutoMinorVersionUpgrade
+	}
+
+	now := time.Now()
+	stored := &replicationGroup{
+		group: types.ReplicationGroup{
+			ARN:                        f.arn("replicationgroup", id),
+			AtRestEncryptionEnabled:    aws.Bool(aws.ToBool(params.AtRestEncryptionEnabled)),
+			AuthTokenEnabled:           aws.Bool(params.AuthToken != nil),
+			AutomaticFailover:          automaticFailoverStatus(automaticFailover),
+			CacheNodeType:              params.CacheNodeType,
+			ClusterEnabled:             aws.Bool(clusterEnabled),
+			Description:                params.ReplicationGroupDescription,
+			KmsKeyId:                   params.KmsKeyId,
+			MultiAZ:                    multiAZStatus(multiAZ),
+			ReplicationGroupCreateTime: &now,
+			ReplicationGroupId:         aws.String(id),
+			SnapshotRetentionLimit:     aws.Int32(aws.ToInt32(params.SnapshotRetentionLimit)),
+			SnapshotWindow:             aws.String(snapshotWindow),
+			Status:                     aws.String(statusCreating),
+			TransitEncryptionEnabled:   aws.Bool(aws.ToBool(params.TransitEncryptionEnabled)),
+			UserGroupIds:               append([]string(nil), params.UserGroupIds...),
+		},
+		engineVersion:      resolveEngineVersion(engineRedis, aws.ToString(params.EngineVersion)),
+		parameterGroupName: aws.ToString(params.CacheParameterGroupName),
+		subnetGroupName:    aws.ToString(params.CacheSubnetGroupName),
+		port:               port,
+		numNodeGroups:      numNodeGroups,
+		replicas:           replicas,
+
+		maintenanceWindow:       maintenanceWindow,
+		securityGroupIds:        append([]string(nil), params.SecurityGroupIds...),
+		cacheSecurityGroupNames: append([]string(nil), params.CacheSecurityGroupNames...),
+		notificationTopicArn:    params.NotificationTopicArn,
+		autoMinorVersionUpgrade: autoMinorVersionUpgrade,
+	}
+	f.layoutReplicationGroup(stored, false)
+
+	f.replicationGroups[id] = stored
+	f.tags[*stored.group.ARN] = cloneTags(params.Tags)
+
+	return &elasticache.CreateReplicationGroupOutput{ReplicationGroup: f.describeReplicationGroup(id)}, nil
+}
+
+// DescribeReplicationGroups implements the DescribeReplicationGroups operation.
+// All groups are returned in a single page.
+func (f *ElastiCache) DescribeReplicationGroups(_ context.Context, params *elasticache.DescribeReplicationGroupsInput, _ ...func(*elasticache.Options)) (*elasticache.DescribeReplicationGroupsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "DescribeReplicationGroups"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	if id := params.ReplicationGroupId; id != nil {
+		if _, ok := f.replicationGroups[*id]; !ok {
+			return nil, operationError(operation, replicationGroupNotFound(*id))
+		}
+		return &elasticache.DescribeReplicationGroupsOutput{
+			ReplicationGroups: []types.ReplicationGroup{*f.describeReplicationGroup(*id)},
+		}, nil
+	}
+
+	ids := make([]string, 0, len(f.replicationGroups))
+	for id := range f.replicationGroups {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	output := &elasticache.DescribeReplicationGroupsOutput{ReplicationGroups: []types.ReplicationGroup{}}
+	for _, id := range ids {
+		output.ReplicationGroups = append(output.ReplicationGroups, *f.describeReplicationGroup(id))
+	}
+	return output, nil
+}
+
+// ModifyReplicationGroup implements the ModifyReplicationGroup operation.
+func (f *ElastiCache) ModifyReplicationGroup(_ context.Context, params *elasticache.ModifyReplicationGroupInput, _ ...func(*elasticache.Options)) (*elasticache.ModifyReplicationGroupOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "ModifyReplicationGroup"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	id := aws.ToString(params.ReplicationGroupId)
+	stored, err := f.availableReplicationGroup(id)
+	if err != nil {
+		return nil, operationError(operation, err)
+	}
+	group := &stored.group
+
+	automaticFailover := group.AutomaticFailover == types.AutomaticFailoverStatusEnabled
+	if params.AutomaticFailoverEnabled != nil {
+		automaticFailover = *params.AutomaticFailoverEnabled
+	}
+	switch {
+	case aws.ToBool(group.ClusterEnabled) && !automaticFailover:
+		return nil, operationError(operation, invalidParameterCombination("Automatic failover must be enabled for cluster mode enabled replication groups."))
+	case automaticFailover && stored.replicas < 1:
+		return nil, operationError(operation, invalidParameterCombination("Automatic failover requires at least one read replica."))
+	case aws.ToBool(params.MultiAZEnabled) && !automaticFailover:
+		return nil, operationError(operation, invalidParameterCombination("Multi-AZ requires automatic failover to be enabled."))
+	case params.AuthToken != nil && !aws.ToBool(group.TransitEncryptionEnabled):
+		return nil, operationError(operation, invalidParameterCombination("AuthToken requires TransitEncryptionEnabled."))
+	}
+	for _, userGroupId := range params.UserGroupIdsToAdd {
+		if _, ok := f.userGroups[userGroupId]; !ok {
+			return nil, operationError(operation, userGroupNotFound(userGroupId))
+		}
+	}
+
+	pending := &types.ReplicationGroupPendingModifiedValues{}
+	if group.PendingModifiedValues != nil {
+		pending = group.PendingModifiedValues
+	}
+	if params.CacheNodeType != nil {
+		stored.pending.cacheNodeType = params.CacheNodeType
+	}
+	if params.EngineVersion != nil {
+		stored.pending.engineVersion = aws.String(resolveEngineVersion(engineRedis, *params.EngineVersion))
+	}
+	if params.AutomaticFailoverEnabled != nil && automaticFailover != (group.AutomaticFailover == types.AutomaticFailoverStatusEnabled) {
+		pending.AutomaticFailoverStatus = types.PendingAutomaticFailoverStatusDisabled
+		if automaticFailover {
+			pending.AutomaticFailoverStatus = types.PendingAutomaticFailoverStatusEnabled
+		}
+	}
+	if params.AuthToken != nil {
+		pending.AuthTokenStatus = types.AuthTokenUpdateStatusRotating
+		if params.AuthTokenUpdateStrategy == types.AuthTokenUpdateStrategyTypeSet {
+			pending.AuthTokenStatus = types.AuthTokenUpdateStatusSetting
+		}
+	}
+	if len(params.UserGroupIdsToAdd) > 0 || len(params.UserGroupIdsToRemove) > 0 {
+		pending.UserGroups = &types.UserGroupsUpdateStatus{
+			UserGroupIdsToAdd:    params.UserGroupIdsToAdd,
+			UserGroupIdsToRemove: params.UserGroupIdsToRemove,
+		}
+	}
+	if pending.AutomaticFailoverStatus != "" || pending.AuthTokenStatus != "" || pending.UserGroups != nil || pending.Resharding != nil {
+		group.PendingModifiedValues = pending
+	}
+
+	if name := params.CacheParameterGroupName; name != nil {
+		if _, ok := f.parameterGroups[*name]; !ok && !strings.HasPrefix(*name, "default.") {
+			return nil, operationError(operation, &types.CacheParameterGroupNotFoundFault{Message: aws.String("Cache parameter group " + *name + " not found.")})
+		}
+		stored.parameterGroupName = *name
+	}
+	if params.ReplicationGroupDescription != nil {
+		group.Description = params.ReplicationGroupDescription
+	}
+	if params.MultiAZEnabled != nil {
+		group.MultiAZ = multiAZStatus(*params.MultiAZEnabled)
+	}
+	if params.SnapshotRetentionLimit != nil {
+		group.SnapshotRetentionLimit = params.SnapshotRetentionLimit
+	}
+	if params.SnapshotWindow != nil {
+		group.SnapshotWindow = params.SnapshotWindow
+	}
+	if params.PreferredMaintenanceWindow != nil {
+		stored.maintenanceWindow = strings.ToLower(*params.PreferredMaintenanceWindow)
+	}
+	if params.SecurityGroupIds != nil {
+		stored.securityGroupIds = append([]string(nil), params.SecurityGroupIds...)
+	}
+	if params.CacheSecurityGroupNames != nil {
+		stored.cacheSecurityGroupNames = append([]string(nil), params.CacheSecurityGroupNames...)
+	}
+	if params.NotificationTopicArn != nil {
+		stored.notificationTopicArn = params.NotificationTopicArn
+	}
+	if params.AutoMinorVersionUpgrade != nil {
+		stored.autoMinorVersionUpgrade = *params.AutoMinorVersionUpgrade
+	}
+
+	if params.ApplyImmediately {
+		group.Status = aws.String(statusModifying)
+		stored.applyPending = true
+	}
+
+	return &elasticache.ModifyReplicationGroupOutput{ReplicationGroup: f.describeReplicationGroup(id)}, nil
+}
+
+// ModifyReplicationGroupShardConfiguration implements the
+// ModifyReplicationGroupShardConfiguration operation. The new node groups are
+// added or removed on the next Tick.
+func (f *ElastiCache) ModifyReplicationGroupShardConfiguration(_ context.Context, params *elasticache.ModifyReplicationGroupShardConfigurationInput, _ ...func(*elasticache.Options)) (*elasticache.ModifyReplicationGroupShardConfigurationOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "ModifyReplicationGroupShardConfiguration"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	id := aws.ToString(params.ReplicationGroupId)
+	stored, err := f.availableReplicationGroup(id)
+	if err != nil {
+		return nil, operationError(operation, err)
+	}
+
+	count := params.NodeGroupCount
+	switch {
+	case !aws.ToBool(stored.group.ClusterEnabled):
+		return nil, operationError(operation, invalidParameterValue("Replication group "+id+" does not have cluster mode enabled."))
+	case !params.ApplyImmediately:
+		return nil, operationError(operation, invalidParameterValue("ApplyImmediately must be true."))
+	case count < 1 || count > 500:
+		return nil, operationError(operation, invalidParameterValue("NodeGroupCount must be between 1 and 500."))
+	case count == stored.numNodeGroups:
+		return nil, operationError(operation, invalidParameterValue(fmt.Sprintf("Replication group %s already has %d node groups.", id, count)))
+	case count < stored.numNodeGroups && len(params.NodeGroupsToRemove) == 0 && len(params.NodeGroupsToRetain) == 0:
+		return nil, operationError(operation, invalidParameterCombination("NodeGroupsToRemove or NodeGroupsToRetain is required when the number of node groups is decreased."))
+	}
+
+	stored.pending.numNodeGroups = aws.Int32(count)
+	stored.applyPending = true
+	if stored.group.PendingModifiedValues == nil {
+		stored.group.PendingModifiedValues = &types.ReplicationGroupPendingModifiedValues{}
+	}
+	stored.group.PendingModifiedValues.Resharding = &types.ReshardingStatus{SlotMigration: &types.SlotMigration{}}
+	stored.group.Status = aws.String(statusModifying)
+
+	return &elasticache.ModifyReplicationGroupShardConfigurationOutput{ReplicationGroup: f.describeReplicationGroup(id)}, nil
+}
+
+// IncreaseReplicaCount implements the IncreaseReplicaCount operation for the
+// replica count of all node groups.
+func (f *ElastiCache) IncreaseReplicaCount(_ context.Context, params *elasticache.IncreaseReplicaCountInput, _ ...func(*elasticache.Options)) (*elasticache.IncreaseReplicaCountOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "IncreaseReplicaCount"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	id := aws.ToString(params.ReplicationGroupId)
+	stored, err := f.availableReplicationGroup(id)
+	if err != nil {
+		return nil, operationError(operation, err)
+	}
+	count := aws.ToInt32(params.NewReplicaCount)
+	switch {
+	case params.NewReplicaCount == nil:
+		return nil, operationError(operation, invalidParameterValue("The parameter NewReplicaCount must be provided."))
+	case count <= stored.replicas:
+		return nil, operationError(operation, invalidParameterValue(fmt.Sprintf("NewReplicaCount must be greater than the current replica count %d.", stored.replicas)))
+	case count > maxReplicasPerNodeGroup:
+		return nil, operationError(operation, invalidParameterValue(fmt.Sprintf("A node group can have at most %d replicas.", maxReplicasPerNodeGroup)))
+	}
+
+	f.setPendingReplicas(stored, count, params.ApplyImmediately)
+	return &elasticache.IncreaseReplicaCountOutput{ReplicationGroup: f.describeReplicationGroup(id)}, nil
+}
+
+// DecreaseReplicaCount implements the DecreaseReplicaCount operation for the
+// replica count of all node groups.
+func (f *ElastiCache) DecreaseReplicaCount(_ context.Context, params *elasticache.DecreaseReplicaCountInput, _ ...func(*elasticache.Options)) (*elasticache.DecreaseReplicaCountOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "DecreaseReplicaCount"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	id := aws.ToString(params.ReplicationGroupId)
+	stored, err := f.availableReplicationGroup(id)
+	if err != nil {
+		return nil, operationError(operation, err)
+	}
+	count := aws.ToInt32(params.NewReplicaCount)
+	switch {
+	case params.NewReplicaCount == nil:
+		return nil, operationError(operation, invalidParameterValue("The parameter NewReplicaCount must be provided."))
+	case count >= stored.replicas || count < 0:
+		return nil, operationError(operation, invalidParameterValue(fmt.Sprintf("NewReplicaCount must be less than the current replica count %d.", stored.replicas)))
+	case count == 0 && stored.group.AutomaticFailover == types.AutomaticFailoverStatusEnabled:
+		return nil, operationError(operation, invalidParameterCombination("A replication group with automatic failover enabled must keep at least one replica."))
+	}
+
+	f.setPendingReplicas(stored, count, params.ApplyImmediately)
+	return &elasticache.DecreaseReplicaCountOutput{ReplicationGroup: f.describeReplicationGroup(id)}, nil
+}
+
+// DeleteReplicationGroup implements the DeleteReplicationGroup operation. A
+// final snapshot is created right away, the group is removed on the next Tick.
+func (f *ElastiCache) DeleteReplicationGroup(_ context.Context, params *elasticache.DeleteReplicationGroupInput, _ ...func(*elasticache.Options)) (*elasticache.DeleteReplicationGroupOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "DeleteReplicationGroup"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	id := aws.ToString(params.ReplicationGroupId)
+	stored, err := f.availableReplicationGroup(id)
+	if err != nil {
+		return nil, operationError(operation, err)
+	}
+
+	if name := params.FinalSnapshotIdentifier; name != nil {
+		if _, ok := f.snapshots[*name]; ok {
+			return nil, operationError(operation, &types.SnapshotAlreadyExistsFault{Message: aws.String("Snapshot " + *name + " already exists.")})
+		}
+		f.putSnapshot(f.replicationGroupSnapshot(*name, stored, "manual"))
+	}
+
+	stored.group.Status = aws.String(statusDeleting)
+	return &elasticache.DeleteReplicationGroupOutput{ReplicationGroup: f.describeReplicationGroup(id)}, nil
+}
+
+// tickReplicationGroups moves every replication group on to its next stable
+// state. It must be called with f.mu held.
+func (f *ElastiCache) tickReplicationGroups() {
+	for id, stored := range f.replicationGroups {
+		group := &stored.group
+		switch aws.ToString(group.Status) {
+		case statusCreating:
+			group.Status = aws.String(statusAvailable)
+			f.layoutReplicationGroup(stored, true)
+		case statusModifying:
+			if stored.applyPending {
+				f.applyReplicationGroupChanges(stored)
+				stored.applyPending = false
+			}
+			group.Status = aws.String(statusAvailable)
+			f.layoutReplicationGroup(stored, true)
+		case statusDeleting:
+			delete(f.tags, aws.ToString(group.ARN))
+			delete(f.replicationGroups, id)
+		}
+	}
+}
+
+// applyReplicationGroupChanges applies and clears the pending modifications of
+// a replication group.
+func (f *ElastiCache) applyReplicationGroupChanges(stored *replicationGroup) {
+	group := &stored.group
+	changes := stored.pending
+	stored.pending = replicationGroupChanges{}
+
+	if changes.cacheNodeType != nil {
+		group.CacheNodeType = changes.cacheNodeType
+	}
+	if changes.engineVersion != nil {
+		stored.engineVersion = *changes.engineVersion
+	}
+	if changes.numNodeGroups != nil {
+		stored.numNodeGroups = *changes.numNodeGroups
+	}
+	if changes.replicas != nil {
+		stored.replicas = *changes.replicas
+	}
+
+	pending := group.PendingModifiedValues
+	group.PendingModifiedValues = nil
+	if pending == nil {
+		return
+	}
+	switch pending.AutomaticFailoverStatus {
+	case types.PendingAutomaticFailoverStatusEnabled:
+		group.AutomaticFailover = types.AutomaticFailoverStatusEnabled
+	case types.PendingAutomaticFailoverStatusDisabled:
+		group.AutomaticFailover = types.AutomaticFailoverStatusDisabled
+	}
+	if pending.AuthTokenStatus != "" {
+		group.AuthTokenEnabled = aws.Bool(true)
+		now := time.Now()
+		group.AuthTokenLastModifiedDate = &now
+	}
+	if pending.UserGroups != nil {
+		group.UserGroupIds = stringsWithout(group.UserGroupIds, pending.UserGroups.UserGroupIdsToRemove)
+		group.UserGroupIds = append(stringsWithout(group.UserGroupIds, pending.UserGroups.UserGroupIdsToAdd), pending.UserGroups.UserGroupIdsToAdd...)
+	}
+}
+
+func (f *ElastiCache) setPendingReplicas(stored *replicationGroup, count int32, applyImmediately bool) {
+	stored.pending.replicas = aws.Int32(count)
+	if applyImmediately {
+		stored.group.Status = aws.String(statusModifying)
+		stored.applyPending = true
+	}
+}
+
+// layoutReplicationGroup rebuilds the node groups, member clusters and
+// endpoints of a replication group from its node group and replica counts.
+// Endpoints are only assigned once the group is available.
+func (f *ElastiCache) layoutReplicationGroup(stored *replicationGroup, available bool) {
+	group := &stored.group
+	id := aws.ToString(group.ReplicationGroupId)
+	clusterEnabled := aws.ToBool(group.ClusterEnabled)
+	status := statusCreating
+	if available {
+		status = statusAvailable
+	}
+
+	group.NodeGroups = nil
+	group.MemberClusters = nil
+	group.ConfigurationEndpoint = nil
+	const slots = 16384
+	for i := int32(0); i < stored.numNodeGroups; i++ {
+		nodeGroupId := fmt.Sprintf("%04d", i+1)
+		nodeGroup := types.NodeGroup{
+			NodeGroupId: aws.String(nodeGroupId),
+			Status:      aws.String(status),
+		}
+		if clusterEnabled {
+			first := slots * i / stored.numNodeGroups
+			last := slots*(i+1)/stored.numNodeGroups - 1
+			nodeGroup.Slots = aws.String(fmt.Sprintf("%d-%d", first, last))
+		} else if available {
+			nodeGroup.PrimaryEndpoint = f.endpoint("master."+id, stored.port)
+			nodeGroup.ReaderEndpoint = f.endpoint("replica."+id, stored.port)
+		}
+
+		for j := int32(0); j <= stored.replicas; j++ {
+			clusterId := fmt.Sprintf("%s-%03d", id, j+1)
+			if clusterEnabled {
+				clusterId = fmt.Sprintf("%s-%s-%03d", id, nodeGroupId, j+1)
+			}
+			role := "replica"
+			if j == 0 {
+				role = "primary"
+			}
+			member := types.NodeGroupMember{
+				CacheClusterId:            aws.String(clusterId),
+				CacheNodeId:               aws.String("0001"),
+				PreferredAvailabilityZone: aws.String(f.Region + string(rune('a'+j%3))),
+			}
+			if !clusterEnabled {
+				member.CurrentRole = aws.String(role)
+			}
+			if available {
+				member.ReadEndpoint = f.endpoint(clusterId+".0001", stored.port)
+			}
+			nodeGroup.NodeGroupMembers = append(nodeGroup.NodeGroupMembers, member)
+			group.MemberClusters = append(group.MemberClusters, clusterId)
+		}
+		group.NodeGroups = append(group.NodeGroups, nodeGroup)
+	}
+
+	if clusterEnabled && available {
+		group.ConfigurationEndpoint = f.endpoint("clustercfg."+id, stored.port)
+	}
+}
+
+// availableReplicationGroup returns the replication group that can be changed.
+// It must be called with f.mu held.
+func (f *ElastiCache) availableReplicationGroup(id string) (*replicationGroup, error) {
+	stored, ok := f.replicationGroups[id]
+	if !ok {
+		return nil, replicationGroupNotFound(id)
+	}
+	if status := aws.ToString(stored.group.Status); status != statusAvailable {
+		return nil, &types.InvalidReplicationGroupStateFault{
+			Message: aws.String(fmt.Sprintf("Replication group %s is not in available state, it is %s.", id, status)),
+		}
+	}
+	return stored, nil
+}
+
+// describeReplicationGroup returns a copy of the stored replication group. It
+// must be called with f.mu held.
+func (f *ElastiCache) describeReplicationGroup(id string) *types.ReplicationGroup {
+	group := &types.ReplicationGroup{}
+	clone(f.replicationGroups[id].group, group)
+	return group
+}
+
+// describeMemberCluster returns the member cluster with the given identifier
+// of any replication group. It must be called with f.mu held.
+func (f *ElastiCache) describeMemberCluster(clusterId string) (*types.CacheCluster, bool) {
+	for _, stored := range f.replicationGroups {
+		for _, member := range stored.group.MemberClusters {
+			if member != clusterId {
+				continue
+			}
+			cluster := &types.CacheCluster{
+				ARN:                        f.arn("cluster", clusterId),
+				AutoMinorVersionUpgrade:    stored.autoMinorVersionUpgrade,
+				CacheClusterId:             aws.String(clusterId),
+				CacheClusterStatus:         stored.group.Status,
+				CacheNodeType:              stored.group.CacheNodeType,
+				CacheSecurityGroups:        cacheSecurityGroupMemberships(stored.cacheSecurityGroupNames),
+				CacheSubnetGroupName:       aws.String(stored.subnetGroupName),
+				Engine:                     aws.String(engineRedis),
+				EngineVersion:              aws.String(stored.engineVersion),
+				NumCacheNodes:              aws.Int32(1),
+				PreferredMaintenanceWindow: aws.String(stored.maintenanceWindow),
+				ReplicationGroupId:         stored.group.ReplicationGroupId,
+				SecurityGroups:             securityGroupMemberships(stored.securityGroupIds),
+				SnapshotRetentionLimit:     stored.group.SnapshotRetentionLimit,
+				SnapshotWindow:             stored.group.SnapshotWindow,
+			}
+			parameterGroupName := stored.parameterGroupName
+			if parameterGroupName == "" {
+				parameterGroupName = "default." + parameterGroupFamily(engineRedis, stored.engineVersion)
+			}
+			cluster.CacheParameterGroup = &types.CacheParameterGroupStatus{
+				CacheParameterGroupName: aws.String(parameterGroupName),
+				ParameterApplyStatus:    aws.String("in-sync"),
+			}
+			if stored.notificationTopicArn != nil {
+				cluster.NotificationConfiguration = &types.NotificationConfiguration{
+					TopicArn:    stored.notificationTopicArn,
+					TopicStatus: aws.String(statusActive),
+				}
+			}
+			return cluster, true
+		}
+	}
+	return nil, false
+}
+
+func replicationGroupNotFound(id string) error {
+	return &types.ReplicationGroupNotFoundFault{Message: aws.String("Replication group " + id + " not found.")}
+}
+
+func automaticFailoverStatus(enabled bool) types.AutomaticFailoverStatus {
+	if enabled {
+		return types.AutomaticFailoverStatusEnabled
+	}
+	return types.AutomaticFailoverStatusDisabled
+}
+
+func multiAZStatus(enabled bool) types.MultiAZStatus {
+	if enabled {
+		return types.MultiAZStatusEnabled
+	}
+	return types.MultiAZStatusDisabled
+}
+
+// stringsWithout returns values without the entries of remove.
+func stringsWithout(values []string, remove []string) []string {
+	removed := map[string]bool{}
+	for _, value := range remove {
+		removed[value] = true
+	}
+	result := []string{}
+	for _, value := range values {
+		if !removed[value] {
+			result = append(result, value)
+		}
+	}
+	return result
+}
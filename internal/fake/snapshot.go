@@ -0,0 +1,330 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+)
+
+// Snapshot states reported by ElastiCache besides the common ones.
+const (
+	statusExporting = "exporting"
+	statusCopying   = "copying"
+)
+
+// CreateSnapshot implements the CreateSnapshot operation for redis cache
+// clusters and replication groups.
+func (f *ElastiCache) CreateSnapshot(_ context.Context, params *elasticache.CreateSnapshotInput, _ ...func(*elasticache.Options)) (*elasticache.CreateSnapshotOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "CreateSnapshot"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	name := aws.ToString(params.SnapshotName)
+	switch {
+	case name == "":
+		return nil, operationError(operation, invalidParameterValue("The parameter SnapshotName must be provided."))
+	case (params.CacheClusterId == nil) == (params.ReplicationGroupId == nil):
+		return nil, operationError(operation, invalidParameterCombination("Exactly one of CacheClusterId and ReplicationGroupId must be provided."))
+	}
+	if _, ok := f.snapshots[name]; ok {
+		return nil, operationError(operation, &types.SnapshotAlreadyExistsFault{Message: aws.String("Snapshot " + name + " already exists.")})
+	}
+
+	var snapshot *types.Snapshot
+	if id := params.CacheClusterId; id != nil {
+		stored, ok := f.cacheClusters[*id]
+		if !ok {
+			return nil, operationError(operation, cacheClusterNotFound(*id))
+		}
+		cluster := &stored.cluster
+		switch {
+		case aws.ToString(cluster.Engine) != engineRedis:
+			return nil, operationError(operation, &types.SnapshotFeatureNotSupportedFault{Message: aws.String("Snapshots are only supported for redis.")})
+		case aws.ToString(cluster.CacheClusterStatus) != statusAvailable:
+			return nil, operationError(operation, &types.InvalidCacheClusterStateFault{Message: aws.String("Cache cluster " + *id + " is not in available state.")})
+		}
+		snapshot = f.clusterSnapshot(name, cluster, "manual")
+	} else {
+		stored, err := f.availableReplicationGroup(*params.ReplicationGroupId)
+		if err != nil {
+			return nil, operationError(operation, err)
+		}
+		snapshot = f.replicationGroupSnapshot(name, stored, "manual")
+	}
+	if params.KmsKeyId != nil {
+		snapshot.KmsKeyId = params.KmsKeyId
+	}
+
+	f.putSnapshot(snapshot)
+	f.tags[*snapshot.ARN] = cloneTags(params.Tags)
+	return &elasticache.CreateSnapshotOutput{Snapshot: f.describeSnapshot(name)}, nil
+}
+
+// DescribeSnapshots implements the DescribeSnapshots operation. All matching
+// snapshots are returned in a single page.
+func (f *ElastiCache) DescribeSnapshots(_ context.Context, params *elasticache.DescribeSnapshotsInput, _ ...func(*elasticache.Options)) (*elasticache.DescribeSnapshotsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "DescribeSnapshots"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	if name := params.SnapshotName; name != nil {
+		if _, ok := f.snapshots[*name]; !ok {
+			return nil, operationError(operation, snapshotNotFound(*name))
+		}
+	}
+
+	names := make([]string, 0, len(f.snapshots))
+	for name, snapshot := range f.snapshots {
+		switch {
+		case params.SnapshotName != nil && name != *params.SnapshotName,
+			params.CacheClusterId != nil && aws.ToString(snapshot.CacheClusterId) != *params.CacheClusterId,
+			params.ReplicationGroupId != nil && aws.ToString(snapshot.ReplicationGroupId) != *params.ReplicationGroupId,
+			params.SnapshotSource != nil && aws.ToString(snapshot.SnapshotSource) != *params.SnapshotSource:
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	output := &elasticache.DescribeSnapshotsOutput{Snapshots: []types.Snapshot{}}
+	for _, name := range names {
+		output.Snapshots = append(output.Snapshots, *f.describeSnapshot(name))
+	}
+	return output, nil
+}
+
+// CopySnapshot implements the CopySnapshot operation. Copies into an Amazon S3
+// bucket are not kept, the source snapshot is reported as exporting until the
+// next Tick instead.
+func (f *ElastiCache) CopySnapshot(_ context.Context, params *elasticache.CopySnapshotInput, _ ...func(*elasticache.Options)) (*elasticache.CopySnapshotOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "CopySnapshot"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	sourceName := aws.ToString(params.SourceSnapshotName)
+	targetName := aws.ToString(params.TargetSnapshotName)
+	source, err := f.availableSnapshot(sourceName)
+	if err != nil {
+		return nil, operationError(operation, err)
+	}
+	if targetName == "" {
+		return nil, operationError(operation, invalidParameterValue("The parameter TargetSnapshotName must be provided."))
+	}
+
+	if params.TargetBucket != nil {
+		source.SnapshotStatus = aws.String(statusExporting)
+		copied := f.describeSnapshot(sourceName)
+		copied.SnapshotName = aws.String(targetName)
+		return &elasticache.CopySnapshotOutput{Snapshot: copied}, nil
+	}
+
+	if _, ok := f.snapshots[targetName]; ok {
+		return nil, operationError(operation, &types.SnapshotAlreadyExistsFault{Message: aws.String("Snapshot " + targetName + " already exists.")})
+	}
+	target := &types.Snapshot{}
+	clone(source, target)
+	target.ARN = f.arn("snapshot", targetName)
+	target.SnapshotName = aws.String(targetName)
+	target.SnapshotSource = aws.String("manual")
+	target.SnapshotStatus = aws.String(statusCreating)
+	if params.KmsKeyId != nil {
+		target.KmsKeyId = params.KmsKeyId
+	}
+	source.SnapshotStatus = aws.String(statusCopying)
+
+	f.putSnapshot(target)
+	f.tags[*target.ARN] = cloneTags(params.Tags)
+	return &elasticache.CopySnapshotOutput{Snapshot: f.describeSnapshot(targetName)}, nil
+}
+
+// DeleteSnapshot implements the DeleteSnapshot operation. The snapshot is
+// removed on the next Tick.
+func (f *ElastiCache) DeleteSnapshot(_ context.Context, params *elasticache.DeleteSnapshotInput, _ ...func(*elasticache.Options)) (*elasticache.DeleteSnapshotOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "DeleteSnapshot"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	name := aws.ToString(params.SnapshotName)
+	snapshot, err := f.availableSnapshot(name)
+	if err != nil {
+		return nil, operationError(operation, err)
+	}
+	snapshot.SnapshotStatus = aws.String(statusDeleting)
+	return &elasticache.DeleteSnapshotOutput{Snapshot: f.describeSnapshot(name)}, nil
+}
+
+// tickSnapshots moves every snapshot on to its next stable state. It must be
+// called with f.mu held.
+func (f *ElastiCache) tickSnapshots() {
+	for name, snapshot := range f.snapshots {
+		switch aws.ToString(snapshot.SnapshotStatus) {
+		case statusCreating, statusCopying, statusExporting:
+			snapshot.SnapshotStatus = aws.String(statusAvailable)
+			now := time.Now()
+			for i := range snapshot.NodeSnapshots {
+				if snapshot.NodeSnapshots[i].SnapshotCreateTime == nil {
+					snapshot.NodeSnapshots[i].SnapshotCreateTime = &now
+				}
+			}
+		case statusDeleting:
+			delete(f.tags, aws.ToString(snapshot.ARN))
+			delete(f.snapshots, name)
+		}
+	}
+}
+
+// putSnapshot stores snapshot under its name. It must be called with f.mu
+// held.
+func (f *ElastiCache) putSnapshot(snapshot *types.Snapshot) {
+	f.snapshots[aws.ToString(snapshot.SnapshotName)] = snapshot
+	if _, ok := f.tags[aws.ToString(snapshot.ARN)]; !ok {
+		f.tags[aws.ToString(snapshot.ARN)] = []types.Tag{}
+	}
+}
+
+// clusterSnapshot returns a snapshot of a cache cluster that is being created.
+func (f *ElastiCache) clusterSnapshot(name string, cluster *types.CacheCluster, source string) *types.Snapshot {
+	snapshot := &types.Snapshot{
+		ARN:                        f.arn("snapshot", name),
+		CacheClusterCreateTime:     cluster.CacheClusterCreateTime,
+		CacheClusterId:             cluster.CacheClusterId,
+		CacheNodeType:              cluster.CacheNodeType,
+		CacheSubnetGroupName:       cluster.CacheSubnetGroupName,
+		Engine:                     cluster.Engine,
+		EngineVersion:              cluster.EngineVersion,
+		NumCacheNodes:              cluster.NumCacheNodes,
+		PreferredAvailabilityZone:  cluster.PreferredAvailabilityZone,
+		PreferredMaintenanceWindow: cluster.PreferredMaintenanceWindow,
+		SnapshotName:               aws.String(name),
+		SnapshotRetentionLimit:     cluster.SnapshotRetentionLimit,
+		SnapshotSource:             aws.String(source),
+		SnapshotStatus:             aws.String(statusCreating),
+		SnapshotWindow:             cluster.SnapshotWindow,
+		VpcId:                      aws.String(fakeVpcId),
+	}
+	if group := cluster.CacheParameterGroup; group != nil {
+		snapshot.CacheParameterGroupName = group.CacheParameterGroupName
+	}
+	for _, node := range cluster.CacheNodes {
+		snapshot.NodeSnapshots = append(snapshot.NodeSnapshots, types.NodeSnapshot{
+			CacheClusterId:      cluster.CacheClusterId,
+			CacheNodeCreateTime: node.CacheNodeCreateTime,
+			CacheNodeId:         node.CacheNodeId,
+			CacheSize:           aws.String("0 MB"),
+		})
+	}
+	if len(cluster.CacheNodes) > 0 && cluster.CacheNodes[0].Endpoint != nil {
+		snapshot.Port = aws.Int32(cluster.CacheNodes[0].Endpoint.Port)
+	}
+	return snapshot
+}
+
+// replicationGroupSnapshot returns a snapshot of a replication group that is
+// being created, with one node snapshot per node group.
+func (f *ElastiCache) replicationGroupSnapshot(name string, stored *replicationGroup, source string) *types.Snapshot {
+	group := &stored.group
+	snapshot := &types.Snapshot{
+		ARN:                         f.arn("snapshot", name),
+		AutomaticFailover:           group.AutomaticFailover,
+		CacheNodeType:               group.CacheNodeType,
+		Engine:                      aws.String(engineRedis),
+		EngineVersion:               aws.String(stored.engineVersion),
+		KmsKeyId:                    group.KmsKeyId,
+		NumNodeGroups:               aws.Int32(stored.numNodeGroups),
+		Port:                        aws.Int32(stored.port),
+		ReplicationGroupDescription: group.Description,
+		ReplicationGroupId:          group.ReplicationGroupId,
+		SnapshotName:                aws.String(name),
+		SnapshotRetentionLimit:      group.SnapshotRetentionLimit,
+		SnapshotSource:              aws.String(source),
+		SnapshotStatus:              aws.String(statusCreating),
+		SnapshotWindow:              group.SnapshotWindow,
+		VpcId:                       aws.String(fakeVpcId),
+	}
+	if stored.parameterGroupName != "" {
+		snapshot.CacheParameterGroupName = aws.String(stored.parameterGroupName)
+	}
+	if stored.subnetGroupName != "" {
+		snapshot.CacheSubnetGroupName = aws.String(stored.subnetGroupName)
+	}
+	for _, nodeGroup := range group.NodeGroups {
+		if len(nodeGroup.NodeGroupMembers) == 0 {
+			continue
+		}
+		member := nodeGroup.NodeGroupMembers[0]
+		snapshot.NodeSnapshots = append(snapshot.NodeSnapshots, types.NodeSnapshot{
+			CacheClusterId: member.CacheClusterId,
+			CacheNodeId:    member.CacheNodeId,
+			CacheSize:      aws.String("0 MB"),
+			NodeGroupId:    nodeGroup.NodeGroupId,
+			NodeGroupConfiguration: &types.NodeGroupConfiguration{
+				NodeGroupId:  nodeGroup.NodeGroupId,
+				ReplicaCount: aws.Int32(stored.replicas),
+				Slots:        nodeGroup.Slots,
+			},
+		})
+	}
+	return snapshot
+}
+
+// availableSnapshot returns the snapshot that can be copied or deleted. It
+// must be called with f.mu held.
+func (f *ElastiCache) availableSnapshot(name string) (*types.Snapshot, error) {
+	snapshot, ok := f.snapshots[name]
+	if !ok {
+		return nil, snapshotNotFound(name)
+	}
+	if status := aws.ToString(snapshot.SnapshotStatus); status != statusAvailable {
+		return nil, &types.InvalidSnapshotStateFault{Message: aws.String(fmt.Sprintf("Snapshot %s is not in available state, it is %s.", name, status))}
+	}
+	return snapshot, nil
+}
+
+// describeSnapshot returns a copy of the stored snapshot. It must be called
+// with f.mu held.
+func (f *ElastiCache) describeSnapshot(name string) *types.Snapshot {
+	snapshot := &types.Snapshot{}
+	clone(f.snapshots[name], snapshot)
+	return snapshot
+}
+
+func snapshotNotFound(name string) error {
+	return &types.SnapshotNotFoundFault{Message: aws.String("Snapshot " + name + " not found.")}
+}
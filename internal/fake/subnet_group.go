@@ -0,0 +1,201 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+)
+
+// fakeVpcId is the VPC every subnet of the fake belongs to.
+const fakeVpcId = "vpc-0123456789abcdef0"
+
+// CreateCacheSubnetGroup implements the CreateCacheSubnetGroup operation. Any
+// subnet identifier starting with "subnet-" is accepted, subnets are spread
+// over the availability zones of the region in order.
+func (f *ElastiCache) CreateCacheSubnetGroup(_ context.Context, params *elasticache.CreateCacheSubnetGroupInput, _ ...func(*elasticache.Options)) (*elasticache.CreateCacheSubnetGroupOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "CreateCacheSubnetGroup"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	name := aws.ToString(params.CacheSubnetGroupName)
+	switch {
+	case name == "":
+		return nil, operationError(operation, invalidParameterValue("The parameter CacheSubnetGroupName must be provided."))
+	case params.CacheSubnetGroupDescription == nil:
+		return nil, operationError(operation, invalidParameterValue("The parameter CacheSubnetGroupDescription must be provided."))
+	}
+	if _, ok := f.subnetGroups[name]; ok {
+		return nil, operationError(operation, &types.CacheSubnetGroupAlreadyExistsFault{Message: aws.String("Cache subnet group " + name + " already exists.")})
+	}
+	subnets, err := f.subnets(params.SubnetIds)
+	if err != nil {
+		return nil, operationError(operation, err)
+	}
+
+	group := &types.CacheSubnetGroup{
+		ARN:                         f.arn("subnetgroup", name),
+		CacheSubnetGroupDescription: params.CacheSubnetGroupDescription,
+		CacheSubnetGroupName:        aws.String(name),
+		Subnets:                     subnets,
+		VpcId:                       aws.String(fakeVpcId),
+	}
+	f.subnetGroups[name] = group
+	f.tags[*group.ARN] = cloneTags(params.Tags)
+
+	return &elasticache.CreateCacheSubnetGroupOutput{CacheSubnetGroup: f.describeSubnetGroup(name)}, nil
+}
+
+// DescribeCacheSubnetGroups implements the DescribeCacheSubnetGroups
+// operation. All groups are returned in a single page.
+func (f *ElastiCache) DescribeCacheSubnetGroups(_ context.Context, params *elasticache.DescribeCacheSubnetGroupsInput, _ ...func(*elasticache.Options)) (*elasticache.DescribeCacheSubnetGroupsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "DescribeCacheSubnetGroups"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	if name := params.CacheSubnetGroupName; name != nil {
+		if _, ok := f.subnetGroups[*name]; !ok {
+			return nil, operationError(operation, subnetGroupNotFound(*name))
+		}
+		return &elasticache.DescribeCacheSubnetGroupsOutput{
+			CacheSubnetGroups: []types.CacheSubnetGroup{*f.describeSubnetGroup(*name)},
+		}, nil
+	}
+
+	names := make([]string, 0, len(f.subnetGroups))
+	for name := range f.subnetGroups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	output := &elasticache.DescribeCacheSubnetGroupsOutput{CacheSubnetGroups: []types.CacheSubnetGroup{}}
+	for _, name := range names {
+		output.CacheSubnetGroups = append(output.CacheSubnetGroups, *f.describeSubnetGroup(name))
+	}
+	return output, nil
+}
+
+// ModifyCacheSubnetGroup implements the ModifyCacheSubnetGroup operation.
+func (f *ElastiCache) ModifyCacheSubnetGroup(_ context.Context, params *elasticache.ModifyCacheSubnetGroupInput, _ ...func(*elasticache.Options)) (*elasticache.ModifyCacheSubnetGroupOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "ModifyCacheSubnetGroup"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	name := aws.ToString(params.CacheSubnetGroupName)
+	group, ok := f.subnetGroups[name]
+	if !ok {
+		return nil, operationError(operation, subnetGroupNotFound(name))
+	}
+	if params.SubnetIds != nil {
+		subnets, err := f.subnets(params.SubnetIds)
+		if err != nil {
+			return nil, operationError(operation, err)
+		}
+		group.Subnets = subnets
+	}
+	if params.CacheSubnetGroupDescription != nil {
+		group.CacheSubnetGroupDescription = params.CacheSubnetGroupDescription
+	}
+
+	return &elasticache.ModifyCacheSubnetGroupOutput{CacheSubnetGroup: f.describeSubnetGroup(name)}, nil
+}
+
+// DeleteCacheSubnetGroup implements the DeleteCacheSubnetGroup operation.
+// Groups used by a cache cluster or replication group can not be deleted.
+func (f *ElastiCache) DeleteCacheSubnetGroup(_ context.Context, params *elasticache.DeleteCacheSubnetGroupInput, _ ...func(*elasticache.Options)) (*elasticache.DeleteCacheSubnetGroupOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "DeleteCacheSubnetGroup"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	name := aws.ToString(params.CacheSubnetGroupName)
+	group, ok := f.subnetGroups[name]
+	if !ok {
+		return nil, operationError(operation, subnetGroupNotFound(name))
+	}
+	if f.subnetGroupInUse(name) {
+		return nil, operationError(operation, &types.CacheSubnetGroupInUse{Message: aws.String("Cache subnet group " + name + " is in use.")})
+	}
+
+	delete(f.tags, aws.ToString(group.ARN))
+	delete(f.subnetGroups, name)
+	return &elasticache.DeleteCacheSubnetGroupOutput{}, nil
+}
+
+// subnets returns the subnets with the given identifiers.
+func (f *ElastiCache) subnets(subnetIds []string) ([]types.Subnet, error) {
+	if len(subnetIds) == 0 {
+		return nil, invalidParameterValue("At least one subnet must be provided.")
+	}
+	var subnets []types.Subnet
+	for i, subnetId := range subnetIds {
+		if !strings.HasPrefix(subnetId, "subnet-") {
+			return nil, &types.InvalidSubnet{Message: aws.String("The subnet identifier " + subnetId + " is invalid.")}
+		}
+		subnets = append(subnets, types.Subnet{
+			SubnetAvailabilityZone: &types.AvailabilityZone{Name: aws.String(f.Region + string(rune('a'+i%3)))},
+			SubnetIdentifier:       aws.String(subnetId),
+		})
+	}
+	return subnets, nil
+}
+
+func (f *ElastiCache) subnetGroupInUse(name string) bool {
+	for _, stored := range f.cacheClusters {
+		if aws.ToString(stored.cluster.CacheSubnetGroupName) == name {
+			return true
+		}
+	}
+	for _, stored := range f.replicationGroups {
+		if stored.subnetGroupName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// describeSubnetGroup returns a copy of the stored subnet group. It must be
+// called with f.mu held.
+func (f *ElastiCache) describeSubnetGroup(name string) *types.CacheSubnetGroup {
+	group := &types.CacheSubnetGroup{}
+	clone(f.subnetGroups[name], group)
+	return group
+}
+
+func subnetGroupNotFound(name string) error {
+	return &types.CacheSubnetGroupNotFoundFault{Message: aws.String("Cache subnet group " + name + " not found.")}
+}
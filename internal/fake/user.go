@@ -0,0 +1,506 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+)
+
+type userGroup struct {
+	group types.UserGroup
+}
+
+// CreateUser implements the CreateUser operation.
+func (f *ElastiCache) CreateUser(_ context.Context, params *elasticache.CreateUserInput, _ ...func(*elasticache.Options)) (*elasticache.CreateUserOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "CreateUser"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	id := aws.ToString(params.UserId)
+	switch {
+	case id == "":
+		return nil, operationError(operation, invalidParameterValue("The parameter UserId must be provided."))
+	case aws.ToString(params.UserName) == "":
+		return nil, operationError(operation, invalidParameterValue("The parameter UserName must be provided."))
+	case aws.ToString(params.Engine) != engineRedis:
+		return nil, operationError(operation, invalidParameterValue("Users only support the redis engine."))
+	case params.AccessString == nil:
+		return nil, operationError(operation, invalidParameterValue("The parameter AccessString must be provided."))
+	}
+	if _, ok := f.users[id]; ok {
+		return nil, operationError(operation, &types.UserAlreadyExistsFault{Message: aws.String("User " + id + " already exists.")})
+	}
+	authentication, err := userAuthentication(params.NoPasswordRequired, params.Passwords)
+	if err != nil {
+		return nil, operationError(operation, err)
+	}
+
+	user := &types.User{
+		ARN:            f.arn("user", id),
+		AccessString:   aws.String(normalizeAccessString(*params.AccessString)),
+		Authentication: authentication,
+		Engine:         params.Engine,
+		Status:         aws.String(statusCreating),
+		UserGroupIds:   []string{},
+		UserId:         aws.String(id),
+		UserName:       params.UserName,
+	}
+	f.users[id] = user
+	f.tags[*user.ARN] = cloneTags(params.Tags)
+
+	described := f.describeUser(id)
+	return &elasticache.CreateUserOutput{
+		ARN:            described.ARN,
+		AccessString:   described.AccessString,
+		Authentication: described.Authentication,
+		Engine:         described.Engine,
+		Status:         described.Status,
+		UserGroupIds:   described.UserGroupIds,
+		UserId:         described.UserId,
+		UserName:       described.UserName,
+	}, nil
+}
+
+// DescribeUsers implements the DescribeUsers operation. All users are returned
+// in a single page.
+func (f *ElastiCache) DescribeUsers(_ context.Context, params *elasticache.DescribeUsersInput, _ ...func(*elasticache.Options)) (*elasticache.DescribeUsersOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "DescribeUsers"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	if id := params.UserId; id != nil {
+		if _, ok := f.users[*id]; !ok {
+			return nil, operationError(operation, userNotFound(*id))
+		}
+		return &elasticache.DescribeUsersOutput{Users: []types.User{*f.describeUser(*id)}}, nil
+	}
+
+	ids := make([]string, 0, len(f.users))
+	for id := range f.users {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	output := &elasticache.DescribeUsersOutput{Users: []types.User{}}
+	for _, id := range ids {
+		output.Users = append(output.Users, *f.describeUser(id))
+	}
+	return output, nil
+}
+
+// ModifyUser implements the ModifyUser operation.
+func (f *ElastiCache) ModifyUser(_ context.Context, params *elasticache.ModifyUserInput, _ ...func(*elasticache.Options)) (*elasticache.ModifyUserOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "ModifyUser"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	id := aws.ToString(params.UserId)
+	user, err := f.activeUser(id)
+	if err != nil {
+		return nil, operationError(operation, err)
+	}
+	if params.AccessString != nil && params.AppendAccessString != nil {
+		return nil, operationError(operation, invalidParameterCombination("AccessString and AppendAccessString can not be used together."))
+	}
+	if params.NoPasswordRequired != nil || params.Passwords != nil {
+		authentication, err := userAuthentication(params.NoPasswordRequired, params.Passwords)
+		if err != nil {
+			return nil, operationError(operation, err)
+		}
+		user.Authentication = authentication
+	}
+	if params.AccessString != nil {
+		user.AccessString = aws.String(normalizeAccessString(*params.AccessString))
+	}
+	if params.AppendAccessString != nil {
+		user.AccessString = aws.String(normalizeAccessString(aws.ToString(user.AccessString) + " " + *params.AppendAccessString))
+	}
+	user.Status = aws.String(statusModifying)
+
+	described := f.describeUser(id)
+	return &elasticache.ModifyUserOutput{
+		ARN:            described.ARN,
+		AccessString:   described.AccessString,
+		Authentication: described.Authentication,
+		Engine:         described.Engine,
+		Status:         described.Status,
+		UserGroupIds:   described.UserGroupIds,
+		UserId:         described.UserId,
+		UserName:       described.UserName,
+	}, nil
+}
+
+// DeleteUser implements the DeleteUser operation. The user is removed from its
+// user groups on the next Tick.
+func (f *ElastiCache) DeleteUser(_ context.Context, params *elasticache.DeleteUserInput, _ ...func(*elasticache.Options)) (*elasticache.DeleteUserOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "DeleteUser"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	id := aws.ToString(params.UserId)
+	user, err := f.activeUser(id)
+	if err != nil {
+		return nil, operationError(operation, err)
+	}
+	user.Status = aws.String(statusDeleting)
+
+	described := f.describeUser(id)
+	return &elasticache.DeleteUserOutput{
+		ARN:            described.ARN,
+		AccessString:   described.AccessString,
+		Authentication: described.Authentication,
+		Engine:         described.Engine,
+		Status:         described.Status,
+		UserGroupIds:   described.UserGroupIds,
+		UserId:         described.UserId,
+		UserName:       described.UserName,
+	}, nil
+}
+
+// CreateUserGroup implements the CreateUserGroup operation.
+func (f *ElastiCache) CreateUserGroup(_ context.Context, params *elasticache.CreateUserGroupInput, _ ...func(*elasticache.Options)) (*elasticache.CreateUserGroupOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "CreateUserGroup"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	id := aws.ToString(params.UserGroupId)
+	switch {
+	case id == "":
+		return nil, operationError(operation, invalidParameterValue("The parameter UserGroupId must be provided."))
+	case aws.ToString(params.Engine) != engineRedis:
+		return nil, operationError(operation, invalidParameterValue("User groups only support the redis engine."))
+	}
+	if _, ok := f.userGroups[id]; ok {
+		return nil, operationError(operation, &types.UserGroupAlreadyExistsFault{Message: aws.String("User group " + id + " already exists.")})
+	}
+	for _, userId := range params.UserIds {
+		if _, ok := f.users[userId]; !ok {
+			return nil, operationError(operation, userNotFound(userId))
+		}
+	}
+
+	stored := &userGroup{
+		group: types.UserGroup{
+			ARN:         f.arn("usergroup", id),
+			Engine:      params.Engine,
+			Status:      aws.String(statusCreating),
+			UserGroupId: aws.String(id),
+			UserIds:     append([]string{}, params.UserIds...),
+		},
+	}
+	f.userGroups[id] = stored
+	f.tags[*stored.group.ARN] = cloneTags(params.Tags)
+
+	described := f.describeUserGroup(id)
+	return &elasticache.CreateUserGroupOutput{
+		ARN:               described.ARN,
+		Engine:            described.Engine,
+		PendingChanges:    described.PendingChanges,
+		ReplicationGroups: described.ReplicationGroups,
+		Status:            described.Status,
+		UserGroupId:       described.UserGroupId,
+		UserIds:           described.UserIds,
+	}, nil
+}
+
+// DescribeUserGroups implements the DescribeUserGroups operation. All user
+// groups are returned in a single page.
+func (f *ElastiCache) DescribeUserGroups(_ context.Context, params *elasticache.DescribeUserGroupsInput, _ ...func(*elasticache.Options)) (*elasticache.DescribeUserGroupsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "DescribeUserGroups"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	if id := params.UserGroupId; id != nil {
+		if _, ok := f.userGroups[*id]; !ok {
+			return nil, operationError(operation, userGroupNotFound(*id))
+		}
+		return &elasticache.DescribeUserGroupsOutput{UserGroups: []types.UserGroup{*f.describeUserGroup(*id)}}, nil
+	}
+
+	ids := make([]string, 0, len(f.userGroups))
+	for id := range f.userGroups {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	output := &elasticache.DescribeUserGroupsOutput{UserGroups: []types.UserGroup{}}
+	for _, id := range ids {
+		output.UserGroups = append(output.UserGroups, *f.describeUserGroup(id))
+	}
+	return output, nil
+}
+
+// ModifyUserGroup implements the ModifyUserGroup operation. The users are added
+// and removed on the next Tick.
+func (f *ElastiCache) ModifyUserGroup(_ context.Context, params *elasticache.ModifyUserGroupInput, _ ...func(*elasticache.Options)) (*elasticache.ModifyUserGroupOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "ModifyUserGroup"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	id := aws.ToString(params.UserGroupId)
+	stored, err := f.activeUserGroup(id)
+	if err != nil {
+		return nil, operationError(operation, err)
+	}
+	if len(params.UserIdsToAdd) == 0 && len(params.UserIdsToRemove) == 0 {
+		return nil, operationError(operation, invalidParameterValue("Either UserIdsToAdd or UserIdsToRemove must be provided."))
+	}
+	for _, userId := range params.UserIdsToAdd {
+		if _, ok := f.users[userId]; !ok {
+			return nil, operationError(operation, userNotFound(userId))
+		}
+	}
+
+	stored.group.PendingChanges = &types.UserGroupPendingChanges{
+		UserIdsToAdd:    params.UserIdsToAdd,
+		UserIdsToRemove: params.UserIdsToRemove,
+	}
+	stored.group.Status = aws.String(statusModifying)
+
+	described := f.describeUserGroup(id)
+	return &elasticache.ModifyUserGroupOutput{
+		ARN:               described.ARN,
+		Engine:            described.Engine,
+		PendingChanges:    described.PendingChanges,
+		ReplicationGroups: described.ReplicationGroups,
+		Status:            described.Status,
+		UserGroupId:       described.UserGroupId,
+		UserIds:           described.UserIds,
+	}, nil
+}
+
+// DeleteUserGroup implements the DeleteUserGroup operation. User groups
+// associated with a replication group can not be deleted.
+func (f *ElastiCache) DeleteUserGroup(_ context.Context, params *elasticache.DeleteUserGroupInput, _ ...func(*elasticache.Options)) (*elasticache.DeleteUserGroupOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	const operation = "DeleteUserGroup"
+	if err := f.call(operation); err != nil {
+		return nil, err
+	}
+
+	id := aws.ToString(params.UserGroupId)
+	stored, err := f.activeUserGroup(id)
+	if err != nil {
+		return nil, operationError(operation, err)
+	}
+	if replicationGroups := f.userGroupReplicationGroups(id); len(replicationGroups) > 0 {
+		return nil, operationError(operation, &types.InvalidUserGroupStateFault{
+			Message: aws.String(fmt.Sprintf("User group %s is associated with the replication groups %v.", id, replicationGroups)),
+		})
+	}
+	stored.group.Status = aws.String(statusDeleting)
+
+	described := f.describeUserGroup(id)
+	return &elasticache.DeleteUserGroupOutput{
+		ARN:               described.ARN,
+		Engine:            described.Engine,
+		PendingChanges:    described.PendingChanges,
+		ReplicationGroups: described.ReplicationGroups,
+		Status:            described.Status,
+		UserGroupId:       described.UserGroupId,
+		UserIds:           described.UserIds,
+	}, nil
+}
+
+// tickUsers moves every user and user group on to its next stable state. It
+// must be called with f.mu held.
+func (f *ElastiCache) tickUsers() {
+	for id, user := range f.users {
+		switch aws.ToString(user.Status) {
+		case statusCreating, statusModifying:
+			user.Status = aws.String(statusActive)
+		case statusDeleting:
+			for _, stored := range f.userGroups {
+				stored.group.UserIds = stringsWithout(stored.group.UserIds, []string{id})
+			}
+			delete(f.tags, aws.ToString(user.ARN))
+			delete(f.users, id)
+		}
+	}
+
+	for id, stored := range f.userGroups {
+		group := &stored.group
+		switch aws.ToString(group.Status) {
+		case statusCreating:
+			group.Status = aws.String(statusActive)
+		case statusModifying:
+			if pending := group.PendingChanges; pending != nil {
+				group.UserIds = stringsWithout(group.UserIds, pending.UserIdsToRemove)
+				group.UserIds = append(stringsWithout(group.UserIds, pending.UserIdsToAdd), pending.UserIdsToAdd...)
+				group.PendingChanges = nil
+			}
+			group.Status = aws.String(statusActive)
+		case statusDeleting:
+			delete(f.tags, aws.ToString(group.ARN))
+			delete(f.userGroups, id)
+		}
+	}
+}
+
+// userAuthentication returns the authentication of a user created or modified
+// with the given parameters.
+func userAuthentication(noPasswordRequired *bool, passwords []string) (*types.Authentication, error) {
+	switch {
+	case aws.ToBool(noPasswordRequired) && len(passwords) > 0:
+		return nil, invalidParameterCombination("NoPasswordRequired can not be used together with Passwords.")
+	case aws.ToBool(noPasswordRequired):
+		return &types.Authentication{Type: types.AuthenticationTypeNoPassword}, nil
+	case len(passwords) == 0:
+		return nil, invalidParameterValue("Either NoPasswordRequired or Passwords must be provided.")
+	case len(passwords) > 2:
+		return nil, invalidParameterValue("A user can have at most two passwords.")
+	}
+	for _, password := range passwords {
+		if len(password) < 16 || len(password) > 128 {
+			return nil, invalidParameterValue("Passwords must be between 16 and 128 characters long.")
+		}
+	}
+	return &types.Authentication{Type: types.AuthenticationTypePassword, PasswordCount: aws.Int32(int32(len(passwords)))}, nil
+}
+
+// activeUser returns the user that can be changed. It must be called with f.mu
+// held.
+// normalizeAccessString rewrites an access string the way ElastiCache reports
+// it: rules are separated by single spaces and users without a channel rule
+// are granted every channel ahead of their command rules.
+func normalizeAccessString(accessString string) string {
+	rules := strings.Fields(accessString)
+	for _, rule := range rules {
+		if strings.HasPrefix(rule, "&") || rule == "allchannels" || rule == "resetchannels" {
+			return strings.Join(rules, " ")
+		}
+	}
+
+	position := len(rules)
+	for i, rule := range rules {
+		if strings.HasPrefix(rule, "+") || strings.HasPrefix(rule, "-") {
+			position = i
+			break
+		}
+	}
+	normalized := append(append(append([]string{}, rules[:position]...), "&*"), rules[position:]...)
+	return strings.Join(normalized, " ")
+}
+
+func (f *ElastiCache) activeUser(id string) (*types.User, error) {
+	user, ok := f.users[id]
+	if !ok {
+		return nil, userNotFound(id)
+	}
+	if status := aws.ToString(user.Status); status != statusActive {
+		return nil, &types.InvalidUserStateFault{Message: aws.String(fmt.Sprintf("User %s is not in active state, it is %s.", id, status))}
+	}
+	return user, nil
+}
+
+// activeUserGroup returns the user group that can be changed. It must be
+// called with f.mu held.
+func (f *ElastiCache) activeUserGroup(id string) (*userGroup, error) {
+	stored, ok := f.userGroups[id]
+	if !ok {
+		return nil, userGroupNotFound(id)
+	}
+	if status := aws.ToString(stored.group.Status); status != statusActive {
+		return nil, &types.InvalidUserGroupStateFault{Message: aws.String(fmt.Sprintf("User group %s is not in active state, it is %s.", id, status))}
+	}
+	return stored, nil
+}
+
+// describeUser returns a copy of the stored user with the user groups it
+// belongs to. It must be called with f.mu held.
+func (f *ElastiCache) describeUser(id string) *types.User {
+	user := &types.User{}
+	clone(f.users[id], user)
+
+	user.UserGroupIds = []string{}
+	for groupId, stored := range f.userGroups {
+		for _, userId := range stored.group.UserIds {
+			if userId == id {
+				user.UserGroupIds = append(user.UserGroupIds, groupId)
+			}
+		}
+	}
+	sort.Strings(user.UserGroupIds)
+	return user
+}
+
+// describeUserGroup returns a copy of the stored user group with the
+// replication groups it is associated with. It must be called with f.mu held.
+func (f *ElastiCache) describeUserGroup(id string) *types.UserGroup {
+	group := &types.UserGroup{}
+	clone(f.userGroups[id].group, group)
+	group.ReplicationGroups = f.userGroupReplicationGroups(id)
+	return group
+}
+
+func (f *ElastiCache) userGroupReplicationGroups(id string) []string {
+	replicationGroups := []string{}
+	for replicationGroupId, stored := range f.replicationGroups {
+		for _, userGroupId := range stored.group.UserGroupIds {
+			if userGroupId == id {
+				replicationGroups = append(replicationGroups, replicationGroupId)
+			}
+		}
+	}
+	sort.Strings(replicationGroups)
+	return replicationGroups
+}
+
+func userNotFound(id string) error {
+	return &types.UserNotFoundFault{Message: aws.String("User " + id + " not found.")}
+}
+
+func userGroupNotFound(id string) error {
+	return &types.UserGroupNotFoundFault{Message: aws.String("User group " + id + " not found.")}
+}
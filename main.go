@@ -19,13 +19,18 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"os"
+	"sort"
+	"strings"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -35,6 +40,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	awsv1alpha1 "github.com/sergeyshevch/cloud-resource-operator/api/v1alpha1"
+	awsv1beta1 "github.com/sergeyshevch/cloud-resource-operator/api/v1beta1"
 	"github.com/sergeyshevch/cloud-resource-operator/controllers"
 	//+kubebuilder:scaffold:imports
 )
@@ -48,6 +54,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(awsv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(awsv1beta1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -55,11 +62,24 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var elastiCacheEndpoint string
+	var requeueIntervals controllers.RequeueIntervals
+	defaultTags := tagsFlag{}
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&elastiCacheEndpoint, "elasticache-endpoint", "",
+		"The URL of the ElastiCache API, e.g. of the emulator used in end-to-end tests. "+
+			"Defaults to the AWS endpoint of the configured region.")
+	flag.DurationVar(&requeueIntervals.Transitional, "transitional-requeue-interval", controllers.DefaultTransitionalRequeueInterval,
+		"How often AWS resources are polled while they are being created, modified or deleted.")
+	flag.DurationVar(&requeueIntervals.Stable, "stable-requeue-interval", controllers.DefaultStableRequeueInterval,
+		"How often available AWS resources are polled to detect changes made outside of the operator.")
+	flag.Var(defaultTags, "default-tags",
+		"Tags added to every cache cluster and replication group as a comma separated list of key=value pairs, e.g. team=platform,env=prod. "+
+			"Tags of the spec take precedence over them.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -68,13 +88,17 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
-	awsConfig, err := config.LoadDefaultConfig(context.TODO())
+	var loadOptions []func(*config.LoadOptions) error
+	if elastiCacheEndpoint != "" {
+		setupLog.Info("using custom ElastiCache endpoint", "endpoint", elastiCacheEndpoint)
+		loadOptions = append(loadOptions, config.WithEndpointResolver(elastiCacheEndpointResolver(elastiCacheEndpoint)))
+	}
+	awsConfig, err := config.LoadDefaultConfig(context.TODO(), loadOptions...)
 	if err != nil {
 		setupLog.Error(err, "")
 		os.Exit(1)
 	}
 
-
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
@@ -88,14 +112,83 @@ func main() {
 		os.Exit(1)
 	}
 
-
-
 	if err = (&controllers.ElasticCacheReconciler{
+		Client:           mgr.GetClient(),
+		Scheme:           mgr.GetScheme(),
+		AwsConfig:        awsConfig,
+		RequeueIntervals: requeueIntervals,
+		DefaultTags:      defaultTags,
+		Recorder:         mgr.GetEventRecorderFor("elasticcache-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ElasticCache")
+		os.Exit(1)
+	}
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&awsv1alpha1.ElasticCache{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ElasticCache")
+			os.Exit(1)
+		}
+	}
+	if err = (&controllers.ReplicationGroupReconciler{
+		Client:           mgr.GetClient(),
+		Scheme:           mgr.GetScheme(),
+		AwsConfig:        awsConfig,
+		RequeueIntervals: requeueIntervals,
+		DefaultTags:      defaultTags,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ReplicationGroup")
+		os.Exit(1)
+	}
+	if err = (&controllers.CacheParameterGroupReconciler{
+		Client:           mgr.GetClient(),
+		Scheme:           mgr.GetScheme(),
+		AwsConfig:        awsConfig,
+		RequeueIntervals: requeueIntervals,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "CacheParameterGroup")
+		os.Exit(1)
+	}
+	if err = (&controllers.CacheSubnetGroupReconciler{
+		Client:           mgr.GetClient(),
+		Scheme:           mgr.GetScheme(),
+		AwsConfig:        awsConfig,
+		RequeueIntervals: requeueIntervals,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "CacheSubnetGroup")
+		os.Exit(1)
+	}
+	if err = (&controllers.UserReconciler{
+		Client:           mgr.GetClient(),
+		Scheme:           mgr.GetScheme(),
+		AwsConfig:        awsConfig,
+		RequeueIntervals: requeueIntervals,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "User")
+		os.Exit(1)
+	}
+	if err = (&controllers.UserGroupReconciler{
+		Client:           mgr.GetClient(),
+		Scheme:           mgr.GetScheme(),
+		AwsConfig:        awsConfig,
+		RequeueIntervals: requeueIntervals,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "UserGroup")
+		os.Exit(1)
+	}
+	if err = (&controllers.CacheSnapshotReconciler{
+		Client:           mgr.GetClient(),
+		Scheme:           mgr.GetScheme(),
+		AwsConfig:        awsConfig,
+		RequeueIntervals: requeueIntervals,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "CacheSnapshot")
+		os.Exit(1)
+	}
+	if err = (&controllers.CacheSnapshotScheduleReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
-		AwsConfig: awsConfig,
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ElasticCache")
+		setupLog.Error(err, "unable to create controller", "controller", "CacheSnapshotSchedule")
 		os.Exit(1)
 	}
 	//+kubebuilder:scaffold:builder
@@ -115,3 +208,49 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// elastiCacheEndpointResolver resolves the ElastiCache API to url. Every other
+// service keeps its default endpoint.
+func elastiCacheEndpointResolver(url string) aws.EndpointResolver {
+	return aws.EndpointResolverFunc(func(service, region string) (aws.Endpoint, error) {
+		if service != elasticache.ServiceID {
+			return aws.Endpoint{}, &aws.EndpointNotFoundError{}
+		}
+		return aws.Endpoint{
+			URL:               url,
+			SigningRegion:     region,
+			HostnameImmutable: true,
+		}, nil
+	})
+}
+
+// tagsFlag is a flag holding AWS tags as a comma separated list of key=value
+// pairs.
+type tagsFlag map[string]string
+
+func (f tagsFlag) String() string {
+	var pairs []string
+	for key, value := range f {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (f tagsFlag) Set(value string) error {
+	for _, pair := range strings.Split(value, ",") {
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		key := strings.TrimSpace(parts[0])
+		if key == "" || len(parts) != 2 {
+			return fmt.Errorf("tag %q must have the form key=value", pair)
+		}
+		if strings.HasPrefix(key, "aws:") || strings.HasPrefix(key, awsv1alpha1.OperatorTagPrefix) {
+			return fmt.Errorf("tag key %q uses a reserved prefix", key)
+		}
+		f[key] = strings.TrimSpace(parts[1])
+	}
+	return nil
+}
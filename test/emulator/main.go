@@ -0,0 +1,74 @@
+/*
+Copyright 2021 Sergey Shevchenko <sergeyshevchdevelop@gmail.com>.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command emulator serves an in-memory ElastiCache API for end-to-end tests.
+// Point the operator at it with the --elasticache-endpoint flag.
+package main
+
+import (
+	"flag"
+	"net/http"
+	"os"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/sergeyshevch/cloud-resource-operator/internal/emulator"
+	"github.com/sergeyshevch/cloud-resource-operator/internal/fake"
+)
+
+func main() {
+	var bindAddress string
+	var region string
+	var options emulator.Options
+	flag.StringVar(&bindAddress, "bind-address", ":8080", "The address the ElastiCache API binds to.")
+	flag.StringVar(&region, "region", "us-east-1", "The region used in the ARNs and endpoints of new resources.")
+	flag.DurationVar(&options.Latency, "latency", 0, "The latency added to every API request.")
+	flag.Float64Var(&options.FailureRate, "failure-rate", 0,
+		"The probability, between 0 and 1, that an API request fails with a throttling error.")
+	flag.DurationVar(&options.TransitionInterval, "transition-interval", 10*time.Second,
+		"The time resources spend in a transitional state such as creating or modifying. "+
+			"With 0, resources only move on when POST /_emulator/tick is called.")
+	opts := zap.Options{
+		Development: true,
+	}
+	opts.BindFlags(flag.CommandLine)
+	flag.Parse()
+
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+	log := ctrl.Log.WithName("emulator")
+	options.Log = log
+
+	api := fake.New()
+	api.Region = region
+	server := emulator.NewServer(api, options)
+
+	ctx := ctrl.SetupSignalHandler()
+	go server.Run(ctx)
+
+	httpServer := &http.Server{Addr: bindAddress, Handler: server}
+	go func() {
+		<-ctx.Done()
+		_ = httpServer.Close()
+	}()
+
+	log.Info("serving the ElastiCache API", "address", bindAddress, "region", region)
+	if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Error(err, "problem serving the ElastiCache API")
+		os.Exit(1)
+	}
+}